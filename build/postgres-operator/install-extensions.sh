@@ -16,6 +16,14 @@ if [[ -n $STORAGE_ENDPOINT ]]; then
 	args+=(-endpoint "$STORAGE_ENDPOINT")
 fi
 
+if [[ -n $EXTENSION_CHECKSUMS ]]; then
+	args+=(-checksums "$EXTENSION_CHECKSUMS")
+fi
+
+if [[ -n $EXTENSIONS_PUBLIC_KEY ]]; then
+	args+=(-public-key "$EXTENSIONS_PUBLIC_KEY")
+fi
+
 for key in "${extensions[@]}"; do
 	if [ -f "${PGDATA_EXTENSIONS}"/"${key}".installed ]; then
 		echo "Extension ${key} already installed"
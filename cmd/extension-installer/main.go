@@ -2,16 +2,21 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"path"
 
+	"github.com/pkg/errors"
+
 	"github.com/fulviodenza/percona-postgresql-operator/percona/extensions"
 )
 
+const terminationLog = "/dev/termination-log"
+
 func main() {
-	var storageType, endpoint, region, bucket, key, extensionPath string
+	var storageType, endpoint, region, bucket, key, extensionPath, checksums, publicKey string
 	var install, uninstall bool
 
 	flag.StringVar(&storageType, "type", "", "Storage type")
@@ -20,6 +25,8 @@ func main() {
 	flag.StringVar(&bucket, "bucket", "", "Storage bucket")
 	flag.StringVar(&extensionPath, "extension-path", "", "Extension installation path")
 	flag.StringVar(&key, "key", "", "Extension archive key")
+	flag.StringVar(&checksums, "checksums", "", "Comma separated list of key=sha256 checksums of extension archives")
+	flag.StringVar(&publicKey, "public-key", "", "Path to the PEM public key to verify archive signatures")
 
 	flag.BoolVar(&install, "install", false, "Install extension")
 	flag.BoolVar(&uninstall, "uninstall", false, "Uninstall extension")
@@ -58,6 +65,8 @@ func main() {
 
 	switch {
 	case install:
+		verify(storage, key, packageName, archivePath, checksums, publicKey)
+
 		log.Printf("installing extension %s", archivePath)
 		if err := extensions.Install(key, archivePath, extensionPath); err != nil {
 			log.Fatalf("ERROR: failed to install extension: %v", err)
@@ -70,12 +79,82 @@ func main() {
 	}
 }
 
+// verify checks the checksum and the signature of the archive. The installer
+// exits with extensions.ExitCodeVerificationFailed if any of them doesn't match
+// and writes the reason to the termination log for the operator to pick up.
+func verify(storage extensions.ObjectGetter, key, packageName, archivePath, checksums, publicKey string) {
+	fail := func(err error) {
+		msg := fmt.Sprintf("extension %s: %v", key, err)
+		if errors.Is(err, extensions.ErrVerificationFailed) {
+			_ = os.WriteFile(terminationLog, []byte(msg), 0o644)
+			log.Printf("ERROR: %s", msg)
+			os.Exit(extensions.ExitCodeVerificationFailed)
+		}
+		log.Fatalf("ERROR: %s", msg)
+	}
+
+	expected, err := extensions.ParseChecksums(checksums)
+	if err != nil {
+		fail(err)
+	}
+	if checksum, ok := expected[key]; ok {
+		log.Printf("verifying checksum of %s", archivePath)
+		if err := extensions.VerifyChecksum(archivePath, checksum); err != nil {
+			fail(err)
+		}
+	}
+
+	if publicKey == "" {
+		return
+	}
+
+	log.Printf("verifying signature of %s", archivePath)
+
+	pem, err := os.ReadFile(publicKey)
+	if err != nil {
+		fail(errors.Wrap(err, "read public key"))
+	}
+
+	object, err := storage.Get(packageName + extensions.SignatureSuffix)
+	if err != nil {
+		fail(errors.Wrapf(extensions.ErrVerificationFailed, "get signature: %v", err))
+	}
+	defer object.Close()
+
+	signature, err := io.ReadAll(object)
+	if err != nil {
+		fail(errors.Wrap(err, "read signature"))
+	}
+
+	if err := extensions.VerifySignature(archivePath, signature, pem); err != nil {
+		fail(err)
+	}
+}
+
 func initStorage(storageType extensions.StorageType, endpoint, bucket, region string) extensions.ObjectGetter {
 	switch storageType {
 	case extensions.StorageTypeS3:
 		return extensions.NewS3(endpoint, region, bucket)
+	case extensions.StorageTypeGCS:
+		storage, err := extensions.NewGCS(endpoint, bucket, []byte(os.Getenv("GCS_SERVICE_ACCOUNT_KEY")))
+		if err != nil {
+			log.Fatalf("ERROR: failed to init GCS storage: %v", err)
+		}
+		return storage
+	case extensions.StorageTypeAzure:
+		storage, err := extensions.NewAzure(endpoint, os.Getenv("AZURE_STORAGE_ACCOUNT"), bucket,
+			os.Getenv("AZURE_STORAGE_KEY"), os.Getenv("AZURE_STORAGE_SAS_TOKEN"))
+		if err != nil {
+			log.Fatalf("ERROR: failed to init Azure storage: %v", err)
+		}
+		return storage
+	case extensions.StorageTypeFilesystem:
+		return extensions.NewFilesystem(endpoint, bucket)
+	case extensions.StorageTypeHTTP:
+		return extensions.NewHTTP(endpoint, bucket,
+			os.Getenv("HTTP_USERNAME"), os.Getenv("HTTP_PASSWORD"), os.Getenv("HTTP_BEARER_TOKEN"))
 	default:
-		log.Fatalf("unknown storage type: %s", os.Getenv("STORAGE_TYPE"))
+		log.Fatalf("unknown storage type: %s", storageType)
 	}
 
 	return nil
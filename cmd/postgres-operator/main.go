@@ -6,6 +6,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
 	goruntime "runtime"
@@ -26,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/fulviodenza/percona-postgresql-operator/internal/controller/pgupgrade"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/controller/postgrescluster"
@@ -38,11 +40,14 @@ import (
 	"github.com/fulviodenza/percona-postgresql-operator/internal/upgradecheck"
 	perconaController "github.com/fulviodenza/percona-postgresql-operator/percona/controller"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/controller/pgbackup"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/controller/pgbackupschedule"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/controller/pgcluster"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/controller/pgrestore"
 	perconaPGUpgrade "github.com/fulviodenza/percona-postgresql-operator/percona/controller/pgupgrade"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/k8s"
 	perconaRuntime "github.com/fulviodenza/percona-postgresql-operator/percona/runtime"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/utils/registry"
+	perconaWebhook "github.com/fulviodenza/percona-postgresql-operator/percona/webhook"
 	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
 	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
 )
@@ -113,6 +118,18 @@ func main() {
 	options, err := initManager(ctx)
 	assertNoError(err)
 
+	// Admission webhooks are served when the operator is configured with
+	// the name of the webhook Service.
+	var webhookCerts *perconaWebhook.CertManager
+	if service := os.Getenv("PGO_WEBHOOK_SERVICE_NAME"); service != "" {
+		webhookCerts, err = initWebhookCerts(ctx, cfg, service)
+		assertNoError(err)
+
+		options.WebhookServer = webhook.NewServer(webhook.Options{
+			TLSOpts: []func(*tls.Config){webhookCerts.TLSOpts},
+		})
+	}
+
 	mgr, err := perconaRuntime.CreateRuntimeManager(
 		cfg,
 		features,
@@ -127,6 +144,11 @@ func main() {
 	err = addControllersToManager(ctx, mgr)
 	assertNoError(err)
 
+	if webhookCerts != nil {
+		assertNoError(perconaWebhook.SetupWithManager(mgr))
+		assertNoError(mgr.Add(webhookCerts))
+	}
+
 	log.Info("starting controller runtime manager and will wait for signal to exit")
 
 	// Disable Crunchy upgrade checking
@@ -212,6 +234,14 @@ func addControllersToManager(ctx context.Context, mgr manager.Manager) error {
 		return err
 	}
 
+	ps := &pgbackupschedule.PGBackupScheduleReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor(pgbackupschedule.PGBackupScheduleControllerName),
+	}
+	if err := ps.SetupWithManager(mgr); err != nil {
+		return err
+	}
+
 	pr := &pgrestore.PGRestoreReconciler{
 		Client:   mgr.GetClient(),
 		Owner:    pgrestore.PGRestoreControllerName,
@@ -252,6 +282,31 @@ func addControllersToManager(ctx context.Context, mgr manager.Manager) error {
 	return nil
 }
 
+// initWebhookCerts creates or loads the certificate of the webhook server.
+// The certificate is needed before the manager and its cache are started, so
+// a client that reads from the API directly is used.
+func initWebhookCerts(ctx context.Context, cfg *rest.Config, service string) (*perconaWebhook.CertManager, error) {
+	if errs := validation.IsDNS1035Label(service); len(errs) > 0 {
+		return nil, fmt.Errorf("value for PGO_WEBHOOK_SERVICE_NAME is invalid: %v", errs)
+	}
+
+	namespace, err := k8s.GetOperatorNamespace()
+	if err != nil {
+		return nil, errors.Wrap(err, "get operator namespace")
+	}
+
+	cl, err := client.New(cfg, client.Options{Scheme: runtime.Scheme})
+	if err != nil {
+		return nil, errors.Wrap(err, "create client")
+	}
+
+	certs := perconaWebhook.NewCertManager(cl, namespace, service)
+	if err := certs.Ensure(ctx); err != nil {
+		return nil, errors.Wrap(err, "ensure webhook certificate")
+	}
+	return certs, nil
+}
+
 //+kubebuilder:rbac:groups="coordination.k8s.io",resources="leases",verbs={get,create,update}
 
 func initManager(ctx context.Context) (runtime.Options, error) {
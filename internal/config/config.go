@@ -111,6 +111,14 @@ func PostgresContainerImage(cluster *v1beta1.PostgresCluster) string {
 	return defaultFromEnv(image, key)
 }
 
+// PasswordFilesDirectory returns the directory that external password files
+// of PostgreSQL users are read from. Files of a cluster are read from the
+// subdirectory named after its namespace. When it is empty, such files are not
+// read at all.
+func PasswordFilesDirectory() string {
+	return os.Getenv("PGO_PASSWORD_FILES_DIR")
+}
+
 // PGONamespace returns the namespace where the PGO is running,
 // based on the env var from the DownwardAPI
 // If no env var is found, returns ""
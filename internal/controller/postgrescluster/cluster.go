@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
@@ -160,7 +161,7 @@ func (r *Reconciler) generateClusterPrimaryService(
 }
 
 // +kubebuilder:rbac:groups="",resources="endpoints",verbs={create,patch}
-// +kubebuilder:rbac:groups="",resources="services",verbs={create,patch}
+// +kubebuilder:rbac:groups="",resources="services",verbs={get,create,patch}
 
 // The OpenShift RestrictedEndpointsAdmission plugin requires special
 // authorization to create Endpoints that contain ClusterIPs.
@@ -172,6 +173,17 @@ func (r *Reconciler) generateClusterPrimaryService(
 func (r *Reconciler) reconcileClusterPrimaryService(
 	ctx context.Context, cluster *v1beta1.PostgresCluster, leader *corev1.Service,
 ) (*corev1.Service, error) {
+	// The clients of the primary Service may have been moved to another
+	// cluster. Resolve to the leader of that cluster instead.
+	if name := cluster.Annotations[naming.PrimaryServiceTargetAnnotation]; name != "" {
+		target := &v1beta1.PostgresCluster{ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: name}}
+		leader = &corev1.Service{ObjectMeta: naming.PatroniLeaderEndpoints(target)}
+
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(leader), leader); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
 	service, endpoints, err := r.generateClusterPrimaryService(cluster, leader)
 
 	if err == nil {
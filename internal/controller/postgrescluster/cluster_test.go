@@ -14,6 +14,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/tools/record"
@@ -710,6 +711,26 @@ func TestReconcileClusterPrimaryService(t *testing.T) {
 	service, err := reconciler.reconcileClusterPrimaryService(ctx, cluster, leader)
 	assert.NilError(t, err)
 	assert.Assert(t, service != nil && service.UID != "", "expected created service")
+
+	t.Run("Target", func(t *testing.T) {
+		cluster := cluster.DeepCopy()
+		cluster.Annotations = map[string]string{naming.PrimaryServiceTargetAnnotation: "other"}
+
+		_, err := reconciler.reconcileClusterPrimaryService(ctx, cluster, leader)
+		assert.Assert(t, apierrors.IsNotFound(err), "expected NotFound, got %#v", err)
+
+		other := &corev1.Service{}
+		other.Namespace, other.Name = cluster.Namespace, "other-ha"
+		other.Spec.Ports = []corev1.ServicePort{{Port: 5432}}
+		assert.NilError(t, cc.Create(ctx, other))
+
+		_, err = reconciler.reconcileClusterPrimaryService(ctx, cluster, leader)
+		assert.NilError(t, err)
+
+		endpoints := &corev1.Endpoints{ObjectMeta: naming.ClusterPrimaryService(cluster)}
+		assert.NilError(t, cc.Get(ctx, client.ObjectKeyFromObject(endpoints), endpoints))
+		assert.Equal(t, endpoints.Subsets[0].Addresses[0].IP, other.Spec.ClusterIP)
+	})
 }
 
 func TestGenerateClusterReplicaServiceIntent(t *testing.T) {
@@ -388,7 +388,11 @@ func (r *Reconciler) Reconcile(
 		err = r.reconcilePostgresDatabases(ctx, cluster, instances)
 	}
 	if err == nil {
-		err = r.reconcilePostgresUsers(ctx, cluster, instances)
+		var requeue time.Duration
+		if requeue, err = r.reconcilePostgresUsers(ctx, cluster, instances); err == nil &&
+			requeue > 0 && (result.RequeueAfter == 0 || requeue < result.RequeueAfter) {
+			result.RequeueAfter = requeue
+		}
 	}
 
 	if err == nil {
@@ -1121,6 +1121,7 @@ func (r *Reconciler) scaleUpInstances(
 			clusterConfigMap, clusterReplicationSecret,
 			rootCA, clusterPodService, instanceServiceAccount,
 			patroniLeaderService, primaryCertificate, instances[i],
+			replicateFromMember(observed, set, i),
 			numInstancePods, clusterVolumes, exporterQueriesConfig, exporterWebConfig,
 			backupsSpecFound,
 		)
@@ -1132,6 +1133,31 @@ func (r *Reconciler) scaleUpInstances(
 	return instances, err
 }
 
+// replicateFromMember returns the Patroni member that the instance at index of
+// set replicates from. Instances of set are spread across the available
+// instances of the set named in its replicateFrom field. It returns empty when
+// there is none, and the instance replicates from the primary.
+func replicateFromMember(observed *observedInstances, set *v1beta1.PostgresInstanceSetSpec, index int) string {
+	if set.Patroni == nil || set.Patroni.ReplicateFrom == "" ||
+		set.Patroni.ReplicateFrom == set.Name {
+		return ""
+	}
+
+	var members []string
+	for _, instance := range observed.bySet[set.Patroni.ReplicateFrom] {
+		if available, known := instance.IsAvailable(); available && known && len(instance.Pods) > 0 {
+			// Patroni names members after their pods.
+			members = append(members, instance.Pods[0].Name)
+		}
+	}
+	if len(members) == 0 {
+		return ""
+	}
+
+	sort.Strings(members)
+	return members[index%len(members)]
+}
+
 // +kubebuilder:rbac:groups="apps",resources="statefulsets",verbs={create,patch}
 
 // reconcileInstance writes instance according to spec of cluster.
@@ -1149,6 +1175,7 @@ func (r *Reconciler) reconcileInstance(
 	patroniLeaderService *corev1.Service,
 	primaryCertificate *corev1.SecretProjection,
 	instance *appsv1.StatefulSet,
+	replicateFrom string,
 	numInstancePods int,
 	clusterVolumes []corev1.PersistentVolumeClaim,
 	exporterQueriesConfig, exporterWebConfig *corev1.ConfigMap,
@@ -1177,7 +1204,7 @@ func (r *Reconciler) reconcileInstance(
 	)
 
 	if err == nil {
-		instanceConfigMap, err = r.reconcileInstanceConfigMap(ctx, cluster, spec, instance)
+		instanceConfigMap, err = r.reconcileInstanceConfigMap(ctx, cluster, spec, instance, replicateFrom)
 	}
 	if err == nil {
 		instanceCertificates, err = r.reconcileInstanceCertificates(
@@ -1413,7 +1440,7 @@ func addPGBackRestToInstancePodSpec(
 // files (etc) that apply to instance of cluster.
 func (r *Reconciler) reconcileInstanceConfigMap(
 	ctx context.Context, cluster *v1beta1.PostgresCluster, spec *v1beta1.PostgresInstanceSetSpec,
-	instance *appsv1.StatefulSet,
+	instance *appsv1.StatefulSet, replicateFrom string,
 ) (*corev1.ConfigMap, error) {
 	instanceConfigMap := &corev1.ConfigMap{ObjectMeta: naming.InstanceConfigMap(instance)}
 	instanceConfigMap.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
@@ -1434,7 +1461,7 @@ func (r *Reconciler) reconcileInstanceConfigMap(
 		}, cluster.Name, "pg", cluster.Labels[naming.LabelVersion]))
 
 	if err == nil {
-		err = patroni.InstanceConfigMap(ctx, cluster, spec, instanceConfigMap)
+		err = patroni.InstanceConfigMap(ctx, cluster, spec, replicateFrom, instanceConfigMap)
 	}
 	if err == nil {
 		err = errors.WithStack(r.apply(ctx, instanceConfigMap))
@@ -137,6 +137,52 @@ func TestInstanceIsWritable(t *testing.T) {
 	assert.Assert(t, !writable)
 }
 
+func TestReplicateFromMember(t *testing.T) {
+	instance := func(name string, ready corev1.ConditionStatus) *Instance {
+		return &Instance{
+			Name: name,
+			Pods: []*corev1.Pod{{
+				ObjectMeta: metav1.ObjectMeta{Name: name + "-0"},
+				Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+					Type: corev1.PodReady, Status: ready,
+				}}},
+			}},
+		}
+	}
+	observed := &observedInstances{bySet: map[string][]*Instance{
+		"source": {
+			instance("hippo-source-cccc", corev1.ConditionTrue),
+			instance("hippo-source-aaaa", corev1.ConditionTrue),
+			instance("hippo-source-bbbb", corev1.ConditionFalse),
+		},
+	}}
+
+	t.Run("Unset", func(t *testing.T) {
+		set := &v1beta1.PostgresInstanceSetSpec{Name: "cascade"}
+		assert.Equal(t, replicateFromMember(observed, set, 0), "")
+	})
+
+	t.Run("Itself", func(t *testing.T) {
+		set := &v1beta1.PostgresInstanceSetSpec{Name: "source",
+			Patroni: &v1beta1.InstancePatroniSpec{ReplicateFrom: "source"}}
+		assert.Equal(t, replicateFromMember(observed, set, 0), "")
+	})
+
+	t.Run("NoneAvailable", func(t *testing.T) {
+		set := &v1beta1.PostgresInstanceSetSpec{Name: "cascade",
+			Patroni: &v1beta1.InstancePatroniSpec{ReplicateFrom: "missing"}}
+		assert.Equal(t, replicateFromMember(observed, set, 0), "")
+	})
+
+	t.Run("Spread", func(t *testing.T) {
+		set := &v1beta1.PostgresInstanceSetSpec{Name: "cascade",
+			Patroni: &v1beta1.InstancePatroniSpec{ReplicateFrom: "source"}}
+		assert.Equal(t, replicateFromMember(observed, set, 0), "hippo-source-aaaa-0")
+		assert.Equal(t, replicateFromMember(observed, set, 1), "hippo-source-cccc-0")
+		assert.Equal(t, replicateFromMember(observed, set, 2), "hippo-source-aaaa-0")
+	})
+}
+
 func TestNewObservedInstances(t *testing.T) {
 	t.Run("Empty", func(t *testing.T) {
 		cluster := new(v1beta1.PostgresCluster)
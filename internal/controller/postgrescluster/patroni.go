@@ -520,6 +520,12 @@ func (r *Reconciler) reconcilePatroniSwitchover(ctx context.Context,
 			// TODO: event
 			return errors.New("TargetInstance was specified but not found in the cluster")
 		}
+		if targetInstance.Spec != nil && targetInstance.Spec.Patroni != nil &&
+			targetInstance.Spec.Patroni.NoFailover {
+			return errors.Errorf(
+				"TargetInstance belongs to instance set %q, which is tagged nofailover",
+				targetInstance.Spec.Name)
+		}
 		if len(targetInstance.Pods) != 1 {
 			// We expect that a target instance should have one associated pod.
 			return errors.Errorf(
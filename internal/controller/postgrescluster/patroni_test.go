@@ -548,6 +548,9 @@ func TestReconcilePatroniSwitchover(t *testing.T) {
 			stdin io.Reader, stdout, stderr io.Writer, command ...string) error {
 			called = true
 			switch {
+			case strings.Contains(strings.Join(command, " "), "list --extended"):
+				// No member is tagged nofailover.
+				_, _ = stdout.Write([]byte(`[]`))
 			case timelineCall:
 				timelineCall = false
 				_, _ = stdout.Write([]byte(`[{"Cluster": "hippo-ha", "Member": "hippo-instance1-67mc-0", "Host": "hippo-instance1-67mc-0.hippo-pods", "Role": "Leader", "State": "running", "TL": 4}, {"Cluster": "hippo-ha", "Member": "hippo-instance1-ltcf-0", "Host": "hippo-instance1-ltcf-0.hippo-pods", "Role": "Replica", "State": "running", "TL": 4, "Lag in MB": 0}]`))
@@ -655,9 +655,13 @@ func (r *Reconciler) generateRepoHostIntent(ctx context.Context, postgresCluster
 		}
 	}
 
+	// an in-place restore of a shutdown cluster reads the repo through the repohost pod
+	restoreInPlace := postgresCluster.Spec.Backups.PGBackRest.Restore != nil &&
+		initialize.FromPointer(postgresCluster.Spec.Backups.PGBackRest.Restore.Enabled)
+
 	// if the cluster is set to be shutdown and no instance Pods remain, stop the repohost pod
 	if postgresCluster.Spec.Shutdown != nil && *postgresCluster.Spec.Shutdown &&
-		!instancePodExists {
+		!instancePodExists && !restoreInPlace {
 		repo.Spec.Replicas = initialize.Int32(0)
 	} else {
 		// the cluster should not be shutdown, set this value to 1
@@ -36,6 +36,7 @@ func (r *Reconciler) reconcilePGBouncer(
 	var (
 		configmap *corev1.ConfigMap
 		secret    *corev1.Secret
+		users     *corev1.Secret
 	)
 
 	service, err := r.reconcilePGBouncerService(ctx, cluster)
@@ -43,7 +44,10 @@ func (r *Reconciler) reconcilePGBouncer(
 		configmap, err = r.reconcilePGBouncerConfigMap(ctx, cluster)
 	}
 	if err == nil {
-		secret, err = r.reconcilePGBouncerSecret(ctx, cluster, root, service)
+		users, err = r.reconcilePGBouncerUsersSecret(ctx, cluster)
+	}
+	if err == nil {
+		secret, err = r.reconcilePGBouncerSecret(ctx, cluster, root, users, service)
 	}
 	if err == nil {
 		err = r.reconcilePGBouncerDeployment(ctx, cluster, primaryCertificate, configmap, secret)
@@ -67,6 +71,9 @@ func (r *Reconciler) reconcilePGBouncerConfigMap(
 	configmap := &corev1.ConfigMap{ObjectMeta: naming.ClusterPGBouncer(cluster)}
 	configmap.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
 
+	// Report the pools that are routed to replicas.
+	cluster.Status.Proxy.PGBouncer.ReadOnlyPools = pgbouncer.ReadOnlyPools(cluster)
+
 	if cluster.Spec.Proxy == nil || cluster.Spec.Proxy.PGBouncer == nil {
 		// PgBouncer is disabled; delete the ConfigMap if it exists. Check the
 		// client cache first using Get.
@@ -198,7 +205,7 @@ func (r *Reconciler) reconcilePGBouncerInPostgreSQL(
 // reconcilePGBouncerSecret writes the Secret for a PgBouncer Pod.
 func (r *Reconciler) reconcilePGBouncerSecret(
 	ctx context.Context, cluster *v1beta1.PostgresCluster,
-	root *pki.RootCertificateAuthority, service *corev1.Service,
+	root *pki.RootCertificateAuthority, users *corev1.Secret, service *corev1.Service,
 ) (*corev1.Secret, error) {
 	existing := &corev1.Secret{ObjectMeta: naming.ClusterPGBouncer(cluster)}
 	err := errors.WithStack(
@@ -239,7 +246,61 @@ func (r *Reconciler) reconcilePGBouncerSecret(
 		}, cluster.Name, "pgbouncer", cluster.Labels[naming.LabelVersion]))
 
 	if err == nil {
-		err = pgbouncer.Secret(ctx, cluster, root, existing, service, intent)
+		err = pgbouncer.Secret(ctx, cluster, root, existing, users, service, intent)
+	}
+	if err == nil {
+		err = errors.WithStack(r.apply(ctx, intent))
+	}
+
+	return intent, err
+}
+
+// +kubebuilder:rbac:groups="",resources="secrets",verbs={get}
+// +kubebuilder:rbac:groups="",resources="secrets",verbs={create,delete,patch}
+
+// reconcilePGBouncerUsersSecret writes the Secret that holds the passwords of
+// the PgBouncer admin console users. It returns nil when there are no such users.
+func (r *Reconciler) reconcilePGBouncerUsersSecret(
+	ctx context.Context, cluster *v1beta1.PostgresCluster,
+) (*corev1.Secret, error) {
+	existing := &corev1.Secret{ObjectMeta: naming.ClusterPGBouncerUsers(cluster)}
+	err := errors.WithStack(
+		r.Client.Get(ctx, client.ObjectKeyFromObject(existing), existing))
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+
+	if len(pgbouncer.ConsoleUsers(cluster)) == 0 {
+		// PgBouncer is disabled or has no console users; delete the Secret if
+		// it exists.
+		if err == nil {
+			err = errors.WithStack(r.deleteControlled(ctx, cluster, existing))
+		}
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	err = client.IgnoreNotFound(err)
+
+	intent := &corev1.Secret{ObjectMeta: naming.ClusterPGBouncerUsers(cluster)}
+	intent.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	intent.Type = corev1.SecretTypeOpaque
+
+	intent.Annotations = naming.Merge(
+		cluster.Spec.Metadata.GetAnnotationsOrNil(),
+		cluster.Spec.Proxy.PGBouncer.Metadata.GetAnnotationsOrNil())
+	intent.Labels = naming.Merge(
+		cluster.Spec.Metadata.GetLabelsOrNil(),
+		cluster.Spec.Proxy.PGBouncer.Metadata.GetLabelsOrNil(),
+		naming.WithPerconaLabels(map[string]string{
+			naming.LabelCluster: cluster.Name,
+			naming.LabelRole:    naming.RolePGBouncer,
+		}, cluster.Name, "pgbouncer", cluster.Labels[naming.LabelVersion]))
+
+	if err == nil {
+		err = errors.WithStack(r.setControllerReference(cluster, intent))
+	}
+	if err == nil {
+		err = pgbouncer.UsersSecret(cluster, existing, intent)
 	}
 	if err == nil {
 		err = errors.WithStack(r.apply(ctx, intent))
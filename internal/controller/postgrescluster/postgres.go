@@ -11,20 +11,25 @@ import (
 	"io"
 	"net"
 	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	gover "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/fulviodenza/percona-postgresql-operator/internal/config"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/feature"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
@@ -41,6 +46,10 @@ import (
 	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
 )
 
+// externalPasswordInterval is how often passwords of PostgreSQL users are read
+// from their external sources.
+const externalPasswordInterval = time.Minute
+
 // generatePostgresUserSecret returns a Secret containing a password and
 // connection details for the first database in spec. When existing is nil or
 // lacks a password or verifier, a new password and verifier are generated.
@@ -65,12 +74,34 @@ func (r *Reconciler) generatePostgresUserSecret(
 	hostname := primary.Name + "." + primary.Namespace + ".svc"
 	port := fmt.Sprint(*cluster.Spec.Port)
 
+	// Keep the state of the password rotation policy, if any. Users rotated
+	// with a grace period may log in as their alternate role. See
+	// rotatePostgresUserPassword.
+	login := username
+	alternate := postgres.AlternateUser(username)
+	var rotatedAt string
+	if existing != nil && spec.Password != nil && spec.Password.Rotation != nil &&
+		spec.Password.Source == nil {
+		rotatedAt = existing.Annotations[naming.PasswordRotatedAtAnnotation]
+		if rotationGracePeriod(spec) > 0 {
+			if string(existing.Data["user"]) == alternate {
+				login = alternate
+			}
+			for _, key := range []string{"previous-user", "previous-password", "previous-verifier"} {
+				if previous := existing.Data[key]; len(previous) > 0 {
+					intent.Data[key] = previous
+				}
+			}
+		}
+	}
+
 	intent.Data["host"] = []byte(hostname)
 	intent.Data["port"] = []byte(port)
-	intent.Data["user"] = []byte(username)
+	intent.Data["user"] = []byte(login)
 
-	// Use the existing password and verifier.
-	if existing != nil {
+	// Use the existing password and verifier. Those of the alternate role are
+	// not used once the user no longer logs in as it.
+	if existing != nil && (string(existing.Data["user"]) != alternate || login == alternate) {
 		intent.Data["password"] = existing.Data["password"]
 		intent.Data["verifier"] = existing.Data["verifier"]
 	}
@@ -96,9 +127,9 @@ func (r *Reconciler) generatePostgresUserSecret(
 	}
 
 	// When a password has been generated or the verifier is empty,
-	// generate a verifier based on the current password.
-	// NOTE(cbandy): We don't have a function to compare a plaintext
-	// password to a SCRAM verifier.
+	// generate a verifier based on the current password. External passwords
+	// lose a stale verifier in withExternalPassword; a password changed in
+	// the Secret keeps its verifier until that is cleared, too.
 	if len(intent.Data["verifier"]) == 0 {
 		verifier, err := pgpassword.NewSCRAMPassword(string(intent.Data["password"])).Build()
 		if err != nil {
@@ -115,7 +146,7 @@ func (r *Reconciler) generatePostgresUserSecret(
 		intent.Data["dbname"] = []byte(database)
 		intent.Data["uri"] = []byte((&url.URL{
 			Scheme: "postgresql",
-			User:   url.UserPassword(username, string(intent.Data["password"])),
+			User:   url.UserPassword(login, string(intent.Data["password"])),
 			Host:   net.JoinHostPort(hostname, port),
 			Path:   database,
 		}).String())
@@ -123,7 +154,7 @@ func (r *Reconciler) generatePostgresUserSecret(
 		// The JDBC driver requires a different URI scheme and query component.
 		// - https://jdbc.postgresql.org/documentation/use/#connection-parameters
 		query := url.Values{}
-		query.Set("user", username)
+		query.Set("user", login)
 		query.Set("password", string(intent.Data["password"]))
 		intent.Data["jdbc-uri"] = []byte((&url.URL{
 			Scheme:   "jdbc:postgresql",
@@ -147,7 +178,7 @@ func (r *Reconciler) generatePostgresUserSecret(
 
 			intent.Data["pgbouncer-uri"] = []byte((&url.URL{
 				Scheme: "postgresql",
-				User:   url.UserPassword(username, string(intent.Data["password"])),
+				User:   url.UserPassword(login, string(intent.Data["password"])),
 				Host:   net.JoinHostPort(hostname, port),
 				Path:   database,
 			}).String())
@@ -158,7 +189,7 @@ func (r *Reconciler) generatePostgresUserSecret(
 			// - https://jdbc.postgresql.org/documentation/use/#connection-parameters
 			// - https://www.pgbouncer.org/faq.html#how-to-use-prepared-statements-with-transaction-pooling
 			query := url.Values{}
-			query.Set("user", username)
+			query.Set("user", login)
 			query.Set("password", string(intent.Data["password"]))
 			query.Set("prepareThreshold", "0")
 			intent.Data["pgbouncer-jdbc-uri"] = []byte((&url.URL{
@@ -170,7 +201,22 @@ func (r *Reconciler) generatePostgresUserSecret(
 		}
 	}
 
+	// Passwords from an external source are never written to the Secret. Keep
+	// only the verifier so it can be applied in PostgreSQL.
+	if spec.Password != nil && spec.Password.Source != nil {
+		for _, key := range []string{
+			"password", "uri", "jdbc-uri", "pgbouncer-uri", "pgbouncer-jdbc-uri",
+		} {
+			delete(intent.Data, key)
+		}
+	}
+
 	intent.Annotations = cluster.Spec.Metadata.GetAnnotationsOrNil()
+	if rotatedAt != "" {
+		intent.Annotations = naming.Merge(intent.Annotations, map[string]string{
+			naming.PasswordRotatedAtAnnotation: rotatedAt,
+		})
+	}
 	intent.Labels = naming.Merge(
 		cluster.Spec.Metadata.GetLabelsOrNil(),
 		naming.WithPerconaLabels(map[string]string{
@@ -186,6 +232,151 @@ func (r *Reconciler) generatePostgresUserSecret(
 	return intent, nil
 }
 
+// rotationGracePeriod returns the grace period of the password rotation
+// policy of spec, if any.
+func rotationGracePeriod(spec *v1beta1.PostgresUserSpec) time.Duration {
+	if spec.Password == nil || spec.Password.Rotation == nil || spec.Password.Rotation.GracePeriod == nil {
+		return 0
+	}
+	return spec.Password.Rotation.GracePeriod.Duration
+}
+
+// rotatePostgresUserPassword applies the password rotation policy of spec to
+// the existing Secret of a user. It returns a copy of existing without its
+// password when the password is due for rotation so that a new one is
+// generated. It also returns the status of the rotation, or nil when spec has
+// no policy, and whether the password was rotated.
+//
+// PostgreSQL keeps only one password per role. With a grace period, the new
+// password belongs to the other of the user's two login roles: the user and
+// its alternate, see [postgres.AlternateUser]. The previous role, password, and
+// verifier are kept in the "previous-" keys until the grace period ends so
+// that the previous credentials keep working.
+func rotatePostgresUserPassword(
+	spec *v1beta1.PostgresUserSpec, existing *corev1.Secret, now time.Time,
+) (*corev1.Secret, *v1beta1.PostgresPasswordRotationStatus, bool) {
+	if spec.Password == nil || spec.Password.Rotation == nil || spec.Password.Source != nil ||
+		spec.Password.Rotation.Interval.Duration <= 0 {
+		return existing, nil, false
+	}
+
+	policy := spec.Password.Rotation
+	secret := new(corev1.Secret)
+	if existing != nil {
+		secret = existing.DeepCopy()
+	}
+	initialize.Map(&secret.Annotations)
+	initialize.Map(&secret.Data)
+
+	// The password has not been generated yet or the policy has just been
+	// applied. Start counting from now rather than rotating right away.
+	rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[naming.PasswordRotatedAtAnnotation])
+	if err != nil || len(secret.Data["password"]) == 0 {
+		rotatedAt = now
+	}
+
+	grace := rotationGracePeriod(spec)
+
+	rotated := false
+	if len(secret.Data["password"]) > 0 && !now.Before(rotatedAt.Add(policy.Interval.Duration)) {
+		if grace > 0 {
+			user, alternate := string(spec.Name), postgres.AlternateUser(string(spec.Name))
+			login := user
+			if string(secret.Data["user"]) == alternate {
+				login = alternate
+			}
+
+			secret.Data["previous-user"] = []byte(login)
+			secret.Data["previous-password"] = secret.Data["password"]
+			secret.Data["previous-verifier"] = secret.Data["verifier"]
+
+			if login == user {
+				secret.Data["user"] = []byte(alternate)
+			} else {
+				secret.Data["user"] = []byte(user)
+			}
+		}
+		delete(secret.Data, "password")
+		delete(secret.Data, "verifier")
+		rotatedAt, rotated = now, true
+	}
+
+	if !now.Before(rotatedAt.Add(grace)) {
+		delete(secret.Data, "previous-user")
+		delete(secret.Data, "previous-password")
+		delete(secret.Data, "previous-verifier")
+	}
+
+	secret.Annotations[naming.PasswordRotatedAtAnnotation] = rotatedAt.UTC().Format(time.RFC3339)
+
+	return secret, &v1beta1.PostgresPasswordRotationStatus{
+		Name:             string(spec.Name),
+		LastRotationTime: metav1.NewTime(rotatedAt.UTC().Truncate(time.Second)),
+		NextRotationTime: metav1.NewTime(rotatedAt.Add(policy.Interval.Duration).UTC().Truncate(time.Second)),
+	}, rotated
+}
+
+// withExternalPassword returns a copy of existing that holds the password of
+// spec read from its external source. The verifier of existing is kept when it
+// matches the password so that PostgreSQL is not changed needlessly.
+func (r *Reconciler) withExternalPassword(
+	ctx context.Context, cluster *v1beta1.PostgresCluster,
+	spec *v1beta1.PostgresUserSpec, existing *corev1.Secret,
+) (*corev1.Secret, error) {
+	source := spec.Password.Source
+
+	var password []byte
+	switch {
+	case source.SecretKeyRef != nil:
+		external := &corev1.Secret{}
+		err := errors.WithStack(r.Client.Get(ctx, client.ObjectKey{
+			Namespace: cluster.Namespace, Name: source.SecretKeyRef.Name,
+		}, external))
+		if err != nil {
+			return nil, err
+		}
+		password = external.Data[source.SecretKeyRef.Key]
+
+	case source.File != "":
+		directory := config.PasswordFilesDirectory()
+		if directory == "" {
+			return nil, errors.New("PGO_PASSWORD_FILES_DIR is not set")
+		}
+
+		// Every namespace has its own subdirectory so that a cluster cannot
+		// read the passwords meant for clusters in other namespaces.
+		scope := filepath.Join(directory, cluster.Namespace)
+		path := filepath.Join(scope, source.File)
+		if rel, err := filepath.Rel(scope, path); err != nil || !filepath.IsLocal(rel) {
+			return nil, errors.Errorf("%q is not a relative path in PGO_PASSWORD_FILES_DIR/%s",
+				source.File, cluster.Namespace)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		// Files rendered from templates usually end with a newline.
+		password = bytes.TrimRight(data, "\r\n")
+	}
+
+	if len(password) == 0 {
+		return nil, errors.Errorf("external password of user %q is empty", spec.Name)
+	}
+
+	secret := new(corev1.Secret)
+	if existing != nil {
+		secret = existing.DeepCopy()
+	}
+	initialize.Map(&secret.Data)
+
+	secret.Data["password"] = password
+	if !pgpassword.NewSCRAMPassword(string(password)).Verify(string(secret.Data["verifier"])) {
+		delete(secret.Data, "verifier")
+	}
+	return secret, nil
+}
+
 // reconcilePostgresDatabases creates databases inside of PostgreSQL.
 func (r *Reconciler) reconcilePostgresDatabases(
 	ctx context.Context, cluster *v1beta1.PostgresCluster, instances *observedInstances,
@@ -248,6 +439,15 @@ func (r *Reconciler) reconcilePostgresDatabases(
 	// K8SPG-375, K8SPG-577, K8SPG-699
 	var pgAuditOK, pgStatMonitorOK, pgStatStatementsOK, pgvectorOK, pgRepackOK, postgisInstallOK bool
 	create := func(ctx context.Context, exec postgres.Executor) error {
+		// enable creates the extension in the databases of its target or, when
+		// there is none, in every database.
+		enable := func(extension string, everywhere func(context.Context, postgres.Executor) error) error {
+			if target := cluster.Spec.Extensions.Target(extension); target != nil {
+				return postgres.CreateExtensionInDatabases(ctx, exec, extension, target.Schema, target.Databases)
+			}
+			return everywhere(ctx, exec)
+		}
+
 		// validate version string before running it in database
 		_, err := gover.NewVersion(cluster.Labels[naming.LabelVersion])
 		if err != nil {
@@ -264,7 +464,7 @@ func (r *Reconciler) reconcilePostgresDatabases(
 		}
 
 		if cluster.Spec.Extensions.PGStatMonitor {
-			if pgStatMonitorOK = pgstatmonitor.EnableInPostgreSQL(ctx, exec) == nil; !pgStatMonitorOK {
+			if pgStatMonitorOK = enable("pg_stat_monitor", pgstatmonitor.EnableInPostgreSQL) == nil; !pgStatMonitorOK {
 				// pg_stat_monitor can only be enabled after its shared library is loaded,
 				// but early versions of PGO do not load it automatically. Assume
 				// that an error here is because the cluster started during one of
@@ -280,7 +480,7 @@ func (r *Reconciler) reconcilePostgresDatabases(
 		}
 
 		if cluster.Spec.Extensions.PGAudit {
-			if pgAuditOK = pgaudit.EnableInPostgreSQL(ctx, exec) == nil; !pgAuditOK {
+			if pgAuditOK = enable("pgaudit", pgaudit.EnableInPostgreSQL) == nil; !pgAuditOK {
 				// pgAudit can only be enabled after its shared library is loaded,
 				// but early versions of PGO do not load it automatically. Assume
 				// that an error here is because the cluster started during one of
@@ -296,7 +496,7 @@ func (r *Reconciler) reconcilePostgresDatabases(
 		}
 
 		if cluster.Spec.Extensions.PGStatStatements {
-			if pgStatStatementsOK = pgstatstatements.EnableInPostgreSQL(ctx, exec) == nil; !pgStatStatementsOK {
+			if pgStatStatementsOK = enable("pg_stat_statements", pgstatstatements.EnableInPostgreSQL) == nil; !pgStatStatementsOK {
 				r.Recorder.Event(cluster, corev1.EventTypeWarning, "pgStatStatementsDisabled",
 					"Unable to install pgStatStatements")
 			}
@@ -309,7 +509,7 @@ func (r *Reconciler) reconcilePostgresDatabases(
 
 		// K8SPG-699
 		if cluster.Spec.Extensions.PGVector {
-			if pgvectorOK = pgvector.EnableInPostgreSQL(ctx, exec) == nil; !pgvectorOK {
+			if pgvectorOK = enable("vector", pgvector.EnableInPostgreSQL) == nil; !pgvectorOK {
 				r.Recorder.Event(cluster, corev1.EventTypeWarning, "pgvectorDisabled",
 					"Unable to install pgvector")
 			}
@@ -322,7 +522,7 @@ func (r *Reconciler) reconcilePostgresDatabases(
 
 		// K8SPG-574
 		if cluster.Spec.Extensions.PGRepack {
-			if pgRepackOK = pgrepack.EnableInPostgreSQL(ctx, exec) == nil; !pgRepackOK {
+			if pgRepackOK = enable("pg_repack", pgrepack.EnableInPostgreSQL) == nil; !pgRepackOK {
 				r.Recorder.Event(cluster, corev1.EventTypeWarning, "pgRepackDisabled",
 					"Unable to install pg_repack")
 			}
@@ -387,9 +587,11 @@ func (r *Reconciler) reconcilePostgresDatabases(
 
 // reconcilePostgresUsers writes the objects necessary to manage users and their
 // passwords in PostgreSQL.
+// It returns how long to wait until the next password rotation or the end of
+// a grace period, if any.
 func (r *Reconciler) reconcilePostgresUsers(
 	ctx context.Context, cluster *v1beta1.PostgresCluster, instances *observedInstances,
-) error {
+) (time.Duration, error) {
 	r.validatePostgresUsers(cluster)
 
 	users, secrets, err := r.reconcilePostgresUserSecrets(ctx, cluster)
@@ -403,7 +605,48 @@ func (r *Reconciler) reconcilePostgresUsers(
 		// are available here, too.
 		err = r.reconcilePGAdminUsers(ctx, cluster, users, secrets)
 	}
-	return err
+	requeue := passwordRotationRequeue(cluster, users, time.Now())
+
+	// Passwords from external sources can change without notice. Read them
+	// again periodically.
+	for _, user := range users {
+		if user.Password != nil && user.Password.Source != nil &&
+			(requeue == 0 || requeue > externalPasswordInterval) {
+			requeue = externalPasswordInterval
+		}
+	}
+
+	return requeue, err
+}
+
+// passwordRotationRequeue returns how long to wait until the earliest password
+// rotation or end of a grace period in the status of cluster. It returns zero
+// when there is nothing to wait for.
+func passwordRotationRequeue(
+	cluster *v1beta1.PostgresCluster, users []v1beta1.PostgresUserSpec, now time.Time,
+) time.Duration {
+	var next time.Time
+	earlier := func(t time.Time) {
+		if t.After(now) && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+
+	for _, status := range cluster.Status.PasswordRotations {
+		earlier(status.NextRotationTime.Time)
+
+		for _, user := range users {
+			if string(user.Name) == status.Name && user.Password != nil &&
+				user.Password.Rotation != nil && user.Password.Rotation.GracePeriod != nil {
+				earlier(status.LastRotationTime.Add(user.Password.Rotation.GracePeriod.Duration))
+			}
+		}
+	}
+
+	if next.IsZero() {
+		return 0
+	}
+	return next.Sub(now)
 }
 
 // validatePostgresUsers emits warnings when cluster.Spec.Users contains values
@@ -422,6 +665,23 @@ func (r *Reconciler) validatePostgresUsers(cluster *v1beta1.PostgresCluster) {
 		errs := field.ErrorList{}
 		spec := cluster.Spec.Users[i]
 
+		if spec.Password != nil && spec.Password.Rotation != nil && spec.Password.Source != nil {
+			errs = append(errs,
+				field.Forbidden(path.Index(i).Child("password", "rotation"),
+					"cannot rotate a password from an external source"))
+		}
+		if spec.Password != nil && spec.Password.Rotation != nil &&
+			spec.Password.Rotation.Interval.Duration <= 0 {
+			errs = append(errs,
+				field.Invalid(path.Index(i).Child("password", "rotation", "interval"),
+					spec.Password.Rotation.Interval.Duration.String(),
+					"must be greater than zero"))
+		}
+		if rotationGracePeriod(&spec) > 0 && len(postgres.AlternateUser(string(spec.Name))) > 63 {
+			errs = append(errs,
+				field.TooLong(path.Index(i).Child("name"), spec.Name,
+					63-len(postgres.AlternateUser(""))))
+		}
 		if reComments.MatchString(spec.Options) {
 			errs = append(errs,
 				field.Invalid(path.Index(i).Child("options"), spec.Options,
@@ -562,6 +822,9 @@ func (r *Reconciler) reconcilePostgresUserSecrets(
 	}
 
 	// Reconcile each PostgreSQL user in the cluster spec.
+	now := time.Now()
+	var rotations []v1beta1.PostgresPasswordRotationStatus
+	var unreadable []string
 	for userName, user := range userSpecs {
 		secret := userSecrets[userName]
 
@@ -571,17 +834,80 @@ func (r *Reconciler) reconcilePostgresUserSecrets(
 			secret = defaultSecret
 		}
 
+		secret, rotation, rotated := rotatePostgresUserPassword(user, secret, now)
+		if rotation != nil {
+			rotations = append(rotations, *rotation)
+		}
+
+		if err == nil && user.Password != nil && user.Password.Source != nil {
+			external, readErr := r.withExternalPassword(ctx, cluster, user, secret)
+			if readErr != nil {
+				// Leave the Secret and password of this user as they are and
+				// move on to the other users.
+				r.Recorder.Eventf(cluster, corev1.EventTypeWarning, "InvalidUserPassword",
+					"Unable to read the password of user %q: %v", userName, readErr)
+				unreadable = append(unreadable, fmt.Sprintf("%q: %v", userName, readErr))
+				continue
+			}
+			secret = external
+		}
+
 		if err == nil {
 			userSecrets[userName], err = r.generatePostgresUserSecret(cluster, user, secret)
 		}
 		if err == nil {
 			err = errors.WithStack(r.apply(ctx, userSecrets[userName]))
 		}
+		if err == nil && rotated {
+			r.Recorder.Eventf(cluster, corev1.EventTypeNormal, "PasswordRotated",
+				"Rotated the password of user %q; the next rotation is at %s",
+				userName, rotation.NextRotationTime.Format(time.RFC3339))
+		}
+	}
+
+	if err == nil {
+		sort.Slice(rotations, func(i, j int) bool { return rotations[i].Name < rotations[j].Name })
+		cluster.Status.PasswordRotations = rotations
+
+		if len(unreadable) > 0 {
+			sort.Strings(unreadable)
+			meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+				Type:    v1beta1.ExternalPasswordsReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  "ReadFailed",
+				Message: "Unable to read the passwords of users " + strings.Join(unreadable, "; "),
+
+				ObservedGeneration: cluster.GetGeneration(),
+			})
+		} else {
+			meta.RemoveStatusCondition(&cluster.Status.Conditions, v1beta1.ExternalPasswordsReady)
+		}
 	}
 
 	return specUsers, userSecrets, err
 }
 
+// postgresUserVerifiers returns the verifiers of the login roles of users by
+// role name. Users rotated with a grace period have two login roles; the one
+// that has neither the current nor the previous verifier cannot log in.
+func postgresUserVerifiers(userSecrets map[string]*corev1.Secret) map[string]string {
+	verifiers := make(map[string]string, len(userSecrets))
+	for userName, secret := range userSecrets {
+		alternate := postgres.AlternateUser(userName)
+
+		login := userName
+		if string(secret.Data["user"]) == alternate {
+			login = alternate
+		}
+		verifiers[login] = string(secret.Data["verifier"])
+
+		if previous := string(secret.Data["previous-user"]); previous == userName || previous == alternate {
+			verifiers[previous] = string(secret.Data["previous-verifier"])
+		}
+	}
+	return verifiers
+}
+
 // reconcilePostgresUsersInPostgreSQL creates users inside of PostgreSQL and
 // sets their options and database access as specified.
 func (r *Reconciler) reconcilePostgresUsersInPostgreSQL(
@@ -620,10 +946,7 @@ func (r *Reconciler) reconcilePostgresUsersInPostgreSQL(
 
 	// Calculate a hash of the SQL that should be executed in PostgreSQL.
 
-	verifiers := make(map[string]string, len(userSecrets))
-	for userName := range userSecrets {
-		verifiers[userName] = string(userSecrets[userName].Data["verifier"])
-	}
+	verifiers := postgresUserVerifiers(userSecrets)
 
 	write := func(ctx context.Context, exec postgres.Executor) error {
 		return postgres.WriteUsersInPostgreSQL(ctx, cluster, exec, specUsers, verifiers)
@@ -9,7 +9,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr/funcr"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -18,9 +21,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/yaml"
 
 	"github.com/fulviodenza/percona-postgresql-operator/internal/controller/runtime"
@@ -29,6 +34,7 @@ import (
 	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/postgres"
+	pgpassword "github.com/fulviodenza/percona-postgresql-operator/internal/postgres/password"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/testing/cmp"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/testing/events"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/testing/require"
@@ -237,6 +243,379 @@ func TestGeneratePostgresUserSecret(t *testing.T) {
 	})
 }
 
+func TestRotatePostgresUserPassword(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	spec := &v1beta1.PostgresUserSpec{Name: "app"}
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			naming.PasswordRotatedAtAnnotation: now.Add(-31 * day).Format(time.RFC3339),
+		}},
+		Data: map[string][]byte{
+			"password": []byte("current"),
+			"verifier": []byte("SCRAM"),
+		},
+	}
+
+	t.Run("NoPolicy", func(t *testing.T) {
+		secret, status, rotated := rotatePostgresUserPassword(spec, existing, now)
+		assert.Equal(t, secret, existing)
+		assert.Assert(t, status == nil)
+		assert.Assert(t, !rotated)
+	})
+
+	spec.Password = &v1beta1.PostgresPasswordSpec{
+		Rotation: &v1beta1.PostgresPasswordRotationSpec{
+			Interval:    metav1.Duration{Duration: 30 * day},
+			GracePeriod: &metav1.Duration{Duration: day},
+		},
+	}
+
+	t.Run("NewSecret", func(t *testing.T) {
+		secret, status, rotated := rotatePostgresUserPassword(spec, nil, now)
+		assert.Assert(t, !rotated)
+		assert.Equal(t, secret.Annotations[naming.PasswordRotatedAtAnnotation], "2024-03-01T12:00:00Z")
+		assert.Equal(t, status.Name, "app")
+		assert.Assert(t, status.LastRotationTime.Time.Equal(now))
+		assert.Assert(t, status.NextRotationTime.Time.Equal(now.Add(30*day)))
+	})
+
+	t.Run("PolicyApplied", func(t *testing.T) {
+		// Existing passwords are not rotated as soon as the policy is applied.
+		existing := existing.DeepCopy()
+		existing.Annotations = nil
+
+		secret, _, rotated := rotatePostgresUserPassword(spec, existing, now)
+		assert.Assert(t, !rotated)
+		assert.Equal(t, string(secret.Data["password"]), "current")
+		assert.Equal(t, secret.Annotations[naming.PasswordRotatedAtAnnotation], "2024-03-01T12:00:00Z")
+	})
+
+	t.Run("NotDue", func(t *testing.T) {
+		secret, status, rotated := rotatePostgresUserPassword(spec, existing, now.Add(-2*day))
+		assert.Assert(t, !rotated)
+		assert.Equal(t, string(secret.Data["password"]), "current")
+		assert.Assert(t, status.NextRotationTime.Time.Equal(now.Add(-day)))
+	})
+
+	t.Run("Due", func(t *testing.T) {
+		secret, status, rotated := rotatePostgresUserPassword(spec, existing, now)
+		assert.Assert(t, rotated)
+		assert.Assert(t, secret.Data["password"] == nil)
+		assert.Assert(t, secret.Data["verifier"] == nil)
+		assert.Assert(t, status.LastRotationTime.Time.Equal(now))
+
+		// The new password belongs to the alternate role. The previous
+		// credentials are kept.
+		assert.Equal(t, string(secret.Data["user"]), "app_alt")
+		assert.Equal(t, string(secret.Data["previous-user"]), "app")
+		assert.Equal(t, string(secret.Data["previous-password"]), "current")
+		assert.Equal(t, string(secret.Data["previous-verifier"]), "SCRAM")
+
+		// The existing Secret is not changed.
+		assert.Equal(t, string(existing.Data["password"]), "current")
+
+		// The previous credentials are removed after the grace period.
+		secret.Data["password"] = []byte("new")
+		secret, _, rotated = rotatePostgresUserPassword(spec, secret, now.Add(day))
+		assert.Assert(t, !rotated)
+		assert.Equal(t, string(secret.Data["password"]), "new")
+		assert.Equal(t, string(secret.Data["user"]), "app_alt")
+		assert.Assert(t, secret.Data["previous-user"] == nil)
+		assert.Assert(t, secret.Data["previous-password"] == nil)
+		assert.Assert(t, secret.Data["previous-verifier"] == nil)
+
+		// The next rotation switches back to the user.
+		secret, _, rotated = rotatePostgresUserPassword(spec, secret, now.Add(30*day))
+		assert.Assert(t, rotated)
+		assert.Equal(t, string(secret.Data["user"]), "app")
+		assert.Equal(t, string(secret.Data["previous-user"]), "app_alt")
+		assert.Equal(t, string(secret.Data["previous-password"]), "new")
+	})
+
+	t.Run("NoGracePeriod", func(t *testing.T) {
+		spec := spec.DeepCopy()
+		spec.Password.Rotation.GracePeriod = nil
+
+		secret, _, rotated := rotatePostgresUserPassword(spec, existing, now)
+		assert.Assert(t, rotated)
+		assert.Assert(t, secret.Data["user"] == nil)
+		assert.Assert(t, secret.Data["previous-password"] == nil)
+	})
+}
+
+func TestPostgresUserVerifiersGracePeriod(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	reconciler := &Reconciler{}
+
+	cluster := &v1beta1.PostgresCluster{}
+	cluster.Namespace = "ns1"
+	cluster.Name = "hippo"
+	cluster.Spec.Port = initialize.Int32(5432)
+
+	spec := &v1beta1.PostgresUserSpec{
+		Name:      "app",
+		Databases: []v1beta1.PostgresIdentifier{"db1"},
+		Password: &v1beta1.PostgresPasswordSpec{
+			Rotation: &v1beta1.PostgresPasswordRotationSpec{
+				Interval:    metav1.Duration{Duration: 30 * day},
+				GracePeriod: &metav1.Duration{Duration: day},
+			},
+		},
+	}
+
+	reconcile := func(existing *corev1.Secret, now time.Time) *corev1.Secret {
+		t.Helper()
+		secret, _, _ := rotatePostgresUserPassword(spec, existing, now)
+		secret, err := reconciler.generatePostgresUserSecret(cluster, spec, secret)
+		assert.NilError(t, err)
+		return secret
+	}
+
+	initial := reconcile(nil, now)
+	assert.Equal(t, string(initial.Data["user"]), "app")
+	old := string(initial.Data["password"])
+
+	verifiers := postgresUserVerifiers(map[string]*corev1.Secret{"app": initial})
+	assert.Assert(t, pgpassword.NewSCRAMPassword(old).Verify(verifiers["app"]))
+	assert.Equal(t, verifiers["app_alt"], "")
+
+	// Within the grace period, the previous password still logs in as the
+	// user while the new one logs in as the alternate role.
+	rotated := reconcile(initial, now.Add(30*day))
+	assert.Equal(t, string(rotated.Data["user"]), "app_alt")
+	assert.Equal(t, string(rotated.Data["previous-password"]), old)
+	current := string(rotated.Data["password"])
+	assert.Assert(t, current != old)
+	assert.Assert(t, cmp.Contains(string(rotated.Data["uri"]), "app_alt:"))
+
+	verifiers = postgresUserVerifiers(map[string]*corev1.Secret{"app": rotated})
+	assert.Assert(t, pgpassword.NewSCRAMPassword(old).Verify(verifiers["app"]))
+	assert.Assert(t, pgpassword.NewSCRAMPassword(current).Verify(verifiers["app_alt"]))
+
+	// Once the grace period ends, the previous password no longer logs in.
+	expired := reconcile(rotated, now.Add(31*day))
+	assert.Equal(t, string(expired.Data["password"]), current)
+	verifiers = postgresUserVerifiers(map[string]*corev1.Secret{"app": expired})
+	assert.Equal(t, verifiers["app"], "")
+	assert.Assert(t, pgpassword.NewSCRAMPassword(current).Verify(verifiers["app_alt"]))
+
+	// Without a grace period the user logs in as itself with a new password.
+	spec.Password.Rotation.GracePeriod = nil
+	reverted := reconcile(expired, now.Add(32*day))
+	assert.Equal(t, string(reverted.Data["user"]), "app")
+	assert.Assert(t, string(reverted.Data["password"]) != current)
+	verifiers = postgresUserVerifiers(map[string]*corev1.Secret{"app": reverted})
+	assert.Equal(t, verifiers["app_alt"], "")
+}
+
+func TestWithExternalPassword(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := new(v1beta1.PostgresCluster)
+	cluster.Namespace = "ns1"
+
+	external := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "vault"},
+		Data:       map[string][]byte{"app": []byte("from-vault")},
+	}
+	reconciler := &Reconciler{Client: fake.NewClientBuilder().WithObjects(external).Build()}
+
+	spec := &v1beta1.PostgresUserSpec{
+		Name: "app",
+		Password: &v1beta1.PostgresPasswordSpec{
+			Source: &v1beta1.PostgresPasswordSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "vault"},
+					Key:                  "app",
+				},
+			},
+		},
+	}
+
+	t.Run("SecretKeyRef", func(t *testing.T) {
+		secret, err := reconciler.withExternalPassword(ctx, cluster, spec, nil)
+		assert.NilError(t, err)
+		assert.Equal(t, string(secret.Data["password"]), "from-vault")
+		assert.Assert(t, secret.Data["verifier"] == nil)
+
+		// A verifier of the password is kept; another one is not.
+		verifier, err := pgpassword.NewSCRAMPassword("from-vault").Build()
+		assert.NilError(t, err)
+
+		existing := &corev1.Secret{Data: map[string][]byte{"verifier": []byte(verifier)}}
+		secret, err = reconciler.withExternalPassword(ctx, cluster, spec, existing)
+		assert.NilError(t, err)
+		assert.Equal(t, string(secret.Data["verifier"]), verifier)
+
+		existing.Data["verifier"] = []byte("SCRAM-SHA-256$other")
+		secret, err = reconciler.withExternalPassword(ctx, cluster, spec, existing)
+		assert.NilError(t, err)
+		assert.Assert(t, secret.Data["verifier"] == nil)
+		assert.Equal(t, string(existing.Data["verifier"]), "SCRAM-SHA-256$other")
+	})
+
+	t.Run("MissingKey", func(t *testing.T) {
+		spec := spec.DeepCopy()
+		spec.Password.Source.SecretKeyRef.Key = "other"
+
+		_, err := reconciler.withExternalPassword(ctx, cluster, spec, nil)
+		assert.ErrorContains(t, err, "empty")
+	})
+
+	t.Run("File", func(t *testing.T) {
+		spec := spec.DeepCopy()
+		spec.Password.Source = &v1beta1.PostgresPasswordSource{File: "app/password"}
+
+		t.Setenv("PGO_PASSWORD_FILES_DIR", "")
+		_, err := reconciler.withExternalPassword(ctx, cluster, spec, nil)
+		assert.ErrorContains(t, err, "PGO_PASSWORD_FILES_DIR")
+
+		dir := t.TempDir()
+		t.Setenv("PGO_PASSWORD_FILES_DIR", dir)
+		assert.NilError(t, os.MkdirAll(filepath.Join(dir, "ns1", "app"), 0o700))
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, "ns1", "app", "password"), []byte("from-file\n"), 0o600))
+		assert.NilError(t, os.MkdirAll(filepath.Join(dir, "ns2", "app"), 0o700))
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, "ns2", "app", "password"), []byte("other\n"), 0o600))
+
+		secret, err := reconciler.withExternalPassword(ctx, cluster, spec, nil)
+		assert.NilError(t, err)
+		assert.Equal(t, string(secret.Data["password"]), "from-file")
+
+		// Files outside of the directory of the namespace are not read.
+		for _, file := range []string{
+			"../ns2/app/password",
+			"app/../../ns2/app/password",
+			"./../../etc/passwd",
+		} {
+			spec.Password.Source.File = file
+			_, err = reconciler.withExternalPassword(ctx, cluster, spec, nil)
+			assert.ErrorContains(t, err, "not a relative path", "file: %q", file)
+		}
+
+		spec.Password.Source.File = "app/../app/password"
+		secret, err = reconciler.withExternalPassword(ctx, cluster, spec, nil)
+		assert.NilError(t, err)
+		assert.Equal(t, string(secret.Data["password"]), "from-file")
+	})
+}
+
+func TestReconcilePostgresUserSecretsExternalPasswordFailure(t *testing.T) {
+	ctx := context.Background()
+	_, cc := setupKubernetes(t)
+	require.ParallelCapacity(t, 0)
+
+	recorder := events.NewRecorder(t, runtime.Scheme)
+	reconciler := &Reconciler{Client: cc, Owner: client.FieldOwner(t.Name()), Recorder: recorder}
+
+	dir := t.TempDir()
+	t.Setenv("PGO_PASSWORD_FILES_DIR", dir)
+
+	ns := setupNamespace(t, cc)
+	cluster := testCluster()
+	cluster.Namespace = ns.Name
+	cluster.Spec.Users = []v1beta1.PostgresUserSpec{
+		{Name: "generated"},
+		{
+			Name: "missing",
+			Password: &v1beta1.PostgresPasswordSpec{
+				Source: &v1beta1.PostgresPasswordSource{File: "missing"},
+			},
+		},
+	}
+	assert.NilError(t, cc.Create(ctx, cluster))
+
+	// One user without a readable password does not stop the others.
+	_, secrets, err := reconciler.reconcilePostgresUserSecrets(ctx, cluster)
+	assert.NilError(t, err)
+	assert.Assert(t, secrets["generated"] != nil)
+	assert.Assert(t, secrets["missing"] == nil)
+
+	condition := meta.FindStatusCondition(cluster.Status.Conditions, v1beta1.ExternalPasswordsReady)
+	assert.Assert(t, condition != nil)
+	assert.Equal(t, condition.Status, metav1.ConditionFalse)
+	assert.Assert(t, cmp.Contains(condition.Message, `"missing"`))
+	assert.Equal(t, len(recorder.Events), 1)
+	assert.Equal(t, recorder.Events[0].Reason, "InvalidUserPassword")
+
+	// The condition goes away once the password can be read.
+	assert.NilError(t, os.MkdirAll(filepath.Join(dir, ns.Name), 0o700))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, ns.Name, "missing"), []byte("found"), 0o600))
+
+	_, secrets, err = reconciler.reconcilePostgresUserSecrets(ctx, cluster)
+	assert.NilError(t, err)
+	assert.Equal(t, string(secrets["missing"].Data["password"]), "found")
+	assert.Assert(t, meta.FindStatusCondition(cluster.Status.Conditions, v1beta1.ExternalPasswordsReady) == nil)
+}
+
+func TestGeneratePostgresUserSecretExternalPassword(t *testing.T) {
+	reconciler := &Reconciler{}
+
+	cluster := &v1beta1.PostgresCluster{}
+	cluster.Namespace = "ns1"
+	cluster.Name = "hippo2"
+	cluster.Spec.Port = initialize.Int32(9999)
+	cluster.Spec.Proxy = &v1beta1.PostgresProxySpec{
+		PGBouncer: &v1beta1.PGBouncerPodSpec{Port: initialize.Int32(6432)},
+	}
+
+	spec := &v1beta1.PostgresUserSpec{
+		Name:      "app",
+		Databases: []v1beta1.PostgresIdentifier{"app"},
+		Password: &v1beta1.PostgresPasswordSpec{
+			Source: &v1beta1.PostgresPasswordSource{File: "app"},
+		},
+	}
+	existing := &corev1.Secret{Data: map[string][]byte{"password": []byte("from-file")}}
+
+	secret, err := reconciler.generatePostgresUserSecret(cluster, spec, existing)
+	assert.NilError(t, err)
+
+	// The plaintext password is not written.
+	for _, key := range []string{"password", "uri", "jdbc-uri", "pgbouncer-uri", "pgbouncer-jdbc-uri"} {
+		_, ok := secret.Data[key]
+		assert.Assert(t, !ok, "unexpected %q", key)
+	}
+	assert.Assert(t, pgpassword.NewSCRAMPassword("from-file").Verify(string(secret.Data["verifier"])))
+	assert.Equal(t, string(secret.Data["dbname"]), "app")
+}
+
+func TestPasswordRotationRequeue(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	cluster := new(v1beta1.PostgresCluster)
+	assert.Equal(t, passwordRotationRequeue(cluster, nil, now), time.Duration(0))
+
+	users := []v1beta1.PostgresUserSpec{
+		{Name: "a", Password: &v1beta1.PostgresPasswordSpec{
+			Rotation: &v1beta1.PostgresPasswordRotationSpec{
+				GracePeriod: &metav1.Duration{Duration: time.Hour},
+			},
+		}},
+		{Name: "b"},
+	}
+	cluster.Status.PasswordRotations = []v1beta1.PostgresPasswordRotationStatus{
+		{
+			Name:             "a",
+			LastRotationTime: metav1.NewTime(now.Add(-30 * time.Minute)),
+			NextRotationTime: metav1.NewTime(now.Add(10 * time.Hour)),
+		},
+		{
+			Name:             "b",
+			LastRotationTime: metav1.NewTime(now.Add(-time.Hour)),
+			NextRotationTime: metav1.NewTime(now.Add(5 * time.Hour)),
+		},
+	}
+
+	// The end of the grace period of "a" comes first.
+	assert.Equal(t, passwordRotationRequeue(cluster, users, now), 30*time.Minute)
+
+	// Times in the past are ignored.
+	assert.Equal(t, passwordRotationRequeue(cluster, users, now.Add(time.Hour)), 4*time.Hour)
+}
+
 func TestReconcilePostgresVolumes(t *testing.T) {
 	ctx := context.Background()
 	_, tClient := setupKubernetes(t)
@@ -81,4 +81,20 @@ const (
 	// is present, the controller will not update the ConfigMap, allowing users to make custom
 	// modifications that won't be overwritten during reconciliation.
 	OverrideConfigAnnotation = perconaAnnotationPrefix + "override-config"
+
+	// PatroniCallbackAnnotation is the annotation the Patroni callbacks set on
+	// their instance Pod. The value describes the last start, stop or role
+	// change of the instance as JSON. See patroni.CallbackEvent.
+	PatroniCallbackAnnotation = perconaAnnotationPrefix + "patroni-callback"
+
+	// PasswordRotatedAtAnnotation is set on the Secret of a PostgreSQL user
+	// with a password rotation policy. The value is the RFC 3339 time when the
+	// password was last rotated.
+	PasswordRotatedAtAnnotation = perconaAnnotationPrefix + "password-rotated-at"
+
+	// PrimaryServiceTargetAnnotation is set on a PostgresCluster whose clients
+	// were moved to another PostgresCluster in the same namespace, e.g. by a
+	// logical upgrade. The value is the name of that cluster, and the primary
+	// Service resolves to its leader instead.
+	PrimaryServiceTargetAnnotation = annotationPrefix + "primary-service-target"
 )
@@ -36,6 +36,8 @@ const (
 	ContainerPGBouncer = "pgbouncer"
 	// ContainerPGBouncerConfig is the name of a container supporting PgBouncer.
 	ContainerPGBouncerConfig = "pgbouncer-config"
+	// ContainerPGBouncerExporter is the name of a container running pgbouncer_exporter.
+	ContainerPGBouncerExporter = "pgbouncer-exporter"
 
 	// ContainerPostgresStartup is the name of the initialization container
 	// that prepares the filesystem for PostgreSQL.
@@ -74,6 +76,8 @@ const (
 	PortPGAdmin = "pgadmin"
 	// PortPGBouncer is the name of a port that connects to PgBouncer.
 	PortPGBouncer = "pgbouncer"
+	// PortPGBouncerExporter is the named port of the "pgbouncer-exporter" container.
+	PortPGBouncerExporter = "pgb-metrics"
 	// PortPostgreSQL is the name of a port that connects to PostgreSQL.
 	PortPostgreSQL = "postgres"
 )
@@ -134,6 +138,28 @@ const (
 	ReplicationCACertPath = "replication/ca.crt"
 )
 
+const (
+	// ExtensionsStorageVolume is the name of the volume and volume mount with
+	// the custom extension archives of a filesystem storage
+	ExtensionsStorageVolume = "extensions-storage"
+
+	// ExtensionsStorageMountPath is the path the custom extension archives of a
+	// filesystem storage are read from
+	ExtensionsStorageMountPath = "/extensions-storage"
+
+	// ExtensionsSignatureKeyVolume is the name of the volume and volume mount
+	// with the public key that verifies custom extension archives
+	ExtensionsSignatureKeyVolume = "extensions-signature-key"
+
+	// ExtensionsSignatureKeyMountPath is the path for mounting the public key
+	// that verifies custom extension archives
+	ExtensionsSignatureKeyMountPath = "/etc/extensions-signature-key"
+
+	// ExtensionsSignatureKeyFile is the file name of the public key at
+	// ExtensionsSignatureKeyMountPath
+	ExtensionsSignatureKeyFile = "public.pem"
+)
+
 const (
 	// PGBackRestRepoContainerName is the name assigned to the container used to run pgBackRest
 	PGBackRestRepoContainerName = "pgbackrest"
@@ -222,6 +248,15 @@ func ClusterPGBouncer(cluster *v1beta1.PostgresCluster) metav1.ObjectMeta {
 	}
 }
 
+// ClusterPGBouncerUsers returns the ObjectMeta necessary to lookup the Secret
+// that holds the passwords of the PgBouncer admin console users.
+func ClusterPGBouncerUsers(cluster *v1beta1.PostgresCluster) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace: cluster.Namespace,
+		Name:      cluster.Name + "-pgbouncer-users",
+	}
+}
+
 // ClusterPodService returns the ObjectMeta necessary to lookup the Service
 // that is responsible for the network identity of Pods.
 func ClusterPodService(cluster *v1beta1.PostgresCluster) metav1.ObjectMeta {
@@ -433,6 +468,16 @@ func PatroniLeaderEndpoints(cluster *v1beta1.PostgresCluster) metav1.ObjectMeta
 	}
 }
 
+// PatroniSync returns the ObjectMeta necessary to lookup the Endpoints
+// created by Patroni for cluster to track its synchronous standbys.
+// See Patroni DCS "sync_path".
+func PatroniSync(cluster *v1beta1.PostgresCluster) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace: cluster.Namespace,
+		Name:      PatroniScope(cluster) + "-sync",
+	}
+}
+
 // PatroniScope returns the "scope" Patroni uses for cluster.
 func PatroniScope(cluster *v1beta1.PostgresCluster) string {
 	return cluster.Name + "-ha"
@@ -9,6 +9,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 
@@ -19,7 +20,7 @@ import (
 type API interface {
 	// ChangePrimaryAndWait tries to demote the current Patroni leader. It
 	// returns true when an election completes successfully. When Patroni is
-	// paused, next cannot be blank.
+	// paused, next cannot be blank. Members tagged "nofailover" are refused.
 	ChangePrimaryAndWait(ctx context.Context, current, next string, patroniVer4 bool) (bool, error)
 
 	// ReplaceConfiguration replaces Patroni's entire dynamic configuration.
@@ -34,13 +35,71 @@ type Executor func(
 // Executor implements API.
 var _ API = Executor(nil)
 
+// ErrNoFailoverCandidate is returned when the candidate of a switchover or
+// failover has the Patroni "nofailover" tag.
+var ErrNoFailoverCandidate = errors.New("candidate is tagged nofailover")
+
+// checkCandidate returns ErrNoFailoverCandidate when the Patroni member named
+// candidate has the "nofailover" tag. A blank candidate lets Patroni choose,
+// and Patroni never chooses such members.
+func (exec Executor) checkCandidate(ctx context.Context, candidate string) error {
+	if candidate == "" {
+		return nil
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	// The extended output includes the tags of each member.
+	err := exec(ctx, nil, &stdout, &stderr,
+		"patronictl", "list", "--extended", "--format", "json")
+	if err != nil {
+		return err
+	}
+
+	var members []struct {
+		Member string          `json:"Member"`
+		Tags   json.RawMessage `json:"Tags"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &members); err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if member.Member != candidate {
+			continue
+		}
+
+		// Members without tags have an empty string rather than an object.
+		var tags map[string]any
+		_ = json.Unmarshal(member.Tags, &tags)
+
+		switch value := tags["nofailover"].(type) {
+		case bool:
+			if value {
+				return fmt.Errorf("%w: %s", ErrNoFailoverCandidate, candidate)
+			}
+		case string:
+			if strings.EqualFold(value, "true") {
+				return fmt.Errorf("%w: %s", ErrNoFailoverCandidate, candidate)
+			}
+		}
+	}
+
+	return nil
+}
+
 // ChangePrimaryAndWait tries to demote the current Patroni leader by calling
 // "patronictl". It returns true when an election completes successfully. It
 // waits up to two "loop_wait" or until an error occurs. When Patroni is paused,
 // next cannot be blank. Similar to the "POST /switchover" REST endpoint.
+// It refuses to promote a next member that has the "nofailover" tag.
 func (exec Executor) ChangePrimaryAndWait(
 	ctx context.Context, current, next string, patroniVer4 bool,
 ) (bool, error) {
+	if err := exec.checkCandidate(ctx, next); err != nil {
+		return false, err
+	}
+
 	var stdout, stderr bytes.Buffer
 
 	// K8SPG-648: patroni v4.0.0 deprecated "master" role.
@@ -77,6 +136,10 @@ func (exec Executor) ChangePrimaryAndWait(
 func (exec Executor) SwitchoverAndWait(
 	ctx context.Context, target string,
 ) (bool, error) {
+	if err := exec.checkCandidate(ctx, target); err != nil {
+		return false, err
+	}
+
 	var stdout, stderr bytes.Buffer
 
 	err := exec(ctx, nil, &stdout, &stderr,
@@ -108,6 +171,10 @@ func (exec Executor) SwitchoverAndWait(
 func (exec Executor) FailoverAndWait(
 	ctx context.Context, target string,
 ) (bool, error) {
+	if err := exec.checkCandidate(ctx, target); err != nil {
+		return false, err
+	}
+
 	var stdout, stderr bytes.Buffer
 
 	err := exec(ctx, nil, &stdout, &stderr,
@@ -213,3 +280,37 @@ func (exec Executor) GetTimeline(ctx context.Context) (int64, error) {
 
 	return 0, err
 }
+
+// GetPrimary gets the name of the running Patroni leader. It returns an empty
+// string when there is no running leader.
+func (exec Executor) GetPrimary(ctx context.Context) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	err := exec(ctx, nil, &stdout, &stderr,
+		"patronictl", "list", "--format", "json")
+	if err != nil {
+		return "", err
+	}
+
+	if stderr.String() != "" {
+		return "", errors.New(stderr.String())
+	}
+
+	var members []struct {
+		Member string `json:"Member"`
+		Role   string `json:"Role"`
+		State  string `json:"State"`
+	}
+	err = json.Unmarshal(stdout.Bytes(), &members)
+	if err != nil {
+		return "", err
+	}
+
+	for _, member := range members {
+		if member.Role == "Leader" && member.State == "running" {
+			return member.Member, nil
+		}
+	}
+
+	return "", nil
+}
@@ -28,6 +28,60 @@ func ExampleExecutor_execCmd() {
 	})
 }
 
+// withMembers answers "patronictl list" with members and passes any other
+// command to exec.
+func withMembers(members string, exec Executor) Executor {
+	return func(
+		ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, command ...string,
+	) error {
+		if len(command) > 1 && command[0] == "patronictl" && command[1] == "list" {
+			_, err := stdout.Write([]byte(members))
+			return err
+		}
+		return exec(ctx, stdin, stdout, stderr, command...)
+	}
+}
+
+func TestExecutorCheckCandidate(t *testing.T) {
+	ctx := context.Background()
+	members := `[
+		{"Cluster": "hippo-ha", "Member": "hippo-instance1-abcd-0", "Role": "Leader", "Tags": ""},
+		{"Cluster": "hippo-ha", "Member": "hippo-analytics-efgh-0", "Role": "Replica", "Tags": {"nofailover": true, "nosync": true}},
+		{"Cluster": "hippo-ha", "Member": "hippo-instance1-abcd-1", "Role": "Replica", "Tags": {"nofailover": false}}
+	]`
+
+	called := false
+	exec := withMembers(members, func(context.Context, io.Reader, io.Writer, io.Writer, ...string) error {
+		called = true
+		return nil
+	})
+
+	for _, action := range []func(string) (bool, error){
+		func(next string) (bool, error) {
+			return exec.ChangePrimaryAndWait(ctx, "hippo-instance1-abcd-0", next, true)
+		},
+		func(next string) (bool, error) { return exec.SwitchoverAndWait(ctx, next) },
+		func(next string) (bool, error) { return exec.FailoverAndWait(ctx, next) },
+	} {
+		called = false
+		success, err := action("hippo-analytics-efgh-0")
+		assert.Assert(t, !success)
+		assert.Assert(t, errors.Is(err, ErrNoFailoverCandidate), "got %v", err)
+		assert.Assert(t, !called, "expected no switchover")
+
+		_, err = action("hippo-instance1-abcd-1")
+		assert.NilError(t, err)
+		assert.Assert(t, called, "expected switchover")
+	}
+
+	// Patroni chooses the candidate when next is blank.
+	_, err := Executor(func(_ context.Context, _ io.Reader, _, _ io.Writer, command ...string) error {
+		assert.Assert(t, command[1] != "list")
+		return nil
+	}).ChangePrimaryAndWait(ctx, "hippo-instance1-abcd-0", "", true)
+	assert.NilError(t, err)
+}
+
 func TestExecutorChangePrimaryAndWait(t *testing.T) {
 	t.Run("Arguments", func(t *testing.T) {
 		called := false
@@ -44,7 +98,7 @@ func TestExecutorChangePrimaryAndWait(t *testing.T) {
 			return nil
 		}
 
-		_, _ = Executor(exec).ChangePrimaryAndWait(context.Background(), "old", "new", true)
+		_, _ = withMembers(`[]`, exec).ChangePrimaryAndWait(context.Background(), "old", "new", true)
 		assert.Assert(t, called)
 	})
 
@@ -60,7 +114,7 @@ func TestExecutorChangePrimaryAndWait(t *testing.T) {
 	})
 
 	t.Run("Result", func(t *testing.T) {
-		success, _ := Executor(func(
+		success, _ := withMembers(`[]`, func(
 			_ context.Context, _ io.Reader, stdout, _ io.Writer, _ ...string,
 		) error {
 			_, _ = stdout.Write([]byte(`no luck`))
@@ -69,7 +123,7 @@ func TestExecutorChangePrimaryAndWait(t *testing.T) {
 
 		assert.Assert(t, !success, "expected failure message to become false")
 
-		success, _ = Executor(func(
+		success, _ = withMembers(`[]`, func(
 			_ context.Context, _ io.Reader, stdout, _ io.Writer, _ ...string,
 		) error {
 			_, _ = stdout.Write([]byte(`Successfully switched over to something`))
@@ -96,7 +150,7 @@ func TestExecutorSwitchoverAndWait(t *testing.T) {
 			return nil
 		}
 
-		_, _ = Executor(exec).SwitchoverAndWait(context.Background(), "new")
+		_, _ = withMembers(`[]`, exec).SwitchoverAndWait(context.Background(), "new")
 		assert.Assert(t, called)
 	})
 
@@ -112,7 +166,7 @@ func TestExecutorSwitchoverAndWait(t *testing.T) {
 	})
 
 	t.Run("Result", func(t *testing.T) {
-		success, _ := Executor(func(
+		success, _ := withMembers(`[]`, func(
 			_ context.Context, _ io.Reader, stdout, _ io.Writer, _ ...string,
 		) error {
 			_, _ = stdout.Write([]byte(`no luck`))
@@ -121,7 +175,7 @@ func TestExecutorSwitchoverAndWait(t *testing.T) {
 
 		assert.Assert(t, !success, "expected failure message to become false")
 
-		success, _ = Executor(func(
+		success, _ = withMembers(`[]`, func(
 			_ context.Context, _ io.Reader, stdout, _ io.Writer, _ ...string,
 		) error {
 			_, _ = stdout.Write([]byte(`Successfully switched over to something`))
@@ -148,7 +202,7 @@ func TestExecutorFailoverAndWait(t *testing.T) {
 			return nil
 		}
 
-		_, _ = Executor(exec).FailoverAndWait(context.Background(), "new")
+		_, _ = withMembers(`[]`, exec).FailoverAndWait(context.Background(), "new")
 		assert.Assert(t, called)
 	})
 
@@ -164,7 +218,7 @@ func TestExecutorFailoverAndWait(t *testing.T) {
 	})
 
 	t.Run("Result", func(t *testing.T) {
-		success, _ := Executor(func(
+		success, _ := withMembers(`[]`, func(
 			_ context.Context, _ io.Reader, stdout, _ io.Writer, _ ...string,
 		) error {
 			_, _ = stdout.Write([]byte(`no luck`))
@@ -173,7 +227,7 @@ func TestExecutorFailoverAndWait(t *testing.T) {
 
 		assert.Assert(t, !success, "expected failure message to become false")
 
-		success, _ = Executor(func(
+		success, _ = withMembers(`[]`, func(
 			_ context.Context, _ io.Reader, stdout, _ io.Writer, _ ...string,
 		) error {
 			_, _ = stdout.Write([]byte(`Successfully failed over to something`))
@@ -287,3 +341,54 @@ func TestExecutorGetTimeline(t *testing.T) {
 		assert.Equal(t, tl, int64(4))
 	})
 }
+
+func TestExecutorGetPrimary(t *testing.T) {
+	t.Run("Error", func(t *testing.T) {
+		expected := errors.New("bang")
+		primary, actual := Executor(func(
+			context.Context, io.Reader, io.Writer, io.Writer, ...string,
+		) error {
+			return expected
+		}).GetPrimary(context.Background())
+
+		assert.Equal(t, expected, actual)
+		assert.Equal(t, primary, "")
+	})
+
+	t.Run("Stderr", func(t *testing.T) {
+		primary, actual := Executor(func(
+			_ context.Context, stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			_, _ = stderr.Write([]byte(`no luck`))
+			return nil
+		}).GetPrimary(context.Background())
+
+		assert.Error(t, actual, "no luck")
+		assert.Equal(t, primary, "")
+	})
+
+	t.Run("NoLeader", func(t *testing.T) {
+		primary, actual := Executor(func(
+			_ context.Context, stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			_, _ = stdout.Write([]byte(`[{"Cluster": "hippo-ha", "Member": "hippo-instance1-ltcf-0", "Host": "hippo-instance1-ltcf-0.hippo-pods", "Role": "Replica", "State": "running", "TL": 4, "Lag in MB": 0}]`))
+			return nil
+		}).GetPrimary(context.Background())
+
+		assert.NilError(t, actual)
+		assert.Equal(t, primary, "")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		primary, actual := Executor(func(
+			_ context.Context, stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			assert.DeepEqual(t, command, strings.Fields(`patronictl list --format json`))
+			_, _ = stdout.Write([]byte(`[{"Cluster": "hippo-ha", "Member": "hippo-instance1-67mc-0", "Host": "hippo-instance1-67mc-0.hippo-pods", "Role": "Leader", "State": "running", "TL": 4}, {"Cluster": "hippo-ha", "Member": "hippo-instance1-ltcf-0", "Host": "hippo-instance1-ltcf-0.hippo-pods", "Role": "Replica", "State": "running", "TL": 4, "Lag in MB": 0}]`))
+			return nil
+		}).GetPrimary(context.Background())
+
+		assert.NilError(t, actual)
+		assert.Equal(t, primary, "hippo-instance1-67mc-0")
+	})
+}
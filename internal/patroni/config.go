@@ -5,9 +5,11 @@
 package patroni
 
 import (
+	"encoding/json"
 	"fmt"
 	"path"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/yaml"
@@ -40,6 +42,69 @@ func quoteShellWord(s string) string {
 	return `'` + strings.ReplaceAll(s, `'`, `'"'"'`) + `'`
 }
 
+// callbackCommand returns the command Patroni runs for its callbacks. Patroni
+// appends the action, the role of the instance, and the scope of the cluster
+// as arguments. The command stores the action and role, along with the current
+// timeline of a primary, in an annotation of the instance Pod.
+func callbackCommand() string {
+	script := fmt.Sprintf(`
+SERVICEACCOUNT="/var/run/secrets/kubernetes.io/serviceaccount"
+NAMESPACE=$(cat ${SERVICEACCOUNT}/namespace)
+TOKEN=$(cat ${SERVICEACCOUNT}/token)
+
+# Only a primary has a timeline. Take it from the current WAL file because the
+# checkpoint may lag behind right after a promotion.
+walfile=$(psql -Xqt --no-align --command="SELECT pg_walfile_name(pg_current_wal_lsn()) WHERE NOT pg_is_in_recovery()" 2>/dev/null) || walfile=
+timeline=${walfile:0:8}
+
+d=$(printf '{"metadata":{"annotations":{%q:"{\\"action\\":\\"%%s\\",\\"role\\":\\"%%s\\",\\"timeline\\":%%d,\\"time\\":\\"%%s\\"}"}}}' \
+  "$1" "$2" "$((16#${timeline:-0}))" "$(date -u +%%Y-%%m-%%dT%%H:%%M:%%S.%%NZ)")
+curl --silent --show-error --max-time 10 --cacert ${SERVICEACCOUNT}/ca.crt --header "Authorization: Bearer ${TOKEN}" -XPATCH "https://kubernetes.default.svc/api/v1/namespaces/${NAMESPACE}/pods/${HOSTNAME}" -H "Content-Type: application/merge-patch+json" --data "$d" > /dev/null
+`, naming.PatroniCallbackAnnotation)
+
+	return "bash -ceu -- " + quoteShellWord(script) + " patroni-callback"
+}
+
+// CallbackEvent is the last start, stop or role change reported by the Patroni
+// callbacks of an instance.
+type CallbackEvent struct {
+	// Action is the Patroni callback, one of "on_start", "on_stop" or
+	// "on_role_change".
+	Action string `json:"action"`
+
+	// Role is the role of the instance when the callback ran. After a role
+	// change, it's the new role.
+	Role string `json:"role"`
+
+	// Timeline is the timeline of a primary. It's zero for other roles.
+	Timeline int64 `json:"timeline"`
+
+	// Time is when the callback ran.
+	Time time.Time `json:"time"`
+}
+
+// Promoted reports whether the instance became the primary.
+func (e CallbackEvent) Promoted() bool {
+	return e.Action == "on_role_change" &&
+		(e.Role == naming.RolePatroniLeader || e.Role == naming.RolePatroniLeaderDeprecated)
+}
+
+// PodCallbackEvent returns the last event reported by the Patroni callbacks of
+// an instance Pod. It returns nil when the Pod has no such event.
+func PodCallbackEvent(pod *corev1.Pod) (*CallbackEvent, error) {
+	value, ok := pod.Annotations[naming.PatroniCallbackAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	event := new(CallbackEvent)
+	if err := json.Unmarshal([]byte(value), event); err != nil {
+		return nil, fmt.Errorf("parse %s annotation of pod %s: %w",
+			naming.PatroniCallbackAnnotation, pod.Name, err)
+	}
+	return event, nil
+}
+
 // clusterYAML returns Patroni settings that apply to the entire cluster.
 func clusterYAML(
 	cluster *v1beta1.PostgresCluster,
@@ -70,8 +135,6 @@ func clusterYAML(
 		},
 
 		"postgresql": map[string]any{
-			// TODO(cbandy): "callbacks"
-
 			// Custom configuration "must exist on all cluster nodes".
 			//
 			// TODO(cbandy): I imagine we will always set this to a file we own. At
@@ -152,6 +215,17 @@ func clusterYAML(
 		},
 	}
 
+	// Report starts, stops and role changes of every instance to the
+	// operator by annotating the instance Pod.
+	// - https://patroni.readthedocs.io/en/latest/yaml_configuration.html#postgresql
+	if cluster.CompareVersion("2.7.0") >= 0 {
+		root["postgresql"].(map[string]any)["callbacks"] = map[string]any{
+			"on_role_change": callbackCommand(),
+			"on_start":       callbackCommand(),
+			"on_stop":        callbackCommand(),
+		}
+	}
+
 	if !ClusterBootstrapped(cluster) {
 		// Patroni has not yet bootstrapped. Populate the "bootstrap.dcs" field to
 		// facilitate it. When Patroni is already bootstrapped, this field is ignored.
@@ -190,6 +264,27 @@ func DynamicConfiguration(
 	root["ttl"] = *cluster.Spec.Patroni.LeaderLeaseDurationSeconds
 	root["loop_wait"] = *cluster.Spec.Patroni.SyncPeriodSeconds
 
+	// Override any synchronous settings of the "configuration" when the
+	// synchronous block is set.
+	// - https://patroni.readthedocs.io/en/latest/replication_modes.html
+	if sync := cluster.Spec.Patroni.Synchronous; sync != nil {
+		switch sync.Mode {
+		case v1beta1.PatroniSynchronousOn:
+			root["synchronous_mode"] = true
+			root["synchronous_mode_strict"] = false
+		case v1beta1.PatroniSynchronousStrict:
+			root["synchronous_mode"] = true
+			root["synchronous_mode_strict"] = true
+		case v1beta1.PatroniSynchronousQuorum:
+			root["synchronous_mode"] = "quorum"
+			root["synchronous_mode_strict"] = false
+		default:
+			root["synchronous_mode"] = false
+			root["synchronous_mode_strict"] = false
+		}
+		root["synchronous_node_count"] = sync.GetNodeCount()
+	}
+
 	// Copy the "postgresql" section before making any changes.
 	postgresql := map[string]any{
 		// TODO(cbandy): explain this. requires an archive, perhaps.
@@ -473,11 +568,18 @@ func instanceConfigFiles(cluster, instance *corev1.ConfigMap) []corev1.VolumePro
 	}
 }
 
-// instanceYAML returns Patroni settings that apply to instance.
+// instanceYAML returns Patroni settings that apply to instance. When
+// replicateFrom is not empty, the instance replicates from that member rather
+// than the primary.
 func instanceYAML(
 	cluster *v1beta1.PostgresCluster, instance *v1beta1.PostgresInstanceSetSpec,
-	pgbackrestReplicaCreateCommand []string,
+	replicateFrom string, pgbackrestReplicaCreateCommand []string,
 ) (string, error) {
+	tags := instance.Patroni.Tags()
+	if replicateFrom != "" {
+		tags["replicatefrom"] = replicateFrom
+	}
+
 	root := map[string]any{
 		// Missing here is "name" which cannot be known until the instance Pod is
 		// created. That value should be injected using the downward API and the
@@ -501,10 +603,7 @@ func instanceYAML(
 			// See the PATRONI_RESTAPI_LISTEN environment variable.
 		},
 
-		"tags": map[string]any{
-			// TODO(cbandy): "nofailover"
-			// TODO(cbandy): "nosync"
-		},
+		"tags": tags,
 	}
 
 	postgresql := map[string]any{
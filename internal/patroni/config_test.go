@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -18,6 +19,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/postgres"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/testing/cmp"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/testing/require"
@@ -144,6 +146,33 @@ watchdog:
   mode: "off"
 	`)+"\n")
 	})
+
+	t.Run("callbacks", func(t *testing.T) {
+		cluster := new(v1beta1.PostgresCluster)
+		assert.NilError(t, cluster.Default(context.Background(), nil))
+
+		var root struct {
+			PostgreSQL struct {
+				Callbacks map[string]string `json:"callbacks"`
+			} `json:"postgresql"`
+		}
+
+		data, err := clusterYAML(cluster, postgres.HBAs{}, postgres.Parameters{})
+		assert.NilError(t, err)
+		assert.NilError(t, yaml.Unmarshal([]byte(data), &root))
+		assert.Assert(t, root.PostgreSQL.Callbacks == nil)
+
+		cluster.Labels = map[string]string{naming.LabelVersion: "2.7.0"}
+
+		data, err = clusterYAML(cluster, postgres.HBAs{}, postgres.Parameters{})
+		assert.NilError(t, err)
+		assert.NilError(t, yaml.Unmarshal([]byte(data), &root))
+		assert.DeepEqual(t, root.PostgreSQL.Callbacks, map[string]string{
+			"on_role_change": callbackCommand(),
+			"on_start":       callbackCommand(),
+			"on_stop":        callbackCommand(),
+		})
+	})
 }
 
 func TestDynamicConfiguration(t *testing.T) {
@@ -220,6 +249,61 @@ func TestDynamicConfiguration(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "synchronous: spec overrides input",
+			cluster: &v1beta1.PostgresCluster{
+				Spec: v1beta1.PostgresClusterSpec{
+					Patroni: &v1beta1.PatroniSpec{
+						Synchronous: &v1beta1.PatroniSynchronous{
+							Mode:      v1beta1.PatroniSynchronousStrict,
+							NodeCount: initialize.Int32(2),
+						},
+					},
+				},
+			},
+			input: map[string]any{
+				"synchronous_mode":       false,
+				"synchronous_node_count": 5,
+			},
+			expected: map[string]any{
+				"loop_wait":               int32(10),
+				"ttl":                     int32(30),
+				"synchronous_mode":        true,
+				"synchronous_mode_strict": true,
+				"synchronous_node_count":  int32(2),
+				"postgresql": map[string]any{
+					"parameters":    map[string]any{},
+					"pg_hba":        []string{},
+					"use_pg_rewind": true,
+					"use_slots":     false,
+				},
+			},
+		},
+		{
+			name: "synchronous: quorum",
+			cluster: &v1beta1.PostgresCluster{
+				Spec: v1beta1.PostgresClusterSpec{
+					Patroni: &v1beta1.PatroniSpec{
+						Synchronous: &v1beta1.PatroniSynchronous{
+							Mode: v1beta1.PatroniSynchronousQuorum,
+						},
+					},
+				},
+			},
+			expected: map[string]any{
+				"loop_wait":               int32(10),
+				"ttl":                     int32(30),
+				"synchronous_mode":        "quorum",
+				"synchronous_mode_strict": false,
+				"synchronous_node_count":  int32(1),
+				"postgresql": map[string]any{
+					"parameters":    map[string]any{},
+					"pg_hba":        []string{},
+					"use_pg_rewind": true,
+					"use_slots":     false,
+				},
+			},
+		},
 		{
 			name: "postgresql: wrong-type is ignored",
 			input: map[string]any{
@@ -874,7 +958,7 @@ func TestInstanceYAML(t *testing.T) {
 	}
 	instance := new(v1beta1.PostgresInstanceSetSpec)
 
-	data, err := instanceYAML(cluster, instance, nil)
+	data, err := instanceYAML(cluster, instance, "", nil)
 	assert.NilError(t, err)
 	assert.Equal(t, data, strings.Trim(`
 # Generated by postgres-operator. DO NOT EDIT UNLESS YOU KNOW WHAT YOU'RE DOING.
@@ -897,7 +981,7 @@ restapi: {}
 tags: {}
 	`, "\t\n")+"\n")
 
-	dataWithReplicaCreate, err := instanceYAML(cluster, instance, []string{"some", "backrest", "cmd"})
+	dataWithReplicaCreate, err := instanceYAML(cluster, instance, "", []string{"some", "backrest", "cmd"})
 	assert.NilError(t, err)
 	assert.Equal(t, dataWithReplicaCreate, strings.Trim(`
 # Generated by postgres-operator. DO NOT EDIT UNLESS YOU KNOW WHAT YOU'RE DOING.
@@ -937,7 +1021,7 @@ tags: {}
 		},
 	}
 
-	datawithTDE, err := instanceYAML(cluster, instance, nil)
+	datawithTDE, err := instanceYAML(cluster, instance, "", nil)
 	assert.NilError(t, err)
 	assert.Equal(t, datawithTDE, strings.Trim(`
 # Generated by postgres-operator. DO NOT EDIT UNLESS YOU KNOW WHAT YOU'RE DOING.
@@ -962,7 +1046,7 @@ tags: {}
 	`, "\t\n")+"\n")
 
 	cluster.Spec.Patroni.CreateReplicaMethods = []v1beta1.CreateReplicaMethod{"basebackup", "pgbackrest"}
-	dataWithCustomMethods, err := instanceYAML(cluster, instance, nil)
+	dataWithCustomMethods, err := instanceYAML(cluster, instance, "", nil)
 	assert.NilError(t, err)
 	assert.Equal(t, dataWithCustomMethods, strings.Trim(`
 # Generated by postgres-operator. DO NOT EDIT UNLESS YOU KNOW WHAT YOU'RE DOING.
@@ -986,6 +1070,22 @@ postgresql:
 restapi: {}
 tags: {}
 	`, "\t\n")+"\n")
+
+	instance.Patroni = &v1beta1.InstancePatroniSpec{
+		NoFailover:    true,
+		NoSync:        true,
+		CloneFrom:     true,
+		ReplicateFrom: "instance1",
+	}
+	dataWithTags, err := instanceYAML(cluster, instance, "hippo-instance1-abcd-0", nil)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.HasSuffix(dataWithTags, `
+tags:
+  clonefrom: true
+  nofailover: true
+  nosync: true
+  replicatefrom: hippo-instance1-abcd-0
+`), "got:\n%s", dataWithTags)
 }
 
 func TestPGBackRestCreateReplicaCommand(t *testing.T) {
@@ -1001,7 +1101,7 @@ func TestPGBackRestCreateReplicaCommand(t *testing.T) {
 	}
 	instance := new(v1beta1.PostgresInstanceSetSpec)
 
-	data, err := instanceYAML(cluster, instance, []string{"some", "backrest", "cmd"})
+	data, err := instanceYAML(cluster, instance, "", []string{"some", "backrest", "cmd"})
 	assert.NilError(t, err)
 
 	var parsed struct {
@@ -1136,3 +1236,105 @@ func TestProbeTiming(t *testing.T) {
 		assert.Assert(t, actual.FailureThreshold >= 1) // Minimum value is 1.
 	}
 }
+
+func TestCallbackCommand(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip(`requires "bash" executable`)
+	}
+
+	// Replace the commands that reach outside the Pod with ones that record
+	// their arguments.
+	dir := t.TempDir()
+	for name, script := range map[string]string{
+		"cat":  `echo "some-${1##*/}"`,
+		"psql": `echo "$PSQL_OUTPUT"`,
+		"curl": `while [ "$1" != --data ]; do echo "$1" >> "$CURL_ARGS"; shift; done; echo "$2" > "$CURL_DATA"`,
+	} {
+		file := filepath.Join(dir, name)
+		assert.NilError(t, os.WriteFile(file, []byte("#!/bin/bash\n"+script+"\n"), 0o700)) // #nosec G306 OK permissions for a temp dir in a test
+	}
+
+	run := func(t *testing.T, psql string, args ...string) (*corev1.Pod, string) {
+		out := t.TempDir()
+		cmd := exec.Command("bash", "-c", callbackCommand()+` "$@"`, "-")
+		cmd.Args = append(cmd.Args, args...)
+		cmd.Env = append(os.Environ(),
+			"PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"),
+			"HOSTNAME=hippo-instance1-abcd-0",
+			"PSQL_OUTPUT="+psql,
+			"CURL_ARGS="+filepath.Join(out, "args"),
+			"CURL_DATA="+filepath.Join(out, "data"),
+		)
+
+		output, err := cmd.CombinedOutput()
+		assert.NilError(t, err, "%s", output)
+
+		curlArgs, err := os.ReadFile(filepath.Join(out, "args"))
+		assert.NilError(t, err)
+		data, err := os.ReadFile(filepath.Join(out, "data"))
+		assert.NilError(t, err)
+
+		pod := new(corev1.Pod)
+		assert.NilError(t, yaml.Unmarshal(data, pod), "%s", data)
+		return pod, string(curlArgs)
+	}
+
+	t.Run("Promoted", func(t *testing.T) {
+		pod, args := run(t, "0000000300000000000000A1", "on_role_change", "primary", "hippo-ha")
+		assert.Assert(t, cmp.Contains(args,
+			"https://kubernetes.default.svc/api/v1/namespaces/some-namespace/pods/hippo-instance1-abcd-0"))
+		assert.Assert(t, cmp.Contains(args, "Authorization: Bearer some-token"))
+		assert.Assert(t, cmp.Contains(args, "Content-Type: application/merge-patch+json"))
+
+		event, err := PodCallbackEvent(pod)
+		assert.NilError(t, err)
+		assert.Assert(t, event != nil)
+		assert.Equal(t, event.Action, "on_role_change")
+		assert.Equal(t, event.Role, "primary")
+		assert.Equal(t, event.Timeline, int64(3))
+		assert.Assert(t, !event.Time.IsZero())
+		assert.Assert(t, event.Promoted())
+	})
+
+	t.Run("Replica", func(t *testing.T) {
+		pod, _ := run(t, "", "on_start", "replica", "hippo-ha")
+
+		event, err := PodCallbackEvent(pod)
+		assert.NilError(t, err)
+		assert.Assert(t, event != nil)
+		assert.Equal(t, event.Action, "on_start")
+		assert.Equal(t, event.Role, "replica")
+		assert.Equal(t, event.Timeline, int64(0))
+		assert.Assert(t, !event.Promoted())
+	})
+}
+
+func TestPodCallbackEvent(t *testing.T) {
+	t.Parallel()
+
+	pod := new(corev1.Pod)
+	pod.Name = "hippo-instance1-abcd-0"
+
+	event, err := PodCallbackEvent(pod)
+	assert.NilError(t, err)
+	assert.Assert(t, event == nil)
+
+	pod.Annotations = map[string]string{
+		"pgv2.percona.com/patroni-callback": `{"action":"on_role_change","role":"master","timeline":7,"time":"2024-01-02T03:04:05.123456789Z"}`,
+	}
+	event, err = PodCallbackEvent(pod)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *event, CallbackEvent{
+		Action:   "on_role_change",
+		Role:     "master",
+		Timeline: 7,
+		Time:     time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC),
+	})
+	assert.Assert(t, event.Promoted())
+
+	pod.Annotations["pgv2.percona.com/patroni-callback"] = "{"
+	_, err = PodCallbackEvent(pod)
+	assert.ErrorContains(t, err, "hippo-instance1-abcd-0")
+}
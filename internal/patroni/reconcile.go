@@ -45,10 +45,12 @@ func ClusterConfigMap(ctx context.Context,
 	return err
 }
 
-// InstanceConfigMap populates the shared ConfigMap with fields needed to run Patroni.
+// InstanceConfigMap populates the shared ConfigMap with fields needed to run
+// Patroni. The instance replicates from the inReplicateFrom member, if any.
 func InstanceConfigMap(ctx context.Context,
 	inCluster *v1beta1.PostgresCluster,
 	inInstanceSpec *v1beta1.PostgresInstanceSetSpec,
+	inReplicateFrom string,
 	outInstanceConfigMap *corev1.ConfigMap,
 ) error {
 	var err error
@@ -58,7 +60,7 @@ func InstanceConfigMap(ctx context.Context,
 	command := pgbackrest.ReplicaCreateCommand(inCluster, inInstanceSpec)
 
 	outInstanceConfigMap.Data[configMapFileKey], err = instanceYAML(
-		inCluster, inInstanceSpec, command)
+		inCluster, inInstanceSpec, inReplicateFrom, command)
 
 	return err
 }
@@ -174,6 +174,12 @@ func MakePGBackrestLogDir(template *corev1.PodTemplateSpec,
 //     This ensures compatibility with the "existing" bootstrap method that is included in the
 //     Patroni config when bootstrapping a cluster using an existing data directory.
 func RestoreCommand(pgdata, hugePagesSetting, fetchKeyCommand string, _ []*corev1.PersistentVolumeClaim, args ...string) []string {
+	return append([]string{"bash", "-ceu", "--", RestoreScript(pgdata, hugePagesSetting, fetchKeyCommand), "-", pgdata}, args...)
+}
+
+// RestoreScript returns the Bash script of RestoreCommand. The script expects
+// pgdata as its first argument and the pgBackRest restore options as its second.
+func RestoreScript(pgdata, hugePagesSetting, fetchKeyCommand string) string {
 	ps := postgres.NewParameterSet()
 	ps.Add("data_directory", pgdata)
 	ps.Add("huge_pages", hugePagesSetting)
@@ -287,7 +293,7 @@ func RestoreCommand(pgdata, hugePagesSetting, fetchKeyCommand string, _ []*corev
 		`mv "${PGDATA}" "${PGDATA}_bootstrap"`,
 	}, "\n")
 
-	return append([]string{"bash", "-ceu", "--", script, "-", pgdata}, args...)
+	return script
 }
 
 // DedicatedSnapshotVolumeRestoreCommand returns the command for performing a pgBackRest delta restore
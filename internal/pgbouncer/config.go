@@ -6,6 +6,7 @@ package pgbouncer
 
 import (
 	"fmt"
+	"maps"
 	"sort"
 	"strings"
 
@@ -20,16 +21,19 @@ const (
 
 	authFileAbsolutePath  = configDirectory + "/" + authFileProjectionPath
 	emptyFileAbsolutePath = configDirectory + "/" + emptyFileProjectionPath
+	hbaFileAbsolutePath   = configDirectory + "/" + hbaFileProjectionPath
 	iniFileAbsolutePath   = configDirectory + "/" + iniFileProjectionPath
 
 	authFileProjectionPath  = "~postgres-operator/users.txt"
 	emptyFileProjectionPath = "pgbouncer.ini"
+	hbaFileProjectionPath   = "~postgres-operator/hba.conf"
 	iniFileProjectionPath   = "~postgres-operator.ini"
 
 	authFileSecretKey   = "pgbouncer-users.txt" // #nosec G101 this is a name, not a credential
 	passwordSecretKey   = "pgbouncer-password"  // #nosec G101 this is a name, not a credential
 	verifierSecretKey   = "pgbouncer-verifier"  // #nosec G101 this is a name, not a credential
 	emptyConfigMapKey   = "pgbouncer-empty"
+	hbaFileConfigMapKey = "pgbouncer-hba.conf"
 	iniFileConfigMapKey = "pgbouncer.ini"
 )
 
@@ -60,8 +64,10 @@ func (vs iniValueSet) String() string {
 	return b.String()
 }
 
-// authFileContents returns a PgBouncer user database.
-func authFileContents(password string) []byte {
+// authFileContents returns a PgBouncer user database. It contains the user
+// PgBouncer logs into PostgreSQL as and the users of the admin console. The
+// latter are authenticated only by this file.
+func authFileContents(password string, consoleUsers map[string][]byte) []byte {
 	// > There should be at least 2 fields, surrounded by double quotes.
 	// > Double quotes in a field value can be escaped by writing two double quotes.
 	// - https://www.pgbouncer.org/config.html#authentication-file-format
@@ -69,9 +75,30 @@ func authFileContents(password string) []byte {
 		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
 	}
 
-	user1 := quote(postgresqlUser) + " " + quote(password) + "\n"
+	result := quote(postgresqlUser) + " " + quote(password) + "\n"
 
-	return []byte(user1)
+	names := make([]string, 0, len(consoleUsers))
+	for name := range consoleUsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		result += quote(name) + " " + quote(string(consoleUsers[name])) + "\n"
+	}
+
+	return []byte(result)
+}
+
+// hbaFileContents returns the PgBouncer HBA file of cluster. The metrics
+// exporter is allowed to connect over localhost before the rules in the spec.
+func hbaFileContents(cluster *v1beta1.PostgresCluster) string {
+	rules := cluster.Spec.Proxy.PGBouncer.Config.HBA
+	if exporterSpec(cluster) != nil {
+		rules = append(exporterHBA(), rules...)
+	}
+
+	return iniGeneratedWarning + "\n" + strings.Join(rules, "\n") + "\n"
 }
 
 func clusterINI(cluster *v1beta1.PostgresCluster) string {
@@ -99,13 +126,6 @@ func clusterINI(cluster *v1beta1.PostgresCluster) string {
 		"auth_query": "SELECT username, password from pgbouncer.get_auth($1)",
 		"auth_user":  postgresqlUser,
 
-		// TODO(cbandy): Use an HBA file to control authentication of PgBouncer
-		// accounts; e.g. "admin_users" below.
-		// - https://www.pgbouncer.org/config.html#hba-file-format
-		//"auth_hba_file": "",
-		//"auth_type":     "hba",
-		//"admin_users": "pgbouncer",
-
 		// Require TLS encryption on client connections.
 		"client_tls_sslmode":   "require",
 		"client_tls_cert_file": certFrontendAbsolutePath,
@@ -124,6 +144,24 @@ func clusterINI(cluster *v1beta1.PostgresCluster) string {
 		"unix_socket_dir": "",
 	}
 
+	// Authenticate clients according to the HBA file when there are any rules.
+	// Passwords are still checked using "auth_file" and "auth_query".
+	// - https://www.pgbouncer.org/config.html#hba-file-format
+	config := cluster.Spec.Proxy.PGBouncer.Config
+	if len(config.HBA) > 0 {
+		global["auth_hba_file"] = hbaFileAbsolutePath
+		global["auth_type"] = "hba"
+	}
+
+	// Users of the admin console. Their passwords are in "auth_file".
+	// - https://www.pgbouncer.org/usage.html#admin-console
+	if len(config.AdminUsers) > 0 {
+		global["admin_users"] = strings.Join(config.AdminUsers, ",")
+	}
+	if users := statsUsers(cluster); len(users) > 0 {
+		global["stats_users"] = strings.Join(users, ",")
+	}
+
 	// Override the above with any specified settings.
 	for k, v := range cluster.Spec.Proxy.PGBouncer.Config.Global {
 		global[k] = v
@@ -153,6 +191,29 @@ func clusterINI(cluster *v1beta1.PostgresCluster) string {
 		databases = iniValueSet(cluster.Spec.Proxy.PGBouncer.Config.Databases)
 	}
 
+	// Add pools that connect to the replica service. Each one is named after
+	// its database, so it cannot be served by the wildcard. Databases that
+	// are specified above take precedence.
+	if pools := config.ReadOnlyPools; pools != nil {
+		databases = maps.Clone(databases)
+		for _, database := range pools.Databases {
+			name := pools.PoolName(database)
+			if _, ok := databases[name]; ok {
+				continue
+			}
+
+			value := fmt.Sprintf("host=%s port=%d dbname=%s",
+				naming.ClusterReplicaService(cluster).Name, postgresPort, database)
+			if pools.PoolSize != nil {
+				value += fmt.Sprintf(" pool_size=%d", *pools.PoolSize)
+			}
+			if pools.MaxDBConnections != nil {
+				value += fmt.Sprintf(" max_db_connections=%d", *pools.MaxDBConnections)
+			}
+			databases[name] = value
+		}
+	}
+
 	users := iniValueSet(cluster.Spec.Proxy.PGBouncer.Config.Users)
 
 	// Include any custom configuration file, then apply global settings, then
@@ -170,6 +231,23 @@ func clusterINI(cluster *v1beta1.PostgresCluster) string {
 	return result
 }
 
+// ReadOnlyPools returns the sorted names of the PgBouncer pools of cluster
+// that connect to replicas.
+func ReadOnlyPools(cluster *v1beta1.PostgresCluster) []string {
+	if cluster.Spec.Proxy == nil || cluster.Spec.Proxy.PGBouncer == nil ||
+		cluster.Spec.Proxy.PGBouncer.Config.ReadOnlyPools == nil {
+		return nil
+	}
+
+	pools := cluster.Spec.Proxy.PGBouncer.Config.ReadOnlyPools
+	names := make([]string, 0, len(pools.Databases))
+	for _, database := range pools.Databases {
+		names = append(names, pools.PoolName(database))
+	}
+	sort.Strings(names)
+	return names
+}
+
 // podConfigFiles returns projections of PgBouncer's configuration files to
 // include in the configuration volume.
 func podConfigFiles(
@@ -198,16 +276,25 @@ func podConfigFiles(
 	projections = append(projections, config.Files...)
 
 	// Add our non-empty configurations last so that they take precedence.
+	// The HBA file is projected only when there are rules so that existing
+	// PgBouncer Pods are not restarted.
+	items := []corev1.KeyToPath{{
+		Key:  iniFileConfigMapKey,
+		Path: iniFileProjectionPath,
+	}}
+	if len(config.HBA) > 0 {
+		items = append(items, corev1.KeyToPath{
+			Key:  hbaFileConfigMapKey,
+			Path: hbaFileProjectionPath,
+		})
+	}
 	projections = append(projections, []corev1.VolumeProjection{
 		{
 			ConfigMap: &corev1.ConfigMapProjection{
 				LocalObjectReference: corev1.LocalObjectReference{
 					Name: configmap.Name,
 				},
-				Items: []corev1.KeyToPath{{
-					Key:  iniFileConfigMapKey,
-					Path: iniFileProjectionPath,
-				}},
+				Items: items,
 			},
 		},
 		{
@@ -17,6 +17,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 
+	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/testing/cmp"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/testing/require"
 	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
@@ -36,8 +37,57 @@ func TestAuthFileContents(t *testing.T) {
 	t.Parallel()
 
 	password := `very"random`
-	data := authFileContents(password)
+	data := authFileContents(password, nil)
 	assert.Equal(t, string(data), `"_crunchypgbouncer" "very""random"`+"\n")
+
+	t.Run("ConsoleUsers", func(t *testing.T) {
+		data := authFileContents(password, map[string][]byte{
+			"stats": []byte("some"),
+			"admin": []byte(`other"one`),
+		})
+		assert.Equal(t, string(data), strings.Join([]string{
+			`"_crunchypgbouncer" "very""random"`,
+			`"admin" "other""one"`,
+			`"stats" "some"`,
+		}, "\n")+"\n")
+	})
+}
+
+func TestHBAFileContents(t *testing.T) {
+	t.Parallel()
+
+	cluster := new(v1beta1.PostgresCluster)
+	cluster.Spec.Proxy = new(v1beta1.PostgresProxySpec)
+	cluster.Spec.Proxy.PGBouncer = new(v1beta1.PGBouncerPodSpec)
+	cluster.Spec.Proxy.PGBouncer.Config.HBA = []string{
+		"hostssl all admin 10.0.0.0/8 scram-sha-256",
+		"hostssl all all all md5",
+	}
+
+	assert.Equal(t, hbaFileContents(cluster), strings.Trim(`
+# Generated by postgres-operator. DO NOT EDIT UNLESS YOU KNOW WHAT YOU'RE DOING.
+# If you want to override the config, annotate this ConfigMap with pgv2.percona.com/override-config=true
+
+hostssl all admin 10.0.0.0/8 scram-sha-256
+hostssl all all all md5
+	`, "\t\n")+"\n")
+
+	t.Run("Exporter", func(t *testing.T) {
+		cluster := cluster.DeepCopy()
+		cluster.Spec.Proxy.PGBouncer.Sidecars = &v1beta1.PGBouncerSidecars{
+			Exporter: &v1beta1.PGBouncerExporter{},
+		}
+
+		assert.Equal(t, hbaFileContents(cluster), strings.Trim(`
+# Generated by postgres-operator. DO NOT EDIT UNLESS YOU KNOW WHAT YOU'RE DOING.
+# If you want to override the config, annotate this ConfigMap with pgv2.percona.com/override-config=true
+
+host pgbouncer _pgbouncer_exporter 127.0.0.1/32 scram-sha-256
+host pgbouncer _pgbouncer_exporter ::1/128 scram-sha-256
+hostssl all admin 10.0.0.0/8 scram-sha-256
+hostssl all all all md5
+	`, "\t\n")+"\n")
+	})
 }
 
 func TestClusterINI(t *testing.T) {
@@ -131,6 +181,97 @@ app = mode=rad
 		cluster.Spec.Proxy.PGBouncer.Config.Global["conffile"] = "too-far"
 		assert.Assert(t, !strings.Contains(clusterINI(cluster), "too-far"))
 	})
+
+	t.Run("HBAAndConsoleUsers", func(t *testing.T) {
+		cluster := cluster.DeepCopy()
+		cluster.Spec.Proxy.PGBouncer.Config = v1beta1.PGBouncerConfiguration{
+			HBA:        []string{"hostssl all all all scram-sha-256"},
+			AdminUsers: []string{"admin", "ops"},
+			StatsUsers: []string{"monitor"},
+		}
+
+		assert.Equal(t, clusterINI(cluster), strings.Trim(`
+# Generated by postgres-operator. DO NOT EDIT UNLESS YOU KNOW WHAT YOU'RE DOING.
+# If you want to override the config, annotate this ConfigMap with pgv2.percona.com/override-config=true
+
+[pgbouncer]
+%include /etc/pgbouncer/pgbouncer.ini
+
+[pgbouncer]
+admin_users = admin,ops
+auth_file = /etc/pgbouncer/~postgres-operator/users.txt
+auth_hba_file = /etc/pgbouncer/~postgres-operator/hba.conf
+auth_query = SELECT username, password from pgbouncer.get_auth($1)
+auth_type = hba
+auth_user = _crunchypgbouncer
+client_tls_ca_file = /etc/pgbouncer/~postgres-operator/frontend-ca.crt
+client_tls_cert_file = /etc/pgbouncer/~postgres-operator/frontend-tls.crt
+client_tls_key_file = /etc/pgbouncer/~postgres-operator/frontend-tls.key
+client_tls_sslmode = require
+conffile = /etc/pgbouncer/~postgres-operator.ini
+ignore_startup_parameters = extra_float_digits
+listen_addr = *
+listen_port = 8888
+server_tls_ca_file = /etc/pgbouncer/~postgres-operator/backend-ca.crt
+server_tls_sslmode = verify-full
+stats_users = monitor
+unix_socket_dir =
+
+[databases]
+* = host=foo-baz-primary port=9999
+		`, "\t\n")+"\n")
+	})
+
+	t.Run("ReadOnlyPools", func(t *testing.T) {
+		cluster := cluster.DeepCopy()
+		cluster.Spec.Proxy.PGBouncer.Config = v1beta1.PGBouncerConfiguration{
+			ReadOnlyPools: &v1beta1.PGBouncerReadOnlyPools{
+				Databases: []string{"app", "reports"},
+				PoolSize:  initialize.Int32(5),
+			},
+		}
+
+		ini := clusterINI(cluster)
+		assert.Assert(t, strings.HasSuffix(ini, strings.Trim(`
+[databases]
+* = host=foo-baz-primary port=9999
+app_ro = host=foo-baz-replicas port=9999 dbname=app pool_size=5
+reports_ro = host=foo-baz-replicas port=9999 dbname=reports pool_size=5
+		`, "\t\n")+"\n"), "got:\n%s", ini)
+
+		// Specified databases take precedence, but are not changed.
+		cluster.Spec.Proxy.PGBouncer.Config.ReadOnlyPools.Suffix = "-replica"
+		cluster.Spec.Proxy.PGBouncer.Config.ReadOnlyPools.MaxDBConnections = initialize.Int32(20)
+		cluster.Spec.Proxy.PGBouncer.Config.Databases = map[string]string{
+			"app":         "host=elsewhere",
+			"app-replica": "host=custom",
+		}
+
+		ini = clusterINI(cluster)
+		assert.Assert(t, strings.HasSuffix(ini, strings.Trim(`
+[databases]
+app = host=elsewhere
+app-replica = host=custom
+reports-replica = host=foo-baz-replicas port=9999 dbname=reports pool_size=5 max_db_connections=20
+		`, "\t\n")+"\n"), "got:\n%s", ini)
+		assert.Equal(t, len(cluster.Spec.Proxy.PGBouncer.Config.Databases), 2)
+	})
+}
+
+func TestReadOnlyPools(t *testing.T) {
+	t.Parallel()
+
+	cluster := new(v1beta1.PostgresCluster)
+	assert.Assert(t, ReadOnlyPools(cluster) == nil)
+
+	cluster.Spec.Proxy = new(v1beta1.PostgresProxySpec)
+	cluster.Spec.Proxy.PGBouncer = new(v1beta1.PGBouncerPodSpec)
+	assert.Assert(t, ReadOnlyPools(cluster) == nil)
+
+	cluster.Spec.Proxy.PGBouncer.Config.ReadOnlyPools = &v1beta1.PGBouncerReadOnlyPools{
+		Databases: []string{"zebra", "apple"},
+	}
+	assert.DeepEqual(t, ReadOnlyPools(cluster), []string{"apple_ro", "zebra_ro"})
 }
 
 func TestPodConfigFiles(t *testing.T) {
@@ -193,6 +334,33 @@ func TestPodConfigFiles(t *testing.T) {
     - key: pgbouncer.ini
       path: ~postgres-operator.ini
     name: some-cm
+- secret:
+    items:
+    - key: pgbouncer-users.txt
+      path: ~postgres-operator/users.txt
+    name: some-shh
+		`))
+	})
+
+	t.Run("HBA", func(t *testing.T) {
+		config := v1beta1.PGBouncerConfiguration{
+			HBA: []string{"hostssl all all all scram-sha-256"},
+		}
+
+		projections := podConfigFiles(config, configmap, secret)
+		assert.Assert(t, cmp.MarshalMatches(projections, `
+- configMap:
+    items:
+    - key: pgbouncer-empty
+      path: pgbouncer.ini
+    name: some-cm
+- configMap:
+    items:
+    - key: pgbouncer.ini
+      path: ~postgres-operator.ini
+    - key: pgbouncer-hba.conf
+      path: ~postgres-operator/hba.conf
+    name: some-cm
 - secret:
     items:
     - key: pgbouncer-users.txt
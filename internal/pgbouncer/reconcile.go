@@ -17,6 +17,7 @@ import (
 	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/pki"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/postgres"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/util"
 	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
 )
 
@@ -34,13 +35,50 @@ func ConfigMap(
 
 	outConfigMap.Data[emptyConfigMapKey] = ""
 	outConfigMap.Data[iniFileConfigMapKey] = clusterINI(inCluster)
+
+	if len(inCluster.Spec.Proxy.PGBouncer.Config.HBA) > 0 {
+		outConfigMap.Data[hbaFileConfigMapKey] = hbaFileContents(inCluster)
+	}
 }
 
-// Secret populates the PgBouncer Secret.
+// UsersSecret populates the Secret with the passwords of the PgBouncer admin
+// console users. Existing passwords are kept; missing ones are generated.
+func UsersSecret(
+	inCluster *v1beta1.PostgresCluster,
+	inSecret *corev1.Secret,
+	outSecret *corev1.Secret,
+) error {
+	if inCluster.Spec.Proxy == nil || inCluster.Spec.Proxy.PGBouncer == nil {
+		// PgBouncer is disabled; there is nothing to do.
+		return nil
+	}
+
+	initialize.Map(&outSecret.Data)
+
+	for _, user := range ConsoleUsers(inCluster) {
+		password := inSecret.Data[user]
+		if len(password) == 0 {
+			// These passwords are alphanumeric so they can be used in
+			// connection URLs without escaping; see exporterContainer.
+			generated, err := util.GenerateAlphaNumericPassword(32)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			password = []byte(generated)
+		}
+		outSecret.Data[user] = password
+	}
+
+	return nil
+}
+
+// Secret populates the PgBouncer Secret. The passwords of the admin console
+// users are read from inUsers; see UsersSecret.
 func Secret(ctx context.Context,
 	inCluster *v1beta1.PostgresCluster,
 	inRoot *pki.RootCertificateAuthority,
 	inSecret *corev1.Secret,
+	inUsers *corev1.Secret,
 	inService *corev1.Service,
 	outSecret *corev1.Secret,
 ) error {
@@ -66,7 +104,11 @@ func Secret(ctx context.Context,
 	if err == nil {
 		// Store the SCRAM verifier alongside the plaintext password so that
 		// later reconciles don't generate it repeatedly.
-		outSecret.Data[authFileSecretKey] = authFileContents(password)
+		var consoleUsers map[string][]byte
+		if inUsers != nil {
+			consoleUsers = inUsers.Data
+		}
+		outSecret.Data[authFileSecretKey] = authFileContents(password, consoleUsers)
 		outSecret.Data[passwordSecretKey] = []byte(password)
 		outSecret.Data[verifierSecretKey] = []byte(verifier)
 	}
@@ -179,6 +221,10 @@ func Pod(
 
 	outPod.Containers = []corev1.Container{container, reloader}
 
+	if exporter := exporterSpec(inCluster); exporter != nil {
+		outPod.Containers = append(outPod.Containers, exporterContainer(inCluster, exporter))
+	}
+
 	// If the PGBouncerSidecars feature gate is enabled and custom pgBouncer
 	// sidecars are defined, add the defined container to the Pod.
 	if feature.Enabled(ctx, feature.PGBouncerSidecars) &&
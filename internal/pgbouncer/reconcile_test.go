@@ -49,6 +49,14 @@ func TestConfigMap(t *testing.T) {
 	before := config.DeepCopy()
 	ConfigMap(cluster, config)
 	assert.DeepEqual(t, before, config)
+
+	// There is no HBA file without rules.
+	_, ok := config.Data["pgbouncer-hba.conf"]
+	assert.Assert(t, !ok)
+
+	cluster.Spec.Proxy.PGBouncer.Config.HBA = []string{"hostssl all all all md5"}
+	ConfigMap(cluster, config)
+	assert.Equal(t, config.Data["pgbouncer-hba.conf"], hbaFileContents(cluster))
 }
 
 func TestSecret(t *testing.T) {
@@ -66,7 +74,7 @@ func TestSecret(t *testing.T) {
 	t.Run("Disabled", func(t *testing.T) {
 		// Nothing happens when PgBouncer is disabled.
 		constant := intent.DeepCopy()
-		assert.NilError(t, Secret(ctx, cluster, root, existing, service, intent))
+		assert.NilError(t, Secret(ctx, cluster, root, existing, nil, service, intent))
 		assert.DeepEqual(t, constant, intent)
 	})
 
@@ -76,7 +84,7 @@ func TestSecret(t *testing.T) {
 	assert.NilError(t, err)
 
 	constant := existing.DeepCopy()
-	assert.NilError(t, Secret(ctx, cluster, root, existing, service, intent))
+	assert.NilError(t, Secret(ctx, cluster, root, existing, nil, service, intent))
 	assert.DeepEqual(t, constant, existing)
 
 	// A password should be generated.
@@ -89,8 +97,51 @@ func TestSecret(t *testing.T) {
 	// Assuming the intent is written, no change when called again.
 	existing.Data = intent.Data
 	before := intent.DeepCopy()
-	assert.NilError(t, Secret(ctx, cluster, root, existing, service, intent))
+	assert.NilError(t, Secret(ctx, cluster, root, existing, nil, service, intent))
 	assert.DeepEqual(t, before, intent)
+
+	t.Run("ConsoleUsers", func(t *testing.T) {
+		users := &corev1.Secret{Data: map[string][]byte{"admin": []byte("pass")}}
+		intent := new(corev1.Secret)
+
+		assert.NilError(t, Secret(ctx, cluster, root, existing, users, service, intent))
+		assert.Assert(t, cmp.Contains(string(intent.Data["pgbouncer-users.txt"]), `"admin" "pass"`))
+		assert.DeepEqual(t, intent.Data["pgbouncer-password"], existing.Data["pgbouncer-password"])
+	})
+}
+
+func TestUsersSecret(t *testing.T) {
+	t.Parallel()
+
+	cluster := new(v1beta1.PostgresCluster)
+	existing := new(corev1.Secret)
+	intent := new(corev1.Secret)
+
+	t.Run("Disabled", func(t *testing.T) {
+		// Nothing happens when PgBouncer is disabled.
+		constant := intent.DeepCopy()
+		assert.NilError(t, UsersSecret(cluster, existing, intent))
+		assert.DeepEqual(t, constant, intent)
+	})
+
+	cluster.Spec.Proxy = new(v1beta1.PostgresProxySpec)
+	cluster.Spec.Proxy.PGBouncer = new(v1beta1.PGBouncerPodSpec)
+	cluster.Spec.Proxy.PGBouncer.Config.AdminUsers = []string{"admin"}
+	cluster.Spec.Proxy.PGBouncer.Config.StatsUsers = []string{"admin", "stats"}
+
+	// Passwords are generated for every console user.
+	assert.NilError(t, UsersSecret(cluster, existing, intent))
+	assert.Equal(t, len(intent.Data), 2)
+	assert.Equal(t, len(intent.Data["admin"]), 32)
+	assert.Equal(t, len(intent.Data["stats"]), 32)
+
+	// Existing passwords are kept; users that are gone are dropped.
+	existing.Data = map[string][]byte{"admin": []byte("kept"), "old": []byte("x")}
+	intent = new(corev1.Secret)
+	assert.NilError(t, UsersSecret(cluster, existing, intent))
+	assert.DeepEqual(t, intent.Data["admin"], []byte("kept"))
+	_, ok := intent.Data["old"]
+	assert.Assert(t, !ok)
 }
 
 func TestPod(t *testing.T) {
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+)
+
+// CreateExtensionInDatabases calls exec to create extension in those of
+// databases that exist and to update it to its default version. The extension
+// is created in schema when it is not empty; the schema is created as well.
+func CreateExtensionInDatabases(
+	ctx context.Context, exec Executor, extension, schema string, databases []string,
+) error {
+	log := logging.FromContext(ctx)
+
+	names, err := json.Marshal(databases)
+	if err != nil {
+		return err
+	}
+
+	stdout, stderr, err := exec.ExecInDatabasesFromQuery(ctx,
+		`SELECT datname FROM pg_catalog.pg_database`+
+			` WHERE datallowconn AND datname IN (SELECT json_array_elements_text(:'databases'::json))`,
+		`SET client_min_messages = WARNING;
+SELECT pg_catalog.format('CREATE SCHEMA IF NOT EXISTS %I', :'schema')
+ WHERE :'schema' <> ''
+\gexec
+SELECT pg_catalog.format('CREATE EXTENSION IF NOT EXISTS %I', :'extension')
+    || CASE WHEN :'schema' <> '' THEN pg_catalog.format(' WITH SCHEMA %I', :'schema') ELSE '' END
+\gexec
+SELECT pg_catalog.format('ALTER EXTENSION %I UPDATE', :'extension')
+\gexec
+`,
+		map[string]string{
+			"databases": string(names),
+			"extension": extension,
+			"schema":    schema,
+
+			"ON_ERROR_STOP": "on", // Abort when any one command fails.
+			"QUIET":         "on", // Do not print successful commands to stdout.
+		})
+
+	log.V(1).Info("created extension", "extension", extension, "stdout", stdout, "stderr", stderr)
+
+	return err
+}
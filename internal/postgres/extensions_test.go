@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/testing/cmp"
+)
+
+func TestCreateExtensionInDatabases(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Arguments", func(t *testing.T) {
+		expected := errors.New("pass-through")
+		exec := func(
+			_ context.Context, stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			assert.Assert(t, stdout != nil, "should capture stdout")
+			assert.Assert(t, stderr != nil, "should capture stderr")
+			return expected
+		}
+
+		assert.Equal(t, expected, CreateExtensionInDatabases(ctx, exec, "vector", "", nil))
+	})
+
+	t.Run("Targeted", func(t *testing.T) {
+		calls := 0
+		exec := func(
+			_ context.Context, stdin io.Reader, _, _ io.Writer, command ...string,
+		) error {
+			calls++
+
+			// The databases are passed to the query that selects them.
+			assert.Assert(t, cmp.Contains(strings.Join(command, "\n"), `json_array_elements_text(:'databases'::json)`))
+			assert.Assert(t, cmp.Contains(command, `--set=databases=["app","postgres"]`))
+			assert.Assert(t, cmp.Contains(command, `--set=extension=pg_stat_monitor`))
+			assert.Assert(t, cmp.Contains(command, `--set=schema=monitoring`))
+
+			b, err := io.ReadAll(stdin)
+			assert.NilError(t, err)
+			assert.Assert(t, cmp.Contains(string(b), `CREATE SCHEMA IF NOT EXISTS %I`))
+			assert.Assert(t, cmp.Contains(string(b), `WITH SCHEMA %I`))
+			assert.Assert(t, cmp.Contains(string(b), `ALTER EXTENSION %I UPDATE`))
+			return nil
+		}
+
+		assert.NilError(t, CreateExtensionInDatabases(ctx, exec,
+			"pg_stat_monitor", "monitoring", []string{"app", "postgres"}))
+		assert.Equal(t, calls, 1)
+	})
+}
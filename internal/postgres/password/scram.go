@@ -12,6 +12,8 @@ import (
 	"errors"
 	"fmt"
 	"hash"
+	"strconv"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 
@@ -109,6 +111,40 @@ func (s *SCRAMPassword) Build() (string, error) {
 	return verifier, nil
 }
 
+// Verify returns true if verifier is a SCRAM verifier of the password. The
+// iterations and salt are read from verifier, so it can be used to check
+// whether a stored verifier is still current without building a new one.
+func (s *SCRAMPassword) Verify(verifier string) bool {
+	// <DIGEST>$<ITERATIONS>:<SALT>$<STORED_KEY>:<SERVER_KEY>
+	digest, rest, _ := strings.Cut(verifier, "$")
+	parameters, keys, _ := strings.Cut(rest, "$")
+	iterations, encodedSalt, _ := strings.Cut(parameters, ":")
+	encodedStoredKey, encodedServerKey, _ := strings.Cut(keys, ":")
+
+	count, err := strconv.Atoi(iterations)
+	if digest != "SCRAM-SHA-256" || err != nil || count < 1 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(encodedSalt)
+	if err != nil {
+		return false
+	}
+	storedKey, err := base64.StdEncoding.DecodeString(encodedStoredKey)
+	if err != nil {
+		return false
+	}
+	serverKey, err := base64.StdEncoding.DecodeString(encodedServerKey)
+	if err != nil {
+		return false
+	}
+
+	saltedPassword := pbkdf2.Key([]byte(s.saslPrep()), salt, count, scramDefaultHash().Size(), scramDefaultHash)
+	clientKey := s.hmac(scramDefaultHash, saltedPassword, scramClientKeyMessage)
+
+	return hmac.Equal(s.hash(scramDefaultHash, clientKey), storedKey) &&
+		hmac.Equal(s.hmac(scramDefaultHash, saltedPassword, scramServerKeyMessage), serverKey)
+}
+
 // encode creates a base64 encoding of a value that's returned as a string
 func (s *SCRAMPassword) encode(value []byte) string {
 	return base64.StdEncoding.EncodeToString(value)
@@ -119,6 +119,38 @@ func TestSCRAMBuild(t *testing.T) {
 	})
 }
 
+func TestSCRAMVerify(t *testing.T) {
+	const datalake = `SCRAM-SHA-256$4096:aDFwcDBwNHJ0eTIwMjA=$xHkOo65LX9eBB8a6v+axqvs3+aMBTH0sCT7w/Nxzh5M=:PXuFoeJNuAGSeExskYSqkwUyiUJu8LPC9DgwDWQ9ARQ=`
+
+	if !NewSCRAMPassword("datalake").Verify(datalake) {
+		t.Errorf("expected %q to verify", datalake)
+	}
+	if NewSCRAMPassword("datalake2").Verify(datalake) {
+		t.Errorf("expected another password not to verify")
+	}
+
+	// A freshly built verifier verifies.
+	verifier, err := NewSCRAMPassword("øásis").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !NewSCRAMPassword("øásis").Verify(verifier) {
+		t.Errorf("expected %q to verify", verifier)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"md53a0689aa9e31a50b5621971fc89f0c64",
+		`SCRAM-SHA-1$4096:aDFwcDBwNHJ0eTIwMjA=$xHkOo65LX9eBB8a6v+axqvs3+aMBTH0sCT7w/Nxzh5M=:PXuFoeJNuAGSeExskYSqkwUyiUJu8LPC9DgwDWQ9ARQ=`,
+		`SCRAM-SHA-256$x:aDFwcDBwNHJ0eTIwMjA=$xHkOo65LX9eBB8a6v+axqvs3+aMBTH0sCT7w/Nxzh5M=:PXuFoeJNuAGSeExskYSqkwUyiUJu8LPC9DgwDWQ9ARQ=`,
+		`SCRAM-SHA-256$4096:!!!$xHkOo65LX9eBB8a6v+axqvs3+aMBTH0sCT7w/Nxzh5M=:PXuFoeJNuAGSeExskYSqkwUyiUJu8LPC9DgwDWQ9ARQ=`,
+	} {
+		if NewSCRAMPassword("datalake").Verify(invalid) {
+			t.Errorf("expected %q not to verify", invalid)
+		}
+	}
+}
+
 func TestSCRAMEncode(t *testing.T) {
 	t.Run("valid", func(t *testing.T) {
 		scram := SCRAMPassword{}
@@ -280,6 +280,42 @@ func InstancePod(ctx context.Context,
 
 	outInstancePod.InitContainers = []corev1.Container{startup}
 	outInstancePod.InitContainers = append(outInstancePod.InitContainers, inInstanceSpec.InitContainers...)
+	outInstancePod.Volumes = append(outInstancePod.Volumes, extensionVolumes(&inCluster.Spec.Extensions)...)
+}
+
+// extensionVolumes returns the volumes the custom extension installer of
+// PostgreSQL instance pods reads archives and their signature key from.
+func extensionVolumes(spec *v1beta1.ExtensionsSpec) []corev1.Volume {
+	var volumes []corev1.Volume
+
+	if spec.StorageVolume != nil {
+		claim := *spec.StorageVolume
+		claim.ReadOnly = true
+
+		volumes = append(volumes, corev1.Volume{
+			Name: naming.ExtensionsStorageVolume,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &claim,
+			},
+		})
+	}
+
+	if spec.SignatureKey != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: naming.ExtensionsSignatureKeyVolume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: spec.SignatureKey.Name,
+					Items: []corev1.KeyToPath{{
+						Key:  spec.SignatureKey.Key,
+						Path: naming.ExtensionsSignatureKeyFile,
+					}},
+				},
+			},
+		})
+	}
+
+	return volumes
 }
 
 // PodSecurityContext returns a v1.PodSecurityContext for cluster that can write
@@ -710,6 +710,32 @@ volumes:
 	})
 }
 
+func TestExtensionVolumes(t *testing.T) {
+	assert.Assert(t, extensionVolumes(&v1beta1.ExtensionsSpec{}) == nil)
+
+	spec := &v1beta1.ExtensionsSpec{
+		StorageVolume: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "pg-extensions"},
+		SignatureKey: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "extensions-key"},
+			Key:                  "key.pem",
+		},
+	}
+
+	assert.Assert(t, cmp.MarshalMatches(extensionVolumes(spec), `
+- name: extensions-storage
+  persistentVolumeClaim:
+    claimName: pg-extensions
+    readOnly: true
+- name: extensions-signature-key
+  secret:
+    items:
+    - key: key.pem
+      path: public.pem
+    secretName: extensions-key
+	`))
+	assert.Assert(t, !spec.StorageVolume.ReadOnly)
+}
+
 func TestPodSecurityContext(t *testing.T) {
 	cluster := new(v1beta1.PostgresCluster)
 	err := cluster.Default(context.Background(), nil)
@@ -53,10 +53,19 @@ func sanitizeAlterRoleOptions(options string) string {
 	return strings.TrimPrefix(sql, AlterRolePrefix)
 }
 
+// AlternateUser returns the name of the login role that takes turns with the
+// role of user when its password is rotated with a grace period. The role is
+// a member of user and switches to it at login, so both share objects and
+// privileges. User names cannot contain underscores, so it never conflicts
+// with another user.
+func AlternateUser(user string) string { return user + "_alt" }
+
 // WriteUsersInPostgreSQL calls exec to create users that do not exist in
 // PostgreSQL. Once they exist, it updates their options and passwords and
 // grants them access to their specified databases. The databases must already
-// exist.
+// exist. A user without a verifier cannot log in with a password. The
+// alternate role of a user is created when it has a verifier; see
+// [AlternateUser].
 func WriteUsersInPostgreSQL(
 	ctx context.Context, cluster *v1beta1.PostgresCluster, exec Executor,
 	users []v1beta1.PostgresUserSpec, verifiers map[string]string,
@@ -95,11 +104,14 @@ CREATE TEMPORARY TABLE input (id serial, data json);
 		}
 
 		if err == nil {
+			alternate := AlternateUser(string(spec.Name))
 			err = encoder.Encode(map[string]any{
-				"databases": databases,
-				"options":   options,
-				"username":  spec.Name,
-				"verifier":  verifiers[string(spec.Name)],
+				"alternate":          alternate,
+				"alternate_verifier": verifiers[alternate],
+				"databases":          databases,
+				"options":            options,
+				"username":           spec.Name,
+				"verifier":           verifiers[string(spec.Name)],
 			})
 		}
 	}
@@ -131,9 +143,45 @@ SELECT pg_catalog.format('CREATE USER %I',
 SELECT pg_catalog.format('ALTER ROLE %I WITH %s PASSWORD %L',
        pg_catalog.json_extract_path_text(input.data, 'username'),
        pg_catalog.json_extract_path_text(input.data, 'options'),
-       pg_catalog.json_extract_path_text(input.data, 'verifier'))
+       NULLIF(pg_catalog.json_extract_path_text(input.data, 'verifier'), ''))
   FROM input ORDER BY input.id
 \gexec
+`)
+
+	// Create the alternate login roles that are in use and let them act as
+	// their users. Those that are no longer in use cannot log in with a
+	// password.
+	// - https://www.postgresql.org/docs/current/sql-createrole.html
+	// - https://www.postgresql.org/docs/current/sql-set-role.html
+	_, _ = sql.WriteString(`
+SELECT pg_catalog.format('CREATE ROLE %I LOGIN IN ROLE %I',
+       pg_catalog.json_extract_path_text(input.data, 'alternate'),
+       pg_catalog.json_extract_path_text(input.data, 'username'))
+  FROM input
+ WHERE pg_catalog.json_extract_path_text(input.data, 'alternate_verifier') <> ''
+   AND NOT EXISTS (
+       SELECT 1 FROM pg_catalog.pg_roles
+       WHERE rolname = pg_catalog.json_extract_path_text(input.data, 'alternate'))
+ ORDER BY input.id
+\gexec
+
+SELECT pg_catalog.format('ALTER ROLE %I WITH LOGIN PASSWORD %L',
+       pg_catalog.json_extract_path_text(input.data, 'alternate'),
+       NULLIF(pg_catalog.json_extract_path_text(input.data, 'alternate_verifier'), ''))
+  FROM input
+ WHERE EXISTS (
+       SELECT 1 FROM pg_catalog.pg_roles
+       WHERE rolname = pg_catalog.json_extract_path_text(input.data, 'alternate'))
+ ORDER BY input.id
+\gexec
+
+SELECT pg_catalog.format('ALTER ROLE %I SET role TO %L',
+       pg_catalog.json_extract_path_text(input.data, 'alternate'),
+       pg_catalog.json_extract_path_text(input.data, 'username'))
+  FROM input
+ WHERE pg_catalog.json_extract_path_text(input.data, 'alternate_verifier') <> ''
+ ORDER BY input.id
+\gexec
 `)
 
 	// Grant access to any specified databases.
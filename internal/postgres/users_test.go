@@ -7,14 +7,21 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
 
+	pgpassword "github.com/fulviodenza/percona-postgresql-operator/internal/postgres/password"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/testing/cmp"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/testing/require"
 	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
 )
 
@@ -80,10 +87,39 @@ SELECT pg_catalog.format('CREATE USER %I',
 SELECT pg_catalog.format('ALTER ROLE %I WITH %s PASSWORD %L',
        pg_catalog.json_extract_path_text(input.data, 'username'),
        pg_catalog.json_extract_path_text(input.data, 'options'),
-       pg_catalog.json_extract_path_text(input.data, 'verifier'))
+       NULLIF(pg_catalog.json_extract_path_text(input.data, 'verifier'), ''))
   FROM input ORDER BY input.id
 \gexec
 
+SELECT pg_catalog.format('CREATE ROLE %I LOGIN IN ROLE %I',
+       pg_catalog.json_extract_path_text(input.data, 'alternate'),
+       pg_catalog.json_extract_path_text(input.data, 'username'))
+  FROM input
+ WHERE pg_catalog.json_extract_path_text(input.data, 'alternate_verifier') <> ''
+   AND NOT EXISTS (
+       SELECT 1 FROM pg_catalog.pg_roles
+       WHERE rolname = pg_catalog.json_extract_path_text(input.data, 'alternate'))
+ ORDER BY input.id
+\gexec
+
+SELECT pg_catalog.format('ALTER ROLE %I WITH LOGIN PASSWORD %L',
+       pg_catalog.json_extract_path_text(input.data, 'alternate'),
+       NULLIF(pg_catalog.json_extract_path_text(input.data, 'alternate_verifier'), ''))
+  FROM input
+ WHERE EXISTS (
+       SELECT 1 FROM pg_catalog.pg_roles
+       WHERE rolname = pg_catalog.json_extract_path_text(input.data, 'alternate'))
+ ORDER BY input.id
+\gexec
+
+SELECT pg_catalog.format('ALTER ROLE %I SET role TO %L',
+       pg_catalog.json_extract_path_text(input.data, 'alternate'),
+       pg_catalog.json_extract_path_text(input.data, 'username'))
+  FROM input
+ WHERE pg_catalog.json_extract_path_text(input.data, 'alternate_verifier') <> ''
+ ORDER BY input.id
+\gexec
+
 SELECT pg_catalog.format('GRANT ALL PRIVILEGES ON DATABASE %I TO %I',
        pg_catalog.json_array_elements_text(
        pg_catalog.json_extract_path(
@@ -118,10 +154,10 @@ COMMIT;`))
 			assert.NilError(t, err)
 			assert.Assert(t, cmp.Contains(string(b), `
 \copy input (data) from stdin with (format text)
-{"databases":["db1"],"options":"","username":"user-no-options","verifier":""}
-{"databases":null,"options":"CREATEDB CREATEROLE","username":"user-no-databases","verifier":""}
-{"databases":null,"options":"","username":"user-with-verifier","verifier":"some$verifier"}
-{"databases":null,"options":"LOGIN","username":"user-invalid-options","verifier":""}
+{"alternate":"user-no-options_alt","alternate_verifier":"","databases":["db1"],"options":"","username":"user-no-options","verifier":""}
+{"alternate":"user-no-databases_alt","alternate_verifier":"","databases":null,"options":"CREATEDB CREATEROLE","username":"user-no-databases","verifier":""}
+{"alternate":"user-with-verifier_alt","alternate_verifier":"","databases":null,"options":"","username":"user-with-verifier","verifier":"some$verifier"}
+{"alternate":"user-invalid-options_alt","alternate_verifier":"","databases":null,"options":"LOGIN","username":"user-invalid-options","verifier":""}
 \.
 `))
 			return nil
@@ -165,7 +201,7 @@ COMMIT;`))
 			assert.NilError(t, err)
 			assert.Assert(t, cmp.Contains(string(b), `
 \copy input (data) from stdin with (format text)
-{"databases":["postgres"],"options":"LOGIN SUPERUSER","username":"postgres","verifier":"allowed"}
+{"alternate":"postgres_alt","alternate_verifier":"","databases":["postgres"],"options":"LOGIN SUPERUSER","username":"postgres","verifier":"allowed"}
 \.
 `))
 			return nil
@@ -235,3 +271,93 @@ func TestWriteUsersSchemasInPostgreSQL(t *testing.T) {
 	})
 
 }
+
+// TestWriteUsersInPostgreSQLGracePeriod runs the statements against a real
+// PostgreSQL server to show that the previous password keeps working while
+// the alternate role holds the new one.
+func TestWriteUsersInPostgreSQLGracePeriod(t *testing.T) {
+	pgctl := require.PGCtl(t)
+	psql := require.Psql(t)
+	if os.Geteuid() == 0 {
+		t.Skip("PostgreSQL cannot run as root")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	port := fmt.Sprint(listener.Addr().(*net.TCPAddr).Port)
+	assert.NilError(t, listener.Close())
+
+	run := func(name string, args ...string) {
+		t.Helper()
+		output, err := exec.Command(name, args...).CombinedOutput()
+		assert.NilError(t, err, "%s", output)
+	}
+
+	run(pgctl, "initdb", "--pgdata="+data, "--silent",
+		"--options=--auth-local=trust --auth-host=scram-sha-256 --username=postgres")
+	run(pgctl, "start", "--pgdata="+data, "--wait", "--silent", "--log="+filepath.Join(dir, "log"),
+		"--options=-c listen_addresses=127.0.0.1 -c unix_socket_directories="+dir+" -p "+port)
+	t.Cleanup(func() { run(pgctl, "stop", "--pgdata="+data, "--mode=immediate", "--silent") })
+
+	executor := func(
+		ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, command ...string,
+	) error {
+		cmd := exec.CommandContext(ctx, psql, command[1:]...)
+		cmd.Env = append(os.Environ(),
+			"PGHOST="+dir, "PGPORT="+port, "PGUSER=postgres", "PGDATABASE=postgres")
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+		return cmd.Run()
+	}
+
+	// login connects over TCP with a password and returns the effective role.
+	login := func(user, password string) (string, error) {
+		output, err := exec.Command(psql, "-Xw", "--tuples-only", "--no-align",
+			"--command=SELECT current_user",
+			fmt.Sprintf("host=127.0.0.1 port=%s dbname=postgres user=%s password=%s", port, user, password),
+		).CombinedOutput()
+		return strings.TrimSpace(string(output)), err
+	}
+
+	verifier := func(password string) string {
+		v, err := pgpassword.NewSCRAMPassword(password).Build()
+		assert.NilError(t, err)
+		return v
+	}
+
+	cluster := new(v1beta1.PostgresCluster)
+	users := []v1beta1.PostgresUserSpec{{Name: "app"}}
+
+	assert.NilError(t, WriteUsersInPostgreSQL(ctx, cluster, executor, users,
+		map[string]string{"app": verifier("first")}))
+
+	role, err := login("app", "first")
+	assert.NilError(t, err, role)
+	assert.Equal(t, role, "app")
+
+	// The password rotates; both passwords work during the grace period.
+	assert.NilError(t, WriteUsersInPostgreSQL(ctx, cluster, executor, users,
+		map[string]string{"app": verifier("first"), "app_alt": verifier("second")}))
+
+	role, err = login("app", "first")
+	assert.NilError(t, err, role)
+	assert.Equal(t, role, "app")
+
+	role, err = login("app_alt", "second")
+	assert.NilError(t, err, role)
+	assert.Equal(t, role, "app", "expected the alternate role to act as the user")
+
+	// The grace period ends; only the new password works.
+	assert.NilError(t, WriteUsersInPostgreSQL(ctx, cluster, executor, users,
+		map[string]string{"app_alt": verifier("second")}))
+
+	_, err = login("app", "first")
+	assert.ErrorContains(t, err, "exit status")
+
+	role, err = login("app_alt", "second")
+	assert.NilError(t, err, role)
+	assert.Equal(t, role, "app")
+}
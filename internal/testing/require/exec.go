@@ -22,6 +22,16 @@ func OpenSSL(t testing.TB) string { t.Helper(); return openssl(t) }
 
 var openssl = executable("openssl", "version", "-a")
 
+// PGCtl returns the path to the "pg_ctl" executable or calls t.Skip.
+func PGCtl(t testing.TB) string { t.Helper(); return pgctl(t) }
+
+var pgctl = executable("pg_ctl", "--version")
+
+// Psql returns the path to the "psql" executable or calls t.Skip.
+func Psql(t testing.TB) string { t.Helper(); return psql(t) }
+
+var psql = executable("psql", "--version")
+
 // ShellCheck returns the path to the "shellcheck" executable or calls t.Skip.
 func ShellCheck(t testing.TB) string { t.Helper(); return shellcheck(t) }
 
@@ -28,6 +28,7 @@ import (
 	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/clientcmd"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/controller"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/metrics"
 	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/pgbackrest"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/watcher"
@@ -63,7 +64,7 @@ func (r *PGBackupReconciler) SetupWithManager(mgr manager.Manager) error {
 // +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgbackups/finalizers,verbs=update;patch
 // +kubebuilder:rbac:groups=postgres-operator.crunchydata.com,resources=postgresclusters,verbs=get;list;create;update;patch;watch
 // +kubebuilder:rbac:groups=postgres-operator.crunchydata.com,resources=postgresclusters/status,verbs=create;update;patch
-// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create
 
 func (r *PGBackupReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	log := logging.FromContext(ctx).WithValues("request", request)
@@ -239,6 +240,8 @@ func (r *PGBackupReconciler) Reconcile(ctx context.Context, request reconcile.Re
 				}); err != nil {
 					return reconcile.Result{}, errors.Wrap(err, "update PGBackup status")
 				}
+				pgBackup.Status.State = v2.BackupFailed
+				metrics.ObserveBackup(pgBackup, 0)
 				return reconcile.Result{}, nil
 			}
 			return reconcile.Result{}, errors.Wrap(err, "get backup job")
@@ -272,6 +275,8 @@ func (r *PGBackupReconciler) Reconcile(ctx context.Context, request reconcile.Re
 		}); err != nil {
 			return reconcile.Result{}, errors.Wrap(err, "update PGBackup status")
 		}
+		pgBackup.Status.State = status
+		metrics.ObserveBackup(pgBackup, controller.JobDuration(job))
 
 		return reconcile.Result{}, nil
 	case v2.BackupSucceeded:
@@ -310,7 +315,7 @@ func (r *PGBackupReconciler) Reconcile(ctx context.Context, request reconcile.Re
 			}
 		}
 
-		return reconcile.Result{}, nil
+		return reconcileVerification(ctx, r.Client, pgBackup)
 	default:
 		return reconcile.Result{}, nil
 	}
@@ -474,6 +479,8 @@ func updatePGBackrestInfo(ctx context.Context, c client.Client, pod *corev1.Pod,
 			}
 
 			stanzaName = info.Name
+			// The repository delta is what this backup added to the repo.
+			metrics.SetBackupSize(pgBackup, backup.Info.Repository.Delta)
 			if pgBackup.Status.BackupName == "" {
 				if err := updateStatus(ctx, c, pgBackup, func(bcp *v2.PerconaPGBackup) {
 					bcp.Status.BackupName = backup.Label
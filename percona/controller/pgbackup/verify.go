@@ -0,0 +1,269 @@
+package pgbackup
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/config"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	crunchyPGBackRest "github.com/fulviodenza/percona-postgresql-operator/internal/pgbackrest"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/postgres"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/controller"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+const verificationContainerName = naming.PGBackRestRestoreContainerName
+
+func verificationJobName(pgBackup *v2.PerconaPGBackup) string {
+	return pgBackup.Name + "-verify"
+}
+
+// reconcileVerification restores a succeeded backup into a scratch instance
+// and records the result of the SQL probe in the backup status.
+func reconcileVerification(ctx context.Context, cl client.Client, pgBackup *v2.PerconaPGBackup) (reconcile.Result, error) {
+	log := logging.FromContext(ctx)
+
+	if !pgBackup.Spec.Verification.IsEnabled() || pgBackup.Status.BackupName == "" {
+		return reconcile.Result{}, nil
+	}
+
+	status := pgBackup.Status.Verification
+	if status != nil && (status.State == v2.BackupVerified || status.State == v2.BackupVerificationFailed) {
+		return reconcile.Result{}, nil
+	}
+
+	job := new(batchv1.Job)
+	err := cl.Get(ctx, types.NamespacedName{Name: verificationJobName(pgBackup), Namespace: pgBackup.Namespace}, job)
+	if client.IgnoreNotFound(err) != nil {
+		return reconcile.Result{}, errors.Wrap(err, "get verification job")
+	}
+	if k8serrors.IsNotFound(err) {
+		if status != nil && status.State == v2.BackupVerificationRunning {
+			if err := updateStatus(ctx, cl, pgBackup, func(bcp *v2.PerconaPGBackup) {
+				bcp.Status.Verification.State = v2.BackupVerificationFailed
+				bcp.Status.Verification.Error = "verification Job not found"
+			}); err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "update PGBackup status")
+			}
+			return reconcile.Result{}, nil
+		}
+
+		postgresCluster := new(v1beta1.PostgresCluster)
+		if err := cl.Get(ctx, types.NamespacedName{Name: pgBackup.Spec.PGCluster, Namespace: pgBackup.Namespace}, postgresCluster); err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "get PostgresCluster")
+		}
+
+		job, err := generateVerificationJob(postgresCluster, pgBackup)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "generate verification job")
+		}
+		if err := controllerutil.SetControllerReference(pgBackup, job, cl.Scheme()); err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "set controller reference")
+		}
+		if err := cl.Create(ctx, job); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return reconcile.Result{}, errors.Wrap(err, "create verification job")
+		}
+
+		if err := updateStatus(ctx, cl, pgBackup, func(bcp *v2.PerconaPGBackup) {
+			bcp.Status.Verification = &v2.PGBackupVerificationStatus{
+				JobName:   job.Name,
+				State:     v2.BackupVerificationRunning,
+				StartedAt: &metav1.Time{Time: time.Now()},
+			}
+		}); err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "update PGBackup status")
+		}
+
+		log.Info("Backup verification is started", "backup", pgBackup.Name, "job", job.Name)
+		return reconcile.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	switch {
+	case controller.JobCompleted(job):
+		log.Info("Backup verified", "backup", pgBackup.Name)
+	case controller.JobFailed(job):
+		log.Info("Backup verification failed", "backup", pgBackup.Name)
+	default:
+		log.V(1).Info("Waiting for backup verification to complete")
+		return reconcile.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	if err := updateStatus(ctx, cl, pgBackup, func(bcp *v2.PerconaPGBackup) {
+		if bcp.Status.Verification == nil {
+			bcp.Status.Verification = &v2.PGBackupVerificationStatus{JobName: job.Name}
+		}
+		setVerificationResult(bcp.Status.Verification, job)
+	}); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "update PGBackup status")
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// setVerificationResult fills status from a finished verification job.
+func setVerificationResult(status *v2.PGBackupVerificationStatus, job *batchv1.Job) {
+	var finished *metav1.Time
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			status.State = v2.BackupVerified
+			status.Error = ""
+			finished = job.Status.CompletionTime
+		case batchv1.JobFailed:
+			status.State = v2.BackupVerificationFailed
+			status.Error = strings.TrimSpace(cond.Reason + ": " + cond.Message)
+			finished = &cond.LastTransitionTime
+		}
+	}
+	if finished == nil {
+		finished = &metav1.Time{Time: time.Now()}
+	}
+	status.CompletedAt = finished
+
+	started := job.Status.StartTime
+	if started == nil {
+		started = status.StartedAt
+	}
+	if started != nil {
+		status.Duration = &metav1.Duration{Duration: finished.Sub(started.Time).Round(time.Second)}
+	}
+}
+
+// generateVerificationJob returns a Job that restores the backup set of
+// pgBackup into an ephemeral data directory, starts PostgreSQL on it and runs
+// the configured SQL probe. The Job uses the same pgBackRest configuration as
+// the restore Job of the cluster.
+func generateVerificationJob(cluster *v1beta1.PostgresCluster, pgBackup *v2.PerconaPGBackup) (*batchv1.Job, error) {
+	verification := pgBackup.Spec.Verification
+
+	repoName := pgBackup.Spec.RepoName
+	if !strings.HasPrefix(repoName, "repo") {
+		return nil, errors.Errorf("invalid repo name %s", repoName)
+	}
+
+	pgdata := postgres.DataDirectory(cluster)
+	opts := []string{
+		"--stanza=" + crunchyPGBackRest.DefaultStanzaName,
+		"--pg1-path=" + pgdata,
+		"--repo=" + strings.TrimPrefix(repoName, "repo"),
+		"--set=" + pgBackup.Status.BackupName,
+		"--type=immediate",
+		"--target-action=promote",
+	}
+
+	cmd := verificationCommand(pgdata, config.FetchKeyCommand(&cluster.Spec),
+		verification.Database, verification.Query, strings.Join(opts, " "))
+
+	labels := naming.WithPerconaLabels(map[string]string{}, cluster.Name, "backup-verification", cluster.Labels[naming.LabelVersion])
+
+	dataEmptyDir := &corev1.EmptyDirVolumeSource{}
+	if sizeLimit, ok := verification.Resources.Limits[corev1.ResourceEphemeralStorage]; ok {
+		dataEmptyDir.SizeLimit = &sizeLimit
+	}
+
+	dataVolumeMount := postgres.DataVolumeMount()
+	tmpVolumeMount := corev1.VolumeMount{Name: "tmp", MountPath: "/tmp"}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      verificationJobName(pgBackup),
+			Namespace: pgBackup.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          initialize.Int32(0),
+			ActiveDeadlineSeconds: verification.ActiveDeadlineSeconds,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+					Annotations: map[string]string{
+						naming.DefaultContainerAnnotation: verificationContainerName,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:            verificationContainerName,
+						Command:         cmd,
+						Image:           config.PostgresContainerImage(cluster),
+						ImagePullPolicy: cluster.Spec.ImagePullPolicy,
+						Env:             []corev1.EnvVar{{Name: "PGHOST", Value: "/tmp"}},
+						SecurityContext: initialize.RestrictedSecurityContext(cluster.CompareVersion("2.5.0") >= 0),
+						Resources:       verification.Resources,
+						VolumeMounts:    []corev1.VolumeMount{dataVolumeMount, tmpVolumeMount},
+					}},
+					Volumes: []corev1.Volume{
+						{
+							Name:         dataVolumeMount.Name,
+							VolumeSource: corev1.VolumeSource{EmptyDir: dataEmptyDir},
+						},
+						{
+							Name:         tmpVolumeMount.Name,
+							VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+						},
+					},
+					RestartPolicy:    corev1.RestartPolicyNever,
+					Affinity:         verification.Affinity,
+					Tolerations:      verification.Tolerations,
+					ImagePullSecrets: cluster.Spec.ImagePullSecrets,
+
+					// pgBackRest may interact with a cloud storage provider. Use
+					// the instance ServiceAccount for its possible cloud identity
+					// without mounting its Kubernetes API credentials.
+					AutomountServiceAccountToken: initialize.Bool(false),
+					ServiceAccountName:           naming.ClusterInstanceRBAC(cluster).Name,
+					EnableServiceLinks:           initialize.Bool(false),
+				},
+			},
+		},
+	}
+
+	if pgbackrest := cluster.Spec.Backups.PGBackRest; pgbackrest.Jobs != nil && pgbackrest.Jobs.SecurityContext != nil {
+		job.Spec.Template.Spec.SecurityContext = pgbackrest.Jobs.SecurityContext
+	} else {
+		job.Spec.Template.Spec.SecurityContext = postgres.PodSecurityContext(cluster)
+	}
+
+	// The backup is restored from the repositories of the cluster itself,
+	// never from the data source the cluster was bootstrapped from.
+	source := cluster.DeepCopy()
+	source.Spec.DataSource = nil
+	crunchyPGBackRest.AddConfigToRestorePod(source, nil, &job.Spec.Template.Spec)
+
+	return job, nil
+}
+
+// verificationCommand returns the command of the verification Job. It runs
+// the restore script used for cluster restores and then starts PostgreSQL on
+// the restored data directory to run the SQL probe.
+func verificationCommand(pgdata, fetchKeyCommand, database, query, opts string) []string {
+	probe := strings.Join([]string{
+		// The restore script moves the data directory aside for the Patroni
+		// bootstrap. Move it back, allow local connections and start PostgreSQL.
+		`mv "${PGDATA}_bootstrap" "${PGDATA}"`,
+		`echo > /tmp/pg_hba.restore.conf 'local all all trust'`,
+		`pg_ctl start --silent --wait --options='-c config_file=/tmp/postgres.restore.conf'`,
+		`psql -U postgres -v ON_ERROR_STOP=1 --no-psqlrc -d "$3" -c "$4"`,
+		`pg_ctl stop --silent --wait --mode=fast`,
+	}, "\n")
+
+	script := crunchyPGBackRest.RestoreScript(pgdata, "off", fetchKeyCommand) + "\n" + probe
+
+	return []string{"bash", "-ceu", "--", script, "-", pgdata, opts, database, query}
+}
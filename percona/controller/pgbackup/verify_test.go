@@ -0,0 +1,121 @@
+package pgbackup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	crunchyPGBackRest "github.com/fulviodenza/percona-postgresql-operator/internal/pgbackrest"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestReconcileVerification(t *testing.T) {
+	ctx := context.Background()
+
+	cr, err := readDefaultCR("verify-backup", "verify-backup")
+	assert.NilError(t, err)
+
+	pgBackup := &v2.PerconaPGBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-backup",
+			Namespace: cr.Namespace,
+		},
+		Spec: v2.PerconaPGBackupSpec{
+			PGCluster: cr.Name,
+			RepoName:  "repo1",
+			Verification: &v2.PGBackupVerification{
+				Enabled: true,
+				Query:   "SELECT count(*) FROM pg_class",
+			},
+		},
+		Status: v2.PerconaPGBackupStatus{
+			State:      v2.BackupSucceeded,
+			BackupName: "20240601-000000F",
+		},
+	}
+	pgBackup.Default()
+
+	cl, err := buildFakeClient(ctx, cr, pgBackup)
+	assert.NilError(t, err)
+
+	_, err = reconcileVerification(ctx, cl, pgBackup)
+	assert.NilError(t, err)
+
+	assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(pgBackup), pgBackup))
+	assert.Assert(t, pgBackup.Status.Verification != nil)
+	assert.Equal(t, pgBackup.Status.Verification.State, v2.BackupVerificationRunning)
+	assert.Equal(t, pgBackup.Status.Verification.JobName, "some-backup-verify")
+
+	job := new(batchv1.Job)
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Name: "some-backup-verify", Namespace: cr.Namespace}, job))
+	assert.Equal(t, metav1.GetControllerOf(job).Name, pgBackup.Name)
+
+	container := job.Spec.Template.Spec.Containers[0]
+	pgdata := fmt.Sprintf("/pgdata/pg%d", cr.Spec.PostgresVersion)
+	assert.DeepEqual(t, container.Command, []string{
+		"bash", "-ceu", "--",
+		crunchyPGBackRest.RestoreScript(pgdata, "off", "") + "\n" + strings.Join([]string{
+			`mv "${PGDATA}_bootstrap" "${PGDATA}"`,
+			`echo > /tmp/pg_hba.restore.conf 'local all all trust'`,
+			`pg_ctl start --silent --wait --options='-c config_file=/tmp/postgres.restore.conf'`,
+			`psql -U postgres -v ON_ERROR_STOP=1 --no-psqlrc -d "$3" -c "$4"`,
+			`pg_ctl stop --silent --wait --mode=fast`,
+		}, "\n"),
+		"-", pgdata,
+		"--stanza=db --pg1-path=" + pgdata + " --repo=1 --set=20240601-000000F --type=immediate --target-action=promote",
+		"postgres",
+		"SELECT count(*) FROM pg_class",
+	})
+	assert.Assert(t, slices.ContainsFunc(container.VolumeMounts, func(m corev1.VolumeMount) bool {
+		return m.Name == "pgbackrest-config"
+	}))
+
+	start := metav1.NewTime(time.Now().Add(-time.Minute))
+	end := metav1.NewTime(start.Add(42 * time.Second))
+	job.Status.StartTime = &start
+	job.Status.CompletionTime = &end
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	assert.NilError(t, cl.Status().Update(ctx, job))
+
+	_, err = reconcileVerification(ctx, cl, pgBackup)
+	assert.NilError(t, err)
+
+	assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(pgBackup), pgBackup))
+	assert.Equal(t, pgBackup.Status.Verification.State, v2.BackupVerified)
+	assert.Equal(t, pgBackup.Status.Verification.Duration.Duration, 42*time.Second)
+}
+
+func TestSetVerificationResult(t *testing.T) {
+	start := metav1.NewTime(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	failed := metav1.NewTime(start.Add(time.Minute))
+
+	job := &batchv1.Job{
+		Status: batchv1.JobStatus{
+			StartTime: &start,
+			Conditions: []batchv1.JobCondition{{
+				Type:               batchv1.JobFailed,
+				Status:             corev1.ConditionTrue,
+				Reason:             "BackoffLimitExceeded",
+				Message:            "Job has reached the specified backoff limit",
+				LastTransitionTime: failed,
+			}},
+		},
+	}
+
+	status := new(v2.PGBackupVerificationStatus)
+	setVerificationResult(status, job)
+
+	assert.Equal(t, status.State, v2.BackupVerificationFailed)
+	assert.Equal(t, status.Error, "BackoffLimitExceeded: Job has reached the specified backoff limit")
+	assert.Equal(t, status.Duration.Duration, time.Minute)
+	assert.Equal(t, status.CompletedAt.Time, failed.Time)
+}
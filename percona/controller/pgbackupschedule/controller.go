@@ -0,0 +1,345 @@
+package pgbackupschedule
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+const (
+	// PGBackupScheduleControllerName is the name of the perconapgbackupschedule controller
+	PGBackupScheduleControllerName = "perconapgbackupschedule-controller"
+)
+
+// PGBackupScheduleReconciler creates PerconaPGBackups on the schedules from
+// PerconaPGBackupSchedules. The schedule state is kept in the status, so the
+// most recent missed backup is started after the operator restarts.
+type PGBackupScheduleReconciler struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+
+	// now returns the current time. It's replaced in tests.
+	now func() time.Time
+}
+
+// SetupWithManager adds the perconapgbackupschedule controller to the provided runtime manager
+func (r *PGBackupScheduleReconciler) SetupWithManager(mgr manager.Manager) error {
+	return builder.ControllerManagedBy(mgr).
+		For(&v2.PerconaPGBackupSchedule{}).
+		WatchesRawSource(source.Kind(mgr.GetCache(), &v2.PerconaPGBackup{}, r.watchPGBackups())).
+		Complete(r)
+}
+
+func (r *PGBackupScheduleReconciler) watchPGBackups() handler.TypedFuncs[*v2.PerconaPGBackup, reconcile.Request] {
+	enqueue := func(pgBackup *v2.PerconaPGBackup, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+		name, ok := pgBackup.GetLabels()[pNaming.LabelBackupSchedule]
+		if !ok {
+			return
+		}
+		q.Add(reconcile.Request{NamespacedName: client.ObjectKey{
+			Namespace: pgBackup.GetNamespace(),
+			Name:      name,
+		}})
+	}
+
+	return handler.TypedFuncs[*v2.PerconaPGBackup, reconcile.Request]{
+		UpdateFunc: func(ctx context.Context, e event.TypedUpdateEvent[*v2.PerconaPGBackup], q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			if e.ObjectOld.Status.State != e.ObjectNew.Status.State {
+				enqueue(e.ObjectNew, q)
+			}
+		},
+		DeleteFunc: func(ctx context.Context, e event.TypedDeleteEvent[*v2.PerconaPGBackup], q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(e.Object, q)
+		},
+	}
+}
+
+// +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgbackupschedules,verbs=get;list;watch
+// +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgbackupschedules/status,verbs=patch;update
+// +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgbackups,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *PGBackupScheduleReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := logging.FromContext(ctx).WithValues("request", request)
+
+	schedule := &v2.PerconaPGBackupSchedule{}
+	if err := r.Client.Get(ctx, request.NamespacedName, schedule); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if schedule.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	status := schedule.Status.DeepCopy()
+	defer func() {
+		if equality.Semantic.DeepEqual(*status, schedule.Status) {
+			return
+		}
+		if err := r.updateStatus(ctx, schedule, status); err != nil {
+			log.Error(err, "failed to update backup schedule status")
+		}
+	}()
+
+	backups, err := r.listBackups(ctx, schedule)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	active := updateHistory(status, backups)
+
+	if err := r.pruneHistory(ctx, schedule, backups); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "prune backup history")
+	}
+
+	sched, err := cron.ParseStandard(schedule.Spec.Schedule)
+	if err != nil {
+		status.NextScheduleTime = nil
+		// The status update triggers another reconcile, so the same reason
+		// is recorded only once.
+		if reason := fmt.Sprintf("invalid schedule %q: %v", schedule.Spec.Schedule, err); reason != status.LastSkipReason {
+			r.skip(schedule, status, reason)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if schedule.Spec.Suspend {
+		status.NextScheduleTime = nil
+		return reconcile.Result{}, nil
+	}
+
+	now := r.clock()
+
+	earliest := schedule.CreationTimestamp.Time
+	if status.LastScheduleTime != nil {
+		earliest = status.LastScheduleTime.Time
+	}
+	// Only the most recent missed backup is started, e.g. when the operator
+	// was down or the schedule was suspended.
+	var scheduled time.Time
+	for t := sched.Next(earliest); !t.After(now); t = sched.Next(t) {
+		scheduled = t
+	}
+
+	if !scheduled.IsZero() {
+		status.LastScheduleTime = &metav1.Time{Time: scheduled}
+
+		cluster := &v2.PerconaPGCluster{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: schedule.Spec.PGCluster, Namespace: schedule.Namespace}, cluster); err != nil {
+			if !k8serrors.IsNotFound(err) {
+				return reconcile.Result{}, errors.Wrap(err, "get PerconaPGCluster")
+			}
+			cluster = nil
+		}
+
+		reason := skipReason(schedule, cluster, active)
+
+		if reason != "" {
+			r.skip(schedule, status, reason)
+		} else {
+			if schedule.ConcurrencyPolicy() == v2.BackupConcurrencyReplace {
+				for _, backup := range active {
+					log.Info("Deleting running backup to replace it", "backup", backup.Name)
+					if err := r.Client.Delete(ctx, backup); client.IgnoreNotFound(err) != nil {
+						return reconcile.Result{}, errors.Wrapf(err, "delete backup %s", backup.Name)
+					}
+				}
+				status.Active = nil
+			}
+
+			backup, err := r.createBackup(ctx, schedule, cluster, scheduled)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			log.Info("Created scheduled backup", "backup", backup.Name)
+
+			status.LastBackup = backup.Name
+			status.Active = append(status.Active, backup.Name)
+		}
+	}
+
+	next := sched.Next(now)
+	status.NextScheduleTime = &metav1.Time{Time: next}
+
+	return reconcile.Result{RequeueAfter: next.Sub(now)}, nil
+}
+
+func (r *PGBackupScheduleReconciler) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+func (r *PGBackupScheduleReconciler) updateStatus(ctx context.Context, schedule *v2.PerconaPGBackupSchedule, status *v2.PerconaPGBackupScheduleStatus) error {
+	latest := &v2.PerconaPGBackupSchedule{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(schedule), latest); err != nil {
+		return errors.Wrap(err, "get PerconaPGBackupSchedule")
+	}
+
+	latest.Status = *status
+
+	return errors.Wrap(r.Client.Status().Update(ctx, latest), "update PerconaPGBackupSchedule status")
+}
+
+// skip records the reason a due backup wasn't started.
+func (r *PGBackupScheduleReconciler) skip(schedule *v2.PerconaPGBackupSchedule, status *v2.PerconaPGBackupScheduleStatus, reason string) {
+	status.LastSkipReason = reason
+	status.LastSkipTime = &metav1.Time{Time: r.clock()}
+
+	if r.Recorder != nil {
+		r.Recorder.Event(schedule, corev1.EventTypeWarning, "BackupSkipped", reason)
+	}
+}
+
+// skipReason returns why a due backup can't be started or an empty string if
+// it can. The cluster is nil if it doesn't exist.
+func skipReason(schedule *v2.PerconaPGBackupSchedule, cluster *v2.PerconaPGCluster, active []*v2.PerconaPGBackup) string {
+	if cluster == nil {
+		return fmt.Sprintf("cluster %s is not found", schedule.Spec.PGCluster)
+	}
+
+	if cluster.Status.State != v2.AppStateReady {
+		return fmt.Sprintf("cluster is not ready, state: %q", cluster.Status.State)
+	}
+
+	condition := meta.FindStatusCondition(cluster.Status.Conditions, pNaming.ConditionClusterIsReadyForBackup)
+	if condition != nil && condition.Status == metav1.ConditionFalse {
+		return fmt.Sprintf("%s condition is False: %s", pNaming.ConditionClusterIsReadyForBackup, condition.Reason)
+	}
+
+	if len(active) > 0 && schedule.ConcurrencyPolicy() == v2.BackupConcurrencyForbid {
+		return fmt.Sprintf("backup %s is still running", active[0].Name)
+	}
+
+	return ""
+}
+
+func (r *PGBackupScheduleReconciler) createBackup(ctx context.Context, schedule *v2.PerconaPGBackupSchedule, cluster *v2.PerconaPGCluster, scheduled time.Time) (*v2.PerconaPGBackup, error) {
+	backup := &v2.PerconaPGBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			// The name is derived from the scheduled time, so the backup isn't
+			// created twice if the status update fails.
+			Name:      fmt.Sprintf("%s-%d", schedule.Name, scheduled.Unix()/60),
+			Namespace: schedule.Namespace,
+		},
+		Spec: v2.PerconaPGBackupSpec{
+			PGCluster: schedule.Spec.PGCluster,
+			RepoName:  schedule.Spec.RepoName,
+			Options:   append([]string{"--type=" + schedule.BackupType()}, schedule.Spec.Options...),
+		},
+	}
+
+	if cluster.CompareVersion("2.6.0") >= 0 && cluster.Spec.Metadata != nil {
+		backup.Annotations = cluster.Spec.Metadata.Annotations
+		backup.Labels = maps.Clone(cluster.Spec.Metadata.Labels)
+	}
+	if backup.Labels == nil {
+		backup.Labels = make(map[string]string)
+	}
+	backup.Labels[pNaming.LabelBackupSchedule] = schedule.Name
+
+	if err := r.Client.Create(ctx, backup); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return nil, errors.Wrapf(err, "create PerconaPGBackup %s", backup.Name)
+	}
+
+	return backup, nil
+}
+
+func (r *PGBackupScheduleReconciler) listBackups(ctx context.Context, schedule *v2.PerconaPGBackupSchedule) ([]*v2.PerconaPGBackup, error) {
+	list := &v2.PerconaPGBackupList{}
+	if err := r.Client.List(ctx, list,
+		client.InNamespace(schedule.Namespace),
+		client.MatchingLabels{pNaming.LabelBackupSchedule: schedule.Name},
+	); err != nil {
+		return nil, errors.Wrap(err, "list backups")
+	}
+
+	backups := make([]*v2.PerconaPGBackup, 0, len(list.Items))
+	for i := range list.Items {
+		backups = append(backups, &list.Items[i])
+	}
+
+	// Newest first.
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[j].CreationTimestamp.Before(&backups[i].CreationTimestamp)
+	})
+
+	return backups, nil
+}
+
+// updateHistory updates the status from the backups of the schedule and
+// returns the running ones.
+func updateHistory(status *v2.PerconaPGBackupScheduleStatus, backups []*v2.PerconaPGBackup) []*v2.PerconaPGBackup {
+	var active []*v2.PerconaPGBackup
+	status.Active = nil
+
+	for _, backup := range backups {
+		switch backup.Status.State {
+		case v2.BackupSucceeded:
+			completed := backup.Status.CompletedAt
+			if completed != nil && (status.LastSuccessfulTime == nil || status.LastSuccessfulTime.Before(completed)) {
+				status.LastSuccessfulTime = completed.DeepCopy()
+			}
+		case v2.BackupFailed:
+		default:
+			if backup.DeletionTimestamp == nil {
+				active = append(active, backup)
+				status.Active = append(status.Active, backup.Name)
+			}
+		}
+	}
+
+	return active
+}
+
+// pruneHistory deletes the oldest finished backups of the schedule beyond the
+// history limits.
+func (r *PGBackupScheduleReconciler) pruneHistory(ctx context.Context, schedule *v2.PerconaPGBackupSchedule, backups []*v2.PerconaPGBackup) error {
+	limits := map[v2.PGBackupState]*int32{
+		v2.BackupSucceeded: schedule.Spec.SuccessfulBackupsHistoryLimit,
+		v2.BackupFailed:    schedule.Spec.FailedBackupsHistoryLimit,
+	}
+	kept := make(map[v2.PGBackupState]int32)
+
+	for _, backup := range backups {
+		limit, ok := limits[backup.Status.State]
+		if !ok || limit == nil || backup.DeletionTimestamp != nil {
+			continue
+		}
+
+		if kept[backup.Status.State] < *limit {
+			kept[backup.Status.State]++
+			continue
+		}
+
+		if err := r.Client.Delete(ctx, backup); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "delete backup %s", backup.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package pgbackupschedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+var created = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func newSchedule() *v2.PerconaPGBackupSchedule {
+	return &v2.PerconaPGBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "hourly",
+			Namespace:         "pg",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v2.PerconaPGBackupScheduleSpec{
+			PGCluster: "cluster1",
+			Schedule:  "0 * * * *",
+			RepoName:  "repo1",
+			Type:      "incr",
+		},
+	}
+}
+
+func newCluster(state v2.AppState) *v2.PerconaPGCluster {
+	return &v2.PerconaPGCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster1",
+			Namespace: "pg",
+		},
+		Spec: v2.PerconaPGClusterSpec{
+			CRVersion: "2.6.0",
+			Metadata: &v1beta1.Metadata{
+				Labels: map[string]string{"team": "db"},
+			},
+		},
+		Status: v2.PerconaPGClusterStatus{State: state},
+	}
+}
+
+func newBackup(name string, state v2.PGBackupState, age time.Duration) *v2.PerconaPGBackup {
+	return &v2.PerconaPGBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "pg",
+			CreationTimestamp: metav1.NewTime(created.Add(-age)),
+			Labels:            map[string]string{pNaming.LabelBackupSchedule: "hourly"},
+		},
+		Status: v2.PerconaPGBackupStatus{
+			State:       state,
+			CompletedAt: ptr.To(metav1.NewTime(created.Add(-age).Add(time.Minute))),
+		},
+	}
+}
+
+func setup(t *testing.T, now time.Time, objs ...client.Object) (*PGBackupScheduleReconciler, client.Client) {
+	t.Helper()
+
+	s := runtime.NewScheme()
+	assert.NilError(t, v2.AddToScheme(s))
+
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).WithStatusSubresource(objs...).Build()
+
+	return &PGBackupScheduleReconciler{
+		Client: cl,
+		now:    func() time.Time { return now },
+	}, cl
+}
+
+func reconcileSchedule(t *testing.T, r *PGBackupScheduleReconciler, cl client.Client) (reconcile.Result, *v2.PerconaPGBackupSchedule) {
+	t.Helper()
+
+	ctx := context.Background()
+	key := client.ObjectKey{Name: "hourly", Namespace: "pg"}
+
+	res, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+	assert.NilError(t, err)
+
+	schedule := new(v2.PerconaPGBackupSchedule)
+	assert.NilError(t, cl.Get(ctx, key, schedule))
+	return res, schedule
+}
+
+func listBackups(t *testing.T, cl client.Client) []string {
+	t.Helper()
+
+	list := new(v2.PerconaPGBackupList)
+	assert.NilError(t, cl.List(context.Background(), list))
+
+	names := make([]string, 0, len(list.Items))
+	for _, b := range list.Items {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+func TestReconcileCreatesBackup(t *testing.T) {
+	now := created.Add(90 * time.Minute)
+	r, cl := setup(t, now, newSchedule(), newCluster(v2.AppStateReady))
+
+	res, schedule := reconcileSchedule(t, r, cl)
+
+	// Only the most recent missed run is started.
+	assert.Equal(t, schedule.Status.LastScheduleTime.UTC(), created.Add(time.Hour))
+	assert.Equal(t, schedule.Status.NextScheduleTime.UTC(), created.Add(2*time.Hour))
+	assert.Equal(t, res.RequeueAfter, 30*time.Minute)
+	assert.Equal(t, schedule.Status.LastSkipReason, "")
+
+	assert.DeepEqual(t, listBackups(t, cl), []string{schedule.Status.LastBackup})
+	assert.DeepEqual(t, schedule.Status.Active, []string{schedule.Status.LastBackup})
+
+	backup := new(v2.PerconaPGBackup)
+	assert.NilError(t, cl.Get(context.Background(), client.ObjectKey{Name: schedule.Status.LastBackup, Namespace: "pg"}, backup))
+	assert.DeepEqual(t, backup.Spec.Options, []string{"--type=incr"})
+	assert.Equal(t, backup.Spec.RepoName, "repo1")
+	assert.Equal(t, backup.Labels[pNaming.LabelBackupSchedule], "hourly")
+	assert.Equal(t, backup.Labels["team"], "db")
+
+	// Nothing is due until the next run.
+	_, schedule = reconcileSchedule(t, r, cl)
+	assert.Equal(t, len(listBackups(t, cl)), 1)
+	assert.Equal(t, schedule.Status.LastScheduleTime.UTC(), created.Add(time.Hour))
+}
+
+func TestReconcileSkipsBackup(t *testing.T) {
+	now := created.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		cluster *v2.PerconaPGCluster
+		objs    []client.Object
+		reason  string
+	}{
+		{
+			name:   "cluster not found",
+			reason: "cluster cluster1 is not found",
+		},
+		{
+			name:    "cluster not ready",
+			cluster: newCluster(v2.AppStateInit),
+			reason:  `cluster is not ready, state: "initializing"`,
+		},
+		{
+			name: "not ready for backup",
+			cluster: func() *v2.PerconaPGCluster {
+				cr := newCluster(v2.AppStateReady)
+				cr.Status.Conditions = []metav1.Condition{{
+					Type:   pNaming.ConditionClusterIsReadyForBackup,
+					Status: metav1.ConditionFalse,
+					Reason: "RepoHostReady",
+				}}
+				return cr
+			}(),
+			reason: "ReadyForBackup condition is False: RepoHostReady",
+		},
+		{
+			name:    "backup is running",
+			cluster: newCluster(v2.AppStateReady),
+			objs:    []client.Object{newBackup("running", v2.BackupRunning, time.Hour)},
+			reason:  "backup running is still running",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := append([]client.Object{newSchedule()}, tt.objs...)
+			if tt.cluster != nil {
+				objs = append(objs, tt.cluster)
+			}
+			r, cl := setup(t, now, objs...)
+			before := len(listBackups(t, cl))
+
+			_, schedule := reconcileSchedule(t, r, cl)
+
+			assert.Equal(t, schedule.Status.LastSkipReason, tt.reason)
+			assert.Equal(t, schedule.Status.LastSkipTime.UTC(), now)
+			assert.Equal(t, schedule.Status.LastScheduleTime.UTC(), now)
+			assert.Equal(t, len(listBackups(t, cl)), before)
+		})
+	}
+}
+
+func TestReconcileInvalidSchedule(t *testing.T) {
+	schedule := newSchedule()
+	schedule.Spec.Schedule = "0 * * *"
+
+	r, cl := setup(t, created.Add(time.Hour), schedule, newCluster(v2.AppStateReady))
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+
+	res, schedule := reconcileSchedule(t, r, cl)
+	assert.Equal(t, res, reconcile.Result{})
+	assert.Assert(t, cmp.Contains(schedule.Status.LastSkipReason, `invalid schedule "0 * * *"`))
+	assert.Equal(t, schedule.Status.LastSkipTime.UTC(), created.Add(time.Hour))
+	assert.Equal(t, len(recorder.Events), 1)
+
+	// The same reason isn't recorded again.
+	r.now = func() time.Time { return created.Add(2 * time.Hour) }
+	_, schedule = reconcileSchedule(t, r, cl)
+	assert.Equal(t, schedule.Status.LastSkipTime.UTC(), created.Add(time.Hour))
+	assert.Equal(t, len(recorder.Events), 1)
+	assert.Equal(t, len(listBackups(t, cl)), 0)
+}
+
+func TestReconcileConcurrencyPolicy(t *testing.T) {
+	now := created.Add(time.Hour)
+
+	for _, tt := range []struct {
+		policy   v2.BackupConcurrencyPolicy
+		expected int
+	}{
+		{v2.BackupConcurrencyAllow, 2},
+		{v2.BackupConcurrencyReplace, 1},
+	} {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			schedule := newSchedule()
+			schedule.Spec.ConcurrencyPolicy = tt.policy
+
+			r, cl := setup(t, now, schedule, newCluster(v2.AppStateReady), newBackup("running", v2.BackupRunning, time.Hour))
+
+			_, schedule = reconcileSchedule(t, r, cl)
+			assert.Equal(t, schedule.Status.LastSkipReason, "")
+			assert.Equal(t, len(listBackups(t, cl)), tt.expected)
+		})
+	}
+}
+
+func TestReconcileSuspendedAndHistory(t *testing.T) {
+	schedule := newSchedule()
+	schedule.Spec.Suspend = true
+	schedule.Spec.SuccessfulBackupsHistoryLimit = ptr.To(int32(1))
+	schedule.Spec.FailedBackupsHistoryLimit = ptr.To(int32(0))
+
+	r, cl := setup(t, created.Add(3*time.Hour), schedule, newCluster(v2.AppStateReady),
+		newBackup("succeeded-1", v2.BackupSucceeded, time.Hour),
+		newBackup("succeeded-2", v2.BackupSucceeded, 2*time.Hour),
+		newBackup("failed-1", v2.BackupFailed, time.Hour),
+	)
+
+	_, schedule = reconcileSchedule(t, r, cl)
+
+	assert.DeepEqual(t, listBackups(t, cl), []string{"succeeded-1"})
+	assert.Assert(t, schedule.Status.NextScheduleTime == nil)
+	assert.Assert(t, schedule.Status.LastScheduleTime == nil)
+	assert.Equal(t, schedule.Status.LastSuccessfulTime.UTC(), created.Add(-time.Hour).Add(time.Minute))
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/pkg/errors"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -18,6 +19,7 @@ import (
 	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/pgbackrest"
 	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
 )
 
 func (r *PGClusterReconciler) reconcileBackups(ctx context.Context, cr *v2.PerconaPGCluster) error {
@@ -39,6 +41,8 @@ func (r *PGClusterReconciler) reconcileBackups(ctx context.Context, cr *v2.Perco
 func (r *PGClusterReconciler) cleanupOutdatedBackups(ctx context.Context, cr *v2.PerconaPGCluster) error {
 	log := logging.FromContext(ctx)
 
+	pruneRetentionStatus(cr)
+
 	if cr.Status.State != v2.AppStateReady {
 		return nil
 	}
@@ -50,7 +54,8 @@ func (r *PGClusterReconciler) cleanupOutdatedBackups(ctx context.Context, cr *v2
 		if err != nil {
 			return errors.Wrap(err, "list pg-backups")
 		}
-		if len(pbList) == 0 {
+		retention := cr.Spec.Backups.PGBackRest.RetentionFor(repo.Name)
+		if len(pbList) == 0 && retention == nil {
 			continue
 		}
 
@@ -67,6 +72,19 @@ func (r *PGClusterReconciler) cleanupOutdatedBackups(ctx context.Context, cr *v2
 			return errors.Wrap(err, "get pgBackRest info")
 		}
 
+		if retention != nil {
+			expired, err := r.applyRetention(ctx, cr, repo, retention, info)
+			if err != nil {
+				return errors.Wrapf(err, "apply retention policy for %s", repo.Name)
+			}
+			if expired {
+				info, err = pgbackrest.GetInfo(ctx, readyPod, repo.Name)
+				if err != nil {
+					return errors.Wrap(err, "get pgBackRest info")
+				}
+			}
+		}
+
 		for _, pgBackup := range pbList {
 			if pgBackup.Status.State != v2.BackupSucceeded || pgBackup.CompareVersion("2.4.0") < 0 {
 				continue
@@ -110,6 +128,118 @@ func (r *PGClusterReconciler) cleanupOutdatedBackups(ctx context.Context, cr *v2
 	return nil
 }
 
+// applyRetention expires the backup sets that fall outside of the retention
+// policy of the repo and records the result in the cluster status.
+// It returns true if any backup set was expired.
+func (r *PGClusterReconciler) applyRetention(ctx context.Context, cr *v2.PerconaPGCluster, repo v1beta1.PGBackRestRepo, retention *v2.PGBackRestRetention, info pgbackrest.InfoOutput) (bool, error) {
+	log := logging.FromContext(ctx)
+
+	now := metav1.Now()
+	status := v2.PGBackRestRetentionStatus{
+		RepoName: repo.Name,
+	}
+	if prev := retentionStatus(cr, repo.Name); prev != nil {
+		status.LastExpired = prev.LastExpired
+		status.ExpiredBackups = prev.ExpiredBackups
+	}
+
+	var expiredSets []string
+	var expireErr error
+	for _, stanza := range info {
+		expired, retained := pgbackrest.ExpiredBackupSets(stanza, retention, now.Time)
+		status.RetainedFullBackups += retained
+		if len(expired) == 0 {
+			continue
+		}
+
+		pod, container, err := getRepoPod(ctx, r.Client, cr, repo)
+		if err != nil {
+			expireErr = errors.Wrap(err, "get pod to run pgbackrest expire")
+			break
+		}
+
+		for _, set := range expired {
+			log.Info("Expiring backup set outside of the retention policy", "repo", repo.Name, "stanza", stanza.Name, "set", set)
+			if err := pgbackrest.ExpireBackupSet(ctx, pod, container, stanza.Name, repo.Name, set); err != nil {
+				expireErr = errors.Wrapf(err, "expire backup set %s", set)
+				break
+			}
+			expiredSets = append(expiredSets, set)
+		}
+		if expireErr != nil {
+			break
+		}
+	}
+
+	if len(expiredSets) > 0 {
+		status.LastExpired = &now
+		status.ExpiredBackups = expiredSets
+	}
+	if expireErr != nil {
+		status.Error = expireErr.Error()
+	}
+	setRetentionStatus(cr, status)
+
+	return len(expiredSets) > 0, expireErr
+}
+
+func retentionStatus(cr *v2.PerconaPGCluster, repoName string) *v2.PGBackRestRetentionStatus {
+	for i := range cr.Status.BackupRetention {
+		if cr.Status.BackupRetention[i].RepoName == repoName {
+			return &cr.Status.BackupRetention[i]
+		}
+	}
+	return nil
+}
+
+// pruneRetentionStatus removes the retention status of the repos that are
+// removed or no longer have a retention policy.
+func pruneRetentionStatus(cr *v2.PerconaPGCluster) {
+	var statuses []v2.PGBackRestRetentionStatus
+	for _, status := range cr.Status.BackupRetention {
+		for _, repo := range cr.Spec.Backups.PGBackRest.Repos {
+			if repo.Name == status.RepoName && cr.Spec.Backups.PGBackRest.RetentionFor(repo.Name) != nil {
+				statuses = append(statuses, status)
+				break
+			}
+		}
+	}
+	cr.Status.BackupRetention = statuses
+}
+
+func setRetentionStatus(cr *v2.PerconaPGCluster, status v2.PGBackRestRetentionStatus) {
+	if s := retentionStatus(cr, status.RepoName); s != nil {
+		*s = status
+		return
+	}
+	cr.Status.BackupRetention = append(cr.Status.BackupRetention, status)
+}
+
+// getRepoPod returns the pod and the container that have local access to the repo.
+// Volume repos are only accessible from the dedicated repo host.
+func getRepoPod(ctx context.Context, cl client.Client, cr *v2.PerconaPGCluster, repo v1beta1.PGBackRestRepo) (*corev1.Pod, string, error) {
+	if repo.Volume == nil {
+		pod, err := controller.GetReadyInstancePod(ctx, cl, cr.Name, cr.Namespace)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "get ready instance pod")
+		}
+		return pod, naming.ContainerDatabase, nil
+	}
+
+	pods := new(corev1.PodList)
+	if err := cl.List(ctx, pods, client.InNamespace(cr.Namespace), client.MatchingLabelsSelector{
+		Selector: naming.PGBackRestDedicatedSelector(cr.Name),
+	}); err != nil {
+		return nil, "", errors.Wrap(err, "list repo host pods")
+	}
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning {
+			return &pods.Items[i], naming.PGBackRestRepoContainerName, nil
+		}
+	}
+	return nil, "", errors.New("no running repo host pod found")
+}
+
 func (r *PGClusterReconciler) reconcileBackupJobs(ctx context.Context, cr *v2.PerconaPGCluster) error {
 	for _, repo := range cr.Spec.Backups.PGBackRest.Repos {
 		backupJobs, err := listBackupJobs(ctx, r.Client, cr, repo.Name)
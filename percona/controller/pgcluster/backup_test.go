@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"testing"
 
+	"gotest.tools/v3/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -216,3 +217,24 @@ func createFakePodsForStatefulsets(ctx context.Context, cl client.Client, stsLis
 	}
 	return nil
 }
+
+func TestPruneRetentionStatus(t *testing.T) {
+	cr := &v2.PerconaPGCluster{}
+	cr.Spec.Backups.PGBackRest.Repos = []v1beta1.PGBackRestRepo{{Name: "repo1"}, {Name: "repo2"}}
+	cr.Spec.Backups.PGBackRest.Retention = []v2.PGBackRestRetention{{RepoName: "repo1"}}
+	cr.Status.BackupRetention = []v2.PGBackRestRetentionStatus{
+		{RepoName: "repo1", RetainedFullBackups: 2},
+		{RepoName: "repo2", RetainedFullBackups: 3},
+		{RepoName: "repo3", RetainedFullBackups: 4},
+	}
+
+	pruneRetentionStatus(cr)
+	assert.DeepEqual(t, cr.Status.BackupRetention, []v2.PGBackRestRetentionStatus{
+		{RepoName: "repo1", RetainedFullBackups: 2},
+	})
+
+	// The status is cleared when no repo has a retention policy.
+	cr.Spec.Backups.PGBackRest.Retention = nil
+	pruneRetentionStatus(cr)
+	assert.Assert(t, cr.Status.BackupRetention == nil)
+}
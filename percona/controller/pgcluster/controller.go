@@ -17,6 +17,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -45,6 +46,7 @@ import (
 	perconaController "github.com/fulviodenza/percona-postgresql-operator/percona/controller"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/extensions"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/k8s"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/metrics"
 	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/pmm"
 	perconaPG "github.com/fulviodenza/percona-postgresql-operator/percona/postgres"
@@ -104,6 +106,7 @@ func (r *PGClusterReconciler) SetupWithManager(mgr manager.Manager) error {
 		Owns(&v1beta1.PostgresCluster{}).
 		WatchesRawSource(source.Kind(mgr.GetCache(), &corev1.Service{}, r.watchServices())).
 		WatchesRawSource(source.Kind(mgr.GetCache(), &corev1.Secret{}, r.watchSecrets())).
+		WatchesRawSource(source.Kind(mgr.GetCache(), &corev1.Pod{}, r.watchPatroniCallbacks())).
 		WatchesRawSource(source.Kind(mgr.GetCache(), &batchv1.Job{}, r.watchBackupJobs())).
 		WatchesRawSource(source.Kind(mgr.GetCache(), &v2.PerconaPGBackup{}, r.watchPGBackups())).
 		Complete(r)
@@ -125,6 +128,24 @@ func (r *PGClusterReconciler) watchServices() handler.TypedFuncs[*corev1.Service
 	}
 }
 
+// watchPatroniCallbacks reconciles the cluster when the Patroni callbacks of an
+// instance Pod report a new event.
+func (r *PGClusterReconciler) watchPatroniCallbacks() handler.TypedFuncs[*corev1.Pod, reconcile.Request] {
+	return handler.TypedFuncs[*corev1.Pod, reconcile.Request]{
+		UpdateFunc: func(ctx context.Context, e event.TypedUpdateEvent[*corev1.Pod], q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			crName := e.ObjectNew.GetLabels()[naming.LabelCluster]
+
+			if len(crName) != 0 &&
+				e.ObjectNew.GetAnnotations()[naming.PatroniCallbackAnnotation] != e.ObjectOld.GetAnnotations()[naming.PatroniCallbackAnnotation] {
+				q.Add(reconcile.Request{NamespacedName: client.ObjectKey{
+					Namespace: e.ObjectNew.GetNamespace(),
+					Name:      crName,
+				}})
+			}
+		},
+	}
+}
+
 func (r *PGClusterReconciler) watchBackupJobs() handler.TypedFuncs[*batchv1.Job, reconcile.Request] {
 	return handler.TypedFuncs[*batchv1.Job, reconcile.Request]{
 		UpdateFunc: func(ctx context.Context, e event.TypedUpdateEvent[*batchv1.Job], q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
@@ -179,7 +200,7 @@ func (r *PGClusterReconciler) watchSecrets() handler.TypedFuncs[*corev1.Secret,
 	}
 }
 
-// +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgclusters,verbs=get;list;watch;patch
 // +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgclusters/status,verbs=patch;update
 // +kubebuilder:rbac:groups=postgres-operator.crunchydata.com,resources=postgresclusters,verbs=get;list;create;update;patch;delete;watch
 // +kubebuilder:rbac:groups=apps,resources=replicasets,verbs=create;delete;get;list;patch;watch
@@ -197,8 +218,10 @@ func (r *PGClusterReconciler) Reconcile(ctx context.Context, request reconcile.R
 		// cluster is deleted.
 		if err = client.IgnoreNotFound(err); err != nil {
 			log.Error(err, "unable to fetch PerconaPGCluster")
+			return ctrl.Result{}, errors.Wrap(err, "get PerconaPGCluster")
 		}
-		return ctrl.Result{}, errors.Wrap(err, "get PerconaPGCluster")
+		metrics.DeleteCluster(request.Namespace, request.Name)
+		return ctrl.Result{}, nil
 	}
 
 	cr.Default()
@@ -271,6 +294,10 @@ func (r *PGClusterReconciler) Reconcile(ctx context.Context, request reconcile.R
 		return reconcile.Result{}, errors.Wrap(err, "failed to add pmm sidecar")
 	}
 
+	if err := r.reconcileExporterService(ctx, cr); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "reconcile exporter service")
+	}
+
 	if err := r.handleMonitorUserPassChange(ctx, cr); err != nil {
 		return reconcile.Result{}, errors.Wrap(err, "failed to handle monitor user password change")
 	}
@@ -321,11 +348,24 @@ func (r *PGClusterReconciler) Reconcile(ctx context.Context, request reconcile.R
 		return ctrl.Result{}, errors.Wrap(err, "get PostgresCluster")
 	}
 
+	switchoverWait, err := r.reconcileSwitchover(ctx, cr)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "reconcile switchover")
+	}
+
+	if err := r.reconcilePatroniCallbacks(ctx, cr); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "reconcile patroni callbacks")
+	}
+
 	if err := r.updateStatus(ctx, cr, &postgresCluster.Status); err != nil {
 		return ctrl.Result{}, errors.Wrap(err, "update status")
 	}
 
-	return ctrl.Result{}, nil
+	if err := r.reconcileBackupMetrics(ctx, cr); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "reconcile backup metrics")
+	}
+
+	return ctrl.Result{RequeueAfter: switchoverWait}, nil
 }
 
 var errPatroniVersionCheckWait = errors.New("waiting for pod to initialize")
@@ -726,7 +766,7 @@ func (r *PGClusterReconciler) handleMonitorUserPassChange(ctx context.Context, c
 }
 
 func (r *PGClusterReconciler) reconcileCustomExtensions(ctx context.Context, cr *v2.PerconaPGCluster) error {
-	if cr.Spec.Extensions.Storage.Secret == nil {
+	if !cr.Spec.Extensions.Storage.Enabled() {
 		return nil
 	}
 
@@ -778,6 +818,20 @@ func (r *PGClusterReconciler) reconcileCustomExtensions(ctx context.Context, cr
 		}
 	}
 
+	// Failing SQL of an extension must not block other changes of the spec.
+	// Report it in the status and try again on the next reconcile.
+	if err := r.enableCustomExtensionsInDB(ctx, cr); err != nil {
+		logging.FromContext(ctx).Error(err, "failed to enable custom extensions")
+		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+			Type:    pNaming.ConditionCustomExtensionsFailed,
+			Status:  metav1.ConditionTrue,
+			Reason:  "EnableFailed",
+			Message: err.Error(),
+		})
+	} else {
+		meta.RemoveStatusCondition(&cr.Status.Conditions, pNaming.ConditionCustomExtensionsFailed)
+	}
+
 	for i := 0; i < len(cr.Spec.InstanceSets); i++ {
 		set := &cr.Spec.InstanceSets[i]
 		set.InitContainers = append(set.InitContainers, extensions.ExtensionRelocatorContainer(
@@ -795,6 +849,91 @@ func (r *PGClusterReconciler) reconcileCustomExtensions(ctx context.Context, cr
 	return nil
 }
 
+// enableCustomExtensionsInDB creates the custom extensions in the databases
+// from the spec and records their versions in the status. An extension is
+// only created or updated once the primary runs with its archive installed.
+func (r *PGClusterReconciler) enableCustomExtensionsInDB(ctx context.Context, cr *v2.PerconaPGCluster) error {
+	log := logging.FromContext(ctx)
+
+	var custom []v2.CustomExtensionSpec
+	for _, extension := range cr.Spec.Extensions.Custom {
+		if len(extension.Databases) > 0 {
+			custom = append(custom, extension)
+		}
+	}
+	if len(custom) == 0 {
+		cr.Status.InstalledCustomExtensionVersions = nil
+		return nil
+	}
+	if customExtensionsInSync(custom, cr.Status.InstalledCustomExtensionVersions) {
+		return nil
+	}
+
+	primary, err := perconaPG.GetPrimaryPod(ctx, r.Client, cr)
+	if err != nil || primary.Status.Phase != corev1.PodRunning {
+		log.V(1).Info("Waiting for primary pod to enable custom extensions")
+		return nil
+	}
+
+	exec := func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, command ...string) error {
+		return r.PodExec(ctx, primary.Namespace, primary.Name, naming.ContainerDatabase, stdin, stdout, stderr, command...)
+	}
+
+	// keepPending keeps the statuses of an extension that can't be reconciled
+	// now and marks them pending.
+	installed := make([]v2.InstalledExtensionStatus, 0)
+	keepPending := func(extension v2.CustomExtensionSpec) {
+		for _, status := range cr.Status.InstalledCustomExtensionVersions {
+			if status.Name == extension.Name && slices.Contains(extension.Databases, status.Database) {
+				status.Pending = true
+				installed = append(installed, status)
+			}
+		}
+	}
+
+	var failed []string
+	for _, extension := range custom {
+		key := extensions.GetExtensionKey(cr.Spec.PostgresVersion, extension.Name, extension.Version)
+		if !extensions.InstalledOnPod(primary, key) {
+			// The primary isn't restarted with the new archive yet.
+			keepPending(extension)
+			continue
+		}
+
+		status, err := extensions.EnableInPostgreSQL(ctx, exec, extension)
+		if err != nil {
+			// Reconcile the other extensions anyway.
+			failed = append(failed, err.Error())
+			keepPending(extension)
+			continue
+		}
+		installed = append(installed, status...)
+	}
+
+	cr.Status.InstalledCustomExtensionVersions = installed
+
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// customExtensionsInSync returns true if every extension is reconciled with
+// its version from the spec in all of its databases.
+func customExtensionsInSync(custom []v2.CustomExtensionSpec, installed []v2.InstalledExtensionStatus) bool {
+	for _, extension := range custom {
+		for _, db := range extension.Databases {
+			if !slices.ContainsFunc(installed, func(status v2.InstalledExtensionStatus) bool {
+				return status.Name == extension.Name && status.Database == db &&
+					status.SpecVersion == extension.Version && !status.Pending
+			}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func disableCustomExtensionsInDB(ctx context.Context, exec postgres.Executor, customExtensionsForDeletion []string) error {
 	log := logging.FromContext(ctx)
 
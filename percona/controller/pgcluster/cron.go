@@ -9,8 +9,9 @@ import (
 )
 
 type CronRegistry struct {
-	crons      *cron.Cron
-	backupJobs *sync.Map
+	crons             *cron.Cron
+	backupJobs        *sync.Map
+	ensureVersionJobs *sync.Map
 }
 
 // AddFuncWithSeconds does the same as cron.AddFunc but changes the schedule so that the function will run the exact second that this method is called.
@@ -26,8 +27,9 @@ func (r *CronRegistry) AddFuncWithSeconds(spec string, cmd func()) (cron.EntryID
 
 func NewCronRegistry() CronRegistry {
 	c := CronRegistry{
-		crons:      cron.New(),
-		backupJobs: new(sync.Map),
+		crons:             cron.New(),
+		backupJobs:        new(sync.Map),
+		ensureVersionJobs: new(sync.Map),
 	}
 
 	c.crons.Start()
@@ -35,39 +37,56 @@ func NewCronRegistry() CronRegistry {
 	return c
 }
 
-func backupJobKey(name, namespace string) string {
+func jobKey(name, namespace string) string {
 	return name + "-" + namespace
 }
 
 func (r *CronRegistry) ApplyBackupJob(name, namespace, schedule string, cmd func()) error {
-	schRaw, ok := r.backupJobs.Load(backupJobKey(name, namespace))
+	return r.applyJob(r.backupJobs, jobKey(name, namespace), schedule, cmd)
+}
+
+func (r *CronRegistry) DeleteBackupJob(name, namespace string) {
+	r.deleteJob(r.backupJobs, jobKey(name, namespace))
+}
+
+// ApplyEnsureVersionJob schedules the version service check of a cluster.
+func (r *CronRegistry) ApplyEnsureVersionJob(name, namespace, schedule string, cmd func()) error {
+	return r.applyJob(r.ensureVersionJobs, jobKey(name, namespace), schedule, cmd)
+}
+
+func (r *CronRegistry) DeleteEnsureVersionJob(name, namespace string) {
+	r.deleteJob(r.ensureVersionJobs, jobKey(name, namespace))
+}
+
+func (r *CronRegistry) applyJob(jobs *sync.Map, key, schedule string, cmd func()) error {
+	schRaw, ok := jobs.Load(key)
 	if ok {
-		sch := schRaw.(BackupScheduleJob)
+		sch := schRaw.(ScheduledJob)
 		if sch.schedule == schedule {
 			return nil
 		}
 	}
 
-	r.DeleteBackupJob(name, namespace)
+	r.deleteJob(jobs, key)
 
 	jobID, err := r.AddFuncWithSeconds(schedule, cmd)
 	if err != nil {
-		return errors.Wrap(err, "failed to add backup job")
+		return errors.Wrap(err, "failed to add job")
 	}
-	r.backupJobs.Store(backupJobKey(name, namespace), BackupScheduleJob{
+	jobs.Store(key, ScheduledJob{
 		schedule: schedule,
 		id:       jobID,
 	})
 	return nil
 }
 
-func (r *CronRegistry) DeleteBackupJob(name, namespace string) {
-	if sch, ok := r.backupJobs.LoadAndDelete(backupJobKey(name, namespace)); ok {
-		r.crons.Remove(sch.(BackupScheduleJob).id)
+func (r *CronRegistry) deleteJob(jobs *sync.Map, key string) {
+	if sch, ok := jobs.LoadAndDelete(key); ok {
+		r.crons.Remove(sch.(ScheduledJob).id)
 	}
 }
 
-type BackupScheduleJob struct {
+type ScheduledJob struct {
 	schedule string
 	id       cron.EntryID
 }
@@ -0,0 +1,102 @@
+package pgcluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestReconcileCustomExtensionsFailure(t *testing.T) {
+	ctx := context.Background()
+
+	const crName = "custom-extensions"
+	const ns = crName
+
+	cr, err := readDefaultCR(crName, ns)
+	assert.NilError(t, err)
+	cr.Status.PatroniVersion = "4.0.0"
+	cr.Spec.Extensions.Image = "extensions-installer"
+	cr.Spec.Extensions.Storage = v2.CustomExtensionsStorageSpec{Type: "http", Endpoint: "https://extensions"}
+	cr.Spec.Extensions.Custom = []v2.CustomExtensionSpec{
+		{Name: "broken", Version: "1.0", Databases: []string{"app"}},
+		{Name: "working", Version: "1.0", Databases: []string{"app"}},
+	}
+
+	keys := []string{
+		fmt.Sprintf("broken-pg%d-1.0", cr.Spec.PostgresVersion),
+		fmt.Sprintf("working-pg%d-1.0", cr.Spec.PostgresVersion),
+	}
+	primary := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      crName + "-instance1-abcd-0",
+			Namespace: ns,
+			Labels: map[string]string{
+				"app.kubernetes.io/instance":             crName,
+				"postgres-operator.crunchydata.com/role": "primary",
+			},
+		},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{
+				Name: "extension-installer",
+				Env:  []corev1.EnvVar{{Name: "INSTALL_EXTENSIONS", Value: strings.Join(keys, ",")}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	cl, err := buildFakeClient(ctx, cr, primary)
+	assert.NilError(t, err)
+
+	r := &PGClusterReconciler{
+		Client: cl,
+		PodExec: func(
+			ctx context.Context, namespace, pod, container string,
+			stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			args := strings.Join(command, " ")
+			if strings.Contains(args, "--set=extension=broken") {
+				_, _ = io.WriteString(stderr, `ERROR:  extension "broken" has no installation script`)
+				return errors.New("exit status 3")
+			}
+			_, _ = io.WriteString(stdout, `{"database":"app","version":"1.0","pending":false}`+"\n")
+			return nil
+		},
+	}
+
+	// The failing extension does not stop the reconcile nor the other extension.
+	assert.NilError(t, r.reconcileCustomExtensions(ctx, cr))
+
+	condition := meta.FindStatusCondition(cr.Status.Conditions, pNaming.ConditionCustomExtensionsFailed)
+	assert.Assert(t, condition != nil)
+	assert.Equal(t, condition.Status, metav1.ConditionTrue)
+	assert.Assert(t, strings.Contains(condition.Message, "no installation script"), condition.Message)
+
+	assert.DeepEqual(t, cr.Status.InstalledCustomExtensionVersions, []v2.InstalledExtensionStatus{{
+		Name: "working", Database: "app", Version: "1.0", SpecVersion: "1.0",
+	}})
+	assert.Assert(t, len(cr.Spec.InstanceSets[0].InitContainers) > 0, "expected the installer")
+
+	// The condition is copied into the status and removed once the SQL works.
+	cluster := cr.DeepCopy()
+	cluster.Status.Conditions = nil
+	updateCustomExtensionsCondition(cluster, cr)
+	assert.Assert(t, meta.IsStatusConditionTrue(cluster.Status.Conditions, pNaming.ConditionCustomExtensionsFailed))
+
+	cr.Spec.Extensions.Custom = cr.Spec.Extensions.Custom[1:]
+	assert.NilError(t, r.reconcileCustomExtensions(ctx, cr))
+	assert.Assert(t, meta.FindStatusCondition(cr.Status.Conditions, pNaming.ConditionCustomExtensionsFailed) == nil)
+
+	updateCustomExtensionsCondition(cluster, cr)
+	assert.Assert(t, meta.FindStatusCondition(cluster.Status.Conditions, pNaming.ConditionCustomExtensionsFailed) == nil)
+}
@@ -0,0 +1,44 @@
+package pgcluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fulviodenza/percona-postgresql-operator/percona/metrics"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+// reconcileBackupMetrics sets the completion time of the latest succeeded
+// backup in every repo of the cluster. It is taken from the PerconaPGBackups
+// so that it survives restarts of the operator.
+func (r *PGClusterReconciler) reconcileBackupMetrics(ctx context.Context, cr *v2.PerconaPGCluster) error {
+	backups := new(v2.PerconaPGBackupList)
+	if err := r.Client.List(ctx, backups, client.InNamespace(cr.Namespace), client.MatchingFields{
+		v2.IndexFieldPGCluster: cr.Name,
+	}); err != nil {
+		return errors.Wrap(err, "list backups")
+	}
+
+	latest := make(map[string]time.Time)
+	for _, backup := range backups.Items {
+		if backup.Status.State != v2.BackupSucceeded {
+			continue
+		}
+		completedAt := backup.CreationTimestamp.Time
+		if backup.Status.CompletedAt != nil {
+			completedAt = backup.Status.CompletedAt.Time
+		}
+		if completedAt.After(latest[backup.Spec.RepoName]) {
+			latest[backup.Spec.RepoName] = completedAt
+		}
+	}
+
+	for repo, completedAt := range latest {
+		metrics.SetLastSuccessfulBackup(cr, repo, completedAt)
+	}
+
+	return nil
+}
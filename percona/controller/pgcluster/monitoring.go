@@ -0,0 +1,113 @@
+package pgcluster
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/pgmonitor"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func exporterServiceName(cr *v2.PerconaPGCluster) string {
+	return cr.Name + "-exporter"
+}
+
+// exporterService returns the Service selecting the postgres_exporter of every
+// instance of the cluster. It is headless so that a ServiceMonitor scrapes each
+// instance instead of a random one.
+func exporterService(cr *v2.PerconaPGCluster) *corev1.Service {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      exporterServiceName(cr),
+			Namespace: cr.Namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: corev1.ClusterIPNone,
+			Selector: map[string]string{
+				naming.LabelCluster:            cr.Name,
+				naming.LabelPGMonitorDiscovery: "true",
+			},
+			Ports: []corev1.ServicePort{{
+				Name:       naming.PortExporter,
+				Port:       pgmonitor.ExporterPort,
+				Protocol:   corev1.ProtocolTCP,
+				TargetPort: intstr.FromString(naming.PortExporter),
+			}},
+		},
+	}
+
+	var annotations, labels map[string]string
+	if cr.Spec.Metadata != nil {
+		annotations = cr.Spec.Metadata.Annotations
+		labels = cr.Spec.Metadata.Labels
+	}
+	if service := cr.Spec.Monitoring.Exporter.Service; service != nil {
+		annotations = naming.Merge(annotations, service.Annotations)
+		labels = naming.Merge(labels, service.Labels)
+	}
+
+	svc.Annotations = annotations
+	svc.Labels = naming.Merge(labels, naming.WithPerconaLabels(map[string]string{
+		naming.LabelCluster: cr.Name,
+		naming.LabelRole:    naming.RoleMonitoring,
+	}, cr.Name, "exporter", cr.Spec.CRVersion))
+
+	return svc
+}
+
+// reconcileExporterService creates the Service of the postgres_exporter sidecars
+// if the exporter is enabled and deletes it otherwise.
+func (r *PGClusterReconciler) reconcileExporterService(ctx context.Context, cr *v2.PerconaPGCluster) error {
+	existing := new(corev1.Service)
+	err := r.Client.Get(ctx, client.ObjectKey{Name: exporterServiceName(cr), Namespace: cr.Namespace}, existing)
+	if client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, "get exporter service")
+	}
+	found := err == nil
+
+	if !cr.ExporterEnabled() {
+		if found && metav1.IsControlledBy(existing, cr) {
+			if err := r.Client.Delete(ctx, existing); client.IgnoreNotFound(err) != nil {
+				return errors.Wrap(err, "delete exporter service")
+			}
+		}
+		return nil
+	}
+
+	svc := exporterService(cr)
+	if err := controllerutil.SetControllerReference(cr, svc, r.Client.Scheme()); err != nil {
+		return errors.Wrap(err, "set controller reference")
+	}
+
+	if !found {
+		if err := r.Client.Create(ctx, svc); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return errors.Wrap(err, "create exporter service")
+		}
+		return nil
+	}
+
+	orig := existing.DeepCopy()
+	existing.Annotations = svc.Annotations
+	existing.Labels = svc.Labels
+	existing.OwnerReferences = svc.OwnerReferences
+	existing.Spec.Selector = svc.Spec.Selector
+	existing.Spec.Ports = svc.Spec.Ports
+	if equality.Semantic.DeepEqual(orig, existing) {
+		return nil
+	}
+	if err := r.Client.Patch(ctx, existing, client.MergeFrom(orig)); err != nil {
+		return errors.Wrap(err, "patch exporter service")
+	}
+
+	return nil
+}
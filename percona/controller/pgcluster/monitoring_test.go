@@ -0,0 +1,60 @@
+package pgcluster
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+func TestReconcileExporterService(t *testing.T) {
+	ctx := context.Background()
+
+	cr, err := readDefaultCR("exporter-service", "exporter-service")
+	assert.NilError(t, err)
+
+	cr.Spec.Monitoring = &v2.MonitoringSpec{
+		Exporter: &v2.ExporterSpec{
+			Enabled: true,
+			Service: &v1beta1.Metadata{
+				Labels: map[string]string{"release": "prometheus"},
+			},
+		},
+	}
+
+	cl, err := buildFakeClient(ctx, cr)
+	assert.NilError(t, err)
+
+	r := reconciler(cr)
+	r.Client = cl
+
+	assert.NilError(t, r.reconcileExporterService(ctx, cr))
+
+	svc := new(corev1.Service)
+	key := client.ObjectKey{Name: "exporter-service-exporter", Namespace: cr.Namespace}
+	assert.NilError(t, cl.Get(ctx, key, svc))
+
+	assert.Equal(t, svc.Labels["release"], "prometheus")
+	assert.Equal(t, svc.Labels[naming.LabelCluster], cr.Name)
+	assert.Equal(t, svc.Spec.Selector[naming.LabelPGMonitorDiscovery], "true")
+	assert.Equal(t, svc.Spec.Ports[0].Name, naming.PortExporter)
+	assert.Equal(t, svc.Spec.Ports[0].Port, int32(9187))
+	assert.Equal(t, metav1.GetControllerOf(svc).Name, cr.Name)
+
+	cr.Spec.Monitoring.Exporter.Service.Labels["release"] = "kube-prometheus"
+	assert.NilError(t, r.reconcileExporterService(ctx, cr))
+	assert.NilError(t, cl.Get(ctx, key, svc))
+	assert.Equal(t, svc.Labels["release"], "kube-prometheus")
+
+	cr.Spec.Monitoring.Exporter.Enabled = false
+	assert.NilError(t, r.reconcileExporterService(ctx, cr))
+	assert.Assert(t, k8serrors.IsNotFound(cl.Get(ctx, key, svc)))
+}
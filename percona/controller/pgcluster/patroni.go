@@ -0,0 +1,110 @@
+package pgcluster
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/patroni"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+// reconcilePatroniCallbacks turns the events reported by the Patroni callbacks
+// of the instance Pods into Kubernetes Events of the cluster and records the
+// changes of the primary in cr.Status.Patroni. The status is written by
+// updateStatus.
+func (r *PGClusterReconciler) reconcilePatroniCallbacks(ctx context.Context, cr *v2.PerconaPGCluster) error {
+	log := logging.FromContext(ctx)
+
+	pods := new(corev1.PodList)
+	instances, err := naming.AsSelector(naming.ClusterInstances(cr.Name))
+	if err != nil {
+		return err
+	}
+	if err := r.Client.List(ctx, pods, client.InNamespace(cr.Namespace), client.MatchingLabelsSelector{Selector: instances}); err != nil {
+		return errors.Wrap(err, "list instance pods")
+	}
+
+	status := &cr.Status.Patroni
+
+	type podEvent struct {
+		pod   string
+		event *patroni.CallbackEvent
+	}
+	var events []podEvent
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		role := pod.Labels[naming.LabelRole]
+		if status.Primary == "" && (role == naming.RolePatroniLeader || role == naming.RolePatroniLeaderDeprecated) {
+			status.Primary = pod.Name
+		}
+
+		event, err := patroni.PodCallbackEvent(pod)
+		if err != nil {
+			// The annotation can be changed by anyone who can patch the Pod.
+			log.Error(err, "failed to get Patroni callback event")
+			continue
+		}
+		if event == nil {
+			continue
+		}
+
+		// The status keeps the time with microsecond precision.
+		event.Time = event.Time.Truncate(time.Microsecond)
+		if status.LastCallbackTime != nil && !event.Time.After(status.LastCallbackTime.Time) {
+			continue
+		}
+		events = append(events, podEvent{pod: pod.Name, event: event})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].event.Time.Before(events[j].event.Time)
+	})
+
+	for _, e := range events {
+		switch {
+		case e.event.Promoted() && e.pod != status.Primary:
+			message := "Instance " + e.pod + " was promoted to primary"
+			if status.Primary != "" {
+				message += ", replacing " + status.Primary
+			}
+			r.Recorder.Eventf(cr, corev1.EventTypeWarning, "PrimaryChanged",
+				"%s (timeline %d)", message, e.event.Timeline)
+
+			status.FailoverHistory = append(status.FailoverHistory, v2.PatroniFailoverStatus{
+				Time:       metav1.NewTime(e.event.Time),
+				OldPrimary: status.Primary,
+				NewPrimary: e.pod,
+				Timeline:   e.event.Timeline,
+			})
+			if n := len(status.FailoverHistory); n > v2.MaxFailoverHistory {
+				status.FailoverHistory = status.FailoverHistory[n-v2.MaxFailoverHistory:]
+			}
+			status.Primary = e.pod
+
+		case e.event.Action == "on_start":
+			r.Recorder.Eventf(cr, corev1.EventTypeNormal, "InstanceStarted",
+				"Instance %s started as %s", e.pod, e.event.Role)
+
+		case e.event.Action == "on_stop":
+			r.Recorder.Eventf(cr, corev1.EventTypeNormal, "InstanceStopped",
+				"Instance %s stopped as %s", e.pod, e.event.Role)
+
+		default:
+			r.Recorder.Eventf(cr, corev1.EventTypeNormal, "InstanceRoleChanged",
+				"Instance %s changed its role to %s", e.pod, e.event.Role)
+		}
+
+		status.LastCallbackTime = &metav1.MicroTime{Time: e.event.Time}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package pgcluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestReconcilePatroniCallbacks(t *testing.T) {
+	ctx := context.Background()
+
+	cr, err := readDefaultCR("patroni-callbacks", "patroni-callbacks")
+	assert.NilError(t, err)
+
+	cl, err := buildFakeClient(ctx, cr)
+	assert.NilError(t, err)
+
+	recorder := record.NewFakeRecorder(10)
+	r := reconciler(cr)
+	r.Client = cl
+	r.Recorder = recorder
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	callback := func(action, role string, timeline int, offset time.Duration) string {
+		return fmt.Sprintf(`{"action":%q,"role":%q,"timeline":%d,"time":%q}`,
+			action, role, timeline, start.Add(offset).Format(time.RFC3339Nano))
+	}
+
+	pod := func(name, role, event string) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: cr.Namespace,
+				Labels: map[string]string{
+					naming.LabelCluster:     cr.Name,
+					naming.LabelInstanceSet: "instance1",
+					naming.LabelInstance:    name,
+					naming.LabelRole:        role,
+				},
+			},
+		}
+		if event != "" {
+			p.Annotations = map[string]string{naming.PatroniCallbackAnnotation: event}
+		}
+		return p
+	}
+
+	events := func() []string {
+		var out []string
+		for len(recorder.Events) > 0 {
+			out = append(out, <-recorder.Events)
+		}
+		return out
+	}
+
+	// The primary is taken from the Pod labels before the first failover.
+	first := pod("instance1-a", naming.RolePatroniLeader, callback("on_start", "primary", 1, 0))
+	second := pod("instance1-b", naming.RolePatroniReplica, callback("on_start", "replica", 0, time.Second))
+	assert.NilError(t, cl.Create(ctx, first))
+	assert.NilError(t, cl.Create(ctx, second))
+
+	assert.NilError(t, r.reconcilePatroniCallbacks(ctx, cr))
+	assert.Equal(t, cr.Status.Patroni.Primary, "instance1-a")
+	assert.Assert(t, cr.Status.Patroni.FailoverHistory == nil)
+	assert.DeepEqual(t, events(), []string{
+		"Normal InstanceStarted Instance instance1-a started as primary",
+		"Normal InstanceStarted Instance instance1-b started as replica",
+	})
+
+	// Events are reported once.
+	assert.NilError(t, r.reconcilePatroniCallbacks(ctx, cr))
+	assert.Assert(t, events() == nil)
+
+	// The replica is promoted before the Pod labels change.
+	first.Annotations[naming.PatroniCallbackAnnotation] = callback("on_stop", "primary", 1, time.Minute)
+	second.Annotations[naming.PatroniCallbackAnnotation] = callback("on_role_change", "primary", 2, time.Minute+time.Second)
+	assert.NilError(t, cl.Update(ctx, first))
+	assert.NilError(t, cl.Update(ctx, second))
+
+	assert.NilError(t, r.reconcilePatroniCallbacks(ctx, cr))
+	assert.Equal(t, cr.Status.Patroni.Primary, "instance1-b")
+	assert.DeepEqual(t, cr.Status.Patroni.FailoverHistory, []v2.PatroniFailoverStatus{{
+		Time:       metav1.NewTime(start.Add(time.Minute + time.Second)),
+		OldPrimary: "instance1-a",
+		NewPrimary: "instance1-b",
+		Timeline:   2,
+	}})
+	assert.DeepEqual(t, events(), []string{
+		"Normal InstanceStopped Instance instance1-a stopped as primary",
+		"Warning PrimaryChanged Instance instance1-b was promoted to primary, replacing instance1-a (timeline 2)",
+	})
+
+	// The history is bounded.
+	for i := 0; i < v2.MaxFailoverHistory; i++ {
+		p := []*corev1.Pod{first, second}[i%2]
+		p.Annotations[naming.PatroniCallbackAnnotation] = callback("on_role_change", "primary", i+3, time.Hour+time.Duration(i)*time.Minute)
+		assert.NilError(t, cl.Update(ctx, p))
+		assert.NilError(t, r.reconcilePatroniCallbacks(ctx, cr))
+	}
+	history := cr.Status.Patroni.FailoverHistory
+	assert.Equal(t, len(history), v2.MaxFailoverHistory)
+	assert.Equal(t, history[len(history)-1].Timeline, int64(v2.MaxFailoverHistory+2))
+	assert.Equal(t, history[0].Timeline, int64(3))
+
+	// A broken annotation doesn't stop the reconcile.
+	second.Annotations[naming.PatroniCallbackAnnotation] = "{"
+	assert.NilError(t, cl.Update(ctx, second))
+	assert.NilError(t, r.reconcilePatroniCallbacks(ctx, cr))
+}
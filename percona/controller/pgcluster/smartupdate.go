@@ -0,0 +1,131 @@
+package pgcluster
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/version"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+// reconcileEnsureVersionJob schedules the version service check of
+// spec.upgradeOptions, or removes it when automatic updates are disabled.
+func (r *PGClusterReconciler) reconcileEnsureVersionJob(ctx context.Context, cr *v2.PerconaPGCluster) {
+	log := logging.FromContext(ctx)
+
+	opts := cr.Spec.UpgradeOptions
+	if opts == nil || opts.Apply.Disabled() || opts.Schedule == "" {
+		r.Cron.DeleteEnsureVersionJob(cr.Name, cr.Namespace)
+		return
+	}
+
+	ensureVersionFunc := r.ensureVersionFunc(log, cr.Name, cr.Namespace)
+	if err := r.Cron.ApplyEnsureVersionJob(cr.Name, cr.Namespace, opts.Schedule, ensureVersionFunc); err != nil {
+		log.Error(err, "failed to create a cron for the version service check")
+	}
+}
+
+func (r *PGClusterReconciler) ensureVersionFunc(log logr.Logger, name, namespace string) func() {
+	return func() {
+		if err := r.applyVersionServiceImages(logging.NewContext(context.Background(), log), name, namespace); err != nil {
+			log.Error(err, "failed to apply versions from the version service")
+		}
+	}
+}
+
+// applyVersionServiceImages asks the version service for the images selected
+// by spec.upgradeOptions.apply and updates the cluster to them. Clusters that
+// are not ready or are being upgraded to another major version are skipped
+// until the next maintenance window.
+func (r *PGClusterReconciler) applyVersionServiceImages(ctx context.Context, name, namespace string) error {
+	log := logging.FromContext(ctx).WithValues("cluster", name, "namespace", namespace)
+
+	cr := &v2.PerconaPGCluster{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cr); err != nil {
+		if k8serrors.IsNotFound(err) {
+			log.Info("cluster is not found, deleting the version service job")
+			r.Cron.DeleteEnsureVersionJob(name, namespace)
+			return nil
+		}
+		return errors.Wrap(err, "get PerconaPGCluster")
+	}
+
+	opts := cr.Spec.UpgradeOptions
+	if opts == nil || opts.Apply.Disabled() {
+		return nil
+	}
+	if cr.Status.State != v2.AppStateReady {
+		log.Info("cluster is not ready, skipping the version service check", "state", cr.Status.State)
+		return nil
+	}
+	if _, ok := cr.Annotations[v2.AnnotationAllowUpgrade]; ok {
+		log.Info("cluster is being upgraded, skipping the version service check")
+		return nil
+	}
+
+	operatorDepl, err := r.getOperatorDeployment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get operator deployment")
+	}
+	vm := r.getVersionMeta(cr, operatorDepl)
+	vm.Apply = string(opts.Apply)
+
+	versions, err := version.GetVersions(ctx, opts.VersionServiceEndpoint, vm)
+	if err != nil {
+		return errors.Wrap(err, "get versions")
+	}
+
+	orig := cr.DeepCopy()
+	applied := setVersionServiceImages(cr, versions)
+	if !equality.Semantic.DeepEqual(orig.Spec, cr.Spec) {
+		log.Info("Updating images from the version service",
+			"postgres", applied.Postgres, "pgbouncer", applied.PGBouncer,
+			"pgbackrest", applied.PGBackRest, "pmm", applied.PMM)
+
+		if err := r.Client.Patch(ctx, cr, client.MergeFrom(orig)); err != nil {
+			return errors.Wrap(err, "patch PerconaPGCluster")
+		}
+	}
+
+	orig = cr.DeepCopy()
+	now := metav1.Now()
+	applied.LastApplied = &now
+	cr.Status.Versions = applied
+	if err := r.Client.Status().Patch(ctx, cr, client.MergeFrom(orig)); err != nil {
+		return errors.Wrap(err, "patch PerconaPGCluster status")
+	}
+
+	return nil
+}
+
+// setVersionServiceImages sets the images of cr to versions and returns the
+// versions it set.
+func setVersionServiceImages(cr *v2.PerconaPGCluster, versions version.DepVersion) *v2.AppliedVersionsStatus {
+	applied := &v2.AppliedVersionsStatus{
+		Postgres:   versions.PostgresVersion,
+		PGBackRest: versions.PGBackRestVersion,
+	}
+
+	cr.Spec.Image = versions.PostgresImage
+	cr.Spec.Backups.PGBackRest.Image = versions.PGBackRestImage
+
+	if cr.Spec.Proxy != nil && cr.Spec.Proxy.PGBouncer != nil {
+		cr.Spec.Proxy.PGBouncer.Image = versions.PGBouncerImage
+		applied.PGBouncer = versions.PGBouncerVersion
+	}
+
+	if cr.PMMEnabled() && versions.PMMImage != "" {
+		cr.Spec.PMM.Image = versions.PMMImage
+		applied.PMM = versions.PMMVersion
+	}
+
+	return applied
+}
@@ -0,0 +1,111 @@
+package pgcluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestApplyVersionServiceImages(t *testing.T) {
+	ctx := context.Background()
+
+	const crName = "smart-update"
+	const ns = crName
+
+	// The server stands in for the version service and records the requested
+	// apply values.
+	var applied []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		applied = append(applied, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"versions": []any{map[string]any{
+				"matrix": map[string]any{
+					"postgresql": map[string]any{
+						"16.9": map[string]any{"imagePath": "postgres:16.9"},
+						"17.5": map[string]any{"imagePath": "postgres:17.5"},
+					},
+					"pgbouncer":  map[string]any{"1.24.1": map[string]any{"imagePath": "pgbouncer:1.24.1"}},
+					"pgbackrest": map[string]any{"2.55.0": map[string]any{"imagePath": "pgbackrest:2.55.0"}},
+					"pmm":        map[string]any{"3.1.0": map[string]any{"imagePath": "pmm-client:3.1.0"}},
+				},
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cr, err := readDefaultCR(crName, ns)
+	assert.NilError(t, err)
+	cr.Spec.PostgresVersion = 17
+	cr.Spec.UpgradeOptions = &v2.UpgradeOptions{
+		VersionServiceEndpoint: server.URL,
+		Apply:                  v2.UpgradeApplyRecommended,
+		Schedule:               "0 4 * * *",
+	}
+	cr.Status.State = v2.AppStateReady
+
+	cl, err := buildFakeClient(ctx, cr)
+	assert.NilError(t, err)
+	r := &PGClusterReconciler{Client: cl, Cron: NewCronRegistry()}
+
+	t.Run("Schedule", func(t *testing.T) {
+		r.reconcileEnsureVersionJob(ctx, cr)
+		_, ok := r.Cron.ensureVersionJobs.Load(jobKey(crName, ns))
+		assert.Assert(t, ok)
+
+		disabled := cr.DeepCopy()
+		disabled.Spec.UpgradeOptions.Apply = v2.UpgradeApplyDisabled
+		r.reconcileEnsureVersionJob(ctx, disabled)
+		_, ok = r.Cron.ensureVersionJobs.Load(jobKey(crName, ns))
+		assert.Assert(t, !ok)
+	})
+
+	t.Run("NotReady", func(t *testing.T) {
+		initializing := cr.DeepCopy()
+		initializing.Status.State = v2.AppStateInit
+		assert.NilError(t, cl.Status().Update(ctx, initializing))
+		t.Cleanup(func() {
+			ready := &v2.PerconaPGCluster{}
+			assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(cr), ready))
+			ready.Status.State = v2.AppStateReady
+			assert.NilError(t, cl.Status().Update(ctx, ready))
+		})
+
+		assert.NilError(t, r.applyVersionServiceImages(ctx, crName, ns))
+		assert.Equal(t, len(applied), 0)
+	})
+
+	assert.NilError(t, r.applyVersionServiceImages(ctx, crName, ns))
+	assert.DeepEqual(t, applied, []string{"recommended"})
+
+	updated := &v2.PerconaPGCluster{}
+	assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(cr), updated))
+	assert.Equal(t, updated.Spec.Image, "postgres:17.5")
+	assert.Equal(t, updated.Spec.Proxy.PGBouncer.Image, "pgbouncer:1.24.1")
+	assert.Equal(t, updated.Spec.Backups.PGBackRest.Image, "pgbackrest:2.55.0")
+	assert.Assert(t, updated.Spec.PMM.Image != "pmm-client:3.1.0", "PMM is disabled")
+
+	assert.Assert(t, updated.Status.Versions != nil)
+	assert.Equal(t, updated.Status.Versions.Postgres, "17.5")
+	assert.Equal(t, updated.Status.Versions.PGBouncer, "1.24.1")
+	assert.Equal(t, updated.Status.Versions.PGBackRest, "2.55.0")
+	assert.Equal(t, updated.Status.Versions.PMM, "")
+	assert.Assert(t, updated.Status.Versions.LastApplied != nil)
+
+	t.Run("NotFound", func(t *testing.T) {
+		r.reconcileEnsureVersionJob(ctx, cr)
+		assert.NilError(t, cl.Delete(ctx, updated))
+
+		assert.NilError(t, r.applyVersionServiceImages(ctx, crName, ns))
+		_, ok := r.Cron.ensureVersionJobs.Load(jobKey(crName, ns))
+		assert.Assert(t, !ok)
+	})
+}
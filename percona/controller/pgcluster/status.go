@@ -2,6 +2,7 @@ package pgcluster
 
 import (
 	"context"
+	"strings"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -9,8 +10,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/fulviodenza/percona-postgresql-operator/internal/controller/postgrescluster"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/extensions"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/metrics"
 	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
 	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
 	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
@@ -87,6 +92,16 @@ func (r *PGClusterReconciler) updateStatus(ctx context.Context, cr *v2.PerconaPG
 		installedCustomExtensions = append(installedCustomExtensions, extension.Name)
 	}
 
+	verificationFailure, err := r.extensionVerificationFailure(ctx, cr)
+	if err != nil {
+		return errors.Wrap(err, "check custom extensions verification")
+	}
+
+	syncStandbys, err := r.synchronousStandbys(ctx, cr)
+	if err != nil {
+		return errors.Wrap(err, "get synchronous standbys")
+	}
+
 	var size, ready int32
 	ss := make([]v2.PostgresInstanceSetStatus, 0, len(status.InstanceSets))
 	for _, is := range status.InstanceSets {
@@ -100,6 +115,7 @@ func (r *PGClusterReconciler) updateStatus(ctx context.Context, cr *v2.PerconaPG
 		ready += is.ReadyReplicas
 	}
 
+	var state v2.AppState
 	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		cluster := &v2.PerconaPGCluster{}
 		if err := r.Client.Get(ctx, types.NamespacedName{
@@ -112,23 +128,36 @@ func (r *PGClusterReconciler) updateStatus(ctx context.Context, cr *v2.PerconaPG
 		cluster.Status.Postgres.Size = size
 		cluster.Status.Postgres.Ready = ready
 		cluster.Status.Postgres.InstanceSets = ss
+		cluster.Status.Postgres.SynchronousStandbys = syncStandbys
 
 		cluster.Status.PGBouncer = v2.PGBouncerStatus{
 			Size:  status.Proxy.PGBouncer.Replicas,
 			Ready: status.Proxy.PGBouncer.ReadyReplicas,
+
+			ReadOnlyPools: status.Proxy.PGBouncer.ReadOnlyPools,
 		}
 		cluster.Status.Host = host
 		cluster.Status.InstalledCustomExtensions = installedCustomExtensions
+		cluster.Status.InstalledCustomExtensionVersions = cr.Status.InstalledCustomExtensionVersions
+		cluster.Status.BackupRetention = cr.Status.BackupRetention
+		cluster.Status.Patroni = cr.Status.Patroni
+		cluster.Status.Switchover = cr.Status.Switchover
+		cluster.Status.PasswordRotations = status.PasswordRotations
 
 		cluster.Status.State = r.getState(cr, &cluster.Status, status)
+		state = cluster.Status.State
 
 		updateConditions(cluster, status)
+		updateExtensionVerificationCondition(cluster, verificationFailure)
+		updateCustomExtensionsCondition(cluster, cr)
 
 		return r.Client.Status().Update(ctx, cluster)
 	}); err != nil {
 		return errors.Wrap(err, "update PerconaPGCluster status")
 	}
 
+	metrics.SetClusterState(cr, state)
+
 	return nil
 }
 
@@ -159,3 +188,83 @@ func updateConditions(cr *v2.PerconaPGCluster, status *v1beta1.PostgresClusterSt
 
 	setClusterNotReadyCondition(metav1.ConditionTrue, "AllConditionsAreTrue")
 }
+
+// extensionVerificationFailure returns the reason an extension installer of
+// any instance pod failed to verify a custom extension archive.
+func (r *PGClusterReconciler) extensionVerificationFailure(ctx context.Context, cr *v2.PerconaPGCluster) (string, error) {
+	if len(cr.Spec.Extensions.Custom) == 0 {
+		return "", nil
+	}
+
+	pods := new(corev1.PodList)
+	instances, err := naming.AsSelector(naming.ClusterInstances(cr.Name))
+	if err != nil {
+		return "", err
+	}
+	if err := r.Client.List(ctx, pods, client.InNamespace(cr.Namespace), client.MatchingLabelsSelector{Selector: instances}); err != nil {
+		return "", errors.Wrap(err, "list instance pods")
+	}
+
+	for i := range pods.Items {
+		if msg := extensions.VerificationFailure(&pods.Items[i]); msg != "" {
+			return msg, nil
+		}
+	}
+
+	return "", nil
+}
+
+// synchronousStandbys returns the instances Patroni currently uses as
+// synchronous standbys of the cluster.
+func (r *PGClusterReconciler) synchronousStandbys(ctx context.Context, cr *v2.PerconaPGCluster) ([]string, error) {
+	if cr.Spec.Patroni == nil || !cr.Spec.Patroni.Synchronous.Enabled() {
+		return nil, nil
+	}
+
+	sync := &corev1.Endpoints{ObjectMeta: naming.PatroniSync(&v1beta1.PostgresCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: cr.Name, Namespace: cr.Namespace},
+	})}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(sync), sync); err != nil {
+		return nil, errors.Wrap(client.IgnoreNotFound(err), "get sync endpoints")
+	}
+
+	var standbys []string
+	for _, name := range strings.Split(sync.Annotations["sync_standby"], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			standbys = append(standbys, name)
+		}
+	}
+	return standbys, nil
+}
+
+func updateExtensionVerificationCondition(cr *v2.PerconaPGCluster, failure string) {
+	if len(cr.Spec.Extensions.Custom) == 0 {
+		meta.RemoveStatusCondition(&cr.Status.Conditions, pNaming.ConditionCustomExtensionVerificationFailed)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:   pNaming.ConditionCustomExtensionVerificationFailed,
+		Status: metav1.ConditionFalse,
+		Reason: "NoFailures",
+	}
+	if failure != "" {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "VerificationFailed"
+		condition.Message = failure
+	}
+
+	meta.SetStatusCondition(&cr.Status.Conditions, condition)
+}
+
+// updateCustomExtensionsCondition copies the result of enabling custom
+// extensions in the databases during this reconcile from cr to cluster.
+func updateCustomExtensionsCondition(cluster, cr *v2.PerconaPGCluster) {
+	condition := meta.FindStatusCondition(cr.Status.Conditions, pNaming.ConditionCustomExtensionsFailed)
+	if condition == nil {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, pNaming.ConditionCustomExtensionsFailed)
+		return
+	}
+
+	meta.SetStatusCondition(&cluster.Status.Conditions, *condition)
+}
@@ -143,6 +143,46 @@ var _ = Describe("PG Cluster status", Ordered, func() {
 		})
 	})
 
+	Context("Updated PG cluster status.postgres.synchronousStandbys", func() {
+		crName := ns + "-sync"
+		crNamespacedName := types.NamespacedName{Name: crName, Namespace: ns}
+
+		cr, err := readDefaultCR(crName, ns)
+		It("should read default cr.yaml and create PerconaPGCluster with synchronous replication", func() {
+			Expect(err).NotTo(HaveOccurred())
+			if cr.Spec.Patroni == nil {
+				cr.Spec.Patroni = new(v1beta1.PatroniSpec)
+			}
+			cr.Spec.Patroni.Synchronous = &v1beta1.PatroniSynchronous{Mode: v1beta1.PatroniSynchronousOn}
+			status := cr.Status
+			Expect(k8sClient.Create(ctx, cr)).Should(Succeed())
+			cr.Status = status
+			Expect(k8sClient.Status().Update(ctx, cr)).Should(Succeed())
+		})
+
+		It("should create Patroni sync endpoints", func() {
+			Expect(k8sClient.Create(ctx, &corev1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        crName + "-ha-sync",
+					Namespace:   ns,
+					Annotations: map[string]string{"leader": "instance1-0", "sync_standby": "instance1-1,instance1-2"},
+				},
+			})).Should(Succeed())
+		})
+
+		It("status.postgres.synchronousStandbys should match Patroni sync standbys", func() {
+			_, err := reconciler(cr).Reconcile(ctx, ctrl.Request{NamespacedName: crNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() bool {
+				err = k8sClient.Get(ctx, crNamespacedName, cr)
+				return err == nil
+			}, time.Second*15, time.Millisecond*250).Should(BeTrue())
+
+			Expect(cr.Status.Postgres.SynchronousStandbys).Should(Equal([]string{"instance1-1", "instance1-2"}))
+		})
+	})
+
 	Context("Update PG cluster status.state", Ordered, func() {
 		crName := ns + "-state"
 		crNamespacedName := types.NamespacedName{Name: crName, Namespace: ns}
@@ -0,0 +1,188 @@
+package pgcluster
+
+import (
+	"context"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/patroni"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// switchoverPendingWait is how long a switchover waits for a running primary
+// or instance pod to perform it.
+const switchoverPendingWait = 5 * time.Second
+
+// reconcileSwitchover performs the switchover requested in cr.Spec.Switchover
+// and records its progress in cr.Status.Switchover. It returns how long to
+// wait for a scheduled or pending switchover.
+func (r *PGClusterReconciler) reconcileSwitchover(ctx context.Context, cr *v2.PerconaPGCluster) (time.Duration, error) {
+	log := logging.FromContext(ctx)
+
+	spec := cr.Spec.Switchover
+	if spec == nil {
+		return 0, nil
+	}
+
+	status := cr.Status.Switchover
+	if status != nil && status.ID == spec.ID &&
+		(status.State == v2.SwitchoverStateCompleted || status.State == v2.SwitchoverStateFailed) {
+		return 0, nil
+	}
+	if status == nil || status.ID != spec.ID {
+		status = &v2.SwitchoverStatus{
+			ID:          spec.ID,
+			State:       v2.SwitchoverStateScheduled,
+			RequestedAt: ptr.To(metav1.Now()),
+		}
+		cr.Status.Switchover = status
+	}
+
+	if spec.ScheduledAt != nil {
+		if wait := time.Until(spec.ScheduledAt.Time); wait > 0 {
+			log.V(1).Info("Waiting for scheduled switchover", "id", spec.ID, "scheduledAt", spec.ScheduledAt)
+			return wait, nil
+		}
+	}
+
+	fail := func(err error) (time.Duration, error) {
+		status.State = v2.SwitchoverStateFailed
+		status.Error = err.Error()
+		status.CompletedAt = ptr.To(metav1.Now())
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, "SwitchoverFailed",
+			"Switchover %s failed: %s", spec.ID, status.Error)
+		return 0, nil
+	}
+
+	failover := spec.Type == v1beta1.PatroniSwitchoverTypeFailover
+	if failover && spec.TargetInstance == "" {
+		return fail(errors.New("targetInstance is required for a failover"))
+	}
+
+	pods := new(corev1.PodList)
+	instances, err := naming.AsSelector(naming.ClusterInstances(cr.Name))
+	if err != nil {
+		return 0, err
+	}
+	if err := r.Client.List(ctx, pods, client.InNamespace(cr.Namespace), client.MatchingLabelsSelector{Selector: instances}); err != nil {
+		return 0, errors.Wrap(err, "list instance pods")
+	}
+
+	var target, running *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if spec.TargetInstance != "" && pod.Labels[naming.LabelInstance] == spec.TargetInstance {
+			target = pod
+		}
+		if running == nil && databaseRunning(pod) {
+			running = pod
+		}
+	}
+	if spec.TargetInstance != "" && target == nil {
+		return fail(errors.Errorf("target instance %s not found", spec.TargetInstance))
+	}
+	if running == nil {
+		log.V(1).Info("Waiting for a running instance pod to perform the switchover", "id", spec.ID)
+		return switchoverPendingWait, nil
+	}
+
+	exec := patroni.Executor(func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, command ...string) error {
+		return r.PodExec(ctx, running.Namespace, running.Name, naming.ContainerDatabase, stdin, stdout, stderr, command...)
+	})
+
+	complete := func() (time.Duration, error) {
+		primary, err := exec.GetPrimary(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "get primary")
+		}
+		status.State = v2.SwitchoverStateCompleted
+		status.Primary = primary
+		status.CompletedAt = ptr.To(metav1.Now())
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, "SwitchoverCompleted",
+			"Switchover %s completed, the primary is %s", spec.ID, primary)
+		return 0, nil
+	}
+
+	// A failover may be needed exactly when there is no running leader and
+	// thus no timeline.
+	timeline, err := exec.GetTimeline(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "get timeline")
+	}
+	if timeline == 0 && !failover {
+		log.V(1).Info("Waiting for a running primary to switchover from", "id", spec.ID)
+		return switchoverPendingWait, nil
+	}
+
+	// The operator may have been interrupted after it triggered the
+	// switchover. Patroni changes the timeline when a new primary is promoted.
+	if status.State == v2.SwitchoverStateRunning && status.Timeline != 0 && status.Timeline != timeline {
+		log.Info("Timeline changed, assuming the switchover completed", "id", spec.ID)
+		return complete()
+	}
+
+	if status.State != v2.SwitchoverStateRunning {
+		status.State = v2.SwitchoverStateRunning
+		status.Timeline = timeline
+		if err := r.updateSwitchoverStatus(ctx, cr); err != nil {
+			return 0, err
+		}
+	}
+
+	next := ""
+	if target != nil {
+		next = target.Name
+	}
+
+	log.Info("Performing switchover", "id", spec.ID, "type", spec.Type, "target", next)
+
+	var success bool
+	if failover {
+		success, err = exec.FailoverAndWait(ctx, next)
+	} else {
+		success, err = exec.SwitchoverAndWait(ctx, next)
+	}
+	// Errors of the exec itself are retried, only a switchover that Patroni
+	// refused fails the request.
+	if err != nil {
+		return 0, errors.Wrap(err, "perform switchover")
+	}
+	if !success {
+		return fail(errors.New("unable to switchover"))
+	}
+
+	return complete()
+}
+
+// updateSwitchoverStatus writes cr.Status.Switchover before the switchover is
+// triggered, so an interrupted switchover isn't triggered twice.
+func (r *PGClusterReconciler) updateSwitchoverStatus(ctx context.Context, cr *v2.PerconaPGCluster) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := new(v2.PerconaPGCluster)
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(cr), cluster); err != nil {
+			return errors.Wrap(err, "get PerconaPGCluster")
+		}
+
+		cluster.Status.Switchover = cr.Status.Switchover
+		return r.Client.Status().Update(ctx, cluster)
+	})
+	return errors.Wrap(err, "update switchover status")
+}
+
+func databaseRunning(pod *corev1.Pod) bool {
+	idx := slices.IndexFunc(pod.Status.ContainerStatuses, func(s corev1.ContainerStatus) bool {
+		return s.Name == naming.ContainerDatabase
+	})
+	return idx != -1 && pod.Status.ContainerStatuses[idx].State.Running != nil
+}
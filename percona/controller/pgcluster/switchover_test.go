@@ -0,0 +1,253 @@
+package pgcluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestReconcileSwitchover(t *testing.T) {
+	ctx := context.Background()
+
+	pod := func(cr *v2.PerconaPGCluster, instance string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      instance + "-0",
+				Namespace: cr.Namespace,
+				Labels: map[string]string{
+					naming.LabelCluster:     cr.Name,
+					naming.LabelInstanceSet: "instance1",
+					naming.LabelInstance:    instance,
+				},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  naming.ContainerDatabase,
+					State: corev1.ContainerState{Running: new(corev1.ContainerStateRunning)},
+				}},
+			},
+		}
+	}
+
+	// patroniExec pretends to be patronictl of a cluster with two members.
+	type patroniExec struct {
+		leader   string
+		timeline int
+		calls    []string
+		output   string
+		err      error
+	}
+	podExec := func(p *patroniExec) func(context.Context, string, string, string, io.Reader, io.Writer, io.Writer, ...string) error {
+		return func(_ context.Context, _, _, _ string, _ io.Reader, stdout, _ io.Writer, command ...string) error {
+			call := strings.Join(command, " ")
+			p.calls = append(p.calls, call)
+
+			switch {
+			case strings.HasPrefix(call, "patronictl list --extended"):
+				_, _ = io.WriteString(stdout, `[]`)
+			case strings.HasPrefix(call, "patronictl list"):
+				_, _ = fmt.Fprintf(stdout, `[{"Member": %q, "Role": "Leader", "State": "running", "TL": %d}]`, p.leader, p.timeline)
+			case strings.HasPrefix(call, "patronictl switchover"), strings.HasPrefix(call, "patronictl failover"):
+				if p.err != nil {
+					return p.err
+				}
+				if p.output != "" {
+					_, _ = io.WriteString(stdout, p.output)
+					return nil
+				}
+				p.leader = strings.TrimPrefix(command[len(command)-1], "--candidate=")
+				if p.leader == "" {
+					p.leader = "cluster-instance1-bbbb-0"
+				}
+				p.timeline++
+				_, _ = io.WriteString(stdout, "Successfully switched over / failed over")
+			}
+			return nil
+		}
+	}
+
+	setup := func(t *testing.T, name string) (*PGClusterReconciler, *v2.PerconaPGCluster, client.Client) {
+		cr, err := readDefaultCR(name, name)
+		assert.NilError(t, err)
+
+		cl, err := buildFakeClient(ctx, cr,
+			pod(cr, "cluster-instance1-aaaa"),
+			pod(cr, "cluster-instance1-bbbb"))
+		assert.NilError(t, err)
+
+		r := reconciler(cr)
+		r.Client = cl
+		r.Recorder = record.NewFakeRecorder(10)
+		return r, cr, cl
+	}
+
+	t.Run("Scheduled", func(t *testing.T) {
+		r, cr, _ := setup(t, "switchover-scheduled")
+		p := &patroniExec{leader: "cluster-instance1-aaaa-0", timeline: 1}
+		r.PodExec = podExec(p)
+
+		cr.Spec.Switchover = &v2.SwitchoverSpec{
+			ID:          "1",
+			ScheduledAt: &metav1.Time{Time: time.Now().Add(time.Hour)},
+		}
+
+		wait, err := r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Assert(t, wait > 59*time.Minute)
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateScheduled)
+		assert.Assert(t, cr.Status.Switchover.RequestedAt != nil)
+		assert.Assert(t, p.calls == nil)
+	})
+
+	t.Run("Switchover", func(t *testing.T) {
+		r, cr, cl := setup(t, "switchover")
+		p := &patroniExec{leader: "cluster-instance1-aaaa-0", timeline: 1}
+		r.PodExec = podExec(p)
+
+		cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "1", TargetInstance: "cluster-instance1-bbbb"}
+
+		wait, err := r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Equal(t, wait, time.Duration(0))
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateCompleted, "%+v", cr.Status.Switchover)
+		assert.Equal(t, cr.Status.Switchover.Primary, "cluster-instance1-bbbb-0")
+		assert.Equal(t, cr.Status.Switchover.Timeline, int64(1))
+		assert.Assert(t, cr.Status.Switchover.CompletedAt != nil)
+		assert.Assert(t, strings.Contains(strings.Join(p.calls, "\n"),
+			"patronictl switchover --scheduled=now --force --candidate=cluster-instance1-bbbb-0"))
+
+		// The running state is stored before the switchover is triggered.
+		stored := new(v2.PerconaPGCluster)
+		assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(cr), stored))
+		assert.Equal(t, stored.Status.Switchover.State, v2.SwitchoverStateRunning)
+
+		// A request is performed once.
+		p.calls = nil
+		_, err = r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Assert(t, p.calls == nil)
+
+		// A new ID is a new request; Patroni chooses the candidate.
+		cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "2"}
+		p.leader = "cluster-instance1-aaaa-0"
+		_, err = r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Equal(t, cr.Status.Switchover.ID, "2")
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateCompleted)
+		assert.Equal(t, cr.Status.Switchover.Primary, "cluster-instance1-bbbb-0")
+	})
+
+	t.Run("Interrupted", func(t *testing.T) {
+		r, cr, _ := setup(t, "switchover-interrupted")
+		p := &patroniExec{leader: "cluster-instance1-bbbb-0", timeline: 2}
+		r.PodExec = podExec(p)
+
+		cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "1"}
+		cr.Status.Switchover = &v2.SwitchoverStatus{
+			ID:       "1",
+			State:    v2.SwitchoverStateRunning,
+			Timeline: 1,
+		}
+
+		_, err := r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateCompleted)
+		assert.Equal(t, cr.Status.Switchover.Primary, "cluster-instance1-bbbb-0")
+		for _, call := range p.calls {
+			assert.Assert(t, !strings.HasPrefix(call, "patronictl switchover"), "unexpected %q", call)
+		}
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		r, cr, _ := setup(t, "switchover-failed")
+		p := &patroniExec{leader: "cluster-instance1-aaaa-0", timeline: 1, output: "Switchover failed"}
+		r.PodExec = podExec(p)
+
+		cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "1"}
+
+		_, err := r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateFailed)
+		assert.Equal(t, cr.Status.Switchover.Error, "unable to switchover")
+
+		// Missing targets and failovers without a target fail too.
+		cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "2", TargetInstance: "missing"}
+		_, err = r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateFailed)
+		assert.Equal(t, cr.Status.Switchover.Error, "target instance missing not found")
+
+		cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "3", Type: "Failover"}
+		_, err = r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateFailed)
+		assert.Equal(t, cr.Status.Switchover.Error, "targetInstance is required for a failover")
+	})
+
+	t.Run("Pending", func(t *testing.T) {
+		r, cr, _ := setup(t, "switchover-pending")
+		p := &patroniExec{timeline: 0}
+		r.PodExec = podExec(p)
+
+		cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "1"}
+
+		// There is no primary to switchover from yet.
+		wait, err := r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Equal(t, wait, switchoverPendingWait)
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateScheduled)
+
+		// The switchover is performed once the primary is running.
+		p.leader, p.timeline = "cluster-instance1-aaaa-0", 1
+		_, err = r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateCompleted, "%+v", cr.Status.Switchover)
+	})
+
+	t.Run("ExecError", func(t *testing.T) {
+		r, cr, _ := setup(t, "switchover-exec-error")
+		p := &patroniExec{leader: "cluster-instance1-aaaa-0", timeline: 1, err: errors.New("connection reset")}
+		r.PodExec = podExec(p)
+
+		cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "1"}
+
+		// The request is retried rather than failed.
+		_, err := r.reconcileSwitchover(ctx, cr)
+		assert.ErrorContains(t, err, "connection reset")
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateRunning)
+		assert.Equal(t, cr.Status.Switchover.Error, "")
+
+		p.err = nil
+		_, err = r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateCompleted, "%+v", cr.Status.Switchover)
+	})
+
+	t.Run("Failover", func(t *testing.T) {
+		r, cr, _ := setup(t, "switchover-failover")
+		p := &patroniExec{timeline: 0}
+		r.PodExec = podExec(p)
+
+		cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "1", Type: "Failover", TargetInstance: "cluster-instance1-bbbb"}
+
+		_, err := r.reconcileSwitchover(ctx, cr)
+		assert.NilError(t, err)
+		assert.Equal(t, cr.Status.Switchover.State, v2.SwitchoverStateCompleted, "%+v", cr.Status.Switchover)
+		assert.Equal(t, cr.Status.Switchover.Primary, "cluster-instance1-bbbb-0")
+		assert.Assert(t, strings.Contains(strings.Join(p.calls, "\n"),
+			"patronictl failover --force --candidate=cluster-instance1-bbbb-0"))
+	})
+}
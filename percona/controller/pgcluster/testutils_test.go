@@ -51,6 +51,7 @@ func reconciler(cr *v2.PerconaPGCluster) *PGClusterReconciler {
 
 	return (&PGClusterReconciler{
 		Client:               k8sClient,
+		Recorder:             new(record.FakeRecorder),
 		Platform:             "unknown",
 		KubeVersion:          "1.26",
 		Cron:                 NewCronRegistry(),
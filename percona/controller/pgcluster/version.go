@@ -19,6 +19,8 @@ import (
 )
 
 func (r *PGClusterReconciler) reconcileVersion(ctx context.Context, cr *v2.PerconaPGCluster) error {
+	r.reconcileEnsureVersionJob(ctx, cr)
+
 	if !telemetryEnabled() {
 		return nil
 	}
@@ -19,6 +19,7 @@ import (
 	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/controller"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/metrics"
 	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
 	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
 	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
@@ -46,7 +47,9 @@ func (r *PGRestoreReconciler) SetupWithManager(mgr manager.Manager) error {
 // +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgrestores/status,verbs=patch;update
 // +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgclusters,verbs=get;list;create;update;patch;watch
 // +kubebuilder:rbac:groups=postgres-operator.crunchydata.com,resources=postgresclusters,verbs=get;list;create;update;patch;watch
+// +kubebuilder:rbac:groups=pgv2.percona.com,resources=perconapgbackups,verbs=get;list;watch
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets;configmaps,verbs=get;create
 
 func (r *PGRestoreReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	log := logging.FromContext(ctx).WithValues("request", request)
@@ -58,10 +61,14 @@ func (r *PGRestoreReconciler) Reconcile(ctx context.Context, request reconcile.R
 		// cluster is deleted.
 		if err = client.IgnoreNotFound(err); err != nil {
 			log.Error(err, "unable to fetch perconapgrestore")
+			return reconcile.Result{}, err
 		}
-		return reconcile.Result{}, err
+		metrics.DeleteRestore(request.Namespace, request.Name)
+		return reconcile.Result{}, nil
 	}
 
+	metrics.SetRestoreState(pgRestore)
+
 	if pgRestore.DeletionTimestamp != nil {
 		if err := runFinalizers(ctx, r.Client, pgRestore); err != nil {
 			return reconcile.Result{}, errors.Wrap(err, "failed to run finalizers")
@@ -79,6 +86,10 @@ func (r *PGRestoreReconciler) Reconcile(ctx context.Context, request reconcile.R
 		return reconcile.Result{}, errors.Wrap(err, "get PostgresCluster")
 	}
 
+	if pgRestore.Spec.NewCluster != nil {
+		return r.reconcileNewCluster(ctx, pgCluster, pgRestore)
+	}
+
 	switch pgRestore.Status.State {
 	case v2.RestoreNew:
 		if restore := pgCluster.Spec.Backups.PGBackRest.Restore; restore != nil && *restore.Enabled {
@@ -87,7 +98,25 @@ func (r *PGRestoreReconciler) Reconcile(ctx context.Context, request reconcile.R
 		}
 
 		if _, ok := pgRestore.Annotations[pNaming.AnnotationClusterBootstrapRestore]; !ok {
-			if err := startRestore(ctx, r.Client, pgCluster, pgRestore); err != nil {
+			options, err := prepareRestoreOptions(ctx, r.Client, pgCluster, pgRestore)
+			if err != nil {
+				var targetErr *targetError
+				if !errors.As(err, &targetErr) {
+					return reconcile.Result{}, errors.Wrap(err, "prepare restore options")
+				}
+
+				log.Info("Restore target can't be reached", "reason", targetErr.Error())
+
+				pgRestore.Status.State = v2.RestoreFailed
+				pgRestore.Status.Error = targetErr.Error()
+				if err := r.Client.Status().Update(ctx, pgRestore); err != nil {
+					return reconcile.Result{}, errors.Wrap(err, "update PGRestore status")
+				}
+
+				return reconcile.Result{}, nil
+			}
+
+			if err := startRestore(ctx, r.Client, pgCluster, pgRestore, options); err != nil {
 				return reconcile.Result{}, errors.Wrap(err, "start restore")
 			}
 		}
@@ -146,6 +175,7 @@ func (r *PGRestoreReconciler) Reconcile(ctx context.Context, request reconcile.R
 		if err := r.Client.Status().Update(ctx, pgRestore); err != nil {
 			return reconcile.Result{}, errors.Wrap(err, "update pgRestore status")
 		}
+		metrics.ObserveRestore(pgRestore, controller.JobDuration(job))
 		return reconcile.Result{}, nil
 	default:
 		return reconcile.Result{}, nil
@@ -199,7 +229,7 @@ func ensureFinalizers(ctx context.Context, cl client.Client, pr *v2.PerconaPGRes
 	return nil
 }
 
-func startRestore(ctx context.Context, c client.Client, pg *v2.PerconaPGCluster, pr *v2.PerconaPGRestore) error {
+func startRestore(ctx context.Context, c client.Client, pg *v2.PerconaPGCluster, pr *v2.PerconaPGRestore, options []string) error {
 	orig := pg.DeepCopy()
 
 	if pg.Annotations == nil {
@@ -229,7 +259,7 @@ func startRestore(ctx context.Context, c client.Client, pg *v2.PerconaPGCluster,
 	tvar := true
 	pg.Spec.Backups.PGBackRest.Restore.Enabled = &tvar
 	pg.Spec.Backups.PGBackRest.Restore.RepoName = pr.Spec.RepoName
-	pg.Spec.Backups.PGBackRest.Restore.Options = pr.Spec.Options
+	pg.Spec.Backups.PGBackRest.Restore.Options = options
 
 	if err := c.Patch(ctx, pg, client.MergeFrom(orig)); err != nil {
 		return errors.Wrap(err, "patch PGCluster")
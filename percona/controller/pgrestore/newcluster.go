@@ -0,0 +1,281 @@
+package pgrestore
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	crunchyPGBackRest "github.com/fulviodenza/percona-postgresql-operator/internal/pgbackrest"
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+func newClusterKey(pr *v2.PerconaPGRestore) types.NamespacedName {
+	key := types.NamespacedName{Name: pr.Spec.NewCluster.Name, Namespace: pr.Spec.NewCluster.Namespace}
+	if key.Namespace == "" {
+		key.Namespace = pr.Namespace
+	}
+	return key
+}
+
+// reconcileNewCluster restores the backup of the source cluster into a new
+// PerconaPGCluster. The source cluster is not changed. The progress is taken
+// from the bootstrap restore of the new cluster.
+func (r *PGRestoreReconciler) reconcileNewCluster(ctx context.Context, source *v2.PerconaPGCluster, pgRestore *v2.PerconaPGRestore) (reconcile.Result, error) {
+	log := logging.FromContext(ctx)
+
+	key := newClusterKey(pgRestore)
+	restoredFrom := pgRestore.Namespace + "/" + pgRestore.Name
+
+	switch pgRestore.Status.State {
+	case v2.RestoreNew:
+		existing := new(v2.PerconaPGCluster)
+		err := r.Client.Get(ctx, key, existing)
+		if client.IgnoreNotFound(err) != nil {
+			return reconcile.Result{}, errors.Wrap(err, "get new PerconaPGCluster")
+		}
+		if err == nil && existing.Annotations[pNaming.AnnotationRestoredFrom] != restoredFrom {
+			return reconcile.Result{}, r.failRestore(ctx, pgRestore, "PerconaPGCluster "+key.String()+" already exists")
+		}
+
+		if k8serrors.IsNotFound(err) {
+			options, err := prepareRestoreOptions(ctx, r.Client, source, pgRestore)
+			if err != nil {
+				var targetErr *targetError
+				if !errors.As(err, &targetErr) {
+					return reconcile.Result{}, errors.Wrap(err, "prepare restore options")
+				}
+				log.Info("Restore target can't be reached", "reason", targetErr.Error())
+				return reconcile.Result{}, r.failRestore(ctx, pgRestore, targetErr.Error())
+			}
+
+			cluster, err := newClusterFromSource(source, pgRestore, options)
+			if err != nil {
+				return reconcile.Result{}, r.failRestore(ctx, pgRestore, err.Error())
+			}
+
+			if err := r.Client.Create(ctx, cluster); err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "create new PerconaPGCluster")
+			}
+			existing = cluster
+
+			log.Info("Restoring into a new cluster", "cluster", key.String())
+		}
+
+		// The copies are owned by the new cluster, so they are created once
+		// it exists and again if an earlier attempt failed.
+		if existing.Spec.DataSource != nil && existing.Spec.DataSource.PGBackRest != nil && key.Namespace != source.Namespace {
+			if err := copyRepoConfiguration(ctx, r.Client, source, existing); err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "copy pgBackRest configuration")
+			}
+		}
+
+		pgRestore.Status.State = v2.RestoreStarting
+		if err := r.Client.Status().Update(ctx, pgRestore); err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "update PGRestore status")
+		}
+
+		return reconcile.Result{}, nil
+	case v2.RestoreStarting, v2.RestoreRunning:
+		bootstrap := new(v2.PerconaPGRestore)
+		err := r.Client.Get(ctx, types.NamespacedName{Name: key.Name + "-bootstrap", Namespace: key.Namespace}, bootstrap)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				log.Info("Waiting for restore to start")
+				return reconcile.Result{RequeueAfter: time.Second * 5}, nil
+			}
+			return reconcile.Result{}, errors.Wrap(err, "get bootstrap restore")
+		}
+
+		switch bootstrap.Status.State {
+		case v2.RestoreRunning:
+			if pgRestore.Status.State == v2.RestoreRunning {
+				log.Info("Waiting for restore to complete")
+				return reconcile.Result{RequeueAfter: time.Second * 5}, nil
+			}
+		case v2.RestoreSucceeded:
+			log.Info("Restore succeeded", "cluster", key.String())
+			pgRestore.Status.CompletedAt = bootstrap.Status.CompletedAt
+		case v2.RestoreFailed:
+			log.Info("Restore failed", "cluster", key.String())
+		default:
+			log.Info("Waiting for restore to start")
+			return reconcile.Result{RequeueAfter: time.Second * 5}, nil
+		}
+
+		pgRestore.Status.JobName = bootstrap.Status.JobName
+		pgRestore.Status.State = bootstrap.Status.State
+		if err := r.Client.Status().Update(ctx, pgRestore); err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "update PGRestore status")
+		}
+
+		return reconcile.Result{RequeueAfter: time.Second * 5}, nil
+	default:
+		return reconcile.Result{}, nil
+	}
+}
+
+func (r *PGRestoreReconciler) failRestore(ctx context.Context, pgRestore *v2.PerconaPGRestore, reason string) error {
+	pgRestore.Status.State = v2.RestoreFailed
+	pgRestore.Status.Error = reason
+	if err := r.Client.Status().Update(ctx, pgRestore); err != nil {
+		return errors.Wrap(err, "update PGRestore status")
+	}
+	return nil
+}
+
+// newClusterFromSource returns the PerconaPGCluster the backup of source is
+// restored into. Its spec is the spec of source with the template of the
+// restore merged on top and a data source pointing to the repo of source.
+func newClusterFromSource(source *v2.PerconaPGCluster, pr *v2.PerconaPGRestore, options []string) (*v2.PerconaPGCluster, error) {
+	var repo *v1beta1.PGBackRestRepo
+	for i := range source.Spec.Backups.PGBackRest.Repos {
+		if source.Spec.Backups.PGBackRest.Repos[i].Name == pr.Spec.RepoName {
+			repo = source.Spec.Backups.PGBackRest.Repos[i].DeepCopy()
+		}
+	}
+	if repo == nil {
+		return nil, errors.Errorf("repo %s is not defined in cluster %s", pr.Spec.RepoName, source.Name)
+	}
+
+	spec := source.Spec.DeepCopy()
+	spec.DataSource = nil
+	spec.Backups.PGBackRest.Manual = nil
+	spec.Backups.PGBackRest.Restore = nil
+
+	// The new cluster must not archive into the repo of the source cluster.
+	if _, ok := pr.Spec.NewCluster.Template["backups"]; !ok {
+		f := false
+		spec.Backups.Enabled = &f
+	}
+
+	if len(pr.Spec.NewCluster.Template) > 0 {
+		original, err := json.Marshal(spec)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal source cluster spec")
+		}
+		patch, err := json.Marshal(pr.Spec.NewCluster.Template)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal cluster template")
+		}
+		merged, err := jsonpatch.MergePatch(original, patch)
+		if err != nil {
+			return nil, errors.Wrap(err, "merge cluster template")
+		}
+
+		spec = new(v2.PerconaPGClusterSpec)
+		if err := json.Unmarshal(merged, spec); err != nil {
+			return nil, errors.Wrap(err, "invalid cluster template")
+		}
+	}
+
+	if spec.Backups.IsEnabled() {
+		// Volume repos of the new cluster use their own volumes.
+		for _, r := range spec.Backups.PGBackRest.Repos {
+			if r.Volume != nil {
+				continue
+			}
+			for _, sr := range source.Spec.Backups.PGBackRest.Repos {
+				path := r.Name + "-path"
+				if equality.Semantic.DeepEqual(r, sr) && spec.Backups.PGBackRest.Global[path] == source.Spec.Backups.PGBackRest.Global[path] {
+					return nil, errors.Errorf("new cluster can't use %s of the source cluster for its backups", r.Name)
+				}
+			}
+		}
+	}
+
+	key := newClusterKey(pr)
+
+	spec.DataSource = new(v1beta1.DataSource)
+	if repo.Volume != nil {
+		// Volume repos are reached through the repo host of the source cluster.
+		spec.DataSource.PostgresCluster = &v1beta1.PostgresClusterDataSource{
+			ClusterName:      source.Name,
+			ClusterNamespace: source.Namespace,
+			RepoName:         repo.Name,
+			Options:          options,
+		}
+	} else {
+		spec.DataSource.PGBackRest = &v1beta1.PGBackRestDataSource{
+			Configuration: source.Spec.Backups.PGBackRest.Configuration,
+			Global:        source.Spec.Backups.PGBackRest.Global,
+			Repo:          *repo,
+			Stanza:        crunchyPGBackRest.DefaultStanzaName,
+			Options:       options,
+		}
+	}
+
+	return &v2.PerconaPGCluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v2.GroupVersion.String(),
+			Kind:       "PerconaPGCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Annotations: map[string]string{
+				pNaming.AnnotationRestoredFrom: pr.Namespace + "/" + pr.Name,
+			},
+		},
+		Spec: *spec,
+	}, nil
+}
+
+// copyRepoConfiguration copies the Secrets and ConfigMaps with the pgBackRest
+// configuration and credentials of source into the namespace of owner. The
+// copies are owned by owner, so they are deleted together with it. Existing
+// objects are left untouched.
+func copyRepoConfiguration(ctx context.Context, cl client.Client, source, owner *v2.PerconaPGCluster) error {
+	for _, projection := range source.Spec.Backups.PGBackRest.Configuration {
+		var obj, copied client.Object
+		switch {
+		case projection.Secret != nil:
+			secret := new(corev1.Secret)
+			if err := cl.Get(ctx, types.NamespacedName{Name: projection.Secret.Name, Namespace: source.Namespace}, secret); err != nil {
+				if k8serrors.IsNotFound(err) && projection.Secret.Optional != nil && *projection.Secret.Optional {
+					continue
+				}
+				return errors.Wrapf(err, "get secret %s", projection.Secret.Name)
+			}
+			obj = secret
+			copied = &corev1.Secret{Type: secret.Type, Data: secret.Data}
+		case projection.ConfigMap != nil:
+			cm := new(corev1.ConfigMap)
+			if err := cl.Get(ctx, types.NamespacedName{Name: projection.ConfigMap.Name, Namespace: source.Namespace}, cm); err != nil {
+				if k8serrors.IsNotFound(err) && projection.ConfigMap.Optional != nil && *projection.ConfigMap.Optional {
+					continue
+				}
+				return errors.Wrapf(err, "get configmap %s", projection.ConfigMap.Name)
+			}
+			obj = cm
+			copied = &corev1.ConfigMap{Data: cm.Data, BinaryData: cm.BinaryData}
+		default:
+			continue
+		}
+
+		copied.SetName(obj.GetName())
+		copied.SetNamespace(owner.Namespace)
+		if err := controllerutil.SetOwnerReference(owner, copied, cl.Scheme()); err != nil {
+			return errors.Wrapf(err, "set owner reference of %s", obj.GetName())
+		}
+
+		if err := cl.Create(ctx, copied); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "create %s", obj.GetName())
+		}
+	}
+
+	return nil
+}
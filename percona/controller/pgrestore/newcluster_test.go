@@ -0,0 +1,181 @@
+package pgrestore
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+func sourceCluster() *v2.PerconaPGCluster {
+	return &v2.PerconaPGCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prod",
+			Namespace: "production",
+		},
+		Spec: v2.PerconaPGClusterSpec{
+			CRVersion:       "2.6.0",
+			PostgresVersion: 16,
+			Backups: v2.Backups{
+				PGBackRest: v2.PGBackRestArchive{
+					Configuration: []corev1.VolumeProjection{{
+						Secret: &corev1.SecretProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "prod-pgbackrest-secrets"},
+						},
+					}},
+					Global: map[string]string{
+						"repo2-path": "/pgbackrest/prod/repo2",
+					},
+					Repos: []v1beta1.PGBackRestRepo{
+						{
+							Name:   "repo1",
+							Volume: &v1beta1.RepoPVC{},
+						},
+						{
+							Name: "repo2",
+							S3: &v1beta1.RepoS3{
+								Bucket:   "backups",
+								Endpoint: "s3.amazonaws.com",
+								Region:   "us-east-1",
+							},
+						},
+					},
+					Restore: &v1beta1.PGBackRestRestore{},
+				},
+			},
+		},
+	}
+}
+
+func TestNewClusterFromSource(t *testing.T) {
+	source := sourceCluster()
+
+	restore := func(repoName string, template v1beta1.SchemalessObject) *v2.PerconaPGRestore {
+		return &v2.PerconaPGRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "clone",
+				Namespace: "production",
+			},
+			Spec: v2.PerconaPGRestoreSpec{
+				PGCluster: source.Name,
+				RepoName:  repoName,
+				NewCluster: &v2.PGRestoreNewCluster{
+					Name:      "staging",
+					Namespace: "staging",
+					Template:  template,
+				},
+			},
+		}
+	}
+
+	t.Run("cloud repo", func(t *testing.T) {
+		options := []string{"--type=immediate"}
+		cluster, err := newClusterFromSource(source, restore("repo2", nil), options)
+		assert.NilError(t, err)
+
+		assert.Equal(t, cluster.Name, "staging")
+		assert.Equal(t, cluster.Namespace, "staging")
+		assert.Equal(t, cluster.Annotations[pNaming.AnnotationRestoredFrom], "production/clone")
+		assert.Assert(t, !cluster.Spec.Backups.IsEnabled())
+		assert.Assert(t, cluster.Spec.Backups.PGBackRest.Restore == nil)
+
+		ds := cluster.Spec.DataSource
+		assert.Assert(t, ds.PostgresCluster == nil)
+		assert.Equal(t, ds.PGBackRest.Repo.Name, "repo2")
+		assert.Equal(t, ds.PGBackRest.Stanza, "db")
+		assert.Equal(t, ds.PGBackRest.Global["repo2-path"], "/pgbackrest/prod/repo2")
+		assert.DeepEqual(t, ds.PGBackRest.Configuration, source.Spec.Backups.PGBackRest.Configuration)
+		assert.DeepEqual(t, ds.PGBackRest.Options, options)
+	})
+
+	t.Run("volume repo", func(t *testing.T) {
+		cluster, err := newClusterFromSource(source, restore("repo1", nil), nil)
+		assert.NilError(t, err)
+
+		ds := cluster.Spec.DataSource
+		assert.Assert(t, ds.PGBackRest == nil)
+		assert.Equal(t, ds.PostgresCluster.ClusterName, "prod")
+		assert.Equal(t, ds.PostgresCluster.ClusterNamespace, "production")
+		assert.Equal(t, ds.PostgresCluster.RepoName, "repo1")
+	})
+
+	t.Run("unknown repo", func(t *testing.T) {
+		_, err := newClusterFromSource(source, restore("repo3", nil), nil)
+		assert.Error(t, err, "repo repo3 is not defined in cluster prod")
+	})
+
+	t.Run("template", func(t *testing.T) {
+		cluster, err := newClusterFromSource(source, restore("repo2", v1beta1.SchemalessObject{
+			"postgresVersion": 17,
+			"backups": map[string]any{
+				"pgbackrest": map[string]any{
+					"repos": []any{map[string]any{
+						"name":   "repo1",
+						"volume": map[string]any{},
+					}},
+				},
+			},
+		}), nil)
+		assert.NilError(t, err)
+
+		assert.Equal(t, cluster.Spec.PostgresVersion, 17)
+		assert.Equal(t, cluster.Spec.CRVersion, "2.6.0")
+		assert.Assert(t, cluster.Spec.Backups.IsEnabled())
+		assert.Equal(t, len(cluster.Spec.Backups.PGBackRest.Repos), 1)
+	})
+
+	t.Run("template with source repo", func(t *testing.T) {
+		_, err := newClusterFromSource(source, restore("repo2", v1beta1.SchemalessObject{
+			"backups": map[string]any{
+				"trackLatestRestorableTime": true,
+			},
+		}), nil)
+		assert.Error(t, err, "new cluster can't use repo2 of the source cluster for its backups")
+	})
+}
+
+func TestCopyRepoConfiguration(t *testing.T) {
+	ctx := context.Background()
+
+	source := sourceCluster()
+	source.Spec.Backups.PGBackRest.Configuration = append(source.Spec.Backups.PGBackRest.Configuration, corev1.VolumeProjection{
+		ConfigMap: &corev1.ConfigMapProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "missing"},
+			Optional:             ptr.To(true),
+		},
+	})
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod-pgbackrest-secrets", Namespace: "production"},
+		Data:       map[string][]byte{"s3.conf": []byte("[global]\nrepo2-s3-key=key\n")},
+	}
+
+	s := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(s))
+	assert.NilError(t, v2.AddToScheme(s))
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(secret).Build()
+
+	owner := &v2.PerconaPGCluster{ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "staging", UID: "uid"}}
+	assert.NilError(t, copyRepoConfiguration(ctx, cl, source, owner))
+
+	copied := new(corev1.Secret)
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Name: secret.Name, Namespace: "staging"}, copied))
+	assert.DeepEqual(t, copied.Data, secret.Data)
+	assert.Equal(t, len(copied.OwnerReferences), 1)
+	assert.Equal(t, copied.OwnerReferences[0].Kind, "PerconaPGCluster")
+	assert.Equal(t, copied.OwnerReferences[0].Name, "staging")
+	assert.Equal(t, copied.OwnerReferences[0].UID, owner.UID)
+
+	// copying again keeps the existing objects
+	assert.NilError(t, copyRepoConfiguration(ctx, cl, source, owner))
+}
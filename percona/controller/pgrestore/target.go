@@ -0,0 +1,213 @@
+package pgrestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fulviodenza/percona-postgresql-operator/percona/controller"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/pgbackrest"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+// targetError is returned when the recovery target of a PerconaPGRestore
+// can't be reached. Restores with such targets are marked as failed without
+// touching the cluster.
+type targetError struct {
+	msg string
+}
+
+func (e *targetError) Error() string { return e.msg }
+
+func targetErrorf(format string, args ...any) error {
+	return &targetError{msg: fmt.Sprintf(format, args...)}
+}
+
+func hasTarget(pr *v2.PerconaPGRestore) bool {
+	return pr.Spec.Type != "" || pr.Spec.Target != "" || pr.Spec.BackupName != ""
+}
+
+// validateTarget checks that the recovery target of the restore can be reached
+// from the backups in the repo. If backupSet is not empty, the recovery starts
+// from that backup set. latestRestorable, if not nil, is the latest time the
+// repo can be restored to.
+func validateTarget(spec v2.PerconaPGRestoreSpec, info pgbackrest.InfoOutput, backupSet string, latestRestorable *time.Time) error {
+	var backups []pgbackrest.InfoBackup
+	for _, stanza := range info {
+		for _, backup := range stanza.Backup {
+			if backupSet == "" || backup.Label == backupSet {
+				backups = append(backups, backup)
+			}
+		}
+	}
+
+	if len(backups) == 0 {
+		if backupSet != "" {
+			return targetErrorf("backup set %s is not found in %s", backupSet, spec.RepoName)
+		}
+		return targetErrorf("no backups found in %s", spec.RepoName)
+	}
+
+	switch spec.Type {
+	case v2.PGRestoreTargetTypeTime:
+		target, err := v2.ParseTargetTime(spec.Target)
+		if err != nil {
+			return targetErrorf("invalid target: %s", err)
+		}
+
+		reachable := false
+		var earliest time.Time
+		for _, backup := range backups {
+			stop := time.Unix(backup.Timestamp.Stop, 0)
+			if earliest.IsZero() || stop.Before(earliest) {
+				earliest = stop
+			}
+			if !stop.After(target) {
+				reachable = true
+			}
+		}
+		if !reachable {
+			return targetErrorf("target time %s is before the backup completed at %s",
+				target.UTC().Format(time.RFC3339), earliest.UTC().Format(time.RFC3339))
+		}
+
+		if latestRestorable != nil && target.After(*latestRestorable) {
+			return targetErrorf("target time %s is after the latest restorable time %s",
+				target.UTC().Format(time.RFC3339), latestRestorable.UTC().Format(time.RFC3339))
+		}
+	case v2.PGRestoreTargetTypeLSN:
+		target, err := v2.ParseLSN(spec.Target)
+		if err != nil {
+			return targetErrorf("invalid target: %s", err)
+		}
+
+		// Backups without a stop LSN tell nothing about the target; if no
+		// backup has one, the restore itself finds out.
+		known := false
+		for _, backup := range backups {
+			stop, err := v2.ParseLSN(backup.LSN.Stop)
+			if err != nil {
+				continue
+			}
+			if stop <= target {
+				return nil
+			}
+			known = true
+		}
+		if known {
+			return targetErrorf("target LSN %s is before the end of the backup", spec.Target)
+		}
+	}
+
+	return nil
+}
+
+// restoreOptions returns the pgBackRest restore options for the restore,
+// including its recovery target.
+func restoreOptions(spec v2.PerconaPGRestoreSpec, backupSet string) []string {
+	options := append([]string{}, spec.Options...)
+
+	if spec.Type != "" {
+		options = append(options, "--type="+string(spec.Type))
+	}
+	if spec.Target != "" {
+		options = append(options, fmt.Sprintf(`--target="%s"`, spec.Target))
+	}
+	if backupSet != "" {
+		options = append(options, "--set="+backupSet)
+	}
+
+	return options
+}
+
+// prepareRestoreOptions validates the recovery target of the restore against
+// the repo and returns the options to restore with.
+func prepareRestoreOptions(ctx context.Context, c client.Client, pg *v2.PerconaPGCluster, pr *v2.PerconaPGRestore) ([]string, error) {
+	if !hasTarget(pr) {
+		return pr.Spec.Options, nil
+	}
+
+	if err := pr.Spec.ValidateTarget(); err != nil {
+		return nil, targetErrorf("%s", err)
+	}
+
+	backupSet := ""
+	if pr.Spec.BackupName != "" {
+		backup := new(v2.PerconaPGBackup)
+		err := c.Get(ctx, client.ObjectKey{Name: pr.Spec.BackupName, Namespace: pr.Namespace}, backup)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				return nil, targetErrorf("backup %s is not found", pr.Spec.BackupName)
+			}
+			return nil, errors.Wrap(err, "get PerconaPGBackup")
+		}
+
+		switch {
+		case backup.Spec.PGCluster != pg.Name:
+			return nil, targetErrorf("backup %s belongs to cluster %s", backup.Name, backup.Spec.PGCluster)
+		case backup.Spec.RepoName != pr.Spec.RepoName:
+			return nil, targetErrorf("backup %s is stored in %s, not in %s", backup.Name, backup.Spec.RepoName, pr.Spec.RepoName)
+		case backup.Status.State != v2.BackupSucceeded || backup.Status.BackupName == "":
+			return nil, targetErrorf("backup %s is not succeeded", backup.Name)
+		}
+
+		backupSet = backup.Status.BackupName
+	}
+
+	// WAL archived after a newer backup can be replayed on top of an older
+	// backup set too, so the latest restorable time of the whole repo applies.
+	latestRestorable, err := latestRestorableTime(ctx, c, pg, pr.Spec.RepoName)
+	if err != nil {
+		return nil, errors.Wrap(err, "get latest restorable time")
+	}
+
+	pod, err := controller.GetReadyInstancePod(ctx, c, pg.Name, pg.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "get ready instance pod")
+	}
+
+	info, err := pgbackrest.GetInfo(ctx, pod, pr.Spec.RepoName)
+	if err != nil {
+		if errors.Is(err, pgbackrest.ErrNoValidBackups) {
+			return nil, targetErrorf("no valid backups found in %s", pr.Spec.RepoName)
+		}
+		return nil, errors.Wrap(err, "get pgBackRest info")
+	}
+
+	if err := validateTarget(pr.Spec, info, backupSet, latestRestorable); err != nil {
+		return nil, err
+	}
+
+	return restoreOptions(pr.Spec, backupSet), nil
+}
+
+// latestRestorableTime returns the latest restorable time reported by the
+// succeeded backups of the cluster in the repo, or nil if none is known.
+func latestRestorableTime(ctx context.Context, c client.Client, pg *v2.PerconaPGCluster, repoName string) (*time.Time, error) {
+	backups := new(v2.PerconaPGBackupList)
+	if err := c.List(ctx, backups, client.InNamespace(pg.Namespace), client.MatchingFields{
+		v2.IndexFieldPGCluster: pg.Name,
+	}); err != nil {
+		return nil, errors.Wrap(err, "list backups")
+	}
+
+	var latest *time.Time
+	for _, backup := range backups.Items {
+		if backup.Status.State != v2.BackupSucceeded || backup.Spec.RepoName != repoName {
+			continue
+		}
+		t := backup.Status.LatestRestorableTime.Time
+		if t == nil || t.IsZero() {
+			continue
+		}
+		if latest == nil || t.After(*latest) {
+			latest = &t.Time
+		}
+	}
+
+	return latest, nil
+}
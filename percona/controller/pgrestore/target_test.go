@@ -0,0 +1,145 @@
+package pgrestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/fulviodenza/percona-postgresql-operator/percona/pgbackrest"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestValidateTarget(t *testing.T) {
+	backup := func(label string, stop time.Time, lsn string) pgbackrest.InfoBackup {
+		b := pgbackrest.InfoBackup{Label: label, Type: v2.PGBackupTypeFull}
+		b.Timestamp.Stop = stop.Unix()
+		b.LSN.Stop = lsn
+		return b
+	}
+
+	first := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+	latest := second.Add(time.Hour)
+
+	info := pgbackrest.InfoOutput{{
+		Name: "db",
+		Backup: []pgbackrest.InfoBackup{
+			backup("20240601-000000F", first, "0/2000100"),
+			backup("20240602-000000F", second, "0/5000100"),
+		},
+	}}
+
+	tests := map[string]struct {
+		spec             v2.PerconaPGRestoreSpec
+		info             pgbackrest.InfoOutput
+		backupSet        string
+		latestRestorable *time.Time
+		expectedErr      string
+	}{
+		"time after first backup": {
+			spec:             v2.PerconaPGRestoreSpec{Type: v2.PGRestoreTargetTypeTime, Target: "2024-06-01 12:00:00+00"},
+			info:             info,
+			latestRestorable: &latest,
+		},
+		"time before all backups": {
+			spec:        v2.PerconaPGRestoreSpec{Type: v2.PGRestoreTargetTypeTime, Target: "2024-05-31 12:00:00+00"},
+			info:        info,
+			expectedErr: "target time 2024-05-31T12:00:00Z is before the backup completed at 2024-06-01T00:00:00Z",
+		},
+		"time before selected backup": {
+			spec:        v2.PerconaPGRestoreSpec{Type: v2.PGRestoreTargetTypeTime, Target: "2024-06-01 12:00:00+00"},
+			info:        info,
+			backupSet:   "20240602-000000F",
+			expectedErr: "target time 2024-06-01T12:00:00Z is before the backup completed at 2024-06-02T00:00:00Z",
+		},
+		"time after latest restorable time": {
+			spec:             v2.PerconaPGRestoreSpec{Type: v2.PGRestoreTargetTypeTime, Target: "2024-06-02 03:00:01+02"},
+			info:             info,
+			latestRestorable: &latest,
+			expectedErr:      "target time 2024-06-02T01:00:01Z is after the latest restorable time 2024-06-02T01:00:00Z",
+		},
+		"time with unknown latest restorable time": {
+			spec: v2.PerconaPGRestoreSpec{Type: v2.PGRestoreTargetTypeTime, Target: "2030-01-01 00:00:00+00"},
+			info: info,
+		},
+		"missing backup set": {
+			spec:        v2.PerconaPGRestoreSpec{RepoName: "repo1", Type: v2.PGRestoreTargetTypeImmediate},
+			info:        info,
+			backupSet:   "20240603-000000F",
+			expectedErr: "backup set 20240603-000000F is not found in repo1",
+		},
+		"no backups": {
+			spec:        v2.PerconaPGRestoreSpec{RepoName: "repo2", Type: v2.PGRestoreTargetTypeImmediate},
+			info:        pgbackrest.InfoOutput{{Name: "db"}},
+			expectedErr: "no backups found in repo2",
+		},
+		"lsn after backup": {
+			spec: v2.PerconaPGRestoreSpec{Type: v2.PGRestoreTargetTypeLSN, Target: "0/3000000"},
+			info: info,
+		},
+		"lsn before selected backup": {
+			spec:        v2.PerconaPGRestoreSpec{Type: v2.PGRestoreTargetTypeLSN, Target: "0/3000000"},
+			info:        info,
+			backupSet:   "20240602-000000F",
+			expectedErr: "target LSN 0/3000000 is before the end of the backup",
+		},
+		"lsn before backup with unknown lsn": {
+			spec: v2.PerconaPGRestoreSpec{Type: v2.PGRestoreTargetTypeLSN, Target: "0/3000000"},
+			info: pgbackrest.InfoOutput{{
+				Name: "db",
+				Backup: []pgbackrest.InfoBackup{
+					backup("20240601-000000F", first, ""),
+					backup("20240602-000000F", second, "0/5000100"),
+				},
+			}},
+			expectedErr: "target LSN 0/3000000 is before the end of the backup",
+		},
+		"lsn with unknown lsn": {
+			spec: v2.PerconaPGRestoreSpec{Type: v2.PGRestoreTargetTypeLSN, Target: "0/3000000"},
+			info: pgbackrest.InfoOutput{{
+				Name:   "db",
+				Backup: []pgbackrest.InfoBackup{backup("20240601-000000F", first, "")},
+			}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateTarget(tt.spec, tt.info, tt.backupSet, tt.latestRestorable)
+			if tt.expectedErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Error(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestRestoreOptions(t *testing.T) {
+	spec := v2.PerconaPGRestoreSpec{
+		Type:    v2.PGRestoreTargetTypeTime,
+		Target:  "2024-06-01 12:00:00+00",
+		Options: []string{"--target-exclusive"},
+	}
+
+	assert.DeepEqual(t, restoreOptions(spec, "20240601-000000F"), []string{
+		"--target-exclusive",
+		"--type=time",
+		`--target="2024-06-01 12:00:00+00"`,
+		"--set=20240601-000000F",
+	})
+	assert.DeepEqual(t, spec.Options, []string{"--target-exclusive"})
+}
+
+func TestPrepareRestoreOptionsInvalidTarget(t *testing.T) {
+	pr := &v2.PerconaPGRestore{Spec: v2.PerconaPGRestoreSpec{Type: v2.PGRestoreTargetTypeXID}}
+
+	_, err := prepareRestoreOptions(context.Background(), nil, new(v2.PerconaPGCluster), pr)
+	assert.Error(t, err, "target is required for type xid")
+
+	var targetErr *targetError
+	assert.Assert(t, errors.As(err, &targetErr))
+}
@@ -2,10 +2,12 @@ package pgupgrade
 
 import (
 	"context"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,7 +18,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/fulviodenza/percona-postgresql-operator/internal/controller/runtime"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/postgres"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/extensions"
 	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
 	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
@@ -30,11 +35,23 @@ const (
 
 // Reconciler holds resources for the PerconaPerconaPGUpgrade reconciler
 type PGUpgradeReconciler struct {
-	Client client.Client
+	Client  client.Client
+	PodExec func(
+		ctx context.Context, namespace, pod, container string,
+		stdin io.Reader, stdout, stderr io.Writer, command ...string,
+	) error
 }
 
 // SetupWithManager adds the PerconaPerconaPGUpgrade controller to the provided runtime manager
 func (r *PGUpgradeReconciler) SetupWithManager(mgr manager.Manager) error {
+	if r.PodExec == nil {
+		var err error
+		r.PodExec, err = runtime.NewPodExecutor(mgr.GetConfig())
+		if err != nil {
+			return err
+		}
+	}
+
 	return builder.ControllerManagedBy(mgr).For(&v2.PerconaPGUpgrade{}).Complete(r)
 }
 
@@ -68,14 +85,82 @@ func (r *PGUpgradeReconciler) Reconcile(ctx context.Context, request reconcile.R
 		return reconcile.Result{}, errors.Wrapf(err, "get PerconaPGCluster %s/%s", perconaPGUpgrade.Namespace, perconaPGUpgrade.Spec.PostgresClusterName)
 	}
 
+	// A dry run only reports whether the cluster can be upgraded.
+	if perconaPGUpgrade.Spec.DryRun != nil {
+		done, err := r.reconcileDryRun(ctx, pgCluster, perconaPGUpgrade)
+
+		if err := r.updateStatus(ctx, perconaPGUpgrade); err != nil {
+			log.Error(err, "update PerconaPGUpgrade status")
+		}
+		if err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "dry run")
+		}
+		if !done {
+			return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		return reconcile.Result{}, nil
+	}
+
+	// The logical strategy replicates to a new cluster instead of upgrading
+	// the cluster in place.
+	if perconaPGUpgrade.Spec.Strategy == pgv2.UpgradeStrategyLogical {
+		done, err := r.reconcileLogical(ctx, pgCluster, perconaPGUpgrade)
+
+		if err := r.updateStatus(ctx, perconaPGUpgrade); err != nil {
+			log.Error(err, "update PerconaPGUpgrade status")
+		}
+		if err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "logical upgrade")
+		}
+		if !done {
+			return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		return reconcile.Result{}, nil
+	}
+
+	// A rolled back upgrade no longer manages the cluster.
+	if perconaPGUpgrade.Status.Rollback != nil {
+		done, err := r.reconcileRollback(ctx, pgCluster, perconaPGUpgrade)
+
+		if err := r.updateStatus(ctx, perconaPGUpgrade); err != nil {
+			log.Error(err, "update PerconaPGUpgrade status")
+		}
+		if err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "rollback")
+		}
+		if !done {
+			return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		return reconcile.Result{}, nil
+	}
+
 	pgUpgrade := &crunchyv1beta1.PGUpgrade{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      perconaPGUpgrade.Name,
 			Namespace: perconaPGUpgrade.Namespace,
 		},
 	}
+
+	defer func() {
+		for _, cond := range pgUpgrade.Status.Conditions {
+			meta.SetStatusCondition(&perconaPGUpgrade.Status.Conditions, cond)
+		}
+		if err := r.updateStatus(ctx, perconaPGUpgrade); err != nil {
+			log.Error(err, "update PerconaPGUpgrade status")
+		}
+	}()
+
 	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(pgUpgrade), pgUpgrade); err != nil {
 		if k8serrors.IsNotFound(err) {
+			done, err := r.reconcilePreUpgradeBackup(ctx, pgCluster, perconaPGUpgrade)
+			if err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "pre-upgrade backup")
+			}
+			if !done {
+				log.Info("Waiting for pre-upgrade backup", "cluster", pgCluster.Name)
+				return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+			}
+
 			if err := controllerutil.SetControllerReference(perconaPGUpgrade, pgUpgrade, r.Client.Scheme()); err != nil {
 				return reconcile.Result{}, errors.Wrap(err, "set controller reference")
 			}
@@ -92,18 +177,6 @@ func (r *PGUpgradeReconciler) Reconcile(ctx context.Context, request reconcile.R
 		return reconcile.Result{}, errors.Wrapf(err, "get PGUpgrade %s/%s", pgUpgrade.Namespace, pgUpgrade.Name)
 	}
 
-	defer func() {
-		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-			perconaPGUpgrade.Status.Conditions = pgUpgrade.Status.Conditions
-			perconaPGUpgrade.Status.ObservedGeneration = perconaPGUpgrade.Generation
-
-			return r.Client.Status().Update(ctx, perconaPGUpgrade)
-		})
-		if err != nil {
-			log.Error(err, "update PerconaPGUpgrade status")
-		}
-	}()
-
 	if cond := meta.FindStatusCondition(pgUpgrade.Status.Conditions, "Progressing"); cond != nil {
 		log.Info("PGUpgrade progressing", "reason", cond.Reason, "message", cond.Message, "type", cond.Type, "status", cond.Status)
 		if cond.Status == metav1.ConditionTrue {
@@ -135,6 +208,12 @@ func (r *PGUpgradeReconciler) Reconcile(ctx context.Context, request reconcile.R
 		switch cond.Reason {
 		case "PGUpgradeFailed":
 			log.Info("PGUpgrade failed", "cluster", pgCluster.Name)
+			if backup := perconaPGUpgrade.Spec.Backup; backup != nil && backup.Rollback {
+				if _, err := r.reconcileRollback(ctx, pgCluster, perconaPGUpgrade); err != nil {
+					return reconcile.Result{}, errors.Wrap(err, "rollback")
+				}
+				return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+			}
 			return reconcile.Result{}, nil
 		case "PGUpgradeSucceeded":
 			if err := r.finalizeUpgrade(ctx, pgCluster, perconaPGUpgrade); err != nil {
@@ -144,12 +223,36 @@ func (r *PGUpgradeReconciler) Reconcile(ctx context.Context, request reconcile.R
 			if err := r.resumeCluster(ctx, pgCluster); err != nil {
 				return reconcile.Result{}, errors.Wrap(err, "resume PGCluster")
 			}
+
+			done, err := r.reconcilePostUpgrade(ctx, pgCluster, perconaPGUpgrade)
+			if err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "post-upgrade")
+			}
+			if !done {
+				return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+			}
 		}
 	}
 
 	return reconcile.Result{}, nil
 }
 
+// databaseExecutor returns an Executor that runs commands in the database
+// container of pod.
+func (r *PGUpgradeReconciler) databaseExecutor(pod *corev1.Pod) postgres.Executor {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, command ...string) error {
+		return r.PodExec(ctx, pod.Namespace, pod.Name, naming.ContainerDatabase, stdin, stdout, stderr, command...)
+	}
+}
+
+func (r *PGUpgradeReconciler) updateStatus(ctx context.Context, perconaPGUpgrade *pgv2.PerconaPGUpgrade) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		perconaPGUpgrade.Status.ObservedGeneration = perconaPGUpgrade.Generation
+
+		return r.Client.Status().Update(ctx, perconaPGUpgrade)
+	})
+}
+
 func (r *PGUpgradeReconciler) createPGUpgrade(ctx context.Context, cluster *pgv2.PerconaPGCluster, pgUpgrade *crunchyv1beta1.PGUpgrade, perconaPGUpgrade *pgv2.PerconaPGUpgrade) error {
 	pgUpgrade.Spec.Metadata = perconaPGUpgrade.Spec.Metadata
 	pgUpgrade.Spec.PostgresClusterName = perconaPGUpgrade.Spec.PostgresClusterName
@@ -168,11 +271,23 @@ func (r *PGUpgradeReconciler) createPGUpgrade(ctx context.Context, cluster *pgv2
 	pgUpgrade.Spec.Tolerations = perconaPGUpgrade.Spec.Tolerations
 	pgUpgrade.Spec.InitContainers = perconaPGUpgrade.Spec.InitContainers
 
-	if cluster.Spec.Extensions.Storage.Secret == nil {
-		return r.Client.Create(ctx, pgUpgrade)
+	initContainers, volumeMounts := extensionInitContainers(cluster, perconaPGUpgrade)
+	pgUpgrade.Spec.InitContainers = append(pgUpgrade.Spec.InitContainers, initContainers...)
+	pgUpgrade.Spec.VolumeMounts = append(pgUpgrade.Spec.VolumeMounts, volumeMounts...)
+
+	return r.Client.Create(ctx, pgUpgrade)
+}
+
+// extensionInitContainers returns the init containers that install the custom
+// extensions of cluster for both versions of upgrade and the volume mounts of
+// the extensions of the target version.
+func extensionInitContainers(cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade) ([]corev1.Container, []corev1.VolumeMount) {
+	if !cluster.Spec.Extensions.Storage.Enabled() {
+		return nil, nil
 	}
 
-	for _, pgVersion := range []int{perconaPGUpgrade.Spec.FromPostgresVersion, perconaPGUpgrade.Spec.ToPostgresVersion} {
+	var initContainers []corev1.Container
+	for _, pgVersion := range []int{upgrade.Spec.FromPostgresVersion, upgrade.Spec.ToPostgresVersion} {
 		extensionKeys := make([]string, 0)
 
 		for _, extension := range cluster.Spec.Extensions.Custom {
@@ -180,11 +295,11 @@ func (r *PGUpgradeReconciler) createPGUpgrade(ctx context.Context, cluster *pgv2
 			extensionKeys = append(extensionKeys, key)
 		}
 
-		pgUpgrade.Spec.InitContainers = append(pgUpgrade.Spec.InitContainers, extensions.ExtensionRelocatorContainer(
-			cluster, *perconaPGUpgrade.Spec.Image, cluster.Spec.ImagePullPolicy, pgVersion,
+		initContainers = append(initContainers, extensions.ExtensionRelocatorContainer(
+			cluster, *upgrade.Spec.Image, cluster.Spec.ImagePullPolicy, pgVersion,
 		))
 
-		pgUpgrade.Spec.InitContainers = append(pgUpgrade.Spec.InitContainers, extensions.ExtensionInstallerContainer(
+		initContainers = append(initContainers, extensions.ExtensionInstallerContainer(
 			cluster,
 			pgVersion,
 			&cluster.Spec.Extensions,
@@ -194,11 +309,7 @@ func (r *PGUpgradeReconciler) createPGUpgrade(ctx context.Context, cluster *pgv2
 	}
 
 	// we're only adding the volume mounts for target version since current volume mounts are already mounted
-	pgUpgrade.Spec.VolumeMounts = append(pgUpgrade.Spec.VolumeMounts, extensions.ExtensionVolumeMounts(
-		perconaPGUpgrade.Spec.ToPostgresVersion)...,
-	)
-
-	return r.Client.Create(ctx, pgUpgrade)
+	return initContainers, extensions.ExtensionVolumeMounts(upgrade.Spec.ToPostgresVersion)
 }
 
 func (r *PGUpgradeReconciler) pauseCluster(ctx context.Context, pgCluster *pgv2.PerconaPGCluster) error {
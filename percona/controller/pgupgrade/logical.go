@@ -0,0 +1,746 @@
+package pgupgrade
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/postgres"
+	pgpassword "github.com/fulviodenza/percona-postgresql-operator/internal/postgres/password"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/util"
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	perconaPG "github.com/fulviodenza/percona-postgresql-operator/percona/postgres"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+const (
+	// logicalUpgradeUser is the role the new cluster uses to copy the schema
+	// and replicate the data of the old cluster. It is a superuser so that it
+	// can read every object; it is dropped at cutover.
+	logicalUpgradeUser = "_perconaupgrade"
+
+	// logicalReplicationName is the name of the publications in the old
+	// cluster, the subscriptions in the new cluster, and the prefix of their
+	// replication slots.
+	logicalReplicationName = "percona_upgrade"
+)
+
+// upgradeRoleSQL creates the superuser :username with the password verifier
+// :verifier. It is used by the upgrade to connect to a cluster over the network.
+const upgradeRoleSQL = `
+SET client_min_messages = WARNING;
+
+SELECT pg_catalog.format('CREATE ROLE %I', :'username')
+  WHERE NOT EXISTS (SELECT 1 FROM pg_catalog.pg_roles WHERE rolname = :'username')
+\gexec
+
+ALTER ROLE :"username" LOGIN SUPERUSER PASSWORD :'verifier';
+`
+
+// logicalDatabasesSQL prints the databases that can be replicated, one per line.
+const logicalDatabasesSQL = `
+SET client_min_messages = WARNING;
+\pset tuples_only on
+\pset format unaligned
+
+SELECT datname FROM pg_catalog.pg_database
+  WHERE datallowconn AND NOT datistemplate
+  ORDER BY datname;
+`
+
+// logicalPublicationSQL publishes every table of the current database.
+const logicalPublicationSQL = `
+SET client_min_messages = WARNING;
+
+SELECT pg_catalog.format('CREATE PUBLICATION %I FOR ALL TABLES', :'name')
+  WHERE NOT EXISTS (SELECT 1 FROM pg_catalog.pg_publication WHERE pubname = :'name')
+\gexec
+`
+
+// logicalLagSQL prints the lag of the replication slots of the subscriptions
+// as JSON, one object per line.
+const logicalLagSQL = `
+SET client_min_messages = WARNING;
+\pset tuples_only on
+\pset format unaligned
+
+SELECT json_build_object('database', database, 'lag',
+  pg_catalog.pg_wal_lsn_diff(pg_catalog.pg_current_wal_lsn(), confirmed_flush_lsn)::bigint)
+  FROM pg_catalog.pg_replication_slots
+  WHERE slot_type = 'logical' AND slot_name LIKE :'name' || '\_%';
+`
+
+// logicalSynchronizedSQL prints whether the initial copy of every table of
+// the subscription in the current database has finished as JSON.
+const logicalSynchronizedSQL = `
+SET client_min_messages = WARNING;
+\pset tuples_only on
+\pset format unaligned
+
+SELECT json_build_object('database', pg_catalog.current_database(), 'synchronized',
+  EXISTS (SELECT 1 FROM pg_catalog.pg_subscription s
+    JOIN pg_catalog.pg_database d ON d.oid = s.subdbid
+    WHERE s.subname = :'name' AND d.datname = pg_catalog.current_database())
+  AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_subscription_rel r
+    JOIN pg_catalog.pg_subscription s ON s.oid = r.srsubid
+    WHERE s.subname = :'name' AND r.srsubstate <> 'r'));
+`
+
+// logicalBlockWritesSQL makes new transactions in the current database
+// read-only and disconnects its clients. Replication connections are kept.
+const logicalBlockWritesSQL = `
+SET client_min_messages = WARNING;
+
+SELECT pg_catalog.format('ALTER DATABASE %I SET default_transaction_read_only = on',
+  pg_catalog.current_database())
+\gexec
+
+SELECT pg_catalog.pg_terminate_backend(pid) FROM pg_catalog.pg_stat_activity
+  WHERE datname = pg_catalog.current_database()
+    AND backend_type = 'client backend'
+    AND pid <> pg_catalog.pg_backend_pid();
+`
+
+// logicalSequencesSQL prints a statement that sets the value of every
+// sequence of the current database. Sequences are not replicated.
+const logicalSequencesSQL = `
+SET client_min_messages = WARNING;
+\pset tuples_only on
+\pset format unaligned
+
+SELECT pg_catalog.format('SELECT pg_catalog.setval(%L, %s, true);',
+  pg_catalog.quote_ident(schemaname) || '.' || pg_catalog.quote_ident(sequencename), last_value)
+  FROM pg_catalog.pg_sequences
+  WHERE last_value IS NOT NULL;
+`
+
+// logicalDropSubscriptionSQL drops the subscription of the current database
+// and its replication slot in the old cluster.
+const logicalDropSubscriptionSQL = `
+SET client_min_messages = WARNING;
+
+SELECT pg_catalog.format('DROP SUBSCRIPTION %I', :'name')
+  WHERE EXISTS (SELECT 1 FROM pg_catalog.pg_subscription s
+    JOIN pg_catalog.pg_database d ON d.oid = s.subdbid
+    WHERE s.subname = :'name' AND d.datname = pg_catalog.current_database())
+\gexec
+`
+
+// logicalDropPublicationSQL drops the publication of the current database.
+// The database is read-only after logicalBlockWritesSQL.
+const logicalDropPublicationSQL = `
+SET client_min_messages = WARNING;
+SET default_transaction_read_only = off;
+
+SELECT pg_catalog.format('DROP PUBLICATION %I', :'name')
+  WHERE EXISTS (SELECT 1 FROM pg_catalog.pg_publication WHERE pubname = :'name')
+\gexec
+`
+
+// upgradeDropRoleSQL drops the role :username of upgradeRoleSQL.
+const upgradeDropRoleSQL = `
+SET client_min_messages = WARNING;
+SET default_transaction_read_only = off;
+
+DROP ROLE IF EXISTS :"username";
+`
+
+// logicalSubscriptionSQL returns SQL that subscribes the current database to
+// the publication of the same database in the old cluster. The slot of each
+// subscription is named after the database it is created in. The password
+// is in conninfo, so the SQL is passed via stdin rather than in a variable.
+func logicalSubscriptionSQL(conninfo string) string {
+	return `
+SET client_min_messages = WARNING;
+
+SELECT pg_catalog.format('CREATE SUBSCRIPTION %I CONNECTION %L PUBLICATION %I WITH (slot_name = %L)',
+    :'name',
+    ` + postgres.QuoteLiteral(conninfo) + ` || ' dbname=''' || pg_catalog.replace(pg_catalog.replace(
+      pg_catalog.current_database(), '\', '\\'), '''', '\''') || '''',
+    :'name',
+    :'name' || '_' || d.oid)
+  FROM pg_catalog.pg_database d
+  WHERE d.datname = pg_catalog.current_database()
+    AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_subscription s
+      WHERE s.subname = :'name' AND s.subdbid = d.oid)
+\gexec
+`
+}
+
+// quoteConninfo quotes v so it can be used as a value in a libpq connection
+// string.
+// - https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNSTRING
+func quoteConninfo(v string) string {
+	return `'` + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + `'`
+}
+
+// databasesQuery returns a query that selects the names of databases.
+func databasesQuery(databases []string) string {
+	quoted := make([]string, len(databases))
+	for i := range databases {
+		quoted[i] = postgres.QuoteLiteral(databases[i])
+	}
+	return `SELECT datname FROM pg_catalog.pg_database WHERE datname IN (` +
+		strings.Join(quoted, ",") + `)`
+}
+
+// logicalSchemaCommand returns a command that copies the roles and the
+// schema of databases from the old cluster at host and port into the local
+// cluster. The password of logicalUpgradeUser is read from stdin. Objects
+// that already exist are reported by psql and skipped.
+func logicalSchemaCommand(host string, port int32, databases []string) []string {
+	script := strings.Join([]string{
+		`declare -r host="$1" port="$2"`,
+		`shift 2`,
+		`read -r PGPASSWORD && export PGPASSWORD`,
+		`remote() { PGHOST="${host}" PGPORT="${port}" PGUSER="` + logicalUpgradeUser + `" PGSSLMODE=require "$@"; }`,
+
+		`roles=$(remote pg_dumpall --roles-only --no-role-passwords --database=postgres)`,
+		`psql -Xq --dbname=postgres <<< "${roles}" > /dev/null 2>&1`,
+
+		`for database in "$@"; do`,
+		`  schema=$(remote pg_dump --schema-only --create --no-publications --no-subscriptions -- "${database}")`,
+		`  psql -Xq --dbname=postgres <<< "${schema}" > /dev/null 2>&1`,
+		`done`,
+	}, "\n")
+
+	args := append([]string{host, fmt.Sprint(port)}, databases...)
+	return append([]string{"bash", "-ceu", "--", script, "schema"}, args...)
+}
+
+// logicalTargetName returns the name of the new cluster of upgrade.
+func logicalTargetName(upgrade *pgv2.PerconaPGUpgrade) string {
+	if spec := upgrade.Spec.Logical; spec != nil && spec.TargetClusterName != "" {
+		return spec.TargetClusterName
+	}
+	return fmt.Sprintf("%s-pg%d", upgrade.Spec.PostgresClusterName, upgrade.Spec.ToPostgresVersion)
+}
+
+// clusterPort returns the PostgreSQL port of cluster.
+func clusterPort(cluster *pgv2.PerconaPGCluster) int32 {
+	if cluster.Spec.Port != nil {
+		return *cluster.Spec.Port
+	}
+	return 5432
+}
+
+// primaryHost returns the hostname of the primary Service of cluster.
+func primaryHost(cluster *pgv2.PerconaPGCluster) string {
+	return cluster.Name + "-primary." + cluster.Namespace + ".svc"
+}
+
+// logicalTargetCluster returns the new cluster of upgrade. It is a copy of
+// cluster at the target version that does not share any storage or
+// certificates with cluster, except the root certificate authority.
+func logicalTargetCluster(cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade) *pgv2.PerconaPGCluster {
+	target := &pgv2.PerconaPGCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      logicalTargetName(upgrade),
+			Namespace: cluster.Namespace,
+			Labels: labels.Merge(upgrade.Spec.Metadata.GetLabelsOrNil(), map[string]string{
+				pNaming.LabelUpgrade: upgrade.Name,
+			}),
+			Annotations: upgrade.Spec.Metadata.GetAnnotationsOrNil(),
+		},
+	}
+	cluster.Spec.DeepCopyInto(&target.Spec)
+
+	target.Spec.PostgresVersion = upgrade.Spec.ToPostgresVersion
+	target.Spec.Image = upgrade.Spec.ToPostgresImage
+	target.Spec.Backups.PGBackRest.Image = upgrade.Spec.ToPgBackRestImage
+	if target.Spec.Proxy != nil && target.Spec.Proxy.PGBouncer != nil {
+		target.Spec.Proxy.PGBouncer.Image = upgrade.Spec.ToPgBouncerImage
+		target.Spec.Proxy.PGBouncer.CustomTLSSecret = nil
+	}
+
+	// The schema and data come from the old cluster.
+	target.Spec.DataSource = nil
+	target.Spec.DatabaseInitSQL = nil
+	target.Spec.Standby = nil
+	target.Spec.Pause = nil
+	target.Spec.Switchover = nil
+	target.Spec.Backups.PGBackRest.Restore = nil
+	target.Spec.Backups.PGBackRest.Manual = nil
+
+	// The certificates of the old cluster are not valid for the Services of
+	// the new cluster.
+	target.Spec.Secrets.CustomTLSSecret = nil
+	target.Spec.Secrets.CustomReplicationClientTLSSecret = nil
+
+	// The Secrets of the users are named after the new cluster.
+	for i := range target.Spec.Users {
+		target.Spec.Users[i].SecretName = ""
+	}
+
+	// Cloud repositories are shared by both clusters; keep the backups of
+	// the new cluster apart from the old ones.
+	for _, repo := range target.Spec.Backups.PGBackRest.Repos {
+		if repo.Volume != nil {
+			continue
+		}
+
+		key := repo.Name + "-path"
+		initialize.Map(&target.Spec.Backups.PGBackRest.Global)
+		repoPath, ok := target.Spec.Backups.PGBackRest.Global[key]
+		if !ok {
+			repoPath = "/pgbackrest/" + repo.Name
+		}
+		target.Spec.Backups.PGBackRest.Global[key] = path.Join(repoPath, target.Name)
+	}
+
+	return target
+}
+
+// copySecret creates a Secret named to with the keys of the Secret named
+// from. Nothing is copied when either Secret exists or not, respectively.
+func (r *PGUpgradeReconciler) copySecret(
+	ctx context.Context, namespace, from, to string, labels map[string]string, keys ...string,
+) error {
+	source := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: from}, source); err != nil {
+		return errors.Wrapf(client.IgnoreNotFound(err), "get secret %s", from)
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: to, Namespace: namespace, Labels: labels}}
+	secret.Data = make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if value, ok := source.Data[key]; ok {
+			secret.Data[key] = value
+		}
+	}
+
+	err := r.Client.Create(ctx, secret)
+	return errors.Wrapf(client.IgnoreAlreadyExists(err), "create secret %s", to)
+}
+
+// copyLogicalSecrets copies the credentials of cluster that clients and
+// PgBouncer of cluster use to the Secrets of target before target is
+// created. The PostgreSQL operator keeps existing passwords, so they are
+// the same in both clusters. The root certificate authority is shared so
+// that PgBouncer of cluster trusts the certificates of target.
+func (r *PGUpgradeReconciler) copyLogicalSecrets(
+	ctx context.Context, cluster, target *pgv2.PerconaPGCluster,
+) error {
+	for _, user := range cluster.Spec.Users {
+		if user.Password != nil && user.Password.Source != nil {
+			continue
+		}
+
+		from := cluster.Name + "-pguser-" + string(user.Name)
+		if user.SecretName != "" {
+			from = string(user.SecretName)
+		}
+		if err := r.copySecret(ctx, cluster.Namespace, from, target.Name+"-pguser-"+string(user.Name),
+			map[string]string{
+				naming.LabelCluster:      target.Name,
+				naming.LabelPostgresUser: string(user.Name),
+			},
+			"password", "verifier",
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := r.copySecret(ctx, cluster.Namespace, cluster.Name+"-pgbouncer", target.Name+"-pgbouncer",
+		nil, "pgbouncer-password", "pgbouncer-verifier",
+	); err != nil {
+		return err
+	}
+
+	// A custom root certificate authority is referenced by both clusters.
+	if cluster.Spec.Secrets.CustomRootCATLSSecret != nil {
+		return nil
+	}
+	from := cluster.Name + "-cluster-ca-cert"
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: from}, &corev1.Secret{}); k8serrors.IsNotFound(err) {
+		from = naming.RootCertSecret
+	}
+	return r.copySecret(ctx, cluster.Namespace, from, target.Name+"-cluster-ca-cert",
+		nil, "root.crt", "root.key")
+}
+
+// reconcileRolePassword returns the password of a role the upgrade creates.
+// It is stored in the Secret of upgrade with suffix.
+func (r *PGUpgradeReconciler) reconcileRolePassword(
+	ctx context.Context, upgrade *pgv2.PerconaPGUpgrade, suffix string,
+) (string, error) {
+	secret := &corev1.Secret{ObjectMeta: upgradeObjectMeta(upgrade, suffix)}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(secret), secret)
+	if err == nil {
+		return string(secret.Data["password"]), nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return "", errors.Wrapf(err, "get %s secret", suffix)
+	}
+
+	password, err := util.GenerateAlphaNumericPassword(util.DefaultGeneratedPasswordLength)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	secret.Data = map[string][]byte{"password": []byte(password)}
+
+	if err := controllerutil.SetControllerReference(upgrade, secret, r.Client.Scheme()); err != nil {
+		return "", errors.Wrap(err, "set controller reference")
+	}
+	return password, errors.Wrapf(r.Client.Create(ctx, secret), "create %s secret", suffix)
+}
+
+// reconcileTargetCluster creates the new cluster of upgrade. It returns nil
+// while the cluster is being created.
+func (r *PGUpgradeReconciler) reconcileTargetCluster(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) (*pgv2.PerconaPGCluster, error) {
+	target := &pgv2.PerconaPGCluster{}
+	key := client.ObjectKey{Namespace: cluster.Namespace, Name: logicalTargetName(upgrade)}
+	err := r.Client.Get(ctx, key, target)
+	if !k8serrors.IsNotFound(err) {
+		return target, errors.Wrap(err, "get target cluster")
+	}
+
+	target = logicalTargetCluster(cluster, upgrade)
+	if err := r.copyLogicalSecrets(ctx, cluster, target); err != nil {
+		return nil, errors.Wrap(err, "copy secrets")
+	}
+
+	logging.FromContext(ctx).Info("Creating target cluster", "cluster", target.Name)
+	return nil, errors.Wrap(r.Client.Create(ctx, target), "create target cluster")
+}
+
+// execJSONLines runs sql in databases with exec and decodes every line of
+// its output that is a JSON object.
+func execJSONLines[T any](
+	ctx context.Context, exec postgres.Executor, databases []string, sql string, variables map[string]string,
+) ([]T, error) {
+	stdout, stderr, err := exec.ExecInDatabasesFromQuery(ctx, databasesQuery(databases), sql, variables)
+	if err != nil {
+		return nil, errors.Wrap(err, strings.TrimSpace(stderr))
+	}
+
+	var values []T
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var value T
+		if err := json.Unmarshal([]byte(line), &value); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		values = append(values, value)
+	}
+	return values, errors.WithStack(scanner.Err())
+}
+
+// execInDatabases runs sql in databases with exec.
+func execInDatabases(
+	ctx context.Context, exec postgres.Executor, databases []string, sql string, variables map[string]string,
+) (string, error) {
+	stdout, stderr, err := exec.ExecInDatabasesFromQuery(ctx, databasesQuery(databases), sql, variables)
+	return stdout, errors.Wrap(err, strings.TrimSpace(stderr))
+}
+
+// logicalVariables are the psql variables of the logical replication SQL.
+var logicalVariables = map[string]string{
+	"ON_ERROR_STOP": "on",
+	"QUIET":         "on",
+	"name":          logicalReplicationName,
+	"username":      logicalUpgradeUser,
+}
+
+// startLogicalReplication copies the roles and schema of the databases of
+// source into target and subscribes target to them.
+func startLogicalReplication(
+	ctx context.Context, source, target postgres.Executor,
+	cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade, password string,
+) ([]string, error) {
+	verifier, err := pgpassword.NewSCRAMPassword(password).Build()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	variables := map[string]string{"verifier": verifier}
+	for k, v := range logicalVariables {
+		variables[k] = v
+	}
+	if _, stderr, err := source.Exec(ctx, strings.NewReader(upgradeRoleSQL), variables); err != nil {
+		return nil, errors.Wrapf(err, "create role: %s", strings.TrimSpace(stderr))
+	}
+
+	var databases []string
+	if upgrade.Spec.Logical != nil {
+		databases = upgrade.Spec.Logical.Databases
+	}
+	if len(databases) == 0 {
+		stdout, stderr, err := source.Exec(ctx, strings.NewReader(logicalDatabasesSQL), logicalVariables)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list databases: %s", strings.TrimSpace(stderr))
+		}
+		databases = strings.Fields(stdout)
+	}
+	if len(databases) == 0 {
+		return nil, errors.New("no databases to replicate")
+	}
+
+	var stderr strings.Builder
+	if err := target(ctx, strings.NewReader(password+"\n"), io.Discard, &stderr,
+		logicalSchemaCommand(primaryHost(cluster), clusterPort(cluster), databases)...,
+	); err != nil {
+		return nil, errors.Wrapf(err, "copy schema: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	if _, err := execInDatabases(ctx, source, databases, logicalPublicationSQL, logicalVariables); err != nil {
+		return nil, errors.Wrap(err, "create publications")
+	}
+
+	conninfo := fmt.Sprintf("host=%s port=%d user=%s password=%s sslmode=require",
+		primaryHost(cluster), clusterPort(cluster), logicalUpgradeUser, quoteConninfo(password))
+	if _, err := execInDatabases(ctx, target, databases, logicalSubscriptionSQL(conninfo), logicalVariables); err != nil {
+		return nil, errors.Wrap(err, "create subscriptions")
+	}
+
+	return databases, nil
+}
+
+// observeLogicalReplication records the state of the subscriptions of target
+// and their lag in source in status.
+func observeLogicalReplication(
+	ctx context.Context, source, target postgres.Executor, status *pgv2.LogicalUpgradeStatus,
+) error {
+	databases := logicalDatabases(status)
+
+	type lag struct {
+		Database string `json:"database"`
+		Lag      int64  `json:"lag"`
+	}
+	lags, err := execJSONLines[lag](ctx, source, []string{"postgres"}, logicalLagSQL, logicalVariables)
+	if err != nil {
+		return errors.Wrap(err, "replication lag")
+	}
+
+	type synchronized struct {
+		Database     string `json:"database"`
+		Synchronized bool   `json:"synchronized"`
+	}
+	states, err := execJSONLines[synchronized](ctx, target, databases, logicalSynchronizedSQL, logicalVariables)
+	if err != nil {
+		return errors.Wrap(err, "subscription state")
+	}
+
+	for i := range status.Databases {
+		database := &status.Databases[i]
+		database.LagBytes = nil
+		database.Synchronized = false
+
+		for _, lag := range lags {
+			if lag.Database == database.Name {
+				database.LagBytes = initialize.Int64(lag.Lag)
+			}
+		}
+		for _, state := range states {
+			if state.Database == database.Name {
+				database.Synchronized = state.Synchronized
+			}
+		}
+	}
+	return nil
+}
+
+// logicalDatabases returns the names of the replicated databases in status.
+func logicalDatabases(status *pgv2.LogicalUpgradeStatus) []string {
+	databases := make([]string, len(status.Databases))
+	for i := range status.Databases {
+		databases[i] = status.Databases[i].Name
+	}
+	return databases
+}
+
+// logicalCaughtUp returns true when every database of status is
+// synchronized and has no lag, if required.
+func logicalCaughtUp(status *pgv2.LogicalUpgradeStatus, noLag bool) bool {
+	for _, database := range status.Databases {
+		if !database.Synchronized || database.LagBytes == nil {
+			return false
+		}
+		if noLag && *database.LagBytes > 0 {
+			return false
+		}
+	}
+	return len(status.Databases) > 0
+}
+
+// completeLogicalCutover copies the values of the sequences of source to
+// target, removes the replication, and connects the primary Service and
+// PgBouncer of cluster to target. It returns true when PgBouncer was switched.
+// Every step can be repeated, so a cutover that fails part way is run again
+// from the start.
+func (r *PGUpgradeReconciler) completeLogicalCutover(
+	ctx context.Context, source, target postgres.Executor,
+	cluster, targetCluster *pgv2.PerconaPGCluster, databases []string,
+) (bool, error) {
+	for _, database := range databases {
+		stdout, err := execInDatabases(ctx, source, []string{database}, logicalSequencesSQL, logicalVariables)
+		if err != nil {
+			return false, errors.Wrap(err, "read sequences")
+		}
+
+		var setval strings.Builder
+		for _, line := range strings.Split(stdout, "\n") {
+			if strings.HasPrefix(line, "SELECT pg_catalog.setval(") {
+				setval.WriteString(line + "\n")
+			}
+		}
+		if _, err := execInDatabases(ctx, target, []string{database}, setval.String(), logicalVariables); err != nil {
+			return false, errors.Wrap(err, "set sequences")
+		}
+	}
+
+	if _, err := execInDatabases(ctx, target, databases, logicalDropSubscriptionSQL, logicalVariables); err != nil {
+		return false, errors.Wrap(err, "drop subscriptions")
+	}
+	if _, err := execInDatabases(ctx, source, databases, logicalDropPublicationSQL, logicalVariables); err != nil {
+		return false, errors.Wrap(err, "drop publications")
+	}
+	for _, exec := range []postgres.Executor{source, target} {
+		if _, stderr, err := exec.Exec(ctx, strings.NewReader(upgradeDropRoleSQL), logicalVariables); err != nil {
+			return false, errors.Wrapf(err, "drop role: %s", strings.TrimSpace(stderr))
+		}
+	}
+
+	orig := cluster.DeepCopy()
+	initialize.Map(&cluster.Annotations)
+	cluster.Annotations[pNaming.AnnotationPrimaryServiceTarget] = targetCluster.Name
+
+	pgBouncer := cluster.Spec.Proxy != nil && cluster.Spec.Proxy.PGBouncer != nil
+	if pgBouncer {
+		initialize.Map(&cluster.Spec.Proxy.PGBouncer.Config.Databases)
+		cluster.Spec.Proxy.PGBouncer.Config.Databases["*"] = fmt.Sprintf("host=%s port=%d",
+			primaryHost(targetCluster), clusterPort(targetCluster))
+	}
+
+	return pgBouncer, errors.Wrap(r.Client.Patch(ctx, cluster.DeepCopy(), client.MergeFrom(orig)), "switch clients")
+}
+
+// reconcileLogical upgrades cluster with the "logical" strategy. It returns
+// true once clients are switched to the new cluster.
+func (r *PGUpgradeReconciler) reconcileLogical(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) (bool, error) {
+	log := logging.FromContext(ctx)
+
+	status := upgrade.Status.Logical
+	if status == nil {
+		status = &pgv2.LogicalUpgradeStatus{
+			Phase:             pgv2.LogicalUpgradeProvisioning,
+			TargetClusterName: logicalTargetName(upgrade),
+		}
+		upgrade.Status.Logical = status
+	}
+	if status.Phase == pgv2.LogicalUpgradeCompleted {
+		return true, nil
+	}
+
+	password, err := r.reconcileRolePassword(ctx, upgrade, "logical")
+	if err != nil {
+		return false, err
+	}
+	targetCluster, err := r.reconcileTargetCluster(ctx, cluster, upgrade)
+	if err != nil || targetCluster == nil {
+		return false, err
+	}
+	status.TargetHost = primaryHost(targetCluster)
+	if cluster.Status.State != pgv2.AppStateReady || targetCluster.Status.State != pgv2.AppStateReady {
+		return false, nil
+	}
+
+	sourcePrimary, err := perconaPG.GetPrimaryPod(ctx, r.Client, cluster)
+	if err != nil {
+		return false, errors.Wrap(err, "get primary pod")
+	}
+	targetPrimary, err := perconaPG.GetPrimaryPod(ctx, r.Client, targetCluster)
+	if err != nil {
+		return false, errors.Wrap(err, "get primary pod of target cluster")
+	}
+	source, target := r.databaseExecutor(sourcePrimary), r.databaseExecutor(targetPrimary)
+
+	switch status.Phase {
+	case pgv2.LogicalUpgradeProvisioning:
+		log.Info("Starting logical replication", "cluster", cluster.Name, "target", targetCluster.Name)
+
+		databases, err := startLogicalReplication(ctx, source, target, cluster, upgrade, password)
+		if err != nil {
+			return false, err
+		}
+
+		status.Databases = make([]pgv2.LogicalReplicationStatus, len(databases))
+		for i := range databases {
+			status.Databases[i].Name = databases[i]
+		}
+		status.Phase = pgv2.LogicalUpgradeReplicating
+		return false, nil
+
+	case pgv2.LogicalUpgradeReplicating:
+		if err := observeLogicalReplication(ctx, source, target, status); err != nil {
+			return false, err
+		}
+		if upgrade.Spec.Logical == nil || !upgrade.Spec.Logical.Cutover || !logicalCaughtUp(status, false) {
+			return false, nil
+		}
+
+		log.Info("Blocking writes to cluster for cutover", "cluster", cluster.Name)
+
+		if _, err := execInDatabases(ctx, source, logicalDatabases(status), logicalBlockWritesSQL, logicalVariables); err != nil {
+			return false, errors.Wrap(err, "block writes")
+		}
+		status.Phase = pgv2.LogicalUpgradeCuttingOver
+		return false, nil
+
+	case pgv2.LogicalUpgradeCuttingOver:
+		if err := observeLogicalReplication(ctx, source, target, status); err != nil {
+			return false, err
+		}
+		if !logicalCaughtUp(status, true) {
+			return false, nil
+		}
+
+		// The subscriptions are dropped next, so the new cluster can't be
+		// seen catching up again. The phase is stored first to resume a
+		// cutover that fails part way.
+		status.Phase = pgv2.LogicalUpgradeSwitching
+		if err := r.updateStatus(ctx, upgrade); err != nil {
+			return false, errors.Wrap(err, "update PerconaPGUpgrade status")
+		}
+		fallthrough
+
+	case pgv2.LogicalUpgradeSwitching:
+		switched, err := r.completeLogicalCutover(ctx, source, target, cluster, targetCluster, logicalDatabases(status))
+		if err != nil {
+			return false, err
+		}
+
+		now := metav1.Now()
+		status.CutoverTime = &now
+		status.PgBouncerSwitched = switched
+		status.Phase = pgv2.LogicalUpgradeCompleted
+		log.Info("Completed cutover to target cluster",
+			"cluster", cluster.Name, "target", targetCluster.Name, "pgBouncerSwitched", switched)
+	}
+
+	return status.Phase == pgv2.LogicalUpgradeCompleted, nil
+}
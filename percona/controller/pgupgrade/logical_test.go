@@ -0,0 +1,286 @@
+package pgupgrade
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	crunchyv1beta1 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+func newLogicalUpgrade() *pgv2.PerconaPGUpgrade {
+	upgrade := newRollbackUpgrade()
+	upgrade.Spec.Backup = nil
+	upgrade.Spec.ToPostgresImage = "postgres:17"
+	upgrade.Spec.ToPgBouncerImage = "pgbouncer:17"
+	upgrade.Spec.ToPgBackRestImage = "pgbackrest:17"
+	upgrade.Spec.Strategy = pgv2.UpgradeStrategyLogical
+	upgrade.Spec.Logical = &pgv2.LogicalUpgradeSpec{}
+	return upgrade
+}
+
+func TestLogicalTargetCluster(t *testing.T) {
+	cluster := newRollbackCluster()
+	cluster.Spec.Users = []crunchyv1beta1.PostgresUserSpec{
+		{Name: "app"},
+		{Name: "custom", SecretName: "custom-secret"},
+	}
+	cluster.Spec.DataSource = &crunchyv1beta1.DataSource{}
+	cluster.Spec.Backups.PGBackRest.Repos = []crunchyv1beta1.PGBackRestRepo{
+		{Name: "repo1", Volume: &crunchyv1beta1.RepoPVC{}},
+		{Name: "repo2", S3: &crunchyv1beta1.RepoS3{Bucket: "bucket"}},
+		{Name: "repo3", GCS: &crunchyv1beta1.RepoGCS{Bucket: "bucket"}},
+	}
+	cluster.Spec.Backups.PGBackRest.Global = map[string]string{"repo3-path": "/backups/hippo/repo3"}
+
+	upgrade := newLogicalUpgrade()
+
+	target := logicalTargetCluster(cluster, upgrade)
+	assert.Equal(t, target.Name, "hippo-pg17")
+	assert.Equal(t, target.Namespace, "ns")
+	assert.Equal(t, target.Labels[pNaming.LabelUpgrade], "to-17")
+	assert.Equal(t, len(target.Annotations), 0)
+
+	assert.Equal(t, target.Spec.PostgresVersion, 17)
+	assert.Equal(t, target.Spec.Image, "postgres:17")
+	assert.Equal(t, target.Spec.Proxy.PGBouncer.Image, "pgbouncer:17")
+	assert.Equal(t, target.Spec.Backups.PGBackRest.Image, "pgbackrest:17")
+	assert.Assert(t, target.Spec.DataSource == nil)
+
+	assert.Equal(t, string(target.Spec.Users[1].SecretName), "")
+	assert.Equal(t, string(cluster.Spec.Users[1].SecretName), "custom-secret")
+
+	assert.DeepEqual(t, target.Spec.Backups.PGBackRest.Global, map[string]string{
+		"repo2-path": "/pgbackrest/repo2/hippo-pg17",
+		"repo3-path": "/backups/hippo/repo3/hippo-pg17",
+	})
+	assert.Equal(t, cluster.Spec.Backups.PGBackRest.Global["repo3-path"], "/backups/hippo/repo3")
+
+	upgrade.Spec.Logical.TargetClusterName = "new-hippo"
+	assert.Equal(t, logicalTargetCluster(cluster, upgrade).Name, "new-hippo")
+}
+
+func TestLogicalSchemaCommand(t *testing.T) {
+	command := logicalSchemaCommand("hippo-primary.ns.svc", 5432, []string{"app", "postgres"})
+	assert.DeepEqual(t, command[:3], []string{"bash", "-ceu", "--"})
+	assert.DeepEqual(t, command[4:], []string{"schema", "hippo-primary.ns.svc", "5432", "app", "postgres"})
+	assert.Assert(t, strings.Contains(command[3], `pg_dumpall --roles-only --no-role-passwords`))
+	assert.Assert(t, strings.Contains(command[3], `pg_dump --schema-only --create --no-publications --no-subscriptions`))
+}
+
+func TestLogicalSubscriptionSQL(t *testing.T) {
+	conninfo := fmt.Sprintf("host=h password=%s", quoteConninfo(`it's`))
+	assert.Equal(t, conninfo, `host=h password='it\'s'`)
+
+	sql := logicalSubscriptionSQL(conninfo)
+	assert.Assert(t, strings.Contains(sql, ` E'host=h password=''it\\''s'''`))
+	assert.Assert(t, strings.Contains(sql, `CREATE SUBSCRIPTION %I CONNECTION %L PUBLICATION %I`))
+}
+
+func TestReconcileLogical(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := newRollbackCluster()
+	cluster.Annotations = nil
+	cluster.Spec.Users = []crunchyv1beta1.PostgresUserSpec{{Name: "app"}}
+	cluster.Status.State = pgv2.AppStateReady
+	cluster.Status.PatroniVersion = "4.0.0"
+
+	upgrade := newLogicalUpgrade()
+
+	pod := func(name, cluster string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ns",
+				Labels: map[string]string{
+					"app.kubernetes.io/instance": cluster,
+					naming.LabelRole:             "primary",
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	secret := func(name string, data map[string]string) *corev1.Secret {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}}
+		s.Data = make(map[string][]byte)
+		for k, v := range data {
+			s.Data[k] = []byte(v)
+		}
+		return s
+	}
+
+	cl := newRollbackClient(t, cluster, upgrade, pod("hippo-instance-0", "hippo"),
+		secret("hippo-pguser-app", map[string]string{"password": "secret", "verifier": "SCRAM", "host": "hippo"}),
+		secret("hippo-pgbouncer", map[string]string{"pgbouncer-password": "pgb", "pgbouncer-verifier": "SCRAM-pgb"}),
+		secret("hippo-cluster-ca-cert", map[string]string{"root.crt": "crt", "root.key": "key"}),
+	)
+
+	var lag int
+	var scripts []string
+	var dropped bool
+	var dropErr error
+	r := &PGUpgradeReconciler{
+		Client: cl,
+		PodExec: func(
+			_ context.Context, _, pod, _ string, stdin io.Reader, stdout, _ io.Writer, command ...string,
+		) error {
+			b, err := io.ReadAll(stdin)
+			assert.NilError(t, err)
+			sql := string(b)
+			scripts = append(scripts, pod+": "+sql+strings.Join(command, " "))
+
+			switch {
+			case strings.Contains(sql, "WHERE datallowconn AND NOT datistemplate"):
+				_, _ = stdout.Write([]byte("app\npostgres\n"))
+			case strings.Contains(sql, "pg_replication_slots"):
+				_, _ = fmt.Fprintf(stdout, "{\"database\" : \"app\", \"lag\" : %d}\n{\"database\" : \"postgres\", \"lag\" : 0}\n", lag)
+			case strings.Contains(sql, "pg_subscription_rel"):
+				if !dropped {
+					_, _ = stdout.Write([]byte(`{"database" : "app", "synchronized" : true}
+{"database" : "postgres", "synchronized" : true}
+`))
+				}
+			case strings.Contains(sql, "DROP SUBSCRIPTION"):
+				dropped = true
+			case strings.Contains(sql, "DROP PUBLICATION"):
+				return dropErr
+			case strings.Contains(sql, "pg_sequences"):
+				_, _ = stdout.Write([]byte("SELECT pg_catalog.setval('public.s', 42, true);\n"))
+			}
+			return nil
+		},
+	}
+
+	done, err := r.reconcileLogical(ctx, cluster, upgrade)
+	assert.NilError(t, err)
+	assert.Assert(t, !done)
+	assert.Equal(t, upgrade.Status.Logical.Phase, pgv2.LogicalUpgradeProvisioning)
+	assert.Equal(t, upgrade.Status.Logical.TargetClusterName, "hippo-pg17")
+
+	// The credentials of the old cluster are copied before the new cluster is created.
+	copied := &corev1.Secret{}
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "hippo-pg17-pguser-app"}, copied))
+	assert.DeepEqual(t, copied.Data, map[string][]byte{"password": []byte("secret"), "verifier": []byte("SCRAM")})
+	assert.Equal(t, copied.Labels[naming.LabelCluster], "hippo-pg17")
+	assert.Equal(t, copied.Labels[naming.LabelPostgresUser], "app")
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "hippo-pg17-pgbouncer"}, copied))
+	assert.Equal(t, string(copied.Data["pgbouncer-verifier"]), "SCRAM-pgb")
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "hippo-pg17-cluster-ca-cert"}, copied))
+	assert.Equal(t, string(copied.Data["root.key"]), "key")
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "to-17-logical"}, copied))
+	assert.Equal(t, len(copied.Data["password"]), 24)
+
+	target := &pgv2.PerconaPGCluster{}
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "hippo-pg17"}, target))
+	assert.Equal(t, target.Spec.PostgresVersion, 17)
+
+	// Nothing runs until the new cluster is ready.
+	done, err = r.reconcileLogical(ctx, cluster, upgrade)
+	assert.NilError(t, err)
+	assert.Assert(t, !done)
+	assert.Equal(t, len(scripts), 0)
+
+	target.Status.State = pgv2.AppStateReady
+	target.Status.PatroniVersion = "4.0.0"
+	assert.NilError(t, cl.Status().Update(ctx, target))
+	assert.NilError(t, cl.Create(ctx, pod("hippo-pg17-instance-0", "hippo-pg17")))
+
+	t.Run("Provisioning", func(t *testing.T) {
+		done, err := r.reconcileLogical(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+		assert.Equal(t, upgrade.Status.Logical.Phase, pgv2.LogicalUpgradeReplicating)
+		assert.DeepEqual(t, upgrade.Status.Logical.Databases, []pgv2.LogicalReplicationStatus{
+			{Name: "app"}, {Name: "postgres"},
+		})
+
+		assert.Equal(t, len(scripts), 5)
+		assert.Assert(t, strings.HasPrefix(scripts[0], "hippo-instance-0: "))
+		assert.Assert(t, strings.Contains(scripts[0], "LOGIN SUPERUSER"))
+		assert.Assert(t, strings.HasPrefix(scripts[2], "hippo-pg17-instance-0: "))
+		assert.Assert(t, strings.Contains(scripts[2], "schema hippo-primary.ns.svc 5432 app postgres"))
+		assert.Assert(t, strings.Contains(scripts[3], "CREATE PUBLICATION"))
+		assert.Assert(t, strings.HasPrefix(scripts[4], "hippo-pg17-instance-0: "))
+		assert.Assert(t, strings.Contains(scripts[4], "CREATE SUBSCRIPTION"))
+		assert.Assert(t, strings.Contains(scripts[4], "host=hippo-primary.ns.svc port=5432 user=_perconaupgrade"))
+	})
+
+	t.Run("Replicating", func(t *testing.T) {
+		scripts, lag = nil, 100
+
+		done, err := r.reconcileLogical(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+		assert.Equal(t, upgrade.Status.Logical.Phase, pgv2.LogicalUpgradeReplicating)
+		assert.Equal(t, *upgrade.Status.Logical.Databases[0].LagBytes, int64(100))
+		assert.Assert(t, upgrade.Status.Logical.Databases[0].Synchronized)
+
+		upgrade.Spec.Logical.Cutover = true
+
+		done, err = r.reconcileLogical(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+		assert.Equal(t, upgrade.Status.Logical.Phase, pgv2.LogicalUpgradeCuttingOver)
+		assert.Assert(t, strings.Contains(scripts[len(scripts)-1], "default_transaction_read_only = on"))
+	})
+
+	t.Run("CuttingOver", func(t *testing.T) {
+		scripts = nil
+
+		// The cutover waits for the new cluster to catch up.
+		done, err := r.reconcileLogical(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+		assert.Equal(t, upgrade.Status.Logical.Phase, pgv2.LogicalUpgradeCuttingOver)
+
+		lag = 0
+
+		// The cutover fails after the subscriptions are dropped. The new
+		// cluster no longer looks synchronized, and the cutover resumes
+		// anyway.
+		dropErr = errors.New("connection lost")
+		_, err = r.reconcileLogical(ctx, cluster, upgrade)
+		assert.ErrorContains(t, err, "connection lost")
+		assert.Assert(t, dropped)
+		assert.Equal(t, upgrade.Status.Logical.Phase, pgv2.LogicalUpgradeSwitching)
+
+		stored := &pgv2.PerconaPGUpgrade{}
+		assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(upgrade), stored))
+		assert.Equal(t, stored.Status.Logical.Phase, pgv2.LogicalUpgradeSwitching)
+
+		dropErr = nil
+		done, err = r.reconcileLogical(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, done)
+		assert.Equal(t, upgrade.Status.Logical.Phase, pgv2.LogicalUpgradeCompleted)
+		assert.Assert(t, upgrade.Status.Logical.CutoverTime != nil)
+		assert.Assert(t, upgrade.Status.Logical.PgBouncerSwitched)
+		assert.Equal(t, upgrade.Status.Logical.TargetHost, "hippo-pg17-primary.ns.svc")
+
+		var setval bool
+		for _, script := range scripts {
+			setval = setval || (strings.HasPrefix(script, "hippo-pg17-instance-0: ") &&
+				strings.Contains(script, "SELECT pg_catalog.setval('public.s', 42, true);"))
+		}
+		assert.Assert(t, setval)
+
+		updated := &pgv2.PerconaPGCluster{}
+		assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(cluster), updated))
+		assert.DeepEqual(t, updated.Spec.Proxy.PGBouncer.Config.Databases, map[string]string{
+			"*": "host=hippo-pg17-primary.ns.svc port=5432",
+		})
+		assert.Equal(t, updated.Annotations[pNaming.AnnotationPrimaryServiceTarget], "hippo-pg17")
+	})
+}
@@ -0,0 +1,352 @@
+package pgupgrade
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/postgres"
+	pgpassword "github.com/fulviodenza/percona-postgresql-operator/internal/postgres/password"
+	perconaPG "github.com/fulviodenza/percona-postgresql-operator/percona/postgres"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+// updateExtensionsSQL updates the extensions of the current database to the
+// default version of their control file. An extension is only updated when
+// there is an update path from the installed version.
+const updateExtensionsSQL = `
+SET client_min_messages = WARNING;
+
+SELECT format('ALTER EXTENSION %I UPDATE', e.extname)
+  FROM pg_catalog.pg_extension e
+  JOIN pg_catalog.pg_available_extensions a ON a.name = e.extname
+  WHERE e.extversion <> a.default_version
+    AND EXISTS (SELECT 1 FROM pg_catalog.pg_extension_update_paths(e.extname) p
+      WHERE p.source = e.extversion AND p.target = a.default_version AND p.path IS NOT NULL)
+\gexec
+`
+
+// postUpgradeUser is the role the post-upgrade Jobs connect to the primary
+// with. It is a superuser so that it can analyze and update every object; it
+// is dropped once the Jobs have finished.
+const postUpgradeUser = "_perconapostupgrade"
+
+// postUpgradeStep is a maintenance step that runs on the primary once the
+// upgraded cluster is ready. Its progress is reported in a condition.
+type postUpgradeStep struct {
+	name      string
+	condition string
+
+	// The command of a step that can take long. It runs in a Job that
+	// connects to the primary as postUpgradeUser.
+	command []string
+
+	// The function of a step that runs in the database container of the
+	// primary when there is no command.
+	run func(context.Context, postgres.Executor) error
+}
+
+// postUpgradeSteps returns the steps enabled in upgrade in the order they run.
+// The steps with a command come first.
+func postUpgradeSteps(upgrade *pgv2.PerconaPGUpgrade) []postUpgradeStep {
+	spec := upgrade.Spec.PostUpgrade
+	if spec == nil {
+		return nil
+	}
+
+	var steps []postUpgradeStep
+	if spec.Analyze {
+		steps = append(steps, postUpgradeStep{
+			name:      "analyze",
+			condition: pgv2.ConditionPostUpgradeAnalyze,
+			command:   analyzeCommand(),
+		})
+	}
+	if spec.UpdateExtensions {
+		steps = append(steps, postUpgradeStep{
+			name:      "update-extensions",
+			condition: pgv2.ConditionPostUpgradeUpdateExtensions,
+			command:   updateExtensionsCommand(),
+		})
+	}
+	if spec.RemoveOldData {
+		steps = append(steps, postUpgradeStep{
+			name:      "remove-old-data",
+			condition: pgv2.ConditionPostUpgradeRemoveOldData,
+			run: func(ctx context.Context, exec postgres.Executor) error {
+				return removeOldData(ctx, exec, upgrade.Spec.FromPostgresVersion)
+			},
+		})
+	}
+	return steps
+}
+
+// analyzeCommand returns a command that collects optimizer statistics in all
+// databases. The statistics are collected in stages so that the planner has
+// some as soon as possible.
+// - https://www.postgresql.org/docs/current/pgupgrade.html#PGUPGRADE-STEP-STATISTICS
+func analyzeCommand() []string {
+	return []string{"vacuumdb", "--all", "--analyze-in-stages"}
+}
+
+// updateExtensionsCommand returns a command that updates the installed
+// extensions in all databases.
+func updateExtensionsCommand() []string {
+	script := strings.Join([]string{
+		`declare -r sql="$1"`,
+		`list=$(psql --dbname=postgres --no-align --tuples-only --command=\`,
+		`'SELECT datname FROM pg_catalog.pg_database WHERE datallowconn ORDER BY datname')`,
+		`readarray -t databases <<< "${list}"`,
+		`for database in "${databases[@]}"; do`,
+		`psql --dbname="${database}" --set=ON_ERROR_STOP=on --quiet --file=- <<< "${sql}"`,
+		`done`,
+	}, "\n")
+
+	return []string{"bash", "-ceu", "--", script, "update", updateExtensionsSQL}
+}
+
+// removeOldDataCommand returns a command that removes the data and WAL
+// directories of oldVersion from the volumes of the primary. pg_upgrade
+// links the files of the new data directory to the old one, so the space
+// is only freed for files that changed since the upgrade.
+func removeOldDataCommand(oldVersion int) []string {
+	script := strings.Join([]string{
+		`declare -r old_version="$1"`,
+		`cd /pgdata || exit`,
+		`[ -d pg"${old_version}" ] || exit 0`,
+		`if [ "${PGDATA}" = /pgdata/pg"${old_version}" ]; then echo "Directory in use, cannot remove" >&2; exit 1; fi`,
+		// The WAL directory can be on its own volume; resolve the symlink
+		// before the data directory is removed.
+		`rm -rf "$(realpath pg"${old_version}"/pg_wal)" pg"${old_version}"`,
+	}, "\n")
+
+	return []string{"bash", "-ceu", "--", script, "remove", fmt.Sprint(oldVersion)}
+}
+
+// removeOldData removes the data directory of oldVersion from the primary.
+func removeOldData(ctx context.Context, exec postgres.Executor, oldVersion int) error {
+	var stderr strings.Builder
+	err := exec(ctx, nil, io.Discard, &stderr, removeOldDataCommand(oldVersion)...)
+	return errors.Wrapf(err, "remove old data: %s", strings.TrimSpace(stderr.String()))
+}
+
+// reconcilePostUpgrade runs the post-upgrade steps that have not completed
+// yet once the upgraded cluster is ready. It returns true when every step
+// has completed or the Job of a step has failed. The steps after a failed Job
+// do not run; deleting the Job runs its step again.
+func (r *PGUpgradeReconciler) reconcilePostUpgrade(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) (bool, error) {
+	log := logging.FromContext(ctx)
+
+	var pending []postUpgradeStep
+	for _, step := range postUpgradeSteps(upgrade) {
+		if !meta.IsStatusConditionTrue(upgrade.Status.Conditions, step.condition) {
+			pending = append(pending, step)
+		}
+	}
+	if len(pending) == 0 {
+		return true, nil
+	}
+
+	setCondition := func(step postUpgradeStep, status metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(&upgrade.Status.Conditions, metav1.Condition{
+			ObservedGeneration: upgrade.Generation,
+			Type:               step.condition,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+
+	primary := r.upgradedPrimary(ctx, cluster, upgrade)
+	if primary == nil {
+		for _, step := range pending {
+			if meta.FindStatusCondition(upgrade.Status.Conditions, step.condition) == nil {
+				setCondition(step, metav1.ConditionUnknown, "Waiting", "Waiting for the upgraded cluster to be ready")
+			}
+		}
+		return false, nil
+	}
+
+	exec := r.databaseExecutor(primary)
+
+	var jobs bool
+	for _, step := range pending {
+		if step.command == nil {
+			continue
+		}
+		jobs = true
+
+		job, err := r.reconcilePostUpgradeJob(ctx, cluster, upgrade, exec, step)
+		if err != nil {
+			return false, errors.Wrapf(err, "post-upgrade step %s", step.condition)
+		}
+
+		finished := finishedCondition(job)
+		if finished == nil {
+			setCondition(step, metav1.ConditionUnknown, "Running", "Job "+job.Name+" is running")
+			return false, nil
+		}
+		if finished.Type == batchv1.JobFailed {
+			message := r.jobMessage(ctx, job)
+			if message == "" {
+				message = finished.Message
+			}
+			log.Info("Post-upgrade step failed", "cluster", cluster.Name, "step", step.condition, "job", job.Name)
+			setCondition(step, metav1.ConditionFalse, "Failed", message)
+			return true, dropPostUpgradeRole(ctx, exec)
+		}
+		setCondition(step, metav1.ConditionTrue, "Completed", "")
+	}
+	if jobs {
+		if err := dropPostUpgradeRole(ctx, exec); err != nil {
+			return false, err
+		}
+	}
+
+	for _, step := range pending {
+		if step.command != nil {
+			continue
+		}
+		log.Info("Running post-upgrade step", "cluster", cluster.Name, "step", step.condition)
+
+		if err := step.run(ctx, exec); err != nil {
+			setCondition(step, metav1.ConditionFalse, "Failed", err.Error())
+			return false, errors.Wrapf(err, "post-upgrade step %s", step.condition)
+		}
+		setCondition(step, metav1.ConditionTrue, "Completed", "")
+	}
+
+	return true, nil
+}
+
+// reconcilePostUpgradeJob returns the Job of step. When there is none, the
+// role of postUpgradeUser is created on the primary and the Job is created.
+func (r *PGUpgradeReconciler) reconcilePostUpgradeJob(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+	exec postgres.Executor, step postUpgradeStep,
+) (*batchv1.Job, error) {
+	job := &batchv1.Job{ObjectMeta: upgradeObjectMeta(upgrade, step.name)}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(job), job)
+	if !k8serrors.IsNotFound(err) {
+		return job, errors.Wrap(err, "get job")
+	}
+
+	password, err := r.reconcileRolePassword(ctx, upgrade, "post-upgrade")
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := pgpassword.NewSCRAMPassword(password).Build()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if _, stderr, err := exec.Exec(ctx, strings.NewReader(upgradeRoleSQL), map[string]string{
+		"ON_ERROR_STOP": "on",
+		"QUIET":         "on",
+		"username":      postUpgradeUser,
+		"verifier":      verifier,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "create role: %s", strings.TrimSpace(stderr))
+	}
+
+	job = generatePostUpgradeJob(cluster, upgrade, step)
+	if err := controllerutil.SetControllerReference(upgrade, job, r.Client.Scheme()); err != nil {
+		return nil, errors.Wrap(err, "set controller reference")
+	}
+	return job, errors.Wrap(r.Client.Create(ctx, job), "create job")
+}
+
+// dropPostUpgradeRole drops the role of postUpgradeUser on the primary.
+func dropPostUpgradeRole(ctx context.Context, exec postgres.Executor) error {
+	_, stderr, err := exec.Exec(ctx, strings.NewReader(upgradeDropRoleSQL), map[string]string{
+		"ON_ERROR_STOP": "on",
+		"QUIET":         "on",
+		"username":      postUpgradeUser,
+	})
+	return errors.Wrapf(err, "drop role: %s", strings.TrimSpace(stderr))
+}
+
+// generatePostUpgradeJob returns a Job that runs the command of step with the
+// image of the new PostgreSQL version. It connects to the primary Service of
+// cluster as postUpgradeUser.
+func generatePostUpgradeJob(
+	cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade, step postUpgradeStep,
+) *batchv1.Job {
+	job := &batchv1.Job{ObjectMeta: upgradeObjectMeta(upgrade, step.name)}
+	job.Spec.Template.ObjectMeta = metav1.ObjectMeta{
+		Labels:      job.Labels,
+		Annotations: job.Annotations,
+	}
+
+	password := &corev1.SecretKeySelector{Key: "password"}
+	password.Name = upgradeObjectMeta(upgrade, "post-upgrade").Name
+
+	job.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name:            step.name,
+		Command:         step.command,
+		Image:           upgrade.Spec.ToPostgresImage,
+		ImagePullPolicy: upgrade.Spec.ImagePullPolicy,
+		Resources:       upgrade.Spec.Resources,
+		SecurityContext: initialize.RestrictedSecurityContext(cluster.CompareVersion("2.5.0") >= 0),
+		Env: []corev1.EnvVar{
+			{Name: "PGHOST", Value: primaryHost(cluster)},
+			{Name: "PGPORT", Value: fmt.Sprint(clusterPort(cluster))},
+			{Name: "PGUSER", Value: postUpgradeUser},
+			{Name: "PGSSLMODE", Value: "require"},
+			{Name: "PGPASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: password}},
+		},
+
+		// The output of the command is reported when the step fails.
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+	}}
+
+	// Attempt the step exactly once.
+	job.Spec.BackoffLimit = initialize.Int32(0)
+	job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	job.Spec.Template.Spec.ImagePullSecrets = upgrade.Spec.ImagePullSecrets
+	job.Spec.Template.Spec.SecurityContext = initialize.PodSecurityContext()
+	job.Spec.Template.Spec.AutomountServiceAccountToken = initialize.Bool(false)
+	job.Spec.Template.Spec.EnableServiceLinks = initialize.Bool(false)
+
+	job.Spec.Template.Spec.Affinity = upgrade.Spec.Affinity
+	job.Spec.Template.Spec.PriorityClassName = initialize.FromPointer(upgrade.Spec.PriorityClassName)
+	job.Spec.Template.Spec.Tolerations = upgrade.Spec.Tolerations
+
+	return job
+}
+
+// upgradedPrimary returns the running primary pod of cluster when the cluster
+// is ready and runs the PostgreSQL image of upgrade. It returns nil otherwise.
+func (r *PGUpgradeReconciler) upgradedPrimary(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) *corev1.Pod {
+	if cluster.Status.State != pgv2.AppStateReady {
+		return nil
+	}
+
+	primary, err := perconaPG.GetPrimaryPod(ctx, r.Client, cluster)
+	if err != nil || primary.Status.Phase != corev1.PodRunning {
+		return nil
+	}
+
+	for _, container := range primary.Spec.Containers {
+		if container.Name == naming.ContainerDatabase && container.Image == upgrade.Spec.ToPostgresImage {
+			return primary
+		}
+	}
+	return nil
+}
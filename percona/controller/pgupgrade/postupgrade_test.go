@@ -0,0 +1,230 @@
+package pgupgrade
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestRemoveOldDataCommand(t *testing.T) {
+	command := removeOldDataCommand(15)
+	assert.DeepEqual(t, command[:3], []string{"bash", "-ceu", "--"})
+	assert.DeepEqual(t, command[4:], []string{"remove", "15"})
+	assert.Assert(t, strings.Contains(command[3], `rm -rf "$(realpath pg"${old_version}"/pg_wal)" pg"${old_version}"`))
+}
+
+func TestUpdateExtensionsCommand(t *testing.T) {
+	command := updateExtensionsCommand()
+	assert.DeepEqual(t, command[:3], []string{"bash", "-ceu", "--"})
+	assert.DeepEqual(t, command[4:], []string{"update", updateExtensionsSQL})
+	assert.Assert(t, strings.Contains(command[3], "datallowconn"))
+}
+
+func TestReconcilePostUpgrade(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	assert.NilError(t, clientgoscheme.AddToScheme(s))
+	assert.NilError(t, pgv2.AddToScheme(s))
+
+	cluster := &pgv2.PerconaPGCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "hippo", Namespace: "ns"},
+		Spec:       pgv2.PerconaPGClusterSpec{CRVersion: "2.6.0"},
+	}
+	cluster.Status.PatroniVersion = "4.0.0"
+
+	primary := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hippo-instance-0",
+			Namespace: "ns",
+			Labels: map[string]string{
+				"app.kubernetes.io/instance": "hippo",
+				naming.LabelRole:             "primary",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: naming.ContainerDatabase, Image: "postgres:17"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	upgrade := &pgv2.PerconaPGUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "to-17", Namespace: "ns", UID: "uid"},
+	}
+	upgrade.Spec.FromPostgresVersion = 16
+	upgrade.Spec.ToPostgresImage = "postgres:17"
+
+	var commands []string
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(primary).Build()
+	r := &PGUpgradeReconciler{
+		Client: cl,
+		PodExec: func(
+			_ context.Context, namespace, pod, container string,
+			stdin io.Reader, _, _ io.Writer, command ...string,
+		) error {
+			assert.Equal(t, namespace, "ns")
+			assert.Equal(t, pod, "hippo-instance-0")
+			assert.Equal(t, container, naming.ContainerDatabase)
+
+			args := strings.Join(command, " ")
+			if stdin != nil {
+				b, err := io.ReadAll(stdin)
+				assert.NilError(t, err)
+				args += " " + string(b)
+			}
+			commands = append(commands, args)
+			return nil
+		},
+	}
+
+	finish := func(t *testing.T, name string, condition batchv1.JobConditionType) {
+		job := &batchv1.Job{}
+		assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: name}, job))
+		job.Status.Conditions = []batchv1.JobCondition{{
+			Type: condition, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded",
+		}}
+		assert.NilError(t, cl.Status().Update(ctx, job))
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		done, err := r.reconcilePostUpgrade(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, done)
+		assert.Equal(t, len(upgrade.Status.Conditions), 0)
+	})
+
+	upgrade.Spec.PostUpgrade = &pgv2.PostUpgradeSpec{
+		Analyze:          true,
+		UpdateExtensions: true,
+		RemoveOldData:    true,
+	}
+
+	t.Run("ClusterNotReady", func(t *testing.T) {
+		done, err := r.reconcilePostUpgrade(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+		assert.Equal(t, len(commands), 0)
+		assert.Equal(t, len(upgrade.Status.Conditions), 3)
+
+		cond := meta.FindStatusCondition(upgrade.Status.Conditions, pgv2.ConditionPostUpgradeAnalyze)
+		assert.Equal(t, cond.Status, metav1.ConditionUnknown)
+		assert.Equal(t, cond.Reason, "Waiting")
+	})
+
+	cluster.Status.State = pgv2.AppStateReady
+
+	t.Run("Job", func(t *testing.T) {
+		done, err := r.reconcilePostUpgrade(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+
+		// The role the Job connects with is created first.
+		assert.Equal(t, len(commands), 1)
+		assert.Assert(t, strings.Contains(commands[0], "CREATE ROLE"))
+
+		job := &batchv1.Job{}
+		assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "to-17-analyze"}, job))
+		assert.Equal(t, job.OwnerReferences[0].Name, "to-17")
+		container := job.Spec.Template.Spec.Containers[0]
+		assert.Equal(t, container.Image, "postgres:17")
+		assert.DeepEqual(t, container.Command, []string{"vacuumdb", "--all", "--analyze-in-stages"})
+		assert.DeepEqual(t, container.Env[:4], []corev1.EnvVar{
+			{Name: "PGHOST", Value: "hippo-primary.ns.svc"},
+			{Name: "PGPORT", Value: "5432"},
+			{Name: "PGUSER", Value: postUpgradeUser},
+			{Name: "PGSSLMODE", Value: "require"},
+		})
+		assert.Equal(t, container.Env[4].ValueFrom.SecretKeyRef.Name, "to-17-post-upgrade")
+
+		secret := &corev1.Secret{}
+		assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "to-17-post-upgrade"}, secret))
+		assert.Assert(t, len(secret.Data["password"]) > 0)
+
+		cond := meta.FindStatusCondition(upgrade.Status.Conditions, pgv2.ConditionPostUpgradeAnalyze)
+		assert.Equal(t, cond.Status, metav1.ConditionUnknown)
+		assert.Equal(t, cond.Reason, "Running")
+
+		// Nothing changes while the Job runs.
+		done, err = r.reconcilePostUpgrade(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+		assert.Equal(t, len(commands), 1)
+	})
+
+	t.Run("JobFails", func(t *testing.T) {
+		commands = nil
+		finish(t, "to-17-analyze", batchv1.JobComplete)
+
+		done, err := r.reconcilePostUpgrade(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+		assert.Assert(t, meta.IsStatusConditionTrue(upgrade.Status.Conditions, pgv2.ConditionPostUpgradeAnalyze))
+		assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "to-17-update-extensions"}, &batchv1.Job{}))
+
+		commands = nil
+		finish(t, "to-17-update-extensions", batchv1.JobFailed)
+
+		done, err = r.reconcilePostUpgrade(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, done)
+
+		cond := meta.FindStatusCondition(upgrade.Status.Conditions, pgv2.ConditionPostUpgradeUpdateExtensions)
+		assert.Equal(t, cond.Status, metav1.ConditionFalse)
+		assert.Equal(t, cond.Reason, "Failed")
+		assert.Equal(t, cond.Message, "BackoffLimitExceeded")
+
+		// The role is dropped and the following steps do not run.
+		assert.Equal(t, len(commands), 1)
+		assert.Assert(t, strings.Contains(commands[0], "DROP ROLE"))
+		cond = meta.FindStatusCondition(upgrade.Status.Conditions, pgv2.ConditionPostUpgradeRemoveOldData)
+		assert.Equal(t, cond.Status, metav1.ConditionUnknown)
+	})
+
+	t.Run("Completed", func(t *testing.T) {
+		// Deleting the failed Job runs its step again.
+		assert.NilError(t, cl.Delete(ctx, &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns", Name: "to-17-update-extensions",
+		}}))
+
+		commands = nil
+		done, err := r.reconcilePostUpgrade(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+		assert.Equal(t, len(commands), 1)
+		assert.Assert(t, strings.Contains(commands[0], "CREATE ROLE"))
+
+		commands = nil
+		finish(t, "to-17-update-extensions", batchv1.JobComplete)
+
+		done, err = r.reconcilePostUpgrade(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, done)
+		assert.Equal(t, len(commands), 2)
+		assert.Assert(t, strings.Contains(commands[0], "DROP ROLE"))
+		assert.Assert(t, strings.Contains(commands[1], "remove 16"))
+
+		for _, cond := range upgrade.Status.Conditions {
+			assert.Equal(t, cond.Status, metav1.ConditionTrue, "condition %s", cond.Type)
+		}
+
+		// Completed steps do not run again.
+		done, err = r.reconcilePostUpgrade(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, done)
+		assert.Equal(t, len(commands), 2)
+	})
+}
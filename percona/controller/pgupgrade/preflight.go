@@ -0,0 +1,570 @@
+package pgupgrade
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/postgres"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/extensions"
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	perconaPG "github.com/fulviodenza/percona-postgresql-operator/percona/postgres"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+// Names of the pre-flight checks in the report.
+const (
+	checkRegTypes             = "regTypes"
+	checkPreparedTransactions = "preparedTransactions"
+	checkLogicalSlots         = "logicalReplicationSlots"
+	checkCustomExtensions     = "customExtensions"
+	checkPGUpgrade            = "pgUpgradeCheck"
+)
+
+// cloneProvisioningTimeout is how long the "pg_upgrade --check" Job waits for
+// the volume clones to be bound. Storage that cannot clone volumes leaves the
+// clones pending forever.
+const cloneProvisioningTimeout = 15 * time.Minute
+
+// preflightSQL prints the objects of the current database that are relevant
+// to the pre-flight checks as JSON, one object per line.
+// - https://www.postgresql.org/docs/current/pgupgrade.html#id-1.9.5.12.7
+const preflightSQL = `
+SET client_min_messages = WARNING;
+\pset tuples_only on
+\pset format unaligned
+
+-- pg_upgrade cannot upgrade columns of reg* types that reference OIDs that
+-- change between versions.
+SELECT json_build_object('check', 'regTypes', 'detail',
+  format('%s.%s.%s.%s', current_database(), n.nspname, c.relname, a.attname))
+  FROM pg_catalog.pg_attribute a
+  JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+  JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+  WHERE a.attnum > 0 AND NOT a.attisdropped
+    AND c.relkind IN ('r', 'm')
+    AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+    AND a.atttypid IN (
+      SELECT t.oid FROM pg_catalog.pg_type t WHERE t.typnamespace = 'pg_catalog'::regnamespace
+        AND t.typname IN ('regcollation', 'regconfig', 'regdictionary', 'regnamespace',
+          'regoper', 'regoperator', 'regproc', 'regprocedure')
+      UNION ALL
+      SELECT t.typarray FROM pg_catalog.pg_type t WHERE t.typnamespace = 'pg_catalog'::regnamespace
+        AND t.typname IN ('regcollation', 'regconfig', 'regdictionary', 'regnamespace',
+          'regoper', 'regoperator', 'regproc', 'regprocedure'));
+
+SELECT json_build_object('check', 'preparedTransactions', 'detail',
+  format('%s.%s', database, gid))
+  FROM pg_catalog.pg_prepared_xacts
+  WHERE database = current_database();
+
+SELECT json_build_object('check', 'logicalReplicationSlots', 'detail',
+  format('%s.%s', database, slot_name))
+  FROM pg_catalog.pg_replication_slots
+  WHERE slot_type = 'logical' AND database = current_database();
+
+SELECT json_build_object('check', 'customExtensions', 'detail', extname)
+  FROM pg_catalog.pg_extension;
+`
+
+// preflightObject is an object found by preflightSQL.
+type preflightObject struct {
+	Check  string `json:"check"`
+	Detail string `json:"detail"`
+}
+
+// onlineChecks runs the checks that do not need the cluster to be stopped
+// on the primary and returns their results.
+func onlineChecks(
+	ctx context.Context, exec postgres.Executor,
+	cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) ([]pgv2.PreflightCheck, error) {
+	stdout, stderr, err := exec.ExecInAllDatabases(ctx, preflightSQL, map[string]string{
+		"ON_ERROR_STOP": "on", // Abort when any one command fails.
+		"QUIET":         "on", // Do not print successful commands to stdout.
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "pre-flight checks: %s", strings.TrimSpace(stderr))
+	}
+
+	found := make(map[string][]string)
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var object preflightObject
+		if err := json.Unmarshal([]byte(line), &object); err != nil {
+			return nil, errors.Wrap(err, "parse pre-flight checks")
+		}
+		found[object.Check] = append(found[object.Check], object.Detail)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	checks := []pgv2.PreflightCheck{
+		failIfAny(checkRegTypes, found[checkRegTypes],
+			"columns of reg* data types other than regclass, regrole and regtype cannot be upgraded"),
+		failIfAny(checkPreparedTransactions, found[checkPreparedTransactions],
+			"prepared transactions must be committed or rolled back before the upgrade"),
+	}
+
+	slots := pgv2.PreflightCheck{Name: checkLogicalSlots, Result: pgv2.PreflightResultPassed}
+	if details := found[checkLogicalSlots]; len(details) > 0 {
+		slots.Result = pgv2.PreflightResultWarning
+		slots.Details = details
+		slots.Message = "logical replication slots are not migrated and must be recreated after the upgrade"
+		if upgrade.Spec.FromPostgresVersion >= 17 {
+			slots.Message = "logical replication slots are migrated only when they consumed all WAL before the upgrade"
+		}
+	}
+	checks = append(checks, slots)
+
+	return append(checks, customExtensionsCheck(cluster, upgrade, found[checkCustomExtensions])), nil
+}
+
+// failIfAny returns a failed check when there are details and a passed check
+// otherwise.
+func failIfAny(name string, details []string, message string) pgv2.PreflightCheck {
+	if len(details) == 0 {
+		return pgv2.PreflightCheck{Name: name, Result: pgv2.PreflightResultPassed}
+	}
+	return pgv2.PreflightCheck{
+		Name:    name,
+		Result:  pgv2.PreflightResultFailed,
+		Message: message,
+		Details: details,
+	}
+}
+
+// customExtensionsCheck checks the custom extensions of cluster that are
+// created in some database. Their archives for the new version are installed
+// by the upgrade. The checksum in the spec is verified against the archive of
+// every version, so it must match the archive for the new version as well.
+func customExtensionsCheck(
+	cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade, created []string,
+) pgv2.PreflightCheck {
+	check := pgv2.PreflightCheck{Name: checkCustomExtensions, Result: pgv2.PreflightResultPassed}
+
+	var checksums []string
+	for _, extension := range cluster.Spec.Extensions.Custom {
+		if !slices.Contains(created, extension.Name) {
+			continue
+		}
+
+		key := extensions.GetExtensionKey(upgrade.Spec.ToPostgresVersion, extension.Name, extension.Version)
+		if !cluster.Spec.Extensions.Storage.Enabled() {
+			check.Result = pgv2.PreflightResultFailed
+			check.Message = "the extension storage is not configured"
+			check.Details = append(check.Details, key)
+			continue
+		}
+		if extension.Checksum != "" {
+			checksums = append(checksums, key)
+		}
+	}
+
+	if len(checksums) > 0 && check.Result == pgv2.PreflightResultPassed {
+		check.Result = pgv2.PreflightResultWarning
+		check.Message = "the checksum in the spec must match the archive for the new version"
+		check.Details = checksums
+	}
+	return check
+}
+
+// checkCommand returns an entrypoint that runs "pg_upgrade --check" against
+// a clone of the data directory of a running primary. The clone is recovered
+// and shut down first because pg_upgrade requires a cleanly shut down cluster.
+func checkCommand(oldVersion, newVersion int) []string {
+	args := []string{fmt.Sprint(oldVersion), fmt.Sprint(newVersion)}
+	script := strings.Join([]string{
+		`declare -r data_volume='/pgdata' old_version="$1" new_version="$2"`,
+		`printf 'Checking PostgreSQL upgrade from version "%s" to "%s" ...\n\n' "$@"`,
+
+		// Enable nss_wrapper so the current UID and GID resolve to "postgres".
+		// This is the same as in the upgrade Job.
+		`gid=$(id -G); NSS_WRAPPER_GROUP=$(mktemp)`,
+		`(sed "/^postgres:x:/ d; /^[^:]*:x:${gid%% *}:/ d" /etc/group`,
+		`echo "postgres:x:${gid%% *}:") > "${NSS_WRAPPER_GROUP}"`,
+		`uid=$(id -u); NSS_WRAPPER_PASSWD=$(mktemp)`,
+		`(sed "/^postgres:x:/ d; /^[^:]*:x:${uid}:/ d" /etc/passwd`,
+		`echo "postgres:x:${uid}:${gid%% *}::${data_volume}:") > "${NSS_WRAPPER_PASSWD}"`,
+		`export LD_PRELOAD='libnss_wrapper.so' NSS_WRAPPER_GROUP NSS_WRAPPER_PASSWD`,
+
+		`cd /pgdata || exit`,
+		`echo -e "Step 1: Recovering the clone of the old pgdata directory...\n"`,
+		`chmod 700 /pgdata/pg"${old_version}"`,
+		`rm -f /pgdata/pg"${old_version}"/postmaster.pid`,
+		`/usr/pgsql-"${old_version}"/bin/pg_ctl --pgdata=/pgdata/pg"${old_version}" --wait --timeout=3600 \`,
+		`--options="-c listen_addresses='' -c unix_socket_directories=/tmp -c archive_mode=off" start`,
+		`/usr/pgsql-"${old_version}"/bin/pg_ctl --pgdata=/pgdata/pg"${old_version}" --wait --mode=fast stop`,
+
+		`echo -e "\nStep 2: Initializing new pgdata directory...\n"`,
+		`rm -rf /pgdata/pg"${new_version}"`,
+		`/usr/pgsql-"${new_version}"/bin/initdb -k -D /pgdata/pg"${new_version}"`,
+		`echo "shared_preload_libraries = '$(/usr/pgsql-"""${old_version}"""/bin/postgres -D \`,
+		`/pgdata/pg"""${old_version}""" -C shared_preload_libraries)'" >> /pgdata/pg"${new_version}"/postgresql.conf`,
+
+		`echo -e "\nStep 3: Running pg_upgrade check...\n"`,
+		`/usr/pgsql-"${new_version}"/bin/pg_upgrade --old-bindir /usr/pgsql-"${old_version}"/bin \`,
+		`--new-bindir /usr/pgsql-"${new_version}"/bin --old-datadir /pgdata/pg"${old_version}" \`,
+		`--new-datadir /pgdata/pg"${new_version}" --link --check`,
+
+		`echo "Clusters are compatible" > /dev/termination-log`,
+	}, "\n")
+
+	return append([]string{"bash", "-ceu", "--", script, "check"}, args...)
+}
+
+// checkObjectMeta returns the ObjectMeta of the objects of the "pg_upgrade
+// --check" Job of upgrade. The suffix distinguishes the volume clones.
+func checkObjectMeta(upgrade *pgv2.PerconaPGUpgrade, suffix string) metav1.ObjectMeta {
+	name := upgrade.Name + "-check"
+	if suffix != "" {
+		name += "-" + suffix
+	}
+
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: upgrade.Namespace,
+		Labels: labels.Merge(upgrade.Spec.Metadata.GetLabelsOrNil(), map[string]string{
+			pNaming.LabelUpgradeCheck: upgrade.Name,
+		}),
+		Annotations: upgrade.Spec.Metadata.GetAnnotationsOrNil(),
+	}
+}
+
+// cloneVolumeClaim returns a PersistentVolumeClaim that clones source.
+func cloneVolumeClaim(meta metav1.ObjectMeta, source *corev1.PersistentVolumeClaim) *corev1.PersistentVolumeClaim {
+	clone := &corev1.PersistentVolumeClaim{ObjectMeta: meta}
+	clone.Spec.AccessModes = source.Spec.AccessModes
+	clone.Spec.StorageClassName = source.Spec.StorageClassName
+	clone.Spec.VolumeMode = source.Spec.VolumeMode
+	clone.Spec.DataSource = &corev1.TypedLocalObjectReference{
+		Kind: "PersistentVolumeClaim",
+		Name: source.Name,
+	}
+
+	// The clone must be at least as large as the source.
+	size := source.Spec.Resources.Requests[corev1.ResourceStorage]
+	if capacity, ok := source.Status.Capacity[corev1.ResourceStorage]; ok && capacity.Cmp(size) > 0 {
+		size = capacity
+	}
+	clone.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: size}
+
+	return clone
+}
+
+// generateCheckJob returns a Job that runs "pg_upgrade --check" with the pod
+// template of the primary instance. The volume claims of the template are
+// replaced by the claims in clones.
+func generateCheckJob(
+	cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+	primary *appsv1.StatefulSet, clones map[string]string,
+) *batchv1.Job {
+	job := &batchv1.Job{ObjectMeta: checkObjectMeta(upgrade, "")}
+
+	var database corev1.Container
+	for _, container := range primary.Spec.Template.Spec.Containers {
+		if container.Name == naming.ContainerDatabase {
+			database = container
+		}
+	}
+
+	primary.Spec.Template.DeepCopyInto(&job.Spec.Template)
+	job.Spec.Template.ObjectMeta = metav1.ObjectMeta{
+		Labels:      job.Labels,
+		Annotations: job.Annotations,
+	}
+
+	for i := range job.Spec.Template.Spec.Volumes {
+		volume := &job.Spec.Template.Spec.Volumes[i]
+		if volume.PersistentVolumeClaim != nil {
+			volume.PersistentVolumeClaim.ClaimName = clones[volume.Name]
+		}
+	}
+
+	// Attempt the check exactly once.
+	job.Spec.BackoffLimit = initialize.Int32(0)
+	job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	job.Spec.Template.Spec.ImagePullSecrets = upgrade.Spec.ImagePullSecrets
+
+	initContainers, volumeMounts := extensionInitContainers(cluster, upgrade)
+	job.Spec.Template.Spec.EphemeralContainers = nil
+	job.Spec.Template.Spec.InitContainers = append(slices.Clone(upgrade.Spec.InitContainers), initContainers...)
+
+	volumeMounts = append(append(slices.Clone(database.VolumeMounts), upgrade.Spec.VolumeMounts...), volumeMounts...)
+
+	job.Spec.Template.Spec.Containers = []corev1.Container{{
+		// There is a downward API volume that refers back to the container by
+		// name, so use the name of the database container.
+		Name:            database.Name,
+		SecurityContext: database.SecurityContext,
+		VolumeMounts:    volumeMounts,
+
+		Command: checkCommand(
+			upgrade.Spec.FromPostgresVersion,
+			upgrade.Spec.ToPostgresVersion),
+		Image:           initialize.FromPointer(upgrade.Spec.Image),
+		ImagePullPolicy: upgrade.Spec.ImagePullPolicy,
+		Resources:       upgrade.Spec.Resources,
+
+		// The output of pg_upgrade is reported when the check fails.
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+	}}
+
+	job.Spec.Template.Spec.Affinity = upgrade.Spec.Affinity
+	job.Spec.Template.Spec.PriorityClassName = initialize.FromPointer(upgrade.Spec.PriorityClassName)
+	job.Spec.Template.Spec.Tolerations = upgrade.Spec.Tolerations
+
+	return job
+}
+
+// reconcileCheckJob runs "pg_upgrade --check" against clones of the volumes
+// of primary. It returns nil until the Job has finished.
+func (r *PGUpgradeReconciler) reconcileCheckJob(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade, primary *corev1.Pod,
+) (*pgv2.PreflightCheck, error) {
+	job := &batchv1.Job{ObjectMeta: checkObjectMeta(upgrade, "")}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(job), job)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, errors.Wrap(err, "get check job")
+	}
+
+	if k8serrors.IsNotFound(err) {
+		sts, err := r.primaryStatefulSet(ctx, primary)
+		if err != nil {
+			return nil, err
+		}
+
+		// The volumes are cloned one at a time, so the clones of separate data
+		// and WAL volumes would be taken at different points in time.
+		if slices.ContainsFunc(sts.Spec.Template.Spec.Volumes, func(volume corev1.Volume) bool {
+			return volume.Name == postgres.WALVolumeMount().Name
+		}) {
+			return &pgv2.PreflightCheck{
+				Name:    checkPGUpgrade,
+				Result:  pgv2.PreflightResultWarning,
+				Message: "skipped: clusters with a separate WAL volume are not supported",
+			}, nil
+		}
+
+		clones := make(map[string]string)
+		for _, volume := range sts.Spec.Template.Spec.Volumes {
+			if volume.PersistentVolumeClaim == nil {
+				continue
+			}
+
+			source := &corev1.PersistentVolumeClaim{}
+			key := client.ObjectKey{Namespace: sts.Namespace, Name: volume.PersistentVolumeClaim.ClaimName}
+			if err := r.Client.Get(ctx, key, source); err != nil {
+				return nil, errors.Wrapf(err, "get volume %s", key.Name)
+			}
+
+			clone := cloneVolumeClaim(checkObjectMeta(upgrade, volume.Name), source)
+			if err := controllerutil.SetControllerReference(upgrade, clone, r.Client.Scheme()); err != nil {
+				return nil, errors.Wrap(err, "set controller reference")
+			}
+			if err := r.Client.Create(ctx, clone); client.IgnoreAlreadyExists(err) != nil {
+				return nil, errors.Wrapf(err, "clone volume %s", key.Name)
+			}
+			clones[volume.Name] = clone.Name
+		}
+
+		job = generateCheckJob(cluster, upgrade, sts, clones)
+		if err := controllerutil.SetControllerReference(upgrade, job, r.Client.Scheme()); err != nil {
+			return nil, errors.Wrap(err, "set controller reference")
+		}
+		return nil, errors.Wrap(r.Client.Create(ctx, job), "create check job")
+	}
+
+	var check *pgv2.PreflightCheck
+	if finished := finishedCondition(job); finished != nil {
+		check = &pgv2.PreflightCheck{
+			Name:    checkPGUpgrade,
+			Result:  pgv2.PreflightResultPassed,
+			Message: r.jobMessage(ctx, job),
+		}
+		if finished.Type == batchv1.JobFailed {
+			check.Result = pgv2.PreflightResultFailed
+			if check.Message == "" {
+				check.Message = finished.Message
+			}
+		}
+	} else {
+		pending, err := r.pendingCheckClone(ctx, upgrade)
+		if err != nil || pending == nil {
+			return nil, err
+		}
+
+		check = &pgv2.PreflightCheck{
+			Name:   checkPGUpgrade,
+			Result: pgv2.PreflightResultFailed,
+			Message: fmt.Sprintf("volume clone %s was not provisioned within %s; "+
+				"the storage class must support CSI volume cloning", pending.Name, cloneProvisioningTimeout),
+		}
+
+		// The pods of the Job cannot start without the clone.
+		if err := r.Client.Delete(ctx, job,
+			client.PropagationPolicy(metav1.DeletePropagationBackground),
+		); client.IgnoreNotFound(err) != nil {
+			return nil, errors.Wrap(err, "delete check job")
+		}
+	}
+
+	// The clones can be as large as the volumes of the primary. They are
+	// deleted once the pods of the Job are gone.
+	clones := &corev1.PersistentVolumeClaimList{}
+	if err := r.Client.List(ctx, clones,
+		client.InNamespace(upgrade.Namespace),
+		client.MatchingLabels{pNaming.LabelUpgradeCheck: upgrade.Name},
+	); err != nil {
+		return nil, errors.Wrap(err, "list volume clones")
+	}
+	for i := range clones.Items {
+		if err := r.Client.Delete(ctx, &clones.Items[i]); client.IgnoreNotFound(err) != nil {
+			return nil, errors.Wrapf(err, "delete volume clone %s", clones.Items[i].Name)
+		}
+	}
+
+	return check, nil
+}
+
+// pendingCheckClone returns a volume clone of the "pg_upgrade --check" Job of
+// upgrade that has been pending longer than cloneProvisioningTimeout, if any.
+func (r *PGUpgradeReconciler) pendingCheckClone(
+	ctx context.Context, upgrade *pgv2.PerconaPGUpgrade,
+) (*corev1.PersistentVolumeClaim, error) {
+	clones := &corev1.PersistentVolumeClaimList{}
+	if err := r.Client.List(ctx, clones,
+		client.InNamespace(upgrade.Namespace),
+		client.MatchingLabels{pNaming.LabelUpgradeCheck: upgrade.Name},
+	); err != nil {
+		return nil, errors.Wrap(err, "list volume clones")
+	}
+
+	for i := range clones.Items {
+		clone := &clones.Items[i]
+		if clone.Status.Phase == corev1.ClaimPending &&
+			time.Since(clone.CreationTimestamp.Time) > cloneProvisioningTimeout {
+			return clone, nil
+		}
+	}
+	return nil, nil
+}
+
+// finishedCondition returns the condition of job that tells it completed or
+// failed, if any.
+func finishedCondition(job *batchv1.Job) *batchv1.JobCondition {
+	for i, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) &&
+			condition.Status == corev1.ConditionTrue {
+			return &job.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// jobMessage returns the termination message of the container of job.
+func (r *PGUpgradeReconciler) jobMessage(ctx context.Context, job *batchv1.Job) string {
+	selector, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return ""
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods,
+		client.InNamespace(job.Namespace), client.MatchingLabelsSelector{Selector: selector},
+	); err != nil {
+		return ""
+	}
+
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil {
+				return strings.TrimSpace(status.State.Terminated.Message)
+			}
+		}
+	}
+	return ""
+}
+
+// reconcileDryRun runs the pre-flight checks of upgrade and publishes the
+// report in its status. It returns true once the report is complete.
+func (r *PGUpgradeReconciler) reconcileDryRun(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) (bool, error) {
+	log := logging.FromContext(ctx)
+
+	report := upgrade.Status.Preflight
+	if report == nil {
+		report = &pgv2.PreflightReport{State: pgv2.PreflightRunning}
+		upgrade.Status.Preflight = report
+	}
+	if report.State != pgv2.PreflightRunning {
+		return true, nil
+	}
+
+	primary, err := perconaPG.GetPrimaryPod(ctx, r.Client, cluster)
+	if err != nil || primary.Status.Phase != corev1.PodRunning {
+		log.V(1).Info("Waiting for primary pod to run pre-flight checks")
+		return false, nil
+	}
+
+	has := func(name string) bool {
+		return slices.ContainsFunc(report.Checks, func(check pgv2.PreflightCheck) bool {
+			return check.Name == name
+		})
+	}
+
+	if !has(checkRegTypes) {
+		exec := r.databaseExecutor(primary)
+
+		checks, err := onlineChecks(ctx, exec, cluster, upgrade)
+		if err != nil {
+			return false, err
+		}
+		report.Checks = append(report.Checks, checks...)
+	}
+
+	if upgrade.Spec.DryRun.PGUpgradeCheck && !has(checkPGUpgrade) {
+		check, err := r.reconcileCheckJob(ctx, cluster, upgrade, primary)
+		if err != nil || check == nil {
+			return false, err
+		}
+		report.Checks = append(report.Checks, *check)
+	}
+
+	report.State = pgv2.PreflightPassed
+	for _, check := range report.Checks {
+		if check.Result == pgv2.PreflightResultFailed {
+			report.State = pgv2.PreflightFailed
+		}
+	}
+	now := metav1.Now()
+	report.CompletionTime = &now
+
+	log.Info("Pre-flight checks completed", "cluster", cluster.Name, "state", report.State)
+
+	return true, nil
+}
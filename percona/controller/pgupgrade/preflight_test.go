@@ -0,0 +1,323 @@
+package pgupgrade
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestOnlineChecks(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := &pgv2.PerconaPGCluster{}
+	upgrade := &pgv2.PerconaPGUpgrade{}
+	upgrade.Spec.FromPostgresVersion = 16
+	upgrade.Spec.ToPostgresVersion = 17
+
+	exec := func(
+		_ context.Context, stdin io.Reader, stdout, _ io.Writer, command ...string,
+	) error {
+		b, err := io.ReadAll(stdin)
+		assert.NilError(t, err)
+		assert.Equal(t, string(b), preflightSQL)
+		assert.Assert(t, strings.Contains(strings.Join(command, " "), "--set=ON_ERROR_STOP=on"))
+
+		_, _ = stdout.Write([]byte(`{"check" : "regTypes", "detail" : "app.public.t.proc"}
+{"check" : "logicalReplicationSlots", "detail" : "app.cdc"}
+{"check" : "customExtensions", "detail" : "plpgsql"}
+`))
+		return nil
+	}
+
+	checks, err := onlineChecks(ctx, exec, cluster, upgrade)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, checks, []pgv2.PreflightCheck{
+		{
+			Name:    checkRegTypes,
+			Result:  pgv2.PreflightResultFailed,
+			Message: "columns of reg* data types other than regclass, regrole and regtype cannot be upgraded",
+			Details: []string{"app.public.t.proc"},
+		},
+		{Name: checkPreparedTransactions, Result: pgv2.PreflightResultPassed},
+		{
+			Name:    checkLogicalSlots,
+			Result:  pgv2.PreflightResultWarning,
+			Message: "logical replication slots are not migrated and must be recreated after the upgrade",
+			Details: []string{"app.cdc"},
+		},
+		{Name: checkCustomExtensions, Result: pgv2.PreflightResultPassed},
+	})
+}
+
+func TestCustomExtensionsCheck(t *testing.T) {
+	cluster := &pgv2.PerconaPGCluster{}
+	cluster.Spec.Extensions.Custom = []pgv2.CustomExtensionSpec{
+		{Name: "pg_cron", Version: "1.6.1"},
+		{Name: "pgvector", Version: "0.7.0", Checksum: "sha256:abc"},
+	}
+	upgrade := &pgv2.PerconaPGUpgrade{}
+	upgrade.Spec.ToPostgresVersion = 17
+
+	t.Run("NotCreated", func(t *testing.T) {
+		check := customExtensionsCheck(cluster, upgrade, []string{"plpgsql"})
+		assert.Equal(t, check.Result, pgv2.PreflightResultPassed)
+	})
+
+	t.Run("NoStorage", func(t *testing.T) {
+		check := customExtensionsCheck(cluster, upgrade, []string{"pg_cron"})
+		assert.Equal(t, check.Result, pgv2.PreflightResultFailed)
+		assert.DeepEqual(t, check.Details, []string{"pg_cron-pg17-1.6.1"})
+	})
+
+	cluster.Spec.Extensions.Storage.Type = "http"
+
+	t.Run("Checksum", func(t *testing.T) {
+		check := customExtensionsCheck(cluster, upgrade, []string{"pg_cron", "pgvector"})
+		assert.Equal(t, check.Result, pgv2.PreflightResultWarning)
+		assert.DeepEqual(t, check.Details, []string{"pgvector-pg17-0.7.0"})
+	})
+}
+
+func TestCloneVolumeClaim(t *testing.T) {
+	source := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "hippo-instance1-abcd-pgdata"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: initialize.String("csi"),
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")},
+		},
+	}
+
+	clone := cloneVolumeClaim(metav1.ObjectMeta{Name: "clone"}, source)
+	assert.Equal(t, clone.Name, "clone")
+	assert.Equal(t, *clone.Spec.StorageClassName, "csi")
+	assert.DeepEqual(t, clone.Spec.AccessModes, source.Spec.AccessModes)
+	assert.DeepEqual(t, clone.Spec.DataSource, &corev1.TypedLocalObjectReference{
+		Kind: "PersistentVolumeClaim", Name: "hippo-instance1-abcd-pgdata",
+	})
+	assert.Assert(t, clone.Spec.Resources.Requests.Storage().Equal(resource.MustParse("2Gi")))
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	assert.NilError(t, clientgoscheme.AddToScheme(s))
+	assert.NilError(t, pgv2.AddToScheme(s))
+
+	cluster := &pgv2.PerconaPGCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "hippo", Namespace: "ns"},
+	}
+	cluster.Status.PatroniVersion = "4.0.0"
+
+	upgrade := &pgv2.PerconaPGUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "to-17", Namespace: "ns", UID: "uid"},
+	}
+	upgrade.Spec.FromPostgresVersion = 16
+	upgrade.Spec.ToPostgresVersion = 17
+	upgrade.Spec.Image = initialize.String("upgrade-image")
+	upgrade.Spec.DryRun = &pgv2.DryRunSpec{PGUpgradeCheck: true}
+
+	primary := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hippo-instance1-abcd-0",
+			Namespace: "ns",
+			Labels: map[string]string{
+				"app.kubernetes.io/instance": "hippo",
+				naming.LabelRole:             "primary",
+				naming.LabelInstance:         "hippo-instance1-abcd",
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "hippo-instance1-abcd", Namespace: "ns"},
+	}
+	sts.Spec.Template.Spec.Containers = []corev1.Container{
+		{Name: naming.ContainerDatabase, VolumeMounts: []corev1.VolumeMount{{Name: "postgres-data", MountPath: "/pgdata"}}},
+		{Name: "pgbackrest"},
+	}
+	sts.Spec.Template.Spec.Volumes = []corev1.Volume{
+		{Name: "postgres-data", VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "hippo-instance1-abcd-pgdata"},
+		}},
+		{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+	pgdata := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "hippo-instance1-abcd-pgdata", Namespace: "ns"},
+	}
+
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(primary, sts, pgdata).Build()
+
+	var execs int
+	r := &PGUpgradeReconciler{
+		Client: cl,
+		PodExec: func(
+			_ context.Context, _, pod, _ string, _ io.Reader, _, _ io.Writer, _ ...string,
+		) error {
+			assert.Equal(t, pod, "hippo-instance1-abcd-0")
+			execs++
+			return nil
+		},
+	}
+
+	done, err := r.reconcileDryRun(ctx, cluster, upgrade)
+	assert.NilError(t, err)
+	assert.Assert(t, !done)
+	assert.Equal(t, execs, 1)
+	assert.Equal(t, upgrade.Status.Preflight.State, pgv2.PreflightRunning)
+	assert.Equal(t, len(upgrade.Status.Preflight.Checks), 4)
+
+	// The volumes of the primary are cloned for the check Job.
+	clone := &corev1.PersistentVolumeClaim{}
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "to-17-check-postgres-data"}, clone))
+	assert.Equal(t, clone.Spec.DataSource.Name, "hippo-instance1-abcd-pgdata")
+	assert.Equal(t, clone.Labels[pNaming.LabelUpgradeCheck], "to-17")
+
+	job := &batchv1.Job{}
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "to-17-check"}, job))
+	assert.Equal(t, job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName, "to-17-check-postgres-data")
+	assert.Equal(t, len(job.Spec.Template.Spec.Containers), 1)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, container.Name, naming.ContainerDatabase)
+	assert.Equal(t, container.Image, "upgrade-image")
+	assert.DeepEqual(t, container.Command[4:], []string{"check", "16", "17"})
+	assert.Equal(t, container.TerminationMessagePolicy, corev1.TerminationMessageFallbackToLogsOnError)
+
+	// The online checks do not run again while the Job runs.
+	done, err = r.reconcileDryRun(ctx, cluster, upgrade)
+	assert.NilError(t, err)
+	assert.Assert(t, !done)
+	assert.Equal(t, execs, 1)
+
+	job.Status.Conditions = []batchv1.JobCondition{{
+		Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded",
+	}}
+	assert.NilError(t, cl.Status().Update(ctx, job))
+
+	done, err = r.reconcileDryRun(ctx, cluster, upgrade)
+	assert.NilError(t, err)
+	assert.Assert(t, done)
+	assert.Equal(t, upgrade.Status.Preflight.State, pgv2.PreflightFailed)
+	assert.Assert(t, upgrade.Status.Preflight.CompletionTime != nil)
+	assert.DeepEqual(t, upgrade.Status.Preflight.Checks[4], pgv2.PreflightCheck{
+		Name: checkPGUpgrade, Result: pgv2.PreflightResultFailed, Message: "BackoffLimitExceeded",
+	})
+
+	// The clones are deleted once the check is done.
+	clones := &corev1.PersistentVolumeClaimList{}
+	assert.NilError(t, cl.List(ctx, clones, client.MatchingLabels{pNaming.LabelUpgradeCheck: "to-17"}))
+	assert.Equal(t, len(clones.Items), 0)
+}
+
+func TestReconcileCheckJobUnsupportedVolumes(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	assert.NilError(t, clientgoscheme.AddToScheme(s))
+	assert.NilError(t, pgv2.AddToScheme(s))
+
+	cluster := &pgv2.PerconaPGCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "hippo", Namespace: "ns"},
+	}
+	upgrade := &pgv2.PerconaPGUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "to-17", Namespace: "ns", UID: "uid"},
+	}
+	upgrade.Spec.FromPostgresVersion = 16
+	upgrade.Spec.ToPostgresVersion = 17
+
+	primary := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hippo-instance1-abcd-0",
+			Namespace: "ns",
+			Labels:    map[string]string{naming.LabelInstance: "hippo-instance1-abcd"},
+		},
+	}
+
+	t.Run("WALVolume", func(t *testing.T) {
+		sts := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "hippo-instance1-abcd", Namespace: "ns"},
+		}
+		sts.Spec.Template.Spec.Volumes = []corev1.Volume{
+			{Name: "postgres-data", VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "hippo-instance1-abcd-pgdata"},
+			}},
+			{Name: "postgres-wal", VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "hippo-instance1-abcd-pgwal"},
+			}},
+		}
+
+		cl := fake.NewClientBuilder().WithScheme(s).WithObjects(primary, sts).Build()
+		r := &PGUpgradeReconciler{Client: cl}
+
+		check, err := r.reconcileCheckJob(ctx, cluster, upgrade, primary)
+		assert.NilError(t, err)
+		assert.Assert(t, check != nil)
+		assert.Equal(t, check.Result, pgv2.PreflightResultWarning)
+
+		// Nothing is cloned.
+		clones := &corev1.PersistentVolumeClaimList{}
+		assert.NilError(t, cl.List(ctx, clones))
+		assert.Equal(t, len(clones.Items), 0)
+	})
+
+	t.Run("PendingClone", func(t *testing.T) {
+		job := &batchv1.Job{ObjectMeta: checkObjectMeta(upgrade, "")}
+		clone := &corev1.PersistentVolumeClaim{ObjectMeta: checkObjectMeta(upgrade, "postgres-data")}
+		clone.Status.Phase = corev1.ClaimPending
+
+		cl := fake.NewClientBuilder().WithScheme(s).WithObjects(job, clone).Build()
+		r := &PGUpgradeReconciler{Client: cl}
+
+		// A clone that was just created is waited for.
+		assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(clone), clone))
+		clone.CreationTimestamp = metav1.Now()
+		assert.NilError(t, cl.Update(ctx, clone))
+
+		pending, err := r.pendingCheckClone(ctx, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, pending == nil)
+
+		clone.CreationTimestamp = metav1.NewTime(time.Now().Add(-cloneProvisioningTimeout - time.Minute))
+		assert.NilError(t, cl.Delete(ctx, clone))
+		clone.ResourceVersion = ""
+		assert.NilError(t, cl.Create(ctx, clone))
+
+		check, err := r.reconcileCheckJob(ctx, cluster, upgrade, primary)
+		assert.NilError(t, err)
+		assert.Assert(t, check != nil)
+		assert.Equal(t, check.Result, pgv2.PreflightResultFailed)
+		assert.Assert(t, strings.Contains(check.Message, "to-17-check-postgres-data"), check.Message)
+
+		// The Job and the clones are deleted.
+		err = cl.Get(ctx, client.ObjectKeyFromObject(job), &batchv1.Job{})
+		assert.Assert(t, k8serrors.IsNotFound(err), "got %v", err)
+		clones := &corev1.PersistentVolumeClaimList{}
+		assert.NilError(t, cl.List(ctx, clones))
+		assert.Equal(t, len(clones.Items), 0)
+	})
+}
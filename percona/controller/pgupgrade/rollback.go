@@ -0,0 +1,409 @@
+package pgupgrade
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	volumesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/feature"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	perconaPG "github.com/fulviodenza/percona-postgresql-operator/percona/postgres"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	crunchyv1beta1 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// upgradeObjectMeta returns the ObjectMeta of an object that upgrade creates
+// to roll back. The backups are not owned by the upgrade, so they are kept
+// when it is deleted.
+func upgradeObjectMeta(upgrade *pgv2.PerconaPGUpgrade, suffix string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      upgrade.Name + "-" + suffix,
+		Namespace: upgrade.Namespace,
+		Labels: labels.Merge(upgrade.Spec.Metadata.GetLabelsOrNil(), map[string]string{
+			pNaming.LabelUpgrade: upgrade.Name,
+		}),
+		Annotations: upgrade.Spec.Metadata.GetAnnotationsOrNil(),
+	}
+}
+
+// primaryStatefulSet returns the StatefulSet of the primary pod.
+func (r *PGUpgradeReconciler) primaryStatefulSet(ctx context.Context, primary *corev1.Pod) (*appsv1.StatefulSet, error) {
+	sts := &appsv1.StatefulSet{}
+	key := client.ObjectKey{Namespace: primary.Namespace, Name: primary.Labels[naming.LabelInstance]}
+	return sts, errors.Wrap(r.Client.Get(ctx, key, sts), "get primary statefulset")
+}
+
+// reconcilePreUpgradeBackup records the version and images of cluster and
+// backs it up before it is paused for the upgrade. It returns true once the
+// backup is ready.
+func (r *PGUpgradeReconciler) reconcilePreUpgradeBackup(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) (bool, error) {
+	spec := upgrade.Spec.Backup
+	if spec == nil || meta.IsStatusConditionTrue(upgrade.Status.Conditions, pgv2.ConditionPreUpgradeBackup) {
+		return true, nil
+	}
+
+	if upgrade.Status.PreUpgrade == nil {
+		upgrade.Status.PreUpgrade = &pgv2.PreUpgradeStatus{
+			PostgresVersion: cluster.Spec.PostgresVersion,
+			Image:           cluster.Spec.Image,
+			PGBackRestImage: cluster.Spec.Backups.PGBackRest.Image,
+		}
+		if cluster.Spec.Proxy != nil && cluster.Spec.Proxy.PGBouncer != nil {
+			upgrade.Status.PreUpgrade.PGBouncerImage = cluster.Spec.Proxy.PGBouncer.Image
+		}
+	}
+
+	setCondition := func(status metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(&upgrade.Status.Conditions, metav1.Condition{
+			ObservedGeneration: upgrade.Generation,
+			Type:               pgv2.ConditionPreUpgradeBackup,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+
+	var ready bool
+	var err error
+	switch {
+	case spec.Rollback && spec.RepoName != "":
+		// Only a backup in a repo can be restored to roll back.
+		ready, err = r.reconcilePreUpgradeBackupCR(ctx, cluster, upgrade)
+	case spec.VolumeSnapshotClassName != "" && feature.Enabled(ctx, feature.VolumeSnapshots):
+		ready, err = r.reconcilePreUpgradeSnapshots(ctx, cluster, upgrade)
+	case spec.RepoName != "":
+		ready, err = r.reconcilePreUpgradeBackupCR(ctx, cluster, upgrade)
+	default:
+		err = errors.Errorf("the %s feature gate is disabled and no repoName is set", feature.VolumeSnapshots)
+	}
+
+	switch {
+	case err != nil:
+		setCondition(metav1.ConditionFalse, "Failed", err.Error())
+	case ready:
+		setCondition(metav1.ConditionTrue, "Ready", "")
+	default:
+		setCondition(metav1.ConditionUnknown, "Running", "")
+	}
+	return ready, err
+}
+
+// reconcilePreUpgradeBackupCR takes a full backup of cluster with a PerconaPGBackup.
+func (r *PGUpgradeReconciler) reconcilePreUpgradeBackupCR(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) (bool, error) {
+	backup := &pgv2.PerconaPGBackup{ObjectMeta: upgradeObjectMeta(upgrade, "pre-upgrade")}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(backup), backup)
+	if k8serrors.IsNotFound(err) {
+		backup.Spec.PGCluster = cluster.Name
+		backup.Spec.RepoName = upgrade.Spec.Backup.RepoName
+		backup.Spec.Options = []string{"--type=full"}
+
+		upgrade.Status.PreUpgrade.BackupName = backup.Name
+		return false, errors.Wrap(r.Client.Create(ctx, backup), "create pre-upgrade backup")
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "get pre-upgrade backup")
+	}
+
+	upgrade.Status.PreUpgrade.BackupName = backup.Name
+	switch backup.Status.State {
+	case pgv2.BackupSucceeded:
+		return true, nil
+	case pgv2.BackupFailed:
+		return false, errors.Errorf("backup %s failed: %s", backup.Name, backup.Status.Error)
+	}
+	return false, nil
+}
+
+// reconcilePreUpgradeSnapshots takes VolumeSnapshots of the volumes of the
+// primary of cluster.
+func (r *PGUpgradeReconciler) reconcilePreUpgradeSnapshots(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) (bool, error) {
+	primary, err := perconaPG.GetPrimaryPod(ctx, r.Client, cluster)
+	if err != nil {
+		return false, errors.Wrap(err, "get primary pod")
+	}
+	sts, err := r.primaryStatefulSet(ctx, primary)
+	if err != nil {
+		return false, err
+	}
+
+	ready := true
+	var names []string
+	for _, volume := range sts.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		snapshot := &volumesnapshotv1.VolumeSnapshot{ObjectMeta: upgradeObjectMeta(upgrade, "pre-upgrade-"+volume.Name)}
+		err := r.Client.Get(ctx, client.ObjectKeyFromObject(snapshot), snapshot)
+		if k8serrors.IsNotFound(err) {
+			snapshot.Spec.Source.PersistentVolumeClaimName = &volume.PersistentVolumeClaim.ClaimName
+			snapshot.Spec.VolumeSnapshotClassName = &upgrade.Spec.Backup.VolumeSnapshotClassName
+			err = r.Client.Create(ctx, snapshot)
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "snapshot volume %s", volume.Name)
+		}
+
+		if status := snapshot.Status; status != nil && status.Error != nil && status.Error.Message != nil {
+			return false, errors.Errorf("snapshot %s failed: %s", snapshot.Name, *status.Error.Message)
+		}
+		ready = ready && snapshot.Status != nil && initialize.FromPointer(snapshot.Status.ReadyToUse)
+		names = append(names, snapshot.Name)
+	}
+
+	upgrade.Status.PreUpgrade.VolumeSnapshots = names
+	return ready, nil
+}
+
+// rollbackCommand returns an entrypoint that recovers the data directory of
+// oldVersion after a failed upgrade. pg_upgrade renames the control file of
+// the old cluster before it links the data files. The new cluster is never
+// started when the upgrade fails, so the old cluster can be used again once
+// the control file is renamed back.
+// - https://www.postgresql.org/docs/current/pgupgrade.html#PGUPGRADE-STEP-REVERT
+func rollbackCommand(oldVersion, newVersion int) []string {
+	args := []string{fmt.Sprint(oldVersion), fmt.Sprint(newVersion)}
+	script := strings.Join([]string{
+		`declare -r old_version="$1" new_version="$2"`,
+		`printf 'Rolling back PostgreSQL upgrade from version "%s" to "%s" ...\n\n' "$@"`,
+		`cd /pgdata || exit`,
+		`if [ -f pg"${old_version}"/global/pg_control.old ]; then`,
+		`  mv pg"${old_version}"/global/pg_control.old pg"${old_version}"/global/pg_control`,
+		`fi`,
+		`if [ ! -f pg"${old_version}"/global/pg_control ]; then`,
+		`  echo "Old pgdata directory cannot be recovered" >&2; exit 1`,
+		`fi`,
+		`rm -rf pg"${new_version}"`,
+		`echo -e "Rollback Job Complete!"`,
+	}, "\n")
+
+	return append([]string{"bash", "-ceu", "--", script, "rollback"}, args...)
+}
+
+// generateRollbackJob returns a Job that runs rollbackCommand with the pod
+// template of the failed upgrade Job.
+func generateRollbackJob(upgrade *pgv2.PerconaPGUpgrade, failed *batchv1.Job) *batchv1.Job {
+	job := &batchv1.Job{ObjectMeta: upgradeObjectMeta(upgrade, "rollback")}
+
+	failed.Spec.Template.DeepCopyInto(&job.Spec.Template)
+	job.Spec.Template.ObjectMeta = metav1.ObjectMeta{
+		Labels:      job.Labels,
+		Annotations: job.Annotations,
+	}
+
+	// Attempt the rollback exactly once.
+	job.Spec.BackoffLimit = initialize.Int32(0)
+	job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	// The container of the upgrade Job has the volumes of the primary and
+	// the binaries of both versions.
+	job.Spec.Template.Spec.InitContainers = nil
+	job.Spec.Template.Spec.Containers = job.Spec.Template.Spec.Containers[:1]
+	job.Spec.Template.Spec.Containers[0].Command = rollbackCommand(
+		upgrade.Spec.FromPostgresVersion, upgrade.Spec.ToPostgresVersion)
+
+	return job
+}
+
+// reconcileRollback rolls back a failed upgrade. It recovers the old data
+// directory or restores the backup taken before the upgrade, then resumes
+// cluster with the version and images it had before.
+func (r *PGUpgradeReconciler) reconcileRollback(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) (bool, error) {
+	log := logging.FromContext(ctx)
+
+	status := upgrade.Status.Rollback
+	if status == nil {
+		now := metav1.Now()
+		status = &pgv2.UpgradeRollbackStatus{
+			State:     pgv2.RollbackRunning,
+			Method:    pgv2.RollbackDataDirectory,
+			StartTime: &now,
+		}
+		upgrade.Status.Rollback = status
+		log.Info("Rolling back upgrade", "cluster", cluster.Name)
+	}
+	if status.State != pgv2.RollbackRunning {
+		return true, nil
+	}
+
+	fail := func(message string) (bool, error) {
+		now := metav1.Now()
+		status.State = pgv2.RollbackFailed
+		status.Message = message
+		status.CompletionTime = &now
+		log.Info("Rollback failed", "cluster", cluster.Name, "message", message)
+		return true, nil
+	}
+
+	var done bool
+	switch status.Method {
+	case pgv2.RollbackDataDirectory:
+		job, err := r.reconcileRollbackJob(ctx, upgrade)
+		if err != nil || job == nil {
+			return false, err
+		}
+
+		switch {
+		case jobFinished(job, batchv1.JobComplete):
+			done = true
+		case !jobFinished(job, batchv1.JobFailed):
+			return false, nil
+		case upgrade.Status.PreUpgrade != nil && upgrade.Status.PreUpgrade.BackupName != "":
+			status.Method = pgv2.RollbackRestore
+			return false, nil
+		default:
+			return fail("the old data directory cannot be recovered and there is no pre-upgrade backup to restore")
+		}
+
+	case pgv2.RollbackRestore:
+		restore, err := r.reconcileRollbackRestore(ctx, cluster, upgrade)
+		if err != nil || restore == nil {
+			return false, err
+		}
+
+		switch restore.Status.State {
+		case pgv2.RestoreSucceeded:
+			done = true
+		case pgv2.RestoreFailed:
+			return fail(fmt.Sprintf("restore %s failed; the cluster stays paused", restore.Name))
+		}
+	}
+	if !done {
+		return false, nil
+	}
+
+	if err := r.finalizeRollback(ctx, cluster, upgrade, true); err != nil {
+		return false, err
+	}
+
+	now := metav1.Now()
+	status.State = pgv2.RollbackSucceeded
+	status.CompletionTime = &now
+	log.Info("Rolled back upgrade", "cluster", cluster.Name, "method", status.Method)
+
+	return true, nil
+}
+
+// reconcileRollbackJob creates the Job that recovers the old data directory.
+// It returns nil while the Job is being created.
+func (r *PGUpgradeReconciler) reconcileRollbackJob(ctx context.Context, upgrade *pgv2.PerconaPGUpgrade) (*batchv1.Job, error) {
+	job := &batchv1.Job{ObjectMeta: upgradeObjectMeta(upgrade, "rollback")}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(job), job)
+	if !k8serrors.IsNotFound(err) {
+		return job, errors.Wrap(err, "get rollback job")
+	}
+
+	// The upgrade Job of the PGUpgrade is named after the data volume it upgrades.
+	failed := &batchv1.Job{}
+	key := client.ObjectKey{Namespace: upgrade.Namespace, Name: upgrade.Name + "-pgdata"}
+	if err := r.Client.Get(ctx, key, failed); err != nil {
+		return nil, errors.Wrap(err, "get upgrade job")
+	}
+
+	job = generateRollbackJob(upgrade, failed)
+	if err := controllerutil.SetControllerReference(upgrade, job, r.Client.Scheme()); err != nil {
+		return nil, errors.Wrap(err, "set controller reference")
+	}
+	return nil, errors.Wrap(r.Client.Create(ctx, job), "create rollback job")
+}
+
+// reconcileRollbackRestore restores the backup taken before the upgrade. It
+// returns nil while the restore is being created.
+func (r *PGUpgradeReconciler) reconcileRollbackRestore(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade,
+) (*pgv2.PerconaPGRestore, error) {
+	restore := &pgv2.PerconaPGRestore{ObjectMeta: upgradeObjectMeta(upgrade, "rollback")}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(restore), restore)
+	if !k8serrors.IsNotFound(err) {
+		return restore, errors.Wrap(err, "get rollback restore")
+	}
+
+	backup := &pgv2.PerconaPGBackup{}
+	key := client.ObjectKey{Namespace: upgrade.Namespace, Name: upgrade.Status.PreUpgrade.BackupName}
+	if err := r.Client.Get(ctx, key, backup); err != nil {
+		return nil, errors.Wrap(err, "get pre-upgrade backup")
+	}
+
+	// The restore runs against the cluster with the version and images it
+	// had before the upgrade. The cluster stays paused so that PostgreSQL
+	// doesn't start on the upgraded data directory before it is restored.
+	if err := r.finalizeRollback(ctx, cluster, upgrade, false); err != nil {
+		return nil, err
+	}
+
+	restore.Spec.PGCluster = cluster.Name
+	restore.Spec.RepoName = backup.Spec.RepoName
+	restore.Spec.Options = []string{"--set=" + backup.Status.BackupName, "--type=immediate"}
+
+	if err := controllerutil.SetControllerReference(upgrade, restore, r.Client.Scheme()); err != nil {
+		return nil, errors.Wrap(err, "set controller reference")
+	}
+	return nil, errors.Wrap(r.Client.Create(ctx, restore), "create rollback restore")
+}
+
+// finalizeRollback deletes the PGUpgrade so that it no longer manages
+// cluster, then reverts cluster to the version and images it had before the
+// upgrade. When resume is true, cluster is resumed too.
+func (r *PGUpgradeReconciler) finalizeRollback(
+	ctx context.Context, cluster *pgv2.PerconaPGCluster, upgrade *pgv2.PerconaPGUpgrade, resume bool,
+) error {
+	pgUpgrade := &crunchyv1beta1.PGUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: upgrade.Name, Namespace: upgrade.Namespace},
+	}
+	if err := r.Client.Delete(ctx, pgUpgrade,
+		client.PropagationPolicy(metav1.DeletePropagationBackground),
+	); client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, "delete PGUpgrade")
+	}
+
+	orig := cluster.DeepCopy()
+
+	delete(cluster.Annotations, pgv2.AnnotationAllowUpgrade)
+	if resume {
+		cluster.Spec.Pause = nil
+	}
+
+	if before := upgrade.Status.PreUpgrade; before != nil {
+		cluster.Spec.PostgresVersion = before.PostgresVersion
+		cluster.Spec.Image = before.Image
+		cluster.Spec.Backups.PGBackRest.Image = before.PGBackRestImage
+		if cluster.Spec.Proxy != nil && cluster.Spec.Proxy.PGBouncer != nil {
+			cluster.Spec.Proxy.PGBouncer.Image = before.PGBouncerImage
+		}
+	}
+
+	return errors.Wrap(r.Client.Patch(ctx, cluster.DeepCopy(), client.MergeFrom(orig)), "resume PGCluster")
+}
+
+// jobFinished returns true when job has the condition of type finished.
+func jobFinished(job *batchv1.Job, finished batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == finished && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
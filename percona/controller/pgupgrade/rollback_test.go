@@ -0,0 +1,254 @@
+package pgupgrade
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/feature"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/initialize"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	crunchyv1beta1 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+func TestRollbackCommand(t *testing.T) {
+	command := rollbackCommand(16, 17)
+	assert.DeepEqual(t, command[:3], []string{"bash", "-ceu", "--"})
+	assert.DeepEqual(t, command[4:], []string{"rollback", "16", "17"})
+	assert.Assert(t, strings.Contains(command[3],
+		`mv pg"${old_version}"/global/pg_control.old pg"${old_version}"/global/pg_control`))
+	assert.Assert(t, strings.Contains(command[3], `rm -rf pg"${new_version}"`))
+}
+
+func newRollbackClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+
+	s := runtime.NewScheme()
+	assert.NilError(t, clientgoscheme.AddToScheme(s))
+	assert.NilError(t, pgv2.AddToScheme(s))
+	assert.NilError(t, crunchyv1beta1.AddToScheme(s))
+
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).
+		WithStatusSubresource(&pgv2.PerconaPGCluster{}, &pgv2.PerconaPGBackup{}, &pgv2.PerconaPGRestore{}, &pgv2.PerconaPGUpgrade{}).Build()
+}
+
+func newRollbackCluster() *pgv2.PerconaPGCluster {
+	cluster := &pgv2.PerconaPGCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "hippo",
+			Namespace:   "ns",
+			Annotations: map[string]string{pgv2.AnnotationAllowUpgrade: "to-17"},
+		},
+	}
+	cluster.Spec.PostgresVersion = 16
+	cluster.Spec.Image = "postgres:16"
+	cluster.Spec.Backups.PGBackRest.Image = "pgbackrest:16"
+	cluster.Spec.Proxy = &pgv2.PGProxySpec{PGBouncer: &pgv2.PGBouncerSpec{Image: "pgbouncer:16"}}
+	return cluster
+}
+
+func newRollbackUpgrade() *pgv2.PerconaPGUpgrade {
+	upgrade := &pgv2.PerconaPGUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "to-17", Namespace: "ns", UID: "uid"},
+	}
+	upgrade.Spec.PostgresClusterName = "hippo"
+	upgrade.Spec.FromPostgresVersion = 16
+	upgrade.Spec.ToPostgresVersion = 17
+	upgrade.Spec.Backup = &pgv2.UpgradeBackupSpec{RepoName: "repo1", Rollback: true}
+	return upgrade
+}
+
+func TestReconcilePreUpgradeBackup(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := newRollbackCluster()
+	upgrade := newRollbackUpgrade()
+	cl := newRollbackClient(t, cluster)
+	r := &PGUpgradeReconciler{Client: cl}
+
+	done, err := r.reconcilePreUpgradeBackup(ctx, cluster, upgrade)
+	assert.NilError(t, err)
+	assert.Assert(t, !done)
+	assert.DeepEqual(t, upgrade.Status.PreUpgrade, &pgv2.PreUpgradeStatus{
+		PostgresVersion: 16,
+		Image:           "postgres:16",
+		PGBouncerImage:  "pgbouncer:16",
+		PGBackRestImage: "pgbackrest:16",
+		BackupName:      "to-17-pre-upgrade",
+	})
+	cond := meta.FindStatusCondition(upgrade.Status.Conditions, pgv2.ConditionPreUpgradeBackup)
+	assert.Equal(t, cond.Status, metav1.ConditionUnknown)
+
+	// The backup is kept when the upgrade is deleted.
+	backup := &pgv2.PerconaPGBackup{}
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "to-17-pre-upgrade"}, backup))
+	assert.Equal(t, backup.Spec.PGCluster, "hippo")
+	assert.Equal(t, backup.Spec.RepoName, "repo1")
+	assert.DeepEqual(t, backup.Spec.Options, []string{"--type=full"})
+	assert.Equal(t, backup.Labels[pNaming.LabelUpgrade], "to-17")
+	assert.Equal(t, len(backup.OwnerReferences), 0)
+
+	t.Run("Failed", func(t *testing.T) {
+		backup.Status.State = pgv2.BackupFailed
+		assert.NilError(t, cl.Status().Update(ctx, backup))
+
+		done, err := r.reconcilePreUpgradeBackup(ctx, cluster, upgrade)
+		assert.ErrorContains(t, err, "backup to-17-pre-upgrade failed")
+		assert.Assert(t, !done)
+		cond := meta.FindStatusCondition(upgrade.Status.Conditions, pgv2.ConditionPreUpgradeBackup)
+		assert.Equal(t, cond.Status, metav1.ConditionFalse)
+	})
+
+	t.Run("Succeeded", func(t *testing.T) {
+		backup.Status.State = pgv2.BackupSucceeded
+		assert.NilError(t, cl.Status().Update(ctx, backup))
+
+		done, err := r.reconcilePreUpgradeBackup(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, done)
+		assert.Assert(t, meta.IsStatusConditionTrue(upgrade.Status.Conditions, pgv2.ConditionPreUpgradeBackup))
+	})
+
+	t.Run("RollbackIgnoresSnapshots", func(t *testing.T) {
+		upgrade := newRollbackUpgrade()
+		upgrade.Spec.Backup.VolumeSnapshotClassName = "csi"
+
+		gate := feature.NewGate()
+		assert.NilError(t, gate.SetFromMap(map[string]bool{feature.VolumeSnapshots: true}))
+		ctx := feature.NewContext(ctx, gate)
+
+		_, err := r.reconcilePreUpgradeBackup(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Equal(t, upgrade.Status.PreUpgrade.BackupName, "to-17-pre-upgrade")
+		assert.Equal(t, len(upgrade.Status.PreUpgrade.VolumeSnapshots), 0)
+	})
+
+	t.Run("NoRepo", func(t *testing.T) {
+		upgrade := newRollbackUpgrade()
+		upgrade.Spec.Backup = &pgv2.UpgradeBackupSpec{VolumeSnapshotClassName: "csi"}
+
+		done, err := r.reconcilePreUpgradeBackup(ctx, cluster, upgrade)
+		assert.ErrorContains(t, err, "feature gate is disabled")
+		assert.Assert(t, !done)
+	})
+}
+
+func TestReconcileRollback(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := newRollbackCluster()
+	cluster.Spec.PostgresVersion = 17
+	cluster.Spec.Image = "postgres:17"
+	cluster.Spec.Pause = initialize.Bool(true)
+
+	upgrade := newRollbackUpgrade()
+	upgrade.Status.PreUpgrade = &pgv2.PreUpgradeStatus{
+		PostgresVersion: 16,
+		Image:           "postgres:16",
+		PGBouncerImage:  "pgbouncer:16",
+		PGBackRestImage: "pgbackrest:16",
+		BackupName:      "to-17-pre-upgrade",
+	}
+
+	pgUpgrade := &crunchyv1beta1.PGUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "to-17", Namespace: "ns"},
+	}
+	failed := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "to-17-pgdata", Namespace: "ns"},
+	}
+	failed.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: "relocate"}}
+	failed.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name:         naming.ContainerDatabase,
+		Image:        "upgrade-image",
+		VolumeMounts: []corev1.VolumeMount{{Name: "postgres-data", MountPath: "/pgdata"}},
+	}}
+	backup := &pgv2.PerconaPGBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "to-17-pre-upgrade", Namespace: "ns"},
+		Spec:       pgv2.PerconaPGBackupSpec{PGCluster: "hippo", RepoName: "repo1"},
+		Status:     pgv2.PerconaPGBackupStatus{BackupName: "20261018-120000F"},
+	}
+
+	cl := newRollbackClient(t, cluster, upgrade, pgUpgrade, failed, backup)
+	r := &PGUpgradeReconciler{Client: cl}
+
+	done, err := r.reconcileRollback(ctx, cluster, upgrade)
+	assert.NilError(t, err)
+	assert.Assert(t, !done)
+	assert.Equal(t, upgrade.Status.Rollback.State, pgv2.RollbackRunning)
+	assert.Equal(t, upgrade.Status.Rollback.Method, pgv2.RollbackDataDirectory)
+
+	job := &batchv1.Job{}
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "to-17-rollback"}, job))
+	assert.Equal(t, len(job.Spec.Template.Spec.InitContainers), 0)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, container.Image, "upgrade-image")
+	assert.DeepEqual(t, container.Command[4:], []string{"rollback", "16", "17"})
+	assert.DeepEqual(t, container.VolumeMounts, failed.Spec.Template.Spec.Containers[0].VolumeMounts)
+
+	t.Run("Restore", func(t *testing.T) {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+		assert.NilError(t, cl.Status().Update(ctx, job))
+
+		// The backup is restored when the data directory cannot be recovered.
+		done, err := r.reconcileRollback(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+		assert.Equal(t, upgrade.Status.Rollback.Method, pgv2.RollbackRestore)
+
+		done, err = r.reconcileRollback(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+
+		restore := &pgv2.PerconaPGRestore{}
+		assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "to-17-rollback"}, restore))
+		assert.Equal(t, restore.Spec.RepoName, "repo1")
+		assert.DeepEqual(t, restore.Spec.Options, []string{"--set=20261018-120000F", "--type=immediate"})
+
+		// The restore runs while the reverted cluster is still paused.
+		paused := &pgv2.PerconaPGCluster{}
+		assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(cluster), paused))
+		assert.Equal(t, paused.Spec.PostgresVersion, 16)
+		assert.Equal(t, paused.Spec.Image, "postgres:16")
+		assert.DeepEqual(t, paused.Spec.Pause, initialize.Bool(true))
+
+		restore.Status.State = pgv2.RestoreSucceeded
+		assert.NilError(t, cl.Status().Update(ctx, restore))
+
+		done, err = r.reconcileRollback(ctx, cluster, upgrade)
+		assert.NilError(t, err)
+		assert.Assert(t, done)
+		assert.Equal(t, upgrade.Status.Rollback.State, pgv2.RollbackSucceeded)
+		assert.Assert(t, upgrade.Status.Rollback.CompletionTime != nil)
+	})
+
+	// The cluster is resumed with the version and images it had before.
+	assert.Assert(t, k8serrors.IsNotFound(cl.Get(ctx, client.ObjectKeyFromObject(pgUpgrade), pgUpgrade)))
+
+	updated := &pgv2.PerconaPGCluster{}
+	assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(cluster), updated))
+	assert.Equal(t, updated.Spec.PostgresVersion, 16)
+	assert.Equal(t, updated.Spec.Image, "postgres:16")
+	assert.Equal(t, updated.Spec.Proxy.PGBouncer.Image, "pgbouncer:16")
+	assert.Equal(t, updated.Spec.Backups.PGBackRest.Image, "pgbackrest:16")
+	assert.Assert(t, updated.Spec.Pause == nil)
+	_, annotated := updated.Annotations[pgv2.AnnotationAllowUpgrade]
+	assert.Assert(t, !annotated)
+
+	// A finished rollback does nothing.
+	done, err = r.reconcileRollback(ctx, cluster, upgrade)
+	assert.NilError(t, err)
+	assert.Assert(t, done)
+}
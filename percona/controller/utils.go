@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	batchv1 "k8s.io/api/batch/v1"
@@ -38,6 +39,28 @@ func JobFailed(job *batchv1.Job) bool {
 	return false
 }
 
+// JobDuration returns how long the finished Job provided ran. It returns zero if the Job
+// didn't start or didn't finish.
+func JobDuration(job *batchv1.Job) time.Duration {
+	if job.Status.StartTime == nil {
+		return 0
+	}
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			if job.Status.CompletionTime != nil {
+				return job.Status.CompletionTime.Sub(job.Status.StartTime.Time)
+			}
+		case batchv1.JobFailed:
+			return cond.LastTransitionTime.Sub(job.Status.StartTime.Time)
+		}
+	}
+	return 0
+}
+
 // CustomManager is needed to receive a crunchy controller without modifying the crunchy code.
 // It should be used in the `(r *postgrescluster.Reconciler) SetupWithManager(mgr manager.Manager)` method.
 // A Crunchy controller is received when `controller.New` is called which uses the `Add` method.
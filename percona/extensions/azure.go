@@ -0,0 +1,110 @@
+package extensions
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const azureAPIVersion = "2021-08-06"
+
+// Azure gets extension archives from an Azure Blob Storage container.
+// Requests are authorized with the storage account key or a SAS token.
+type Azure struct {
+	Endpoint  string
+	Account   string
+	Container string
+
+	key      []byte
+	sasToken string
+
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewAzure(endpoint, account, container, key, sasToken string) (*Azure, error) {
+	if account == "" {
+		return nil, errors.New("storage account is not set")
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	}
+
+	var decodedKey []byte
+	if key != "" {
+		var err error
+		decodedKey, err = base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode storage account key")
+		}
+	}
+
+	return &Azure{
+		Endpoint:  endpoint,
+		Account:   account,
+		Container: container,
+		key:       decodedKey,
+		sasToken:  strings.TrimPrefix(sasToken, "?"),
+		client:    http.DefaultClient,
+		now:       time.Now,
+	}, nil
+}
+
+func (a *Azure) Get(key string) (io.ReadCloser, error) {
+	u, err := url.Parse(a.Endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse endpoint")
+	}
+	u = u.JoinPath(a.Container, key)
+	if a.key == nil && a.sasToken != "" {
+		u.RawQuery = a.sasToken
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+	req.Header.Set("x-ms-date", a.now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+
+	if a.key != nil {
+		req.Header.Set("Authorization", "SharedKey "+a.Account+":"+a.sign(req))
+	}
+
+	return doGet(a.client, req)
+}
+
+// sign returns the Shared Key signature of a request without a body.
+// - https://learn.microsoft.com/rest/api/storageservices/authorize-with-shared-key
+func (a *Azure) sign(req *http.Request) string {
+	stringToSign := strings.Join([]string{
+		req.Method,
+		"", // Content-Encoding
+		"", // Content-Language
+		"", // Content-Length
+		"", // Content-MD5
+		"", // Content-Type
+		"", // Date
+		"", // If-Modified-Since
+		"", // If-Match
+		"", // If-None-Match
+		"", // If-Unmodified-Since
+		"", // Range
+		"x-ms-date:" + req.Header.Get("x-ms-date"),
+		"x-ms-version:" + req.Header.Get("x-ms-version"),
+		"/" + a.Account + req.URL.EscapedPath(),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(stringToSign))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,36 @@
+package extensions
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Filesystem gets extension archives from a local directory, e.g. a mounted
+// PersistentVolumeClaim.
+type Filesystem struct {
+	Root string
+}
+
+func NewFilesystem(root, bucket string) *Filesystem {
+	return &Filesystem{Root: filepath.Join(root, bucket)}
+}
+
+func (f *Filesystem) Get(key string) (io.ReadCloser, error) {
+	p := filepath.Join(f.Root, key)
+
+	root := filepath.Clean(f.Root) + string(filepath.Separator)
+	if !strings.HasPrefix(p, root) {
+		return nil, errors.Errorf("key %s is outside of %s", key, f.Root)
+	}
+
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "open archive")
+	}
+
+	return file, nil
+}
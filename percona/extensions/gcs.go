@@ -0,0 +1,86 @@
+package extensions
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	gcsDefaultEndpoint = "https://storage.googleapis.com"
+	gcsDefaultTokenURL = "https://oauth2.googleapis.com/token"
+	gcsReadOnlyScope   = "https://www.googleapis.com/auth/devstorage.read_only"
+)
+
+// GCS gets extension archives from a Google Cloud Storage bucket using the
+// JSON API. Requests are authorized with a service account key. Without a key
+// the bucket must be publicly readable.
+type GCS struct {
+	Endpoint string
+	Bucket   string
+
+	client *http.Client
+}
+
+type gcsServiceAccountKey struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+func NewGCS(endpoint, bucket string, serviceAccountKey []byte) (*GCS, error) {
+	if endpoint == "" {
+		endpoint = gcsDefaultEndpoint
+	}
+
+	client := http.DefaultClient
+	if len(serviceAccountKey) > 0 {
+		key := new(gcsServiceAccountKey)
+		if err := json.Unmarshal(serviceAccountKey, key); err != nil {
+			return nil, errors.Wrap(err, "parse service account key")
+		}
+		if key.TokenURI == "" {
+			key.TokenURI = gcsDefaultTokenURL
+		}
+
+		cfg := &jwt.Config{
+			Email:        key.ClientEmail,
+			PrivateKey:   []byte(key.PrivateKey),
+			PrivateKeyID: key.PrivateKeyID,
+			Scopes:       []string{gcsReadOnlyScope},
+			TokenURL:     key.TokenURI,
+		}
+		client = cfg.Client(context.Background())
+	}
+
+	return &GCS{
+		Endpoint: endpoint,
+		Bucket:   bucket,
+		client:   client,
+	}, nil
+}
+
+func (g *GCS) Get(key string) (io.ReadCloser, error) {
+	u, err := url.Parse(g.Endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse endpoint")
+	}
+	// The object name is a single path segment, slashes included.
+	u = u.JoinPath("storage/v1/b", g.Bucket, "o")
+	u.RawPath = u.EscapedPath() + "/" + url.PathEscape(key)
+	u.Path += "/" + key
+	u.RawQuery = url.Values{"alt": []string{"media"}}.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+
+	return doGet(g.client, req)
+}
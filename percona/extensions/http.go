@@ -0,0 +1,71 @@
+package extensions
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HTTP gets extension archives from a web server. Archives are expected at
+// <endpoint>/<bucket>/<key>.
+type HTTP struct {
+	Endpoint string
+	Bucket   string
+
+	username, password string
+	token              string
+
+	client *http.Client
+}
+
+func NewHTTP(endpoint, bucket, username, password, token string) *HTTP {
+	return &HTTP{
+		Endpoint: endpoint,
+		Bucket:   bucket,
+		username: username,
+		password: password,
+		token:    token,
+		client:   http.DefaultClient,
+	}
+}
+
+func (h *HTTP) Get(key string) (io.ReadCloser, error) {
+	u, err := url.Parse(h.Endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse endpoint")
+	}
+	u = u.JoinPath(strings.Trim(h.Bucket, "/"), key)
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+
+	switch {
+	case h.token != "":
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	case h.username != "":
+		req.SetBasicAuth(h.username, h.password)
+	}
+
+	return doGet(h.client, req)
+}
+
+// doGet sends the request and returns the body of a successful response.
+func doGet(client *http.Client, req *http.Request) (io.ReadCloser, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get %s", req.URL.Redacted())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, errors.Errorf("get %s: %s: %s", req.URL.Redacted(), resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return resp.Body, nil
+}
@@ -2,10 +2,14 @@ package extensions
 
 import (
 	"fmt"
+	"path"
+	"slices"
 	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
 	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
 )
 
@@ -41,6 +45,40 @@ func ExtensionRelocatorContainer(cr *pgv2.PerconaPGCluster, image string, imageP
 	}
 }
 
+const installerContainerName = "extension-installer"
+
+// VerificationFailure returns the reason the extension installer of the pod
+// failed to verify an extension archive, if it did.
+func VerificationFailure(pod *corev1.Pod) string {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if !strings.HasPrefix(status.Name, installerContainerName) {
+			continue
+		}
+		for _, state := range []corev1.ContainerState{status.State, status.LastTerminationState} {
+			if state.Terminated != nil && state.Terminated.ExitCode == ExitCodeVerificationFailed {
+				return state.Terminated.Message
+			}
+		}
+	}
+	return ""
+}
+
+// InstalledOnPod returns true if the extension installer of the pod was
+// started to install the extension archive with the key.
+func InstalledOnPod(pod *corev1.Pod, key string) bool {
+	for _, container := range pod.Spec.InitContainers {
+		if !strings.HasPrefix(container.Name, installerContainerName) {
+			continue
+		}
+		for _, env := range container.Env {
+			if env.Name == "INSTALL_EXTENSIONS" && slices.Contains(strings.Split(env.Value, ","), key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func ExtensionInstallerContainer(cr *pgv2.PerconaPGCluster, postgresVersion int, spec *pgv2.ExtensionsSpec, extensions string, openshift *bool) corev1.Container {
 	mounts := []corev1.VolumeMount{
 		{
@@ -50,7 +88,7 @@ func ExtensionInstallerContainer(cr *pgv2.PerconaPGCluster, postgresVersion int,
 	}
 	mounts = append(mounts, ExtensionVolumeMounts(postgresVersion)...)
 
-	containerName := "extension-installer"
+	containerName := installerContainerName
 	if cr.CompareVersion("2.4.0") >= 0 {
 		containerName = fmt.Sprintf("extension-installer-%d", postgresVersion)
 	}
@@ -67,7 +105,7 @@ func ExtensionInstallerContainer(cr *pgv2.PerconaPGCluster, postgresVersion int,
 			},
 			{
 				Name:  "STORAGE_ENDPOINT",
-				Value: spec.Storage.Endpoint,
+				Value: storageEndpoint(&spec.Storage),
 			},
 			{
 				Name:  "STORAGE_REGION",
@@ -81,6 +119,10 @@ func ExtensionInstallerContainer(cr *pgv2.PerconaPGCluster, postgresVersion int,
 				Name:  "INSTALL_EXTENSIONS",
 				Value: extensions,
 			},
+			{
+				Name:  "EXTENSION_CHECKSUMS",
+				Value: extensionChecksums(postgresVersion, spec.Custom),
+			},
 			{
 				Name:  "PG_VERSION",
 				Value: strconv.Itoa(postgresVersion),
@@ -90,14 +132,37 @@ func ExtensionInstallerContainer(cr *pgv2.PerconaPGCluster, postgresVersion int,
 				Value: "/pgdata/extension/" + strconv.Itoa(postgresVersion),
 			},
 		},
-		EnvFrom: []corev1.EnvFromSource{
+		VolumeMounts: mounts,
+	}
+
+	if spec.Storage.Secret != nil {
+		container.EnvFrom = []corev1.EnvFromSource{
 			{
 				SecretRef: &corev1.SecretEnvSource{
 					LocalObjectReference: spec.Storage.Secret.LocalObjectReference,
 				},
 			},
-		},
-		VolumeMounts: mounts,
+		}
+	}
+
+	if spec.Storage.StorageVolume() != nil {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      naming.ExtensionsStorageVolume,
+			MountPath: naming.ExtensionsStorageMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	if spec.SignatureKey != nil {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      naming.ExtensionsSignatureKeyVolume,
+			MountPath: naming.ExtensionsSignatureKeyMountPath,
+			ReadOnly:  true,
+		})
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "EXTENSIONS_PUBLIC_KEY",
+			Value: path.Join(naming.ExtensionsSignatureKeyMountPath, naming.ExtensionsSignatureKeyFile),
+		})
 	}
 
 	if openshift == nil || !*openshift {
@@ -112,6 +177,28 @@ func ExtensionInstallerContainer(cr *pgv2.PerconaPGCluster, postgresVersion int,
 	return container
 }
 
+// storageEndpoint returns the endpoint the extension installer reads archives
+// from. A filesystem storage is always read where its volume is mounted.
+func storageEndpoint(storage *pgv2.CustomExtensionsStorageSpec) string {
+	if StorageType(storage.Type) == StorageTypeFilesystem {
+		return naming.ExtensionsStorageMountPath
+	}
+	return storage.Endpoint
+}
+
+// extensionChecksums returns the checksums of the extension archives as a
+// comma separated list of key=checksum pairs.
+func extensionChecksums(postgresVersion int, custom []pgv2.CustomExtensionSpec) string {
+	checksums := make([]string, 0, len(custom))
+	for _, extension := range custom {
+		if extension.Checksum == "" {
+			continue
+		}
+		checksums = append(checksums, GetExtensionKey(postgresVersion, extension.Name, extension.Version)+"="+extension.Checksum)
+	}
+	return strings.Join(checksums, ",")
+}
+
 func ExtensionVolumeMounts(postgresVersion int) []corev1.VolumeMount {
 	return []corev1.VolumeMount{
 		{
@@ -0,0 +1,48 @@
+package extensions
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestExtensionInstallerContainerFilesystem(t *testing.T) {
+	cr := &pgv2.PerconaPGCluster{Spec: pgv2.PerconaPGClusterSpec{CRVersion: "2.6.0"}}
+	spec := &pgv2.ExtensionsSpec{
+		Storage: pgv2.CustomExtensionsStorageSpec{
+			Type:     "filesystem",
+			Bucket:   "extensions",
+			Endpoint: "/somewhere/else",
+			Volume:   &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "pg-extensions"},
+		},
+	}
+
+	container := ExtensionInstallerContainer(cr, 16, spec, "pg_cron-pg16-1.6.1", nil)
+
+	env := make(map[string]string)
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+
+	// The archives are read where the volume is mounted, whatever the endpoint.
+	var mountPath string
+	for _, m := range container.VolumeMounts {
+		if m.Name == naming.ExtensionsStorageVolume {
+			mountPath = m.MountPath
+		}
+	}
+	assert.Equal(t, mountPath, naming.ExtensionsStorageMountPath)
+	assert.Equal(t, env["STORAGE_ENDPOINT"], mountPath)
+
+	spec.Storage = pgv2.CustomExtensionsStorageSpec{Type: "http", Endpoint: "https://example.com"}
+	container = ExtensionInstallerContainer(cr, 16, spec, "pg_cron-pg16-1.6.1", nil)
+	for _, e := range container.Env {
+		if e.Name == "STORAGE_ENDPOINT" {
+			assert.Equal(t, e.Value, "https://example.com")
+		}
+	}
+}
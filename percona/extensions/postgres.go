@@ -0,0 +1,115 @@
+package extensions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/postgres"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+// enableSQL creates the extension and updates it to the version from the
+// spec. The version from the spec is the version of the archive and may not
+// match any version in the control file, e.g. 1.6.1 for 1.6. The extension
+// is updated to the default version of the control file in this case. It's
+// only updated if there is an update path from the installed version, so
+// downgrades are never attempted. It prints the installed version as JSON.
+const enableSQL = `
+SET client_min_messages = WARNING;
+\pset tuples_only on
+\pset format unaligned
+
+SELECT COALESCE(
+  (SELECT version FROM pg_catalog.pg_available_extension_versions
+    WHERE name = :'extension' AND version = :'version'),
+  (SELECT default_version FROM pg_catalog.pg_available_extensions
+    WHERE name = :'extension'),
+  '') AS target
+\gset
+
+BEGIN;
+
+SELECT format('CREATE SCHEMA IF NOT EXISTS %I', :'schema')
+  WHERE :'schema' <> '' AND :'target' <> ''
+\gexec
+
+SELECT format('CREATE EXTENSION IF NOT EXISTS %I', :'extension')
+  || CASE WHEN :'schema' <> '' THEN format(' WITH SCHEMA %I', :'schema') ELSE '' END
+  || format(' VERSION %L', :'target')
+  WHERE :'target' <> ''
+\gexec
+
+SELECT format('ALTER EXTENSION %I UPDATE TO %L', e.extname, :'target')
+  FROM pg_catalog.pg_extension e
+  WHERE e.extname = :'extension' AND e.extversion <> :'target'
+    AND EXISTS (SELECT 1 FROM pg_catalog.pg_extension_update_paths(e.extname) p
+      WHERE p.source = e.extversion AND p.target = :'target' AND p.path IS NOT NULL)
+\gexec
+
+COMMIT;
+
+SELECT json_build_object(
+  'database', current_database(),
+  'version', e.extversion,
+  'pending', :'target' = '' OR (e.extversion <> :'target'
+    AND EXISTS (SELECT 1 FROM pg_catalog.pg_extension_update_paths(e.extname) p
+      WHERE p.source = e.extversion AND p.target = :'target' AND p.path IS NOT NULL)))
+  FROM (SELECT :'extension' AS name) x
+  LEFT JOIN pg_catalog.pg_extension e ON e.extname = x.name;
+`
+
+// EnableInPostgreSQL creates the extension in the databases from the spec
+// and updates it to the version from the spec. It returns the extension
+// versions installed in the databases.
+func EnableInPostgreSQL(ctx context.Context, exec postgres.Executor, extension pgv2.CustomExtensionSpec) ([]pgv2.InstalledExtensionStatus, error) {
+	log := logging.FromContext(ctx)
+
+	databases, err := json.Marshal(extension.Databases)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal databases")
+	}
+
+	stdout, stderr, err := exec.ExecInDatabasesFromQuery(ctx,
+		`SELECT datname FROM pg_catalog.pg_database`+
+			` WHERE datallowconn AND datname IN (SELECT json_array_elements_text(:'databases'::json))`,
+		enableSQL,
+		map[string]string{
+			"databases": string(databases),
+			"extension": extension.Name,
+			"version":   extension.Version,
+			"schema":    extension.Schema,
+
+			"ON_ERROR_STOP": "on", // Abort when any one command fails.
+			"QUIET":         "on", // Do not print successful commands to stdout.
+		})
+
+	log.V(1).Info("enabled custom extension", "extensionName", extension.Name, "stdout", stdout, "stderr", stderr)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "enable extension %s: %s", extension.Name, strings.TrimSpace(stderr))
+	}
+
+	var installed []pgv2.InstalledExtensionStatus
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		status := pgv2.InstalledExtensionStatus{}
+		if err := json.Unmarshal([]byte(line), &status); err != nil {
+			return nil, errors.Wrapf(err, "parse extension %s status", extension.Name)
+		}
+		status.Name = extension.Name
+		status.SpecVersion = extension.Version
+		installed = append(installed, status)
+	}
+
+	return installed, errors.WithStack(scanner.Err())
+}
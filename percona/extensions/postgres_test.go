@@ -0,0 +1,76 @@
+package extensions
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestEnableInPostgreSQL(t *testing.T) {
+	ctx := context.Background()
+	extension := pgv2.CustomExtensionSpec{
+		Name:      "pg_cron",
+		Version:   "1.6.1",
+		Databases: []string{"app", "postgres"},
+		Schema:    "cron",
+	}
+
+	exec := func(
+		_ context.Context, stdin io.Reader, stdout, stderr io.Writer, command ...string,
+	) error {
+		args := strings.Join(command, "\n")
+		assert.Assert(t, strings.Contains(args, `json_array_elements_text(:'databases'::json)`))
+		assert.Assert(t, strings.Contains(args, `--set=databases=["app","postgres"]`))
+		assert.Assert(t, strings.Contains(args, `--set=extension=pg_cron`))
+		assert.Assert(t, strings.Contains(args, `--set=schema=cron`))
+		assert.Assert(t, strings.Contains(args, `--set=version=1.6.1`))
+
+		b, err := io.ReadAll(stdin)
+		assert.NilError(t, err)
+		assert.Equal(t, string(b), enableSQL)
+
+		_, _ = stdout.Write([]byte(`{"database" : "app", "version" : "1.6", "pending" : false}
+{"database" : "postgres", "version" : "1.5", "pending" : true}
+`))
+		return nil
+	}
+
+	installed, err := EnableInPostgreSQL(ctx, exec, extension)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, installed, []pgv2.InstalledExtensionStatus{
+		{Name: "pg_cron", Database: "app", Version: "1.6", SpecVersion: "1.6.1"},
+		{Name: "pg_cron", Database: "postgres", Version: "1.5", SpecVersion: "1.6.1", Pending: true},
+	})
+
+	expected := errors.New("whoops")
+	_, err = EnableInPostgreSQL(ctx, func(
+		_ context.Context, _ io.Reader, _, stderr io.Writer, _ ...string,
+	) error {
+		_, _ = stderr.Write([]byte(`ERROR: schema "cron" does not exist`))
+		return expected
+	}, extension)
+	assert.ErrorIs(t, err, expected)
+	assert.ErrorContains(t, err, `schema "cron" does not exist`)
+}
+
+func TestInstalledOnPod(t *testing.T) {
+	pod := &corev1.Pod{}
+	assert.Assert(t, !InstalledOnPod(pod, "pg_cron-pg16-1.6.1"))
+
+	pod.Spec.InitContainers = []corev1.Container{{
+		Name: "extension-installer-16",
+		Env: []corev1.EnvVar{{
+			Name:  "INSTALL_EXTENSIONS",
+			Value: "pg_cron-pg16-1.6.1,pgvector-pg16-0.7.0",
+		}},
+	}}
+	assert.Assert(t, InstalledOnPod(pod, "pg_cron-pg16-1.6.1"))
+	assert.Assert(t, !InstalledOnPod(pod, "pg_cron-pg16-1.6.2"))
+}
@@ -12,7 +12,9 @@ type ObjectGetter interface {
 type StorageType string
 
 const (
-	StorageTypeS3    StorageType = "s3"
-	StorageTypeGCS   StorageType = "gcs"
-	StorageTypeAzure StorageType = "azure"
+	StorageTypeS3         StorageType = "s3"
+	StorageTypeGCS        StorageType = "gcs"
+	StorageTypeAzure      StorageType = "azure"
+	StorageTypeFilesystem StorageType = "filesystem"
+	StorageTypeHTTP       StorageType = "http"
 )
@@ -0,0 +1,166 @@
+package extensions
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+const archive = "pg_cron-pg16-1.6.1.tar.gz"
+
+func readAll(t *testing.T, getter ObjectGetter, key string) string {
+	t.Helper()
+
+	object, err := getter.Get(key)
+	assert.NilError(t, err)
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	assert.NilError(t, err)
+	return string(data)
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/extensions/"+archive {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("archive"))
+	}))
+	defer srv.Close()
+
+	assert.Equal(t, readAll(t, NewHTTP(srv.URL, "extensions", "user", "pass", ""), archive), "archive")
+
+	_, err := NewHTTP(srv.URL, "extensions", "user", "wrong", "").Get(archive)
+	assert.ErrorContains(t, err, "401 Unauthorized")
+
+	_, err = NewHTTP(srv.URL, "", "user", "pass", "").Get(archive)
+	assert.ErrorContains(t, err, "404 Not Found")
+}
+
+func TestFilesystem(t *testing.T) {
+	root := t.TempDir()
+	assert.NilError(t, os.Mkdir(filepath.Join(root, "extensions"), 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(root, "extensions", archive), []byte("archive"), 0o600))
+
+	fs := NewFilesystem(root, "extensions")
+	assert.Equal(t, readAll(t, fs, archive), "archive")
+
+	_, err := fs.Get("missing.tar.gz")
+	assert.ErrorContains(t, err, "open archive")
+
+	_, err = fs.Get("../../etc/passwd")
+	assert.ErrorContains(t, err, "is outside of")
+}
+
+func TestGCS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NilError(t, err)
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NilError(t, r.ParseForm())
+		assert.Equal(t, r.Form.Get("grant_type"), "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	})
+	mux.HandleFunc("/storage/v1/b/bucket/o/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, r.URL.Query().Get("alt"), "media")
+		assert.Equal(t, r.URL.EscapedPath(), "/storage/v1/b/bucket/o/pg16%2F"+archive)
+		_, _ = w.Write([]byte("archive"))
+	})
+
+	serviceAccountKey, err := json.Marshal(map[string]string{
+		"client_email":   "installer@project.iam.gserviceaccount.com",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"private_key_id": "1",
+		"token_uri":      srv.URL + "/token",
+	})
+	assert.NilError(t, err)
+
+	gcs, err := NewGCS(srv.URL, "bucket", serviceAccountKey)
+	assert.NilError(t, err)
+	assert.Equal(t, readAll(t, gcs, "pg16/"+archive), "archive")
+
+	anonymous, err := NewGCS(srv.URL, "bucket", nil)
+	assert.NilError(t, err)
+	_, err = anonymous.Get("pg16/" + archive)
+	assert.ErrorContains(t, err, "401 Unauthorized")
+
+	_, err = NewGCS(srv.URL, "bucket", []byte("{"))
+	assert.ErrorContains(t, err, "parse service account key")
+}
+
+func TestAzure(t *testing.T) {
+	accountKey := base64.StdEncoding.EncodeToString([]byte("secret"))
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("GET\n\n\n\n\n\n\n\n\n\n\n\n" +
+		"x-ms-date:Sat, 01 Jun 2024 00:00:00 GMT\n" +
+		"x-ms-version:2021-08-06\n" +
+		"/account/extensions/" + archive))
+	expected := "SharedKey account:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("sig") == "signature":
+		case r.Header.Get("Authorization") == expected:
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/extensions/"+archive {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("archive"))
+	}))
+	defer srv.Close()
+
+	azure, err := NewAzure(srv.URL, "account", "extensions", accountKey, "")
+	assert.NilError(t, err)
+	azure.now = func() time.Time { return now }
+	assert.Equal(t, readAll(t, azure, archive), "archive")
+
+	sas, err := NewAzure(srv.URL, "account", "extensions", "", "?sv=2021-08-06&sig=signature")
+	assert.NilError(t, err)
+	assert.Equal(t, readAll(t, sas, archive), "archive")
+
+	anonymous, err := NewAzure(srv.URL, "account", "extensions", "", "")
+	assert.NilError(t, err)
+	_, err = anonymous.Get(archive)
+	assert.ErrorContains(t, err, "403 Forbidden")
+
+	_, err = NewAzure("", "", "extensions", accountKey, "")
+	assert.Error(t, err, "storage account is not set")
+}
@@ -0,0 +1,131 @@
+package extensions
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ExitCodeVerificationFailed is the exit code of the extension installer if
+// an archive doesn't match its checksum or signature. The operator uses it to
+// tell verification failures from other installation errors.
+const ExitCodeVerificationFailed = 3
+
+// SignatureSuffix is appended to the archive key to get its detached signature.
+const SignatureSuffix = ".sig"
+
+// ErrVerificationFailed is returned if an archive doesn't match its checksum
+// or signature.
+var ErrVerificationFailed = errors.New("verification failed")
+
+// ParseChecksums parses a comma separated list of key=checksum pairs.
+func ParseChecksums(s string) (map[string]string, error) {
+	checksums := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, checksum, ok := strings.Cut(pair, "=")
+		if !ok || key == "" || checksum == "" {
+			return nil, errors.Errorf("invalid checksum %q, expected key=checksum", pair)
+		}
+		checksums[strings.TrimSpace(key)] = strings.TrimSpace(checksum)
+	}
+	return checksums, nil
+}
+
+// VerifyChecksum checks that the sha256 checksum of the file matches expected.
+// The expected checksum is hex encoded and may be prefixed with "sha256:".
+func VerifyChecksum(filePath, expected string) error {
+	expected = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(expected), "sha256:"))
+	if _, err := hex.DecodeString(expected); err != nil || len(expected) != sha256.Size*2 {
+		return errors.Wrapf(ErrVerificationFailed, "invalid sha256 checksum %q", expected)
+	}
+
+	digest, err := fileDigest(filePath)
+	if err != nil {
+		return err
+	}
+
+	if actual := hex.EncodeToString(digest); actual != expected {
+		return errors.Wrapf(ErrVerificationFailed, "checksum mismatch for %s: expected sha256:%s, got sha256:%s",
+			filePath, expected, actual)
+	}
+
+	return nil
+}
+
+// VerifySignature checks the detached signature of the file with the
+// PEM-encoded public key. Ed25519 signatures are made over the file itself,
+// ECDSA and RSA (PKCS #1 v1.5) signatures over its sha256 digest. The
+// signature may be raw or base64 encoded.
+func VerifySignature(filePath string, signature, publicKeyPEM []byte) error {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return errors.New("failed to decode PEM public key")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "parse public key")
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		signature = decoded
+	}
+
+	var valid bool
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return errors.Wrap(err, "read archive")
+		}
+		valid = ed25519.Verify(key, data, signature)
+	case *ecdsa.PublicKey:
+		digest, err := fileDigest(filePath)
+		if err != nil {
+			return err
+		}
+		valid = ecdsa.VerifyASN1(key, digest, signature)
+	case *rsa.PublicKey:
+		digest, err := fileDigest(filePath)
+		if err != nil {
+			return err
+		}
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature) == nil
+	default:
+		return errors.Errorf("unsupported public key type %T", publicKey)
+	}
+
+	if !valid {
+		return errors.Wrapf(ErrVerificationFailed, "invalid signature for %s", filePath)
+	}
+
+	return nil
+}
+
+func fileDigest(filePath string) ([]byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "open archive")
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, errors.Wrap(err, "read archive")
+	}
+
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,165 @@
+package extensions
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
+	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func writeArchive(t *testing.T, data string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), archive)
+	assert.NilError(t, os.WriteFile(p, []byte(data), 0o600))
+	return p
+}
+
+func publicKeyPEM(t *testing.T, key crypto.PublicKey) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.NilError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestParseChecksums(t *testing.T) {
+	checksums, err := ParseChecksums("pg_cron-pg16-1.6.1=sha256:abc, pgvector-pg16-0.7.0=def,")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, checksums, map[string]string{
+		"pg_cron-pg16-1.6.1":  "sha256:abc",
+		"pgvector-pg16-0.7.0": "def",
+	})
+
+	checksums, err = ParseChecksums("")
+	assert.NilError(t, err)
+	assert.Equal(t, len(checksums), 0)
+
+	_, err = ParseChecksums("pg_cron-pg16-1.6.1")
+	assert.ErrorContains(t, err, "expected key=checksum")
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	p := writeArchive(t, "archive")
+	sum := sha256.Sum256([]byte("archive"))
+	checksum := hex.EncodeToString(sum[:])
+
+	assert.NilError(t, VerifyChecksum(p, checksum))
+	assert.NilError(t, VerifyChecksum(p, "sha256:"+checksum))
+
+	other := sha256.Sum256([]byte("other"))
+	err := VerifyChecksum(p, hex.EncodeToString(other[:]))
+	assert.ErrorIs(t, err, ErrVerificationFailed)
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	err = VerifyChecksum(p, "md5:abc")
+	assert.ErrorIs(t, err, ErrVerificationFailed)
+	assert.ErrorContains(t, err, "invalid sha256 checksum")
+}
+
+func TestVerifySignature(t *testing.T) {
+	p := writeArchive(t, "archive")
+	digest := sha256.Sum256([]byte("archive"))
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	ecSignature, err := ecdsa.SignASN1(rand.Reader, ecPrivate, digest[:])
+	assert.NilError(t, err)
+
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	rsaSignature, err := rsa.SignPKCS1v15(rand.Reader, rsaPrivate, crypto.SHA256, digest[:])
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name      string
+		signature []byte
+		publicKey crypto.PublicKey
+	}{
+		{"ed25519", ed25519.Sign(edPrivate, []byte("archive")), edPublic},
+		{"ecdsa", ecSignature, &ecPrivate.PublicKey},
+		{"rsa", rsaSignature, &rsaPrivate.PublicKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := publicKeyPEM(t, tt.publicKey)
+
+			assert.NilError(t, VerifySignature(p, tt.signature, key))
+
+			encoded := []byte(base64.StdEncoding.EncodeToString(tt.signature) + "\n")
+			assert.NilError(t, VerifySignature(p, encoded, key))
+
+			tampered := writeArchive(t, "tampered")
+			assert.ErrorIs(t, VerifySignature(tampered, tt.signature, key), ErrVerificationFailed)
+		})
+	}
+
+	assert.ErrorContains(t, VerifySignature(p, nil, []byte("key")), "failed to decode PEM public key")
+}
+
+func TestVerificationFailure(t *testing.T) {
+	terminated := func(code int32, msg string) corev1.ContainerState {
+		return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: code, Message: msg}}
+	}
+
+	pod := &corev1.Pod{}
+	assert.Equal(t, VerificationFailure(pod), "")
+
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+		{Name: "postgres-startup", State: terminated(ExitCodeVerificationFailed, "other")},
+		{Name: "extension-installer-16", State: terminated(1, "failed to get object")},
+	}
+	assert.Equal(t, VerificationFailure(pod), "")
+
+	pod.Status.InitContainerStatuses[1].LastTerminationState = terminated(ExitCodeVerificationFailed, "checksum mismatch")
+	assert.Equal(t, VerificationFailure(pod), "checksum mismatch")
+}
+
+func TestExtensionInstallerContainerVerification(t *testing.T) {
+	cr := &pgv2.PerconaPGCluster{Spec: pgv2.PerconaPGClusterSpec{CRVersion: "2.6.0"}}
+	spec := &pgv2.ExtensionsSpec{
+		Storage: pgv2.CustomExtensionsStorageSpec{Type: "http", Bucket: "extensions"},
+		Custom: []pgv2.CustomExtensionSpec{
+			{Name: "pg_cron", Version: "1.6.1", Checksum: "sha256:abc"},
+			{Name: "pgvector", Version: "0.7.0"},
+		},
+		SignatureKey: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "extensions-key"},
+			Key:                  "key.pem",
+		},
+	}
+
+	container := ExtensionInstallerContainer(cr, 16, spec, "pg_cron-pg16-1.6.1,pgvector-pg16-0.7.0", nil)
+
+	env := make(map[string]string)
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	assert.Equal(t, env["EXTENSION_CHECKSUMS"], "pg_cron-pg16-1.6.1=sha256:abc")
+	assert.Equal(t, env["EXTENSIONS_PUBLIC_KEY"], "/etc/extensions-signature-key/public.pem")
+
+	var mounted bool
+	for _, m := range container.VolumeMounts {
+		mounted = mounted || m.Name == naming.ExtensionsSignatureKeyVolume
+	}
+	assert.Assert(t, mounted)
+}
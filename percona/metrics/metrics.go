@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+const namespace = "pgv2"
+
+var (
+	backupDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "backup_duration_seconds",
+		Help:      "Duration of finished PerconaPGBackups.",
+		Buckets:   prometheus.ExponentialBuckets(30, 2, 12),
+	}, []string{"namespace", "cluster", "repo", "type", "result"})
+
+	backupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backups_total",
+		Help:      "Number of finished PerconaPGBackups by result.",
+	}, []string{"namespace", "cluster", "repo", "type", "result"})
+
+	backupSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "backup_size_bytes",
+		Help:      "Size of the latest succeeded backup as stored in the repo.",
+	}, []string{"namespace", "cluster", "repo", "type"})
+
+	lastSuccessfulBackup = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_successful_backup_timestamp_seconds",
+		Help:      "Completion time of the latest succeeded backup in the repo as a Unix timestamp.",
+	}, []string{"namespace", "cluster", "repo"})
+
+	restorableTimeLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "latest_restorable_time_lag_seconds",
+		Help:      "Seconds between now and the latest commit that can be restored from the archive.",
+	}, []string{"namespace", "cluster"})
+
+	restoreDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "restore_duration_seconds",
+		Help:      "Duration of finished PerconaPGRestores.",
+		Buckets:   prometheus.ExponentialBuckets(30, 2, 12),
+	}, []string{"namespace", "cluster", "repo", "result"})
+
+	restoreState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "restore_state",
+		Help:      "Current state of a PerconaPGRestore. The value is 1 for the current state.",
+	}, []string{"namespace", "cluster", "restore", "state"})
+
+	clusterState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cluster_state",
+		Help:      "Current state of a PerconaPGCluster. The value is 1 for the current state.",
+	}, []string{"namespace", "cluster", "state"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		backupDuration,
+		backupsTotal,
+		backupSize,
+		lastSuccessfulBackup,
+		restorableTimeLag,
+		restoreDuration,
+		restoreState,
+		clusterState,
+	)
+}
+
+var clusterStates = []v2.AppState{
+	v2.AppStateInit,
+	v2.AppStatePaused,
+	v2.AppStateStopping,
+	v2.AppStateReady,
+	v2.AppStateError,
+}
+
+var restoreStates = []v2.PGRestoreState{
+	v2.RestoreNew,
+	v2.RestoreStarting,
+	v2.RestoreRunning,
+	v2.RestoreFailed,
+	v2.RestoreSucceeded,
+}
+
+// ObserveBackup records a finished backup. The duration is skipped if it is
+// not known.
+func ObserveBackup(pgBackup *v2.PerconaPGBackup, duration time.Duration) {
+	labels := prometheus.Labels{
+		"namespace": pgBackup.Namespace,
+		"cluster":   pgBackup.Spec.PGCluster,
+		"repo":      pgBackup.Spec.RepoName,
+		"type":      backupType(pgBackup),
+		"result":    string(pgBackup.Status.State),
+	}
+
+	backupsTotal.With(labels).Inc()
+	if duration > 0 {
+		backupDuration.With(labels).Observe(duration.Seconds())
+	}
+}
+
+// SetBackupSize sets the size of the latest succeeded backup in the repo.
+func SetBackupSize(pgBackup *v2.PerconaPGBackup, size int64) {
+	backupSize.WithLabelValues(pgBackup.Namespace, pgBackup.Spec.PGCluster, pgBackup.Spec.RepoName, backupType(pgBackup)).Set(float64(size))
+}
+
+func backupType(pgBackup *v2.PerconaPGBackup) string {
+	if pgBackup.Status.BackupType != "" {
+		return string(pgBackup.Status.BackupType)
+	}
+	for _, opt := range pgBackup.Spec.Options {
+		if t, ok := strings.CutPrefix(opt, "--type="); ok {
+			return t
+		}
+	}
+	return ""
+}
+
+// SetLastSuccessfulBackup sets the completion time of the latest succeeded
+// backup of the cluster in the repo.
+func SetLastSuccessfulBackup(cr *v2.PerconaPGCluster, repo string, completedAt time.Time) {
+	lastSuccessfulBackup.WithLabelValues(cr.Namespace, cr.Name, repo).Set(float64(completedAt.Unix()))
+}
+
+// SetRestorableTimeLag sets the lag of the latest restorable time of the
+// backup behind now. It is skipped if the backup has no restorable time yet.
+func SetRestorableTimeLag(cr *v2.PerconaPGCluster, pgBackup *v2.PerconaPGBackup) {
+	latestRestorable := pgBackup.Status.LatestRestorableTime
+	if latestRestorable.Time == nil {
+		return
+	}
+	restorableTimeLag.WithLabelValues(cr.Namespace, cr.Name).Set(time.Since(latestRestorable.Time.Time).Seconds())
+}
+
+// ObserveRestore records a finished restore.
+func ObserveRestore(pgRestore *v2.PerconaPGRestore, duration time.Duration) {
+	restoreDuration.WithLabelValues(
+		pgRestore.Namespace,
+		pgRestore.Spec.PGCluster,
+		pgRestore.Spec.RepoName,
+		string(pgRestore.Status.State),
+	).Observe(duration.Seconds())
+}
+
+// SetRestoreState sets the current state of the restore.
+func SetRestoreState(pgRestore *v2.PerconaPGRestore) {
+	current := pgRestore.Status.State
+	if current == "" {
+		current = v2.RestoreNew
+	}
+	for _, state := range restoreStates {
+		value := 0.0
+		if state == current {
+			value = 1
+		}
+		restoreState.WithLabelValues(pgRestore.Namespace, pgRestore.Spec.PGCluster, pgRestore.Name, string(state)).Set(value)
+	}
+}
+
+// DeleteRestore removes the metrics of a deleted restore.
+func DeleteRestore(namespace, name string) {
+	restoreState.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "restore": name})
+}
+
+// SetClusterState sets the current state of the cluster.
+func SetClusterState(cr *v2.PerconaPGCluster, current v2.AppState) {
+	for _, state := range clusterStates {
+		value := 0.0
+		if state == current {
+			value = 1
+		}
+		clusterState.WithLabelValues(cr.Namespace, cr.Name, string(state)).Set(value)
+	}
+}
+
+// DeleteCluster removes the metrics of a deleted cluster.
+func DeleteCluster(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "cluster": name}
+
+	clusterState.DeletePartialMatch(labels)
+	lastSuccessfulBackup.DeletePartialMatch(labels)
+	restorableTimeLag.DeletePartialMatch(labels)
+	backupSize.DeletePartialMatch(labels)
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestClusterState(t *testing.T) {
+	cr := &v2.PerconaPGCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "ns"}}
+
+	SetClusterState(cr, v2.AppStateInit)
+	SetClusterState(cr, v2.AppStateReady)
+
+	assert.Equal(t, testutil.ToFloat64(clusterState.WithLabelValues("ns", "cluster1", string(v2.AppStateReady))), 1.0)
+	assert.Equal(t, testutil.ToFloat64(clusterState.WithLabelValues("ns", "cluster1", string(v2.AppStateInit))), 0.0)
+
+	SetLastSuccessfulBackup(cr, "repo1", time.Unix(1717200000, 0))
+	assert.Equal(t, testutil.ToFloat64(lastSuccessfulBackup.WithLabelValues("ns", "cluster1", "repo1")), 1717200000.0)
+
+	DeleteCluster("ns", "cluster1")
+	assert.Equal(t, testutil.CollectAndCount(clusterState), 0)
+	assert.Equal(t, testutil.CollectAndCount(lastSuccessfulBackup), 0)
+}
+
+func TestRestorableTimeLag(t *testing.T) {
+	cr := &v2.PerconaPGCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster2", Namespace: "ns"}}
+	pgBackup := &v2.PerconaPGBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "ns"},
+		Spec:       v2.PerconaPGBackupSpec{PGCluster: "cluster2", RepoName: "repo1"},
+		Status:     v2.PerconaPGBackupStatus{State: v2.BackupSucceeded},
+	}
+
+	SetRestorableTimeLag(cr, pgBackup)
+	assert.Equal(t, testutil.CollectAndCount(restorableTimeLag), 0)
+
+	pgBackup.Status.LatestRestorableTime.Time = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+	SetRestorableTimeLag(cr, pgBackup)
+
+	lag := testutil.ToFloat64(restorableTimeLag.WithLabelValues("ns", "cluster2"))
+	assert.Assert(t, lag >= time.Hour.Seconds() && lag < (time.Hour+time.Minute).Seconds(), "lag: %v", lag)
+
+	DeleteCluster("ns", "cluster2")
+	assert.Equal(t, testutil.CollectAndCount(restorableTimeLag), 0)
+}
+
+func TestObserveBackup(t *testing.T) {
+	pgBackup := &v2.PerconaPGBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "ns"},
+		Spec: v2.PerconaPGBackupSpec{
+			PGCluster: "cluster1",
+			RepoName:  "repo1",
+			Options:   []string{"--type=full"},
+		},
+		Status: v2.PerconaPGBackupStatus{State: v2.BackupSucceeded},
+	}
+
+	ObserveBackup(pgBackup, time.Minute)
+	SetBackupSize(pgBackup, 1024)
+
+	assert.Equal(t, testutil.ToFloat64(backupsTotal.WithLabelValues("ns", "cluster1", "repo1", "full", "Succeeded")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(backupSize.WithLabelValues("ns", "cluster1", "repo1", "full")), 1024.0)
+
+	expected := `
+# HELP pgv2_backup_duration_seconds Duration of finished PerconaPGBackups.
+# TYPE pgv2_backup_duration_seconds histogram
+pgv2_backup_duration_seconds_sum{cluster="cluster1",namespace="ns",repo="repo1",result="Succeeded",type="full"} 60
+pgv2_backup_duration_seconds_count{cluster="cluster1",namespace="ns",repo="repo1",result="Succeeded",type="full"} 1
+`
+	assert.NilError(t, testutil.CollectAndCompare(backupDuration, strings.NewReader(expected),
+		"pgv2_backup_duration_seconds_sum", "pgv2_backup_duration_seconds_count"))
+}
+
+func TestRestoreState(t *testing.T) {
+	pgRestore := &v2.PerconaPGRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore1", Namespace: "ns"},
+		Spec:       v2.PerconaPGRestoreSpec{PGCluster: "cluster1", RepoName: "repo1"},
+	}
+
+	SetRestoreState(pgRestore)
+	assert.Equal(t, testutil.ToFloat64(restoreState.WithLabelValues("ns", "cluster1", "restore1", string(v2.RestoreNew))), 1.0)
+
+	pgRestore.Status.State = v2.RestoreSucceeded
+	SetRestoreState(pgRestore)
+	assert.Equal(t, testutil.ToFloat64(restoreState.WithLabelValues("ns", "cluster1", "restore1", string(v2.RestoreNew))), 0.0)
+	assert.Equal(t, testutil.ToFloat64(restoreState.WithLabelValues("ns", "cluster1", "restore1", string(v2.RestoreSucceeded))), 1.0)
+
+	DeleteRestore("ns", "restore1")
+	assert.Equal(t, testutil.CollectAndCount(restoreState), 0)
+}
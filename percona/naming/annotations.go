@@ -38,6 +38,14 @@ const (
 	// indicate that it is a cluster bootstrap restore.
 	AnnotationClusterBootstrapRestore = PrefixPerconaPGV2 + "cluster-bootstrap-restore"
 
+	// AnnotationRestoredFrom is the annotation that is added to a PerconaPGCluster created by
+	// a PerconaPGRestore. The value of the annotation is the namespace and the name of the restore.
+	AnnotationRestoredFrom = PrefixPerconaPGV2 + "restored-from"
+
+	// AnnotationPrimaryServiceTarget is the annotation that is added to a PerconaPGCluster after a
+	// logical upgrade switched its clients to the new cluster. The value is the name of the new cluster.
+	AnnotationPrimaryServiceTarget = PrefixPerconaPGV2 + "primary-service-target"
+
 	AnnotationPatroniVersion = PrefixPerconaPGV2 + "patroni-version"
 
 	// Special annotation to disable `patroni-version-check` by overriding the patroni version with a custom value.
@@ -2,4 +2,7 @@ package naming
 
 const (
 	ConditionClusterIsReadyForBackup = "ReadyForBackup"
+
+	ConditionCustomExtensionVerificationFailed = "CustomExtensionVerificationFailed"
+	ConditionCustomExtensionsFailed            = "CustomExtensionsFailed"
 )
@@ -2,4 +2,16 @@ package naming
 
 const (
 	LabelOperatorVersion = PrefixPerconaPGV2 + "version"
+
+	// LabelBackupSchedule is the label that is added to a PerconaPGBackup
+	// created by a PerconaPGBackupSchedule. The value is the name of the schedule.
+	LabelBackupSchedule = PrefixPerconaPGV2 + "backup-schedule"
+
+	// LabelUpgradeCheck is the label that is added to the objects of the
+	// pre-flight check of a PerconaPGUpgrade. The value is the name of the upgrade.
+	LabelUpgradeCheck = PrefixPerconaPGV2 + "upgrade-check"
+
+	// LabelUpgrade is the label that is added to the backups and Jobs that a
+	// PerconaPGUpgrade creates to roll back. The value is the name of the upgrade.
+	LabelUpgrade = PrefixPerconaPGV2 + "upgrade"
 )
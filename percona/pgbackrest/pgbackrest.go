@@ -25,6 +25,18 @@ type InfoBackup struct {
 		Start int64 `json:"start,omitempty"`
 		Stop  int64 `json:"stop,omitempty"`
 	} `json:"timestamp,omitempty"`
+	LSN struct {
+		Start string `json:"start,omitempty"`
+		Stop  string `json:"stop,omitempty"`
+	} `json:"lsn,omitempty"`
+	Info struct {
+		Size       int64 `json:"size,omitempty"`
+		Delta      int64 `json:"delta,omitempty"`
+		Repository struct {
+			Size  int64 `json:"size,omitempty"`
+			Delta int64 `json:"delta,omitempty"`
+		} `json:"repository,omitempty"`
+	} `json:"info,omitempty"`
 }
 
 type InfoStanza struct {
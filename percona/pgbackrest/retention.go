@@ -0,0 +1,82 @@
+package pgbackrest
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/fulviodenza/percona-postgresql-operator/percona/clientcmd"
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+// ExpiredBackupSets returns the labels of the full backup sets of the stanza
+// that are outside of the retention policy and the number of full backup sets
+// that are kept. Expiring a full backup set with pgBackRest also expires the
+// differential and incremental backups that depend on it.
+func ExpiredBackupSets(stanza InfoStanza, retention *v2.PGBackRestRetention, now time.Time) ([]string, int32) {
+	fulls := make([]InfoBackup, 0, len(stanza.Backup))
+	for _, backup := range stanza.Backup {
+		if backup.Type == v2.PGBackupTypeFull {
+			fulls = append(fulls, backup)
+		}
+	}
+
+	// newest backups first
+	sort.SliceStable(fulls, func(i, j int) bool {
+		return fulls[i].Timestamp.Stop > fulls[j].Timestamp.Stop
+	})
+
+	if retention == nil {
+		return nil, int32(len(fulls))
+	}
+
+	keepChain := retention.KeepRestorableChain == nil || *retention.KeepRestorableChain
+
+	var expired []string
+	var retained int32
+	for i, backup := range fulls {
+		outdated := false
+
+		if retention.Full != nil && int32(i) >= *retention.Full {
+			outdated = true
+		}
+		if retention.MaxAge != nil && time.Unix(backup.Timestamp.Stop, 0).Before(now.Add(-retention.MaxAge.Duration)) {
+			outdated = true
+		}
+		if i == 0 && keepChain {
+			outdated = false
+		}
+
+		if outdated {
+			expired = append(expired, backup.Label)
+			continue
+		}
+		retained++
+	}
+
+	return expired, retained
+}
+
+// ExpireBackupSet runs "pgbackrest expire" for a single backup set. The
+// command should be run in a pod that has local access to the repo.
+func ExpireBackupSet(ctx context.Context, pod *corev1.Pod, container, stanza, repoName, backupSet string) error {
+	stderr := new(bytes.Buffer)
+
+	c, err := clientcmd.NewClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create client")
+	}
+
+	cmd := []string{"pgbackrest", "expire", "--stanza=" + stanza, "--repo=" + strings.TrimPrefix(repoName, "repo"), "--set=" + backupSet}
+
+	if err := c.Exec(ctx, pod, container, nil, nil, stderr, cmd...); err != nil {
+		return errors.Wrapf(err, "exec: %s", stderr.String())
+	}
+
+	return nil
+}
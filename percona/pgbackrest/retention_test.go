@@ -0,0 +1,95 @@
+package pgbackrest
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+func TestExpiredBackupSets(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	backup := func(label string, backupType v2.PGBackupType, age time.Duration) InfoBackup {
+		b := InfoBackup{Label: label, Type: backupType}
+		b.Timestamp.Stop = now.Add(-age).Unix()
+		return b
+	}
+
+	stanza := InfoStanza{
+		Name: "db",
+		Backup: []InfoBackup{
+			backup("full-3", v2.PGBackupTypeFull, 72*time.Hour),
+			backup("diff-3", v2.PGBackupTypeDifferential, 60*time.Hour),
+			backup("full-2", v2.PGBackupTypeFull, 48*time.Hour),
+			backup("incr-2", v2.PGBackupTypeIncremental, 36*time.Hour),
+			backup("full-1", v2.PGBackupTypeFull, 24*time.Hour),
+		},
+	}
+
+	tests := map[string]struct {
+		retention        *v2.PGBackRestRetention
+		stanza           InfoStanza
+		expectedExpired  []string
+		expectedRetained int32
+	}{
+		"no policy": {
+			retention:        nil,
+			stanza:           stanza,
+			expectedRetained: 3,
+		},
+		"keep two full backups": {
+			retention:        &v2.PGBackRestRetention{Full: ptr.To(int32(2))},
+			stanza:           stanza,
+			expectedExpired:  []string{"full-3"},
+			expectedRetained: 2,
+		},
+		"max age": {
+			retention:        &v2.PGBackRestRetention{MaxAge: &metav1.Duration{Duration: 36 * time.Hour}},
+			stanza:           stanza,
+			expectedExpired:  []string{"full-2", "full-3"},
+			expectedRetained: 1,
+		},
+		"max age keeps restorable chain": {
+			retention:        &v2.PGBackRestRetention{MaxAge: &metav1.Duration{Duration: time.Hour}},
+			stanza:           stanza,
+			expectedExpired:  []string{"full-2", "full-3"},
+			expectedRetained: 1,
+		},
+		"max age without restorable chain": {
+			retention: &v2.PGBackRestRetention{
+				MaxAge:              &metav1.Duration{Duration: time.Hour},
+				KeepRestorableChain: ptr.To(false),
+			},
+			stanza:           stanza,
+			expectedExpired:  []string{"full-1", "full-2", "full-3"},
+			expectedRetained: 0,
+		},
+		"full and max age combined": {
+			retention: &v2.PGBackRestRetention{
+				Full:   ptr.To(int32(2)),
+				MaxAge: &metav1.Duration{Duration: 100 * time.Hour},
+			},
+			stanza:           stanza,
+			expectedExpired:  []string{"full-3"},
+			expectedRetained: 2,
+		},
+		"no backups": {
+			retention:        &v2.PGBackRestRetention{Full: ptr.To(int32(1))},
+			stanza:           InfoStanza{Name: "db"},
+			expectedRetained: 0,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			expired, retained := ExpiredBackupSets(tt.stanza, tt.retention, now)
+			assert.DeepEqual(t, tt.expectedExpired, expired)
+			assert.Equal(t, tt.expectedRetained, retained)
+		})
+	}
+}
@@ -10,9 +10,11 @@ import (
 	"strings"
 	"time"
 
+	gover "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 
 	"github.com/fulviodenza/percona-postgresql-operator/percona/version/service/client"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/version/service/client/models"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/version/service/client/version_service"
 )
 
@@ -58,7 +60,7 @@ func getDefaultVersionServiceEndpoint() string {
 }
 
 func EnsureVersion(ctx context.Context, meta Meta) error {
-	err := fetchVersions(ctx, getDefaultVersionServiceEndpoint(), meta)
+	_, err := fetchVersions(ctx, getDefaultVersionServiceEndpoint(), meta)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("failed to send telemetry to %s", getDefaultVersionServiceEndpoint()))
 	}
@@ -66,10 +68,94 @@ func EnsureVersion(ctx context.Context, meta Meta) error {
 	return nil
 }
 
-func fetchVersions(ctx context.Context, endpoint string, vm Meta) error {
+// DepVersion is a set of component versions and their images.
+type DepVersion struct {
+	PostgresVersion   string
+	PostgresImage     string
+	PGBouncerVersion  string
+	PGBouncerImage    string
+	PGBackRestVersion string
+	PGBackRestImage   string
+	PMMVersion        string
+	PMMImage          string
+}
+
+// GetVersions asks the version service at endpoint for the versions selected
+// by meta.Apply. Only PostgreSQL versions of the major version in
+// meta.PGVersion are considered. The default endpoint is used when endpoint
+// is empty.
+func GetVersions(ctx context.Context, endpoint string, meta Meta) (DepVersion, error) {
+	if endpoint == "" {
+		endpoint = getDefaultVersionServiceEndpoint()
+	}
+
+	resp, err := fetchVersions(ctx, endpoint, meta)
+	if err != nil {
+		return DepVersion{}, errors.Wrapf(err, "failed to get versions from %s", endpoint)
+	}
+	if resp == nil || len(resp.Versions) == 0 || resp.Versions[0].Matrix == nil {
+		return DepVersion{}, errors.Errorf("no versions returned by %s", endpoint)
+	}
+	matrix := resp.Versions[0].Matrix
+
+	major, err := gover.NewVersion(meta.PGVersion)
+	if err != nil {
+		return DepVersion{}, errors.Wrapf(err, "parse PostgreSQL version %q", meta.PGVersion)
+	}
+	sameMajor := func(v *gover.Version) bool {
+		return v.Segments()[0] == major.Segments()[0]
+	}
+
+	var dv DepVersion
+	dv.PostgresVersion, dv.PostgresImage, err = newestVersion(matrix.Postgresql, sameMajor)
+	if err != nil {
+		return DepVersion{}, errors.Wrapf(err, "postgresql %d", major.Segments()[0])
+	}
+	dv.PGBouncerVersion, dv.PGBouncerImage, err = newestVersion(matrix.Pgbouncer, nil)
+	if err != nil {
+		return DepVersion{}, errors.Wrap(err, "pgbouncer")
+	}
+	dv.PGBackRestVersion, dv.PGBackRestImage, err = newestVersion(matrix.Pgbackrest, nil)
+	if err != nil {
+		return DepVersion{}, errors.Wrap(err, "pgbackrest")
+	}
+	if len(matrix.Pmm) > 0 {
+		dv.PMMVersion, dv.PMMImage, err = newestVersion(matrix.Pmm, nil)
+		if err != nil {
+			return DepVersion{}, errors.Wrap(err, "pmm")
+		}
+	}
+
+	return dv, nil
+}
+
+// newestVersion returns the newest of versions accepted by match, or of all
+// versions when match is nil.
+func newestVersion(versions map[string]models.VersionVersion, match func(*gover.Version) bool) (string, string, error) {
+	var newest *gover.Version
+	var name, image string
+	for k, v := range versions {
+		parsed, err := gover.NewVersion(k)
+		if err != nil || (match != nil && !match(parsed)) {
+			continue
+		}
+		if newest == nil || parsed.GreaterThan(newest) {
+			newest, name, image = parsed, k, v.ImagePath
+		}
+	}
+	if newest == nil {
+		return "", "", errors.New("no matching version")
+	}
+	if image == "" {
+		return "", "", errors.Errorf("no image for version %s", name)
+	}
+	return name, image, nil
+}
+
+func fetchVersions(ctx context.Context, endpoint string, vm Meta) (*models.VersionVersionResponse, error) {
 	requestURL, err := url.Parse(endpoint)
 	if err != nil {
-		return errors.Wrap(err, "parse endpoint")
+		return nil, errors.Wrap(err, "parse endpoint")
 	}
 
 	srvCl := client.NewHTTPClientWithConfig(nil, &client.TransportConfig{
@@ -99,10 +185,10 @@ func fetchVersions(ctx context.Context, endpoint string, vm Meta) error {
 	}
 	applyParams = applyParams.WithTimeout(10 * time.Second)
 
-	_, err = srvCl.VersionService.VersionServiceApply(applyParams)
+	resp, err := srvCl.VersionService.VersionServiceApply(applyParams)
 	if err != nil {
-		return errors.Wrap(err, "version service apply")
+		return nil, errors.Wrap(err, "version service apply")
 	}
 
-	return nil
+	return resp.Payload, nil
 }
@@ -2,12 +2,17 @@ package version_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
 	"slices"
 	"testing"
 
+	"gotest.tools/v3/assert"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"sigs.k8s.io/yaml"
 
@@ -53,3 +58,78 @@ func TestCRDVersionLabel(t *testing.T) {
 		}
 	}
 }
+
+// versionServer is a stand-in for the version service that answers every
+// apply request with matrix.
+func versionServer(t *testing.T, matrix map[string]any, paths *[]string) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"versions": []any{map[string]any{
+				"product":  version.ProductName,
+				"operator": version.Version(),
+				"matrix":   matrix,
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func TestGetVersions(t *testing.T) {
+	ctx := context.Background()
+
+	matrix := map[string]any{
+		"postgresql": map[string]any{
+			"16.9": map[string]any{"imagePath": "percona/percona-postgresql-operator:2.7.0-ppg16.9-postgres"},
+			"17.4": map[string]any{"imagePath": "percona/percona-postgresql-operator:2.7.0-ppg17.4-postgres"},
+			"17.5": map[string]any{"imagePath": "percona/percona-postgresql-operator:2.7.0-ppg17.5-postgres"},
+		},
+		"pgbouncer": map[string]any{
+			"1.24.1": map[string]any{"imagePath": "percona/percona-pgbouncer:1.24.1"},
+		},
+		"pgbackrest": map[string]any{
+			"2.55.0": map[string]any{"imagePath": "percona/percona-pgbackrest:2.55.0"},
+		},
+		"pmm": map[string]any{
+			"3.1.0":  map[string]any{"imagePath": "percona/pmm-client:3.1.0"},
+			"2.44.1": map[string]any{"imagePath": "percona/pmm-client:2.44.1"},
+		},
+	}
+
+	var paths []string
+	endpoint := versionServer(t, matrix, &paths)
+
+	meta := version.Meta{Apply: "recommended", OperatorVersion: "2.7.0", PGVersion: "17"}
+	dv, err := version.GetVersions(ctx, endpoint, meta)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, dv, version.DepVersion{
+		PostgresVersion:   "17.5",
+		PostgresImage:     "percona/percona-postgresql-operator:2.7.0-ppg17.5-postgres",
+		PGBouncerVersion:  "1.24.1",
+		PGBouncerImage:    "percona/percona-pgbouncer:1.24.1",
+		PGBackRestVersion: "2.55.0",
+		PGBackRestImage:   "percona/percona-pgbackrest:2.55.0",
+		PMMVersion:        "3.1.0",
+		PMMImage:          "percona/pmm-client:3.1.0",
+	})
+	assert.DeepEqual(t, paths, []string{"/versions/v1/pg-operator/2.7.0/recommended"})
+
+	t.Run("OtherMajorVersion", func(t *testing.T) {
+		meta := version.Meta{Apply: "latest", OperatorVersion: "2.7.0", PGVersion: "15"}
+		_, err := version.GetVersions(ctx, endpoint, meta)
+		assert.ErrorContains(t, err, "postgresql 15: no matching version")
+	})
+
+	t.Run("NoVersions", func(t *testing.T) {
+		var paths []string
+		endpoint := versionServer(t, nil, &paths)
+
+		_, err := version.GetVersions(ctx, endpoint, meta)
+		assert.ErrorContains(t, err, "no versions returned")
+	})
+}
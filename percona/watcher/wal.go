@@ -14,6 +14,7 @@ import (
 
 	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/clientcmd"
+	"github.com/fulviodenza/percona-postgresql-operator/percona/metrics"
 	"github.com/fulviodenza/percona-postgresql-operator/percona/pgbackrest"
 	perconaPG "github.com/fulviodenza/percona-postgresql-operator/percona/postgres"
 	pgv2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
@@ -70,6 +71,8 @@ func WatchCommitTimestamps(ctx context.Context, cli client.Client, eventChan cha
 				continue
 			}
 
+			metrics.SetRestorableTimeLag(localCr, latestBackup)
+
 			ts, err := GetLatestCommitTimestamp(ctx, cli, execCli, localCr, latestBackup)
 			if err != nil {
 				switch {
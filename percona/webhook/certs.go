@@ -0,0 +1,275 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
+	"github.com/fulviodenza/percona-postgresql-operator/internal/pki"
+)
+
+const (
+	// keyCACert and keyCAKey are the keys of the certificate authority in
+	// the certificate Secret. The CA is kept so serving certificates can be
+	// renewed without changing the caBundle of the webhook configurations.
+	keyCACert = "ca.crt"
+	keyCAKey  = "ca.key"
+
+	// certCheckInterval is how often the serving certificate is checked
+	// and renewed when it's close to expiring.
+	certCheckInterval = time.Hour
+)
+
+// CertManager manages the certificate of the webhook server. The certificate
+// and its authority are stored in a Secret so all replicas of the operator
+// serve the same certificate. The authority is injected as caBundle into the
+// webhook configurations that point to the webhook Service.
+type CertManager struct {
+	// Client must not be backed by the cache of the manager, the certificate
+	// is needed before the cache is started.
+	Client client.Client
+
+	Namespace   string
+	ServiceName string
+
+	cert atomic.Pointer[tls.Certificate]
+}
+
+// NewCertManager returns a CertManager for the webhook Service in namespace.
+func NewCertManager(cl client.Client, namespace, serviceName string) *CertManager {
+	return &CertManager{
+		Client:      cl,
+		Namespace:   namespace,
+		ServiceName: serviceName,
+	}
+}
+
+// SecretName returns the name of the Secret the certificate is stored in.
+func (m *CertManager) SecretName() string {
+	return m.ServiceName + "-cert"
+}
+
+// DNSNames returns the names the webhook Service can be reached with.
+func (m *CertManager) DNSNames() []string {
+	return []string{
+		m.ServiceName + "." + m.Namespace + ".svc",
+		m.ServiceName + "." + m.Namespace + ".svc.cluster.local",
+		m.ServiceName + "." + m.Namespace,
+		m.ServiceName,
+	}
+}
+
+// GetCertificate returns the current serving certificate. It's meant to be
+// used as tls.Config.GetCertificate of the webhook server.
+func (m *CertManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := m.cert.Load()
+	if cert == nil {
+		return nil, errors.New("webhook certificate is not ready")
+	}
+	return cert, nil
+}
+
+// TLSOpts configures the webhook server to serve the managed certificate.
+func (m *CertManager) TLSOpts(c *tls.Config) {
+	c.GetCertificate = m.GetCertificate
+}
+
+// Ensure creates or renews the certificate, loads it and injects its
+// authority into the webhook configurations.
+func (m *CertManager) Ensure(ctx context.Context) error {
+	var secret *corev1.Secret
+	err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+		// Another replica of the operator updated the Secret first.
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		var err error
+		secret, err = m.ensureSecret(ctx)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "ensure certificate secret")
+	}
+
+	cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return errors.Wrap(err, "load certificate")
+	}
+	m.cert.Store(&cert)
+
+	return errors.Wrap(m.injectCABundle(ctx, secret.Data[keyCACert]), "inject CA bundle")
+}
+
+func (m *CertManager) ensureSecret(ctx context.Context) (*corev1.Secret, error) {
+	secret := new(corev1.Secret)
+	err := m.Client.Get(ctx, client.ObjectKey{Namespace: m.Namespace, Name: m.SecretName()}, secret)
+	exists := err == nil
+	if client.IgnoreNotFound(err) != nil {
+		return nil, errors.Wrap(err, "get secret")
+	}
+
+	data, err := m.certificateData(secret.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists && equalData(secret.Data, data) {
+		return secret, nil
+	}
+
+	if !exists {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      m.SecretName(),
+				Namespace: m.Namespace,
+			},
+			Type: corev1.SecretTypeTLS,
+			Data: data,
+		}
+		return secret, errors.Wrap(m.Client.Create(ctx, secret), "create secret")
+	}
+
+	secret.Data = data
+	return secret, errors.Wrap(m.Client.Update(ctx, secret), "update secret")
+}
+
+// certificateData returns the certificate data from the Secret when it's
+// still valid. Otherwise, it returns new certificates.
+func (m *CertManager) certificateData(current map[string][]byte) (map[string][]byte, error) {
+	root := new(pki.RootCertificateAuthority)
+	_ = root.Certificate.UnmarshalText(current[keyCACert])
+	_ = root.PrivateKey.UnmarshalText(current[keyCAKey])
+
+	if !pki.RootIsValid(root) {
+		var err error
+		root, err = pki.NewRootCertificateAuthority()
+		if err != nil {
+			return nil, errors.Wrap(err, "generate certificate authority")
+		}
+	}
+
+	leaf := new(pki.LeafCertificate)
+	_ = leaf.Certificate.UnmarshalText(current[corev1.TLSCertKey])
+	_ = leaf.PrivateKey.UnmarshalText(current[corev1.TLSPrivateKeyKey])
+
+	dnsNames := m.DNSNames()
+	leaf, err := root.RegenerateLeafWhenNecessary(leaf, dnsNames[0], dnsNames)
+	if err != nil {
+		return nil, errors.Wrap(err, "generate certificate")
+	}
+
+	data := make(map[string][]byte, 4)
+	for key, value := range map[string]interface {
+		MarshalText() ([]byte, error)
+	}{
+		keyCACert:               root.Certificate,
+		keyCAKey:                root.PrivateKey,
+		corev1.TLSCertKey:       leaf.Certificate,
+		corev1.TLSPrivateKeyKey: leaf.PrivateKey,
+	} {
+		if data[key], err = value.MarshalText(); err != nil {
+			return nil, errors.Wrapf(err, "marshal %s", key)
+		}
+	}
+	return data, nil
+}
+
+func equalData(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if !bytes.Equal(v, b[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// injectCABundle sets the caBundle of the webhooks that point to the webhook
+// Service. The webhook configurations are expected to have the same name as
+// the Service. Missing configurations are skipped.
+func (m *CertManager) injectCABundle(ctx context.Context, caBundle []byte) error {
+	key := client.ObjectKey{Name: m.ServiceName}
+
+	validating := new(admissionv1.ValidatingWebhookConfiguration)
+	if err := m.Client.Get(ctx, key, validating); client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, "get validating webhook configuration")
+	} else if err == nil {
+		changed := false
+		for i := range validating.Webhooks {
+			changed = m.setCABundle(&validating.Webhooks[i].ClientConfig, caBundle) || changed
+		}
+		if changed {
+			if err := m.Client.Update(ctx, validating); err != nil {
+				return errors.Wrap(err, "update validating webhook configuration")
+			}
+		}
+	}
+
+	mutating := new(admissionv1.MutatingWebhookConfiguration)
+	if err := m.Client.Get(ctx, key, mutating); client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, "get mutating webhook configuration")
+	} else if err == nil {
+		changed := false
+		for i := range mutating.Webhooks {
+			changed = m.setCABundle(&mutating.Webhooks[i].ClientConfig, caBundle) || changed
+		}
+		if changed {
+			if err := m.Client.Update(ctx, mutating); err != nil {
+				return errors.Wrap(err, "update mutating webhook configuration")
+			}
+		}
+	}
+
+	return nil
+}
+
+// setCABundle sets caBundle in cfg if it points to the webhook Service. It
+// reports whether cfg changed.
+func (m *CertManager) setCABundle(cfg *admissionv1.WebhookClientConfig, caBundle []byte) bool {
+	if cfg.Service == nil || cfg.Service.Name != m.ServiceName || cfg.Service.Namespace != m.Namespace {
+		return false
+	}
+	if bytes.Equal(cfg.CABundle, caBundle) {
+		return false
+	}
+	cfg.CABundle = caBundle
+	return true
+}
+
+// Start renews the certificate periodically until ctx is done. It implements
+// manager.Runnable.
+func (m *CertManager) Start(ctx context.Context) error {
+	log := logging.FromContext(ctx).WithName("webhook-certs")
+
+	ticker := time.NewTicker(certCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := m.Ensure(ctx); err != nil {
+				log.Error(err, "failed to ensure webhook certificate")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica
+// of the operator serves webhooks and needs the current certificate.
+func (m *CertManager) NeedLeaderElection() bool {
+	return false
+}
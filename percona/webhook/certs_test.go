@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCertManagerEnsure(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	assert.NilError(t, clientgoscheme.AddToScheme(s))
+
+	service := func(name string) *admissionv1.ServiceReference {
+		return &admissionv1.ServiceReference{Name: name, Namespace: "pgo"}
+	}
+	validating := &admissionv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook"},
+		Webhooks: []admissionv1.ValidatingWebhook{
+			{Name: "ours", ClientConfig: admissionv1.WebhookClientConfig{Service: service("webhook")}},
+			{Name: "other", ClientConfig: admissionv1.WebhookClientConfig{Service: service("other")}},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(validating).Build()
+
+	m := NewCertManager(cl, "pgo", "webhook")
+
+	_, err := m.GetCertificate(nil)
+	assert.ErrorContains(t, err, "not ready")
+
+	assert.NilError(t, m.Ensure(ctx))
+
+	secret := new(corev1.Secret)
+	assert.NilError(t, cl.Get(ctx, client.ObjectKey{Namespace: "pgo", Name: "webhook-cert"}, secret))
+	assert.Equal(t, secret.Type, corev1.SecretTypeTLS)
+
+	cert, err := m.GetCertificate(nil)
+	assert.NilError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NilError(t, err)
+	assert.DeepEqual(t, leaf.DNSNames, m.DNSNames())
+
+	// The certificate is trusted by the CA in the webhook configuration.
+	assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(validating), validating))
+	assert.DeepEqual(t, validating.Webhooks[0].ClientConfig.CABundle, secret.Data[keyCACert])
+	assert.Assert(t, validating.Webhooks[1].ClientConfig.CABundle == nil)
+
+	roots := x509.NewCertPool()
+	assert.Assert(t, roots.AppendCertsFromPEM(validating.Webhooks[0].ClientConfig.CABundle))
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: "webhook.pgo.svc"})
+	assert.NilError(t, err)
+
+	// A valid certificate is kept.
+	assert.NilError(t, m.Ensure(ctx))
+	renewed := new(corev1.Secret)
+	assert.NilError(t, cl.Get(ctx, client.ObjectKeyFromObject(secret), renewed))
+	assert.DeepEqual(t, renewed.Data, secret.Data)
+
+	// A certificate for another service is replaced, the CA is kept.
+	other := NewCertManager(cl, "pgo", "webhook")
+	other.ServiceName = "renamed"
+	data, err := other.certificateData(secret.Data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, data[keyCACert], secret.Data[keyCACert])
+	assert.Assert(t, string(data[corev1.TLSCertKey]) != string(secret.Data[corev1.TLSCertKey]))
+}
@@ -0,0 +1,484 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	gover "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// maxInstanceSetNameLength is the maximum combined length of the cluster and
+// instance set names. See the Name field of PGInstanceSetSpec.
+const maxInstanceSetNameLength = 46
+
+// maxRotatedUserNameLength is the maximum length of the name of a user whose
+// password is rotated with a grace period. See the GracePeriod field of
+// PostgresPasswordRotationSpec.
+const maxRotatedUserNameLength = 59
+
+var (
+	_ admission.CustomValidator = &clusterValidator{}
+	_ admission.CustomValidator = &backupValidator{}
+	_ admission.CustomValidator = &backupScheduleValidator{}
+	_ admission.CustomValidator = &restoreValidator{}
+	_ admission.CustomValidator = &upgradeValidator{}
+)
+
+type clusterValidator struct{}
+
+func (v *clusterValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	cr, ok := obj.(*v2.PerconaPGCluster)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGCluster but got %T", obj)
+	}
+	return nil, invalid("PerconaPGCluster", cr, validateCluster(cr))
+}
+
+func (v *clusterValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	cr, ok := newObj.(*v2.PerconaPGCluster)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGCluster but got %T", newObj)
+	}
+	old, ok := oldObj.(*v2.PerconaPGCluster)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGCluster but got %T", oldObj)
+	}
+	// Changes of the metadata and status don't need the spec to be validated
+	// again.
+	if deleting(cr) || equality.Semantic.DeepEqual(old.Spec, cr.Spec) {
+		return nil, nil
+	}
+
+	errs := validateCluster(cr)
+	if cr.Spec.PostgresVersion < old.Spec.PostgresVersion {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "postgresVersion"),
+			fmt.Sprintf("can't be decreased from %d to %d", old.Spec.PostgresVersion, cr.Spec.PostgresVersion)))
+	}
+	return nil, invalid("PerconaPGCluster", cr, errs)
+}
+
+func (v *clusterValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateCluster(cr *v2.PerconaPGCluster) field.ErrorList {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+
+	for i, set := range cr.Spec.InstanceSets {
+		if len(cr.Name)+len(set.Name) > maxInstanceSetNameLength {
+			errs = append(errs, field.TooLong(spec.Child("instances").Index(i).Child("name"),
+				set.Name, maxInstanceSetNameLength-len(cr.Name)))
+		}
+	}
+
+	errs = append(errs, validateReplicateFrom(cr, spec.Child("instances"))...)
+
+	failover := false
+	for _, set := range cr.Spec.InstanceSets {
+		failover = failover || set.Patroni == nil || !set.Patroni.NoFailover
+	}
+	if len(cr.Spec.InstanceSets) > 0 && !failover {
+		errs = append(errs, field.Invalid(spec.Child("instances"), len(cr.Spec.InstanceSets),
+			"at least one instance set must not be tagged noFailover"))
+	}
+
+	secrets := spec.Child("secrets")
+	switch {
+	case cr.Spec.Secrets.CustomTLSSecret != nil && cr.Spec.Secrets.CustomReplicationClientTLSSecret == nil:
+		errs = append(errs, field.Required(secrets.Child("customReplicationTLSSecret"),
+			"must be set together with customTLSSecret"))
+	case cr.Spec.Secrets.CustomTLSSecret == nil && cr.Spec.Secrets.CustomReplicationClientTLSSecret != nil:
+		errs = append(errs, field.Required(secrets.Child("customTLSSecret"),
+			"must be set together with customReplicationTLSSecret"))
+	}
+
+	// The exporter runs without an image from neither the spec nor the
+	// environment of the operator.
+	if cr.ExporterEnabled() && cr.Spec.Monitoring.Exporter.Image == "" &&
+		os.Getenv("RELATED_IMAGE_PGEXPORTER") == "" {
+		errs = append(errs, field.Required(spec.Child("monitoring", "exporter", "image"),
+			"must be set unless the operator has RELATED_IMAGE_PGEXPORTER"))
+	}
+
+	if cr.Spec.Patroni != nil && cr.Spec.Patroni.Synchronous.Enabled() {
+		if err := validateSynchronous(cr, spec.Child("patroni", "synchronous", "nodeCount")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if so := cr.Spec.Switchover; so != nil && so.Type == v1beta1.PatroniSwitchoverTypeFailover && so.TargetInstance == "" {
+		errs = append(errs, field.Required(spec.Child("switchover", "targetInstance"),
+			"a failover needs a target instance"))
+	}
+
+	for i, user := range cr.Spec.Users {
+		if user.Password != nil && user.Password.Rotation != nil {
+			path := spec.Child("users").Index(i).Child("password", "rotation")
+			rotation := user.Password.Rotation
+			if rotation.Interval.Duration <= 0 {
+				errs = append(errs, field.Invalid(path.Child("interval"),
+					rotation.Interval.Duration.String(), "must be greater than zero"))
+			}
+			if rotation.GracePeriod != nil && rotation.GracePeriod.Duration >= rotation.Interval.Duration {
+				errs = append(errs, field.Invalid(path.Child("gracePeriod"),
+					rotation.GracePeriod.Duration.String(), "must be less than the interval"))
+			}
+			if user.Password.Source != nil {
+				errs = append(errs, field.Forbidden(path,
+					"cannot rotate a password from an external source"))
+			}
+			// The alternate login role of the user must fit into a
+			// PostgreSQL identifier.
+			if rotation.GracePeriod != nil && rotation.GracePeriod.Duration > 0 &&
+				len(user.Name) > maxRotatedUserNameLength {
+				errs = append(errs, field.TooLong(spec.Child("users").Index(i).Child("name"),
+					user.Name, maxRotatedUserNameLength))
+			}
+		}
+	}
+
+	if cr.Spec.Backups.IsEnabled() && len(cr.Spec.Backups.PGBackRest.Repos) == 0 {
+		errs = append(errs, field.Required(spec.Child("backups", "pgbackrest", "repos"),
+			"at least one repo is required when backups are enabled"))
+	}
+
+	if opts := cr.Spec.UpgradeOptions; opts != nil {
+		errs = append(errs, validateUpgradeOptions(opts, cr.Spec.PostgresVersion, spec.Child("upgradeOptions"))...)
+	}
+
+	return errs
+}
+
+// validateReplicateFrom checks that instance sets replicate from other instance
+// sets of the cluster and that the cascade of sets ends at the primary.
+func validateReplicateFrom(cr *v2.PerconaPGCluster, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	from := make(map[string]string, len(cr.Spec.InstanceSets))
+	for _, set := range cr.Spec.InstanceSets {
+		from[set.Name] = ""
+		if set.Patroni != nil {
+			from[set.Name] = set.Patroni.ReplicateFrom
+		}
+	}
+
+	for i, set := range cr.Spec.InstanceSets {
+		name := from[set.Name]
+		if name == "" {
+			continue
+		}
+		child := path.Index(i).Child("patroni", "replicateFrom")
+
+		if _, ok := from[name]; !ok {
+			errs = append(errs, field.NotFound(child, name))
+			continue
+		}
+		if name == set.Name {
+			errs = append(errs, field.Invalid(child, name, "an instance set cannot replicate from itself"))
+			continue
+		}
+
+		// Follow the cascade; a set that comes back around never reaches the primary.
+		seen := map[string]bool{set.Name: true}
+		for next := name; next != ""; next = from[next] {
+			if seen[next] {
+				errs = append(errs, field.Invalid(child, name, "instance sets cannot replicate from each other in a cycle"))
+				break
+			}
+			seen[next] = true
+		}
+	}
+
+	return errs
+}
+
+// validateUpgradeOptions checks that apply names a known strategy or a version
+// of the cluster's major version, and that the schedule is a Cron expression.
+func validateUpgradeOptions(opts *v2.UpgradeOptions, postgresVersion int, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	switch opts.Apply {
+	case "", v2.UpgradeApplyDisabled, v2.UpgradeApplyNever, v2.UpgradeApplyRecommended, v2.UpgradeApplyLatest:
+	default:
+		v, err := gover.NewVersion(string(opts.Apply))
+		switch {
+		case err != nil:
+			errs = append(errs, field.Invalid(path.Child("apply"), opts.Apply,
+				"must be disabled, never, recommended, latest or a PostgreSQL version"))
+		case v.Segments()[0] != postgresVersion:
+			errs = append(errs, field.Invalid(path.Child("apply"), opts.Apply,
+				fmt.Sprintf("must be a version of PostgreSQL %d; use PerconaPGUpgrade for major upgrades", postgresVersion)))
+		}
+	}
+
+	if opts.Schedule != "" {
+		if _, err := cron.ParseStandard(opts.Schedule); err != nil {
+			errs = append(errs, field.Invalid(path.Child("schedule"), opts.Schedule, err.Error()))
+		}
+	}
+
+	return errs
+}
+
+// validateSynchronous checks that the cluster has enough replicas for the
+// requested number of synchronous standbys. Instances of sets with the nosync
+// tag are never synchronous standbys, and one of the other instances may be
+// the primary.
+func validateSynchronous(cr *v2.PerconaPGCluster, path *field.Path) *field.Error {
+	var candidates int32
+	for _, set := range cr.Spec.InstanceSets {
+		if set.Patroni != nil && set.Patroni.NoSync {
+			continue
+		}
+		replicas := int32(1)
+		if set.Replicas != nil {
+			replicas = *set.Replicas
+		}
+		candidates += replicas
+	}
+	if candidates > 0 {
+		candidates--
+	}
+
+	count := cr.Spec.Patroni.Synchronous.GetNodeCount()
+	if count > candidates {
+		return field.Invalid(path, count,
+			fmt.Sprintf("exceeds the %d replicas that can be synchronous standbys", candidates))
+	}
+	return nil
+}
+
+type backupValidator struct {
+	client client.Reader
+}
+
+func (v *backupValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	backup, ok := obj.(*v2.PerconaPGBackup)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGBackup but got %T", obj)
+	}
+	return nil, invalid("PerconaPGBackup", backup, v.validate(ctx, backup))
+}
+
+func (v *backupValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	backup, ok := newObj.(*v2.PerconaPGBackup)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGBackup but got %T", newObj)
+	}
+	old, ok := oldObj.(*v2.PerconaPGBackup)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGBackup but got %T", oldObj)
+	}
+	// The cluster may be gone by now, only changes of the spec are checked.
+	if deleting(backup) || equality.Semantic.DeepEqual(old.Spec, backup.Spec) {
+		return nil, nil
+	}
+	return nil, invalid("PerconaPGBackup", backup, v.validate(ctx, backup))
+}
+
+func (v *backupValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *backupValidator) validate(ctx context.Context, backup *v2.PerconaPGBackup) field.ErrorList {
+	spec := field.NewPath("spec")
+
+	cluster, err := getCluster(ctx, v.client, backup.Namespace, backup.Spec.PGCluster, spec.Child("pgCluster"))
+	if err != nil {
+		return field.ErrorList{err}
+	}
+	if err := validateRepoName(cluster, backup.Spec.RepoName, spec.Child("repoName")); err != nil {
+		return field.ErrorList{err}
+	}
+	return nil
+}
+
+type backupScheduleValidator struct{}
+
+func (v *backupScheduleValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	schedule, ok := obj.(*v2.PerconaPGBackupSchedule)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGBackupSchedule but got %T", obj)
+	}
+	return nil, invalid("PerconaPGBackupSchedule", schedule, validateBackupSchedule(schedule))
+}
+
+func (v *backupScheduleValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	schedule, ok := newObj.(*v2.PerconaPGBackupSchedule)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGBackupSchedule but got %T", newObj)
+	}
+	old, ok := oldObj.(*v2.PerconaPGBackupSchedule)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGBackupSchedule but got %T", oldObj)
+	}
+	if deleting(schedule) || equality.Semantic.DeepEqual(old.Spec, schedule.Spec) {
+		return nil, nil
+	}
+	return nil, invalid("PerconaPGBackupSchedule", schedule, validateBackupSchedule(schedule))
+}
+
+func (v *backupScheduleValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateBackupSchedule checks that the schedule is a Cron expression. The
+// cluster isn't required to exist, backups are skipped until it does.
+func validateBackupSchedule(schedule *v2.PerconaPGBackupSchedule) field.ErrorList {
+	if _, err := cron.ParseStandard(schedule.Spec.Schedule); err != nil {
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "schedule"), schedule.Spec.Schedule, err.Error())}
+	}
+	return nil
+}
+
+type restoreValidator struct {
+	client client.Reader
+}
+
+func (v *restoreValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	restore, ok := obj.(*v2.PerconaPGRestore)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGRestore but got %T", obj)
+	}
+	return nil, invalid("PerconaPGRestore", restore, v.validate(ctx, restore))
+}
+
+func (v *restoreValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	restore, ok := newObj.(*v2.PerconaPGRestore)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGRestore but got %T", newObj)
+	}
+	old, ok := oldObj.(*v2.PerconaPGRestore)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGRestore but got %T", oldObj)
+	}
+	// The cluster may be gone by now, only changes of the spec are checked.
+	if deleting(restore) || equality.Semantic.DeepEqual(old.Spec, restore.Spec) {
+		return nil, nil
+	}
+	return nil, invalid("PerconaPGRestore", restore, v.validate(ctx, restore))
+}
+
+func (v *restoreValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *restoreValidator) validate(ctx context.Context, restore *v2.PerconaPGRestore) field.ErrorList {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+
+	if err := restore.Spec.ValidateTarget(); err != nil {
+		errs = append(errs, field.Invalid(spec.Child("target"), restore.Spec.Target, err.Error()))
+	}
+
+	cluster, err := getCluster(ctx, v.client, restore.Namespace, restore.Spec.PGCluster, spec.Child("pgCluster"))
+	if err != nil {
+		return append(errs, err)
+	}
+	if err := validateRepoName(cluster, restore.Spec.RepoName, spec.Child("repoName")); err != nil {
+		errs = append(errs, err)
+	}
+
+	if nc := restore.Spec.NewCluster; nc != nil {
+		namespace := nc.Namespace
+		if namespace == "" {
+			namespace = restore.Namespace
+		}
+		if nc.Name == cluster.Name && namespace == cluster.Namespace {
+			errs = append(errs, field.Invalid(spec.Child("newCluster", "name"), nc.Name,
+				"must differ from the name of the source cluster"))
+		}
+	}
+
+	return errs
+}
+
+type upgradeValidator struct {
+	client client.Reader
+}
+
+func (v *upgradeValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	upgrade, ok := obj.(*v2.PerconaPGUpgrade)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGUpgrade but got %T", obj)
+	}
+	return nil, invalid("PerconaPGUpgrade", upgrade, v.validate(ctx, upgrade))
+}
+
+func (v *upgradeValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	upgrade, ok := newObj.(*v2.PerconaPGUpgrade)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGUpgrade but got %T", newObj)
+	}
+	old, ok := oldObj.(*v2.PerconaPGUpgrade)
+	if !ok {
+		return nil, errors.Errorf("expected a PerconaPGUpgrade but got %T", oldObj)
+	}
+	// The version of the cluster changes once the upgrade is done, only
+	// changes of the spec are checked.
+	if deleting(upgrade) || equality.Semantic.DeepEqual(old.Spec, upgrade.Spec) {
+		return nil, nil
+	}
+	return nil, invalid("PerconaPGUpgrade", upgrade, v.validate(ctx, upgrade))
+}
+
+func (v *upgradeValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *upgradeValidator) validate(ctx context.Context, upgrade *v2.PerconaPGUpgrade) field.ErrorList {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+
+	if upgrade.Spec.ToPostgresVersion <= upgrade.Spec.FromPostgresVersion {
+		errs = append(errs, field.Invalid(spec.Child("toPostgresVersion"), upgrade.Spec.ToPostgresVersion,
+			fmt.Sprintf("must be greater than fromPostgresVersion %d", upgrade.Spec.FromPostgresVersion)))
+	}
+
+	cluster, err := getCluster(ctx, v.client, upgrade.Namespace, upgrade.Spec.PostgresClusterName, spec.Child("postgresClusterName"))
+	if err != nil {
+		return append(errs, err)
+	}
+	if cluster.Spec.PostgresVersion != upgrade.Spec.FromPostgresVersion {
+		errs = append(errs, field.Invalid(spec.Child("fromPostgresVersion"), upgrade.Spec.FromPostgresVersion,
+			fmt.Sprintf("doesn't match postgresVersion %d of cluster %s", cluster.Spec.PostgresVersion, cluster.Name)))
+	}
+	if upgrade.Spec.Strategy == v2.UpgradeStrategyLogical {
+		if upgrade.Spec.Backup != nil {
+			errs = append(errs, field.Forbidden(spec.Child("backup"), "not supported by the logical strategy"))
+		}
+		if upgrade.Spec.DryRun != nil {
+			errs = append(errs, field.Forbidden(spec.Child("dryRun"), "not supported by the logical strategy"))
+		}
+		if upgrade.Spec.PostUpgrade != nil {
+			errs = append(errs, field.Forbidden(spec.Child("postUpgrade"), "not supported by the logical strategy"))
+		}
+		if upgrade.Spec.Logical != nil && upgrade.Spec.Logical.TargetClusterName == cluster.Name {
+			errs = append(errs, field.Invalid(spec.Child("logical", "targetClusterName"),
+				upgrade.Spec.Logical.TargetClusterName, "must differ from postgresClusterName"))
+		}
+	}
+	if backup := upgrade.Spec.Backup; backup != nil && backup.RepoName != "" {
+		if err := validateRepoName(cluster, backup.RepoName, spec.Child("backup", "repoName")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if backup := upgrade.Spec.Backup; backup != nil && backup.Rollback && backup.RepoName == "" {
+		errs = append(errs, field.Required(spec.Child("backup", "repoName"),
+			"the backup in a repo is restored to roll back"))
+	}
+
+	return errs
+}
@@ -0,0 +1,614 @@
+package webhook
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+	"github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+func newCluster() *v2.PerconaPGCluster {
+	return &v2.PerconaPGCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster1",
+			Namespace: "pg",
+		},
+		Spec: v2.PerconaPGClusterSpec{
+			PostgresVersion: 16,
+			InstanceSets:    v2.PGInstanceSets{{Name: "instance1"}},
+			Backups: v2.Backups{
+				PGBackRest: v2.PGBackRestArchive{
+					Repos: []v1beta1.PGBackRestRepo{{Name: "repo1"}},
+				},
+			},
+		},
+	}
+}
+
+func newClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+
+	s := runtime.NewScheme()
+	assert.NilError(t, v2.AddToScheme(s))
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+}
+
+// causes returns the field paths of the causes of an Invalid API error.
+func causes(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	assert.Assert(t, apierrors.IsInvalid(err), "unexpected error: %v", err)
+
+	status, ok := err.(apierrors.APIStatus)
+	assert.Assert(t, ok)
+
+	var fields []string
+	for _, cause := range status.Status().Details.Causes {
+		fields = append(fields, cause.Field)
+	}
+	return fields
+}
+
+func TestClusterValidator(t *testing.T) {
+	ctx := context.Background()
+	v := &clusterValidator{}
+
+	tests := []struct {
+		name   string
+		modify func(cr *v2.PerconaPGCluster)
+		fields []string
+	}{
+		{
+			name:   "valid",
+			modify: func(*v2.PerconaPGCluster) {},
+		},
+		{
+			name: "instance set name too long",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.InstanceSets = append(cr.Spec.InstanceSets, v2.PGInstanceSetSpec{
+					Name: strings.Repeat("a", maxInstanceSetNameLength-len(cr.Name)+1),
+				})
+			},
+			fields: []string{"spec.instances[1].name"},
+		},
+		{
+			name: "custom TLS secret without replication secret",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Secrets.CustomTLSSecret = &corev1.SecretProjection{}
+			},
+			fields: []string{"spec.secrets.customReplicationTLSSecret"},
+		},
+		{
+			name: "replication secret without custom TLS secret",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Secrets.CustomReplicationClientTLSSecret = &corev1.SecretProjection{}
+			},
+			fields: []string{"spec.secrets.customTLSSecret"},
+		},
+		{
+			name: "synchronous standbys",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.InstanceSets[0].Replicas = ptr.To(int32(3))
+				cr.Spec.Patroni = &v1beta1.PatroniSpec{
+					Synchronous: &v1beta1.PatroniSynchronous{
+						Mode:      v1beta1.PatroniSynchronousStrict,
+						NodeCount: ptr.To(int32(2)),
+					},
+				}
+			},
+		},
+		{
+			name: "too many synchronous standbys",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.InstanceSets[0].Replicas = ptr.To(int32(3))
+				cr.Spec.InstanceSets = append(cr.Spec.InstanceSets, v2.PGInstanceSetSpec{
+					Name:     "async",
+					Replicas: ptr.To(int32(2)),
+					Patroni:  &v1beta1.InstancePatroniSpec{NoSync: true},
+				})
+				cr.Spec.Patroni = &v1beta1.PatroniSpec{
+					Synchronous: &v1beta1.PatroniSynchronous{
+						Mode:      v1beta1.PatroniSynchronousQuorum,
+						NodeCount: ptr.To(int32(3)),
+					},
+				}
+			},
+			fields: []string{"spec.patroni.synchronous.nodeCount"},
+		},
+		{
+			name: "synchronous replication without replicas",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Patroni = &v1beta1.PatroniSpec{
+					Synchronous: &v1beta1.PatroniSynchronous{Mode: v1beta1.PatroniSynchronousOn},
+				}
+			},
+			fields: []string{"spec.patroni.synchronous.nodeCount"},
+		},
+		{
+			name: "synchronous replication off",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Patroni = &v1beta1.PatroniSpec{
+					Synchronous: &v1beta1.PatroniSynchronous{Mode: v1beta1.PatroniSynchronousOff},
+				}
+			},
+		},
+		{
+			name: "cascading replication",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.InstanceSets = append(cr.Spec.InstanceSets,
+					v2.PGInstanceSetSpec{Name: "cascade1", Patroni: &v1beta1.InstancePatroniSpec{ReplicateFrom: "instance1"}},
+					v2.PGInstanceSetSpec{Name: "cascade2", Patroni: &v1beta1.InstancePatroniSpec{ReplicateFrom: "cascade1"}})
+			},
+		},
+		{
+			name: "replicate from unknown instance set",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.InstanceSets[0].Patroni = &v1beta1.InstancePatroniSpec{ReplicateFrom: "cluster1-instance1-abcd-0"}
+			},
+			fields: []string{"spec.instances[0].patroni.replicateFrom"},
+		},
+		{
+			name: "replicate from itself",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.InstanceSets[0].Patroni = &v1beta1.InstancePatroniSpec{ReplicateFrom: "instance1"}
+			},
+			fields: []string{"spec.instances[0].patroni.replicateFrom"},
+		},
+		{
+			name: "replicate in a cycle",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.InstanceSets = append(cr.Spec.InstanceSets,
+					v2.PGInstanceSetSpec{Name: "cascade1", Patroni: &v1beta1.InstancePatroniSpec{ReplicateFrom: "cascade2"}},
+					v2.PGInstanceSetSpec{Name: "cascade2", Patroni: &v1beta1.InstancePatroniSpec{ReplicateFrom: "cascade1"}})
+			},
+			fields: []string{"spec.instances[1].patroni.replicateFrom", "spec.instances[2].patroni.replicateFrom"},
+		},
+		{
+			name: "all instance sets tagged noFailover",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.InstanceSets[0].Patroni = &v1beta1.InstancePatroniSpec{NoFailover: true}
+			},
+			fields: []string{"spec.instances"},
+		},
+		{
+			name: "failover without target instance",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "1", Type: v1beta1.PatroniSwitchoverTypeFailover}
+			},
+			fields: []string{"spec.switchover.targetInstance"},
+		},
+		{
+			name: "switchover without target instance",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Switchover = &v2.SwitchoverSpec{ID: "1", Type: v1beta1.PatroniSwitchoverTypeSwitchover}
+			},
+		},
+		{
+			name: "password rotation without interval",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Users = []v1beta1.PostgresUserSpec{{
+					Name: "app",
+					Password: &v1beta1.PostgresPasswordSpec{
+						Rotation: &v1beta1.PostgresPasswordRotationSpec{},
+					},
+				}}
+			},
+			fields: []string{"spec.users[0].password.rotation.interval"},
+		},
+		{
+			name: "password rotation grace period longer than interval",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Users = []v1beta1.PostgresUserSpec{{
+					Name: "app",
+					Password: &v1beta1.PostgresPasswordSpec{
+						Rotation: &v1beta1.PostgresPasswordRotationSpec{
+							Interval:    metav1.Duration{Duration: time.Hour},
+							GracePeriod: &metav1.Duration{Duration: 2 * time.Hour},
+						},
+					},
+				}}
+			},
+			fields: []string{"spec.users[0].password.rotation.gracePeriod"},
+		},
+		{
+			name: "password rotation grace period with long user name",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Users = []v1beta1.PostgresUserSpec{{
+					Name: v1beta1.PostgresIdentifier(strings.Repeat("a", 60)),
+					Password: &v1beta1.PostgresPasswordSpec{
+						Rotation: &v1beta1.PostgresPasswordRotationSpec{
+							Interval:    metav1.Duration{Duration: 2 * time.Hour},
+							GracePeriod: &metav1.Duration{Duration: time.Hour},
+						},
+					},
+				}}
+			},
+			fields: []string{"spec.users[0].name"},
+		},
+		{
+			name: "password rotation without grace period with long user name",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Users = []v1beta1.PostgresUserSpec{{
+					Name: v1beta1.PostgresIdentifier(strings.Repeat("a", 60)),
+					Password: &v1beta1.PostgresPasswordSpec{
+						Rotation: &v1beta1.PostgresPasswordRotationSpec{
+							Interval: metav1.Duration{Duration: time.Hour},
+						},
+					},
+				}}
+			},
+		},
+		{
+			name: "password rotation with external source",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Users = []v1beta1.PostgresUserSpec{{
+					Name: "app",
+					Password: &v1beta1.PostgresPasswordSpec{
+						Rotation: &v1beta1.PostgresPasswordRotationSpec{
+							Interval: metav1.Duration{Duration: time.Hour},
+						},
+						Source: &v1beta1.PostgresPasswordSource{File: "app"},
+					},
+				}}
+			},
+			fields: []string{"spec.users[0].password.rotation"},
+		},
+		{
+			name: "backups without repos",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Backups.PGBackRest.Repos = nil
+			},
+			fields: []string{"spec.backups.pgbackrest.repos"},
+		},
+		{
+			name: "disabled backups without repos",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.Backups.Enabled = ptr.To(false)
+				cr.Spec.Backups.PGBackRest.Repos = nil
+			},
+		},
+		{
+			name: "upgrade options",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.UpgradeOptions = &v2.UpgradeOptions{Apply: v2.UpgradeApplyRecommended, Schedule: "0 4 * * *"}
+			},
+		},
+		{
+			name: "upgrade options with version",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.UpgradeOptions = &v2.UpgradeOptions{Apply: "16.9", Schedule: "0 4 * * *"}
+			},
+		},
+		{
+			name: "upgrade options with version of another major",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.UpgradeOptions = &v2.UpgradeOptions{Apply: "17.5"}
+			},
+			fields: []string{"spec.upgradeOptions.apply"},
+		},
+		{
+			name: "invalid upgrade options",
+			modify: func(cr *v2.PerconaPGCluster) {
+				cr.Spec.UpgradeOptions = &v2.UpgradeOptions{Apply: "newest", Schedule: "daily"}
+			},
+			fields: []string{"spec.upgradeOptions.apply", "spec.upgradeOptions.schedule"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := newCluster()
+			tt.modify(cr)
+
+			_, err := v.ValidateCreate(ctx, cr)
+			assert.DeepEqual(t, causes(t, err), tt.fields)
+		})
+	}
+
+	t.Run("postgres version decreased", func(t *testing.T) {
+		old := newCluster()
+		cr := newCluster()
+		cr.Spec.PostgresVersion = 15
+
+		_, err := v.ValidateUpdate(ctx, old, cr)
+		assert.DeepEqual(t, causes(t, err), []string{"spec.postgresVersion"})
+
+		_, err = v.ValidateUpdate(ctx, cr, old)
+		assert.NilError(t, err)
+	})
+
+	t.Run("spec unchanged", func(t *testing.T) {
+		old := newCluster()
+		old.Spec.Backups.PGBackRest.Repos = nil
+		cr := old.DeepCopy()
+		cr.Labels = map[string]string{"test": "label"}
+
+		_, err := v.ValidateUpdate(ctx, old, cr)
+		assert.NilError(t, err)
+
+		cr.Spec.Port = ptr.To(int32(5433))
+		_, err = v.ValidateUpdate(ctx, old, cr)
+		assert.DeepEqual(t, causes(t, err), []string{"spec.backups.pgbackrest.repos"})
+	})
+}
+
+func TestClusterValidatorExporterImage(t *testing.T) {
+	ctx := context.Background()
+	v := &clusterValidator{}
+
+	cr := newCluster()
+	cr.Spec.Monitoring = &v2.MonitoringSpec{Exporter: &v2.ExporterSpec{Enabled: true}}
+
+	t.Setenv("RELATED_IMAGE_PGEXPORTER", "")
+	_, err := v.ValidateCreate(ctx, cr)
+	assert.DeepEqual(t, causes(t, err), []string{"spec.monitoring.exporter.image"})
+
+	t.Setenv("RELATED_IMAGE_PGEXPORTER", "exporter")
+	_, err = v.ValidateCreate(ctx, cr)
+	assert.NilError(t, err)
+
+	t.Setenv("RELATED_IMAGE_PGEXPORTER", "")
+	cr.Spec.Monitoring.Exporter.Image = "exporter"
+	_, err = v.ValidateCreate(ctx, cr)
+	assert.NilError(t, err)
+}
+
+func TestClusterDefaulter(t *testing.T) {
+	cr := newCluster()
+	assert.NilError(t, (&clusterDefaulter{}).Default(context.Background(), cr))
+
+	assert.Assert(t, cr.Spec.CRVersion != "")
+	assert.Equal(t, cr.Spec.InstanceSets[0].Metadata.Labels[v2.LabelOperatorVersion], cr.Spec.CRVersion)
+	assert.Assert(t, cr.Spec.Proxy.PGBouncer != nil)
+}
+
+func TestBackupValidator(t *testing.T) {
+	ctx := context.Background()
+	v := &backupValidator{client: newClient(t, newCluster())}
+
+	tests := []struct {
+		name   string
+		spec   v2.PerconaPGBackupSpec
+		fields []string
+	}{
+		{
+			name: "valid",
+			spec: v2.PerconaPGBackupSpec{PGCluster: "cluster1", RepoName: "repo1"},
+		},
+		{
+			name:   "cluster not found",
+			spec:   v2.PerconaPGBackupSpec{PGCluster: "cluster2", RepoName: "repo1"},
+			fields: []string{"spec.pgCluster"},
+		},
+		{
+			name:   "repo not found",
+			spec:   v2.PerconaPGBackupSpec{PGCluster: "cluster1", RepoName: "repo2"},
+			fields: []string{"spec.repoName"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := &v2.PerconaPGBackup{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "pg"},
+				Spec:       tt.spec,
+			}
+
+			_, err := v.ValidateCreate(ctx, backup)
+			assert.DeepEqual(t, causes(t, err), tt.fields)
+
+			// Updates that don't change the spec are always allowed.
+			_, err = v.ValidateUpdate(ctx, backup.DeepCopy(), backup)
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestBackupScheduleValidator(t *testing.T) {
+	ctx := context.Background()
+	v := &backupScheduleValidator{}
+
+	tests := []struct {
+		name     string
+		schedule string
+		fields   []string
+	}{
+		{name: "valid", schedule: "0 * * * *"},
+		{name: "descriptor", schedule: "@daily"},
+		{name: "missing field", schedule: "0 * * *", fields: []string{"spec.schedule"}},
+		{name: "out of range", schedule: "0 25 * * *", fields: []string{"spec.schedule"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := &v2.PerconaPGBackupSchedule{
+				ObjectMeta: metav1.ObjectMeta{Name: "hourly", Namespace: "pg"},
+				Spec: v2.PerconaPGBackupScheduleSpec{
+					PGCluster: "cluster1",
+					Schedule:  tt.schedule,
+					RepoName:  "repo1",
+				},
+			}
+
+			_, err := v.ValidateCreate(ctx, schedule)
+			assert.DeepEqual(t, causes(t, err), tt.fields)
+
+			old := schedule.DeepCopy()
+			old.Spec.Schedule = "0 0 * * *"
+			_, err = v.ValidateUpdate(ctx, old, schedule)
+			assert.DeepEqual(t, causes(t, err), tt.fields)
+
+			// Updates that don't change the spec are always allowed.
+			_, err = v.ValidateUpdate(ctx, schedule.DeepCopy(), schedule)
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestRestoreValidator(t *testing.T) {
+	ctx := context.Background()
+	v := &restoreValidator{client: newClient(t, newCluster())}
+
+	tests := []struct {
+		name   string
+		spec   v2.PerconaPGRestoreSpec
+		fields []string
+	}{
+		{
+			name: "valid",
+			spec: v2.PerconaPGRestoreSpec{PGCluster: "cluster1", RepoName: "repo1"},
+		},
+		{
+			name: "invalid target",
+			spec: v2.PerconaPGRestoreSpec{
+				PGCluster: "cluster1",
+				RepoName:  "repo1",
+				Type:      v2.PGRestoreTargetTypeLSN,
+				Target:    "whoops",
+			},
+			fields: []string{"spec.target"},
+		},
+		{
+			name:   "cluster not found",
+			spec:   v2.PerconaPGRestoreSpec{PGCluster: "cluster2", RepoName: "repo1"},
+			fields: []string{"spec.pgCluster"},
+		},
+		{
+			name:   "repo not found",
+			spec:   v2.PerconaPGRestoreSpec{PGCluster: "cluster1", RepoName: "repo3"},
+			fields: []string{"spec.repoName"},
+		},
+		{
+			name: "new cluster is the source cluster",
+			spec: v2.PerconaPGRestoreSpec{
+				PGCluster:  "cluster1",
+				RepoName:   "repo1",
+				NewCluster: &v2.PGRestoreNewCluster{Name: "cluster1"},
+			},
+			fields: []string{"spec.newCluster.name"},
+		},
+		{
+			name: "new cluster in another namespace",
+			spec: v2.PerconaPGRestoreSpec{
+				PGCluster:  "cluster1",
+				RepoName:   "repo1",
+				NewCluster: &v2.PGRestoreNewCluster{Name: "cluster1", Namespace: "other"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := &v2.PerconaPGRestore{
+				ObjectMeta: metav1.ObjectMeta{Name: "restore1", Namespace: "pg"},
+				Spec:       tt.spec,
+			}
+
+			_, err := v.ValidateCreate(ctx, restore)
+			assert.DeepEqual(t, causes(t, err), tt.fields)
+		})
+	}
+}
+
+func TestUpgradeValidator(t *testing.T) {
+	ctx := context.Background()
+	v := &upgradeValidator{client: newClient(t, newCluster())}
+
+	tests := []struct {
+		name     string
+		cluster  string
+		from, to int
+		backup   *v2.UpgradeBackupSpec
+		logical  *v2.LogicalUpgradeSpec
+		fields   []string
+	}{
+		{
+			name:    "valid",
+			cluster: "cluster1",
+			from:    16, to: 17,
+		},
+		{
+			name:    "downgrade",
+			cluster: "cluster1",
+			from:    16, to: 15,
+			fields: []string{"spec.toPostgresVersion"},
+		},
+		{
+			name:    "version mismatch",
+			cluster: "cluster1",
+			from:    15, to: 17,
+			fields: []string{"spec.fromPostgresVersion"},
+		},
+		{
+			name:    "cluster not found",
+			cluster: "cluster2",
+			from:    16, to: 17,
+			fields: []string{"spec.postgresClusterName"},
+		},
+		{
+			name:    "backup",
+			cluster: "cluster1",
+			from:    16, to: 17,
+			backup: &v2.UpgradeBackupSpec{RepoName: "repo1", Rollback: true},
+		},
+		{
+			name:    "backup repo not found",
+			cluster: "cluster1",
+			from:    16, to: 17,
+			backup: &v2.UpgradeBackupSpec{RepoName: "repo2"},
+			fields: []string{"spec.backup.repoName"},
+		},
+		{
+			name:    "rollback from snapshots",
+			cluster: "cluster1",
+			from:    16, to: 17,
+			backup: &v2.UpgradeBackupSpec{VolumeSnapshotClassName: "csi", Rollback: true},
+			fields: []string{"spec.backup.repoName"},
+		},
+		{
+			name:    "logical",
+			cluster: "cluster1",
+			from:    16, to: 17,
+			logical: &v2.LogicalUpgradeSpec{TargetClusterName: "cluster1-pg17"},
+		},
+		{
+			name:    "logical with backup",
+			cluster: "cluster1",
+			from:    16, to: 17,
+			backup:  &v2.UpgradeBackupSpec{RepoName: "repo1"},
+			logical: &v2.LogicalUpgradeSpec{TargetClusterName: "cluster1"},
+			fields:  []string{"spec.backup", "spec.logical.targetClusterName"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgrade := &v2.PerconaPGUpgrade{
+				ObjectMeta: metav1.ObjectMeta{Name: "upgrade1", Namespace: "pg"},
+				Spec: v2.PerconaPGUpgradeSpec{
+					PostgresClusterName: tt.cluster,
+					FromPostgresVersion: tt.from,
+					ToPostgresVersion:   tt.to,
+					Backup:              tt.backup,
+				},
+			}
+			if tt.logical != nil {
+				upgrade.Spec.Strategy = v2.UpgradeStrategyLogical
+				upgrade.Spec.Logical = tt.logical
+			}
+
+			_, err := v.ValidateCreate(ctx, upgrade)
+			assert.DeepEqual(t, causes(t, err), tt.fields)
+		})
+	}
+}
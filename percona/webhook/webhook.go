@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	v2 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/pgv2.percona.com/v2"
+)
+
+// SetupWithManager registers the defaulting and validating webhooks of the
+// pgv2.percona.com resources with the webhook server of the manager.
+func SetupWithManager(mgr manager.Manager) error {
+	cl := mgr.GetClient()
+
+	if err := builder.WebhookManagedBy(mgr).
+		For(&v2.PerconaPGCluster{}).
+		WithDefaulter(&clusterDefaulter{}).
+		WithValidator(&clusterValidator{}).
+		Complete(); err != nil {
+		return errors.Wrap(err, "register PerconaPGCluster webhooks")
+	}
+
+	if err := builder.WebhookManagedBy(mgr).
+		For(&v2.PerconaPGBackup{}).
+		WithValidator(&backupValidator{client: cl}).
+		Complete(); err != nil {
+		return errors.Wrap(err, "register PerconaPGBackup webhook")
+	}
+
+	if err := builder.WebhookManagedBy(mgr).
+		For(&v2.PerconaPGBackupSchedule{}).
+		WithValidator(&backupScheduleValidator{}).
+		Complete(); err != nil {
+		return errors.Wrap(err, "register PerconaPGBackupSchedule webhook")
+	}
+
+	if err := builder.WebhookManagedBy(mgr).
+		For(&v2.PerconaPGRestore{}).
+		WithValidator(&restoreValidator{client: cl}).
+		Complete(); err != nil {
+		return errors.Wrap(err, "register PerconaPGRestore webhook")
+	}
+
+	if err := builder.WebhookManagedBy(mgr).
+		For(&v2.PerconaPGUpgrade{}).
+		WithValidator(&upgradeValidator{client: cl}).
+		Complete(); err != nil {
+		return errors.Wrap(err, "register PerconaPGUpgrade webhook")
+	}
+
+	return nil
+}
+
+// invalid returns an Invalid API error for the object if there are any
+// field errors.
+func invalid(kind string, obj client.Object, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(v2.GroupVersion.WithKind(kind).GroupKind(), obj.GetName(), errs)
+}
+
+// getCluster returns the PerconaPGCluster the object refers to. It returns a
+// field error if the cluster doesn't exist.
+func getCluster(ctx context.Context, cl client.Reader, namespace, name string, path *field.Path) (*v2.PerconaPGCluster, *field.Error) {
+	cluster := new(v2.PerconaPGCluster)
+	err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cluster)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil, field.NotFound(path, name)
+	case err != nil:
+		return nil, field.InternalError(path, errors.Wrapf(err, "get PerconaPGCluster %s", name))
+	}
+	return cluster, nil
+}
+
+// validateRepoName checks that the backups of the cluster are enabled and
+// that the cluster has a pgBackRest repo with the given name.
+func validateRepoName(cluster *v2.PerconaPGCluster, repoName string, path *field.Path) *field.Error {
+	if !cluster.Spec.Backups.IsEnabled() {
+		return field.Invalid(path, repoName, "backups are disabled in cluster "+cluster.Name)
+	}
+
+	names := make([]string, 0, len(cluster.Spec.Backups.PGBackRest.Repos))
+	for _, repo := range cluster.Spec.Backups.PGBackRest.Repos {
+		if repo.Name == repoName {
+			return nil
+		}
+		names = append(names, repo.Name)
+	}
+	return field.NotSupported(path, repoName, names)
+}
+
+// deleting reports whether the object is being deleted. Objects that are
+// being deleted aren't validated so their finalizers can always be removed.
+func deleting(obj client.Object) bool {
+	return !obj.GetDeletionTimestamp().IsZero()
+}
+
+var _ admission.CustomDefaulter = &clusterDefaulter{}
+
+type clusterDefaulter struct{}
+
+// Default sets the defaults of the PerconaPGCluster when it's created or
+// updated, the same defaults the operator sets before reconciling it.
+func (d *clusterDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	cr, ok := obj.(*v2.PerconaPGCluster)
+	if !ok {
+		return errors.Errorf("expected a PerconaPGCluster but got %T", obj)
+	}
+	cr.Default()
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	v "github.com/hashicorp/go-version"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -24,6 +25,7 @@ func init() {
 // +kubebuilder:printcolumn:name="Destination",type=string,JSONPath=".status.destination",description="Backup destination"
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=".status.state",description="Job status"
 // +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".status.backupType",description="Backup type"
+// +kubebuilder:printcolumn:name="Verification",type="string",JSONPath=".status.verification.state",description="Verification status",priority=1
 // +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=".status.completed",description="Completed time"
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp",description="Created time"
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -59,6 +61,50 @@ type PerconaPGBackupSpec struct {
 	// https://pgbackrest.org/command.html#command-backup
 	// +optional
 	Options []string `json:"options,omitempty"`
+
+	// Verification restores the backup into a scratch instance once the backup
+	// succeeds and runs a SQL probe against it.
+	// +optional
+	Verification *PGBackupVerification `json:"verification,omitempty"`
+}
+
+type PGBackupVerification struct {
+	// Whether the backup should be verified after it succeeds.
+	Enabled bool `json:"enabled"`
+
+	// SQL query to run against the restored database. The verification fails
+	// if the query returns an error.
+	// +kubebuilder:default="SELECT 1"
+	// +optional
+	Query string `json:"query,omitempty"`
+
+	// The database the SQL query is run against.
+	// +kubebuilder:default=postgres
+	// +optional
+	Database string `json:"database,omitempty"`
+
+	// Resource requirements for the verification Job. The ephemeral-storage
+	// limit, when set, bounds the size of the scratch data volume.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Duration in seconds the verification Job may be active before it is
+	// terminated.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// Tolerations of the verification Job pod.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity of the verification Job pod.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+}
+
+func (v *PGBackupVerification) IsEnabled() bool {
+	return v != nil && v.Enabled
 }
 
 const IndexFieldPGCluster = "spec.pgCluster"
@@ -94,6 +140,24 @@ type PerconaPGBackupStatus struct {
 	BackupName           string                         `json:"backupName,omitempty"`
 	CRVersion            string                         `json:"crVersion,omitempty"`
 	LatestRestorableTime PITRestoreDateTime             `json:"latestRestorableTime,omitempty"`
+	Verification         *PGBackupVerificationStatus    `json:"verification,omitempty"`
+}
+
+type PGBackupVerificationState string
+
+const (
+	BackupVerificationRunning PGBackupVerificationState = "Running"
+	BackupVerified            PGBackupVerificationState = "Verified"
+	BackupVerificationFailed  PGBackupVerificationState = "VerificationFailed"
+)
+
+type PGBackupVerificationStatus struct {
+	JobName     string                    `json:"jobName,omitempty"`
+	State       PGBackupVerificationState `json:"state,omitempty"`
+	Error       string                    `json:"error,omitempty"`
+	StartedAt   *metav1.Time              `json:"started,omitempty"`
+	CompletedAt *metav1.Time              `json:"completed,omitempty"`
+	Duration    *metav1.Duration          `json:"duration,omitempty"`
 }
 
 // +kubebuilder:validation:Type=string
@@ -162,6 +226,15 @@ const (
 
 func (b *PerconaPGBackup) Default() {
 	b.Spec.Options = append(b.Spec.Options, fmt.Sprintf(`--annotation="%s"="%s"`, PGBackrestAnnotationBackupName, b.Name))
+
+	if b.Spec.Verification != nil {
+		if b.Spec.Verification.Query == "" {
+			b.Spec.Verification.Query = "SELECT 1"
+		}
+		if b.Spec.Verification.Database == "" {
+			b.Spec.Verification.Database = "postgres"
+		}
+	}
 }
 
 func (b *PerconaPGBackup) CompareVersion(ver string) int {
@@ -0,0 +1,144 @@
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	SchemeBuilder.Register(&PerconaPGBackupSchedule{}, &PerconaPGBackupScheduleList{})
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=pg-backup-schedule
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=".spec.pgCluster",description="Cluster name"
+// +kubebuilder:printcolumn:name="Repo",type=string,JSONPath=".spec.repoName",description="Repo name"
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=".spec.type",description="Backup type"
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=".spec.schedule",description="Cron schedule"
+// +kubebuilder:printcolumn:name="Suspend",type=boolean,JSONPath=".spec.suspend",description="Whether the schedule is suspended"
+// +kubebuilder:printcolumn:name="Last Schedule",type=date,JSONPath=".status.lastScheduleTime",description="Last scheduled time"
+// +kubebuilder:printcolumn:name="Next Schedule",type=date,JSONPath=".status.nextScheduleTime",description="Next scheduled time",priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp",description="Created time"
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +operator-sdk:csv:customresourcedefinitions:order=5
+// +operator-sdk:csv:customresourcedefinitions:resources={{PerconaPGBackup,v2}}
+//
+// PerconaPGBackupSchedule is the CRD that defines a schedule of Percona PostgreSQL Backups
+type PerconaPGBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   PerconaPGBackupScheduleSpec   `json:"spec"`
+	Status PerconaPGBackupScheduleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// PerconaPGBackupScheduleList contains a list of PerconaPGBackupSchedule
+type PerconaPGBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PerconaPGBackupSchedule `json:"items"`
+}
+
+type PerconaPGBackupScheduleSpec struct {
+	PGCluster string `json:"pgCluster"`
+
+	// The schedule in Cron format.
+	// - https://k8s.io/docs/concepts/workloads/controllers/cron-jobs/#schedule-syntax
+	// +kubebuilder:validation:MinLength=6
+	Schedule string `json:"schedule"`
+
+	// The name of the pgBackRest repo to run the backup command against.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=^repo[1-4]
+	RepoName string `json:"repoName"`
+
+	// The type of the pgBackRest backup.
+	// +kubebuilder:validation:Enum={full,diff,incr}
+	// +kubebuilder:default=full
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Command line options to include when running the pgBackRest backup command.
+	// https://pgbackrest.org/command.html#command-backup
+	// +optional
+	Options []string `json:"options,omitempty"`
+
+	// Whether new backups shouldn't be scheduled. Backups that are already
+	// running aren't affected.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// What to do if a backup of the schedule is still running when the next
+	// one is due: Allow starts the next backup anyway, Forbid skips it and
+	// Replace deletes the running backup before starting the next one.
+	// +kubebuilder:validation:Enum={Allow,Forbid,Replace}
+	// +kubebuilder:default=Forbid
+	// +optional
+	ConcurrencyPolicy BackupConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// The number of succeeded PerconaPGBackup objects of the schedule to keep.
+	// Older ones are deleted, the backups in the repo are kept.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SuccessfulBackupsHistoryLimit *int32 `json:"successfulBackupsHistoryLimit,omitempty"`
+
+	// The number of failed PerconaPGBackup objects of the schedule to keep.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FailedBackupsHistoryLimit *int32 `json:"failedBackupsHistoryLimit,omitempty"`
+}
+
+type BackupConcurrencyPolicy string
+
+const (
+	BackupConcurrencyAllow   BackupConcurrencyPolicy = "Allow"
+	BackupConcurrencyForbid  BackupConcurrencyPolicy = "Forbid"
+	BackupConcurrencyReplace BackupConcurrencyPolicy = "Replace"
+)
+
+type PerconaPGBackupScheduleStatus struct {
+	// The last time a backup was due, whether it was started or skipped.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// The last time a backup of the schedule succeeded.
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// The next time a backup is due. Empty if the schedule is suspended.
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// The name of the last backup started by the schedule.
+	// +optional
+	LastBackup string `json:"lastBackup,omitempty"`
+
+	// Why the last due backup wasn't started.
+	// +optional
+	LastSkipReason string `json:"lastSkipReason,omitempty"`
+
+	// The last time a due backup wasn't started.
+	// +optional
+	LastSkipTime *metav1.Time `json:"lastSkipTime,omitempty"`
+
+	// The names of the running backups of the schedule.
+	// +optional
+	Active []string `json:"active,omitempty"`
+}
+
+// BackupType returns the type of the backups of the schedule.
+func (s *PerconaPGBackupSchedule) BackupType() string {
+	if s.Spec.Type == "" {
+		return "full"
+	}
+	return s.Spec.Type
+}
+
+// ConcurrencyPolicy returns the concurrency policy of the schedule.
+func (s *PerconaPGBackupSchedule) ConcurrencyPolicy() BackupConcurrencyPolicy {
+	if s.Spec.ConcurrencyPolicy == "" {
+		return BackupConcurrencyForbid
+	}
+	return s.Spec.ConcurrencyPolicy
+}
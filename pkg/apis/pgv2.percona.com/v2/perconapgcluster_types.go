@@ -2,6 +2,7 @@ package v2
 
 import (
 	"context"
+	"slices"
 
 	gover "github.com/hashicorp/go-version"
 	corev1 "k8s.io/api/core/v1"
@@ -10,6 +11,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	"github.com/fulviodenza/percona-postgresql-operator/internal/feature"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/logging"
 	"github.com/fulviodenza/percona-postgresql-operator/internal/naming"
 	pNaming "github.com/fulviodenza/percona-postgresql-operator/percona/naming"
@@ -112,6 +114,11 @@ type PerconaPGClusterSpec struct {
 	// +optional
 	Patroni *crunchyv1beta1.PatroniSpec `json:"patroni,omitempty"`
 
+	// Switchover requests a change of the primary instance. Every request is
+	// performed once, change the ID to request another one.
+	// +optional
+	Switchover *SwitchoverSpec `json:"switchover,omitempty"`
+
 	// Users to create inside PostgreSQL and the databases they should access.
 	// The default creates one user that can access one database matching the
 	// PostgresCluster name. An empty list creates no users. Removing a user
@@ -162,6 +169,11 @@ type PerconaPGClusterSpec struct {
 	// +optional
 	PMM *PMMSpec `json:"pmm,omitempty"`
 
+	// The specification of the postgres_exporter sidecars.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Monitoring *MonitoringSpec `json:"monitoring,omitempty"`
+
 	// The specification of extensions.
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +optional
@@ -171,6 +183,11 @@ type PerconaPGClusterSpec struct {
 	// specified in `spec.users` across all databases associated with that user.
 	// +optional
 	AutoCreateUserSchema *bool `json:"autoCreateUserSchema,omitempty"`
+
+	// Automatic image updates driven by the Percona version service.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	UpgradeOptions *UpgradeOptions `json:"upgradeOptions,omitempty"`
 }
 
 func (cr *PerconaPGCluster) Default() {
@@ -222,6 +239,12 @@ func (cr *PerconaPGCluster) Default() {
 		if cr.Spec.Backups.PGBackRest.Jobs == nil {
 			cr.Spec.Backups.PGBackRest.Jobs = new(crunchyv1beta1.BackupJobs)
 		}
+
+		for i := range cr.Spec.Backups.PGBackRest.Retention {
+			if cr.Spec.Backups.PGBackRest.Retention[i].KeepRestorableChain == nil {
+				cr.Spec.Backups.PGBackRest.Retention[i].KeepRestorableChain = &t
+			}
+		}
 	}
 
 	if cr.Spec.Extensions.BuiltIn.PGStatMonitor == nil {
@@ -364,7 +387,14 @@ func (cr *PerconaPGCluster) ToCrunchy(ctx context.Context, postgresCluster *crun
 	postgresCluster.Spec.Extensions.PGStatStatements = *cr.Spec.Extensions.BuiltIn.PGStatStatements
 	postgresCluster.Spec.Extensions.PGAudit = *cr.Spec.Extensions.BuiltIn.PGAudit
 	postgresCluster.Spec.Extensions.PGVector = *cr.Spec.Extensions.BuiltIn.PGVector
+	if cr.Spec.Extensions.Storage.Enabled() {
+		postgresCluster.Spec.Extensions.StorageVolume = cr.Spec.Extensions.Storage.StorageVolume()
+		postgresCluster.Spec.Extensions.SignatureKey = cr.Spec.Extensions.SignatureKey
+	}
 	postgresCluster.Spec.Extensions.PGRepack = *cr.Spec.Extensions.BuiltIn.PGRepack
+	postgresCluster.Spec.Extensions.Targets = cr.Spec.Extensions.BuiltIn.Targets
+
+	postgresCluster.Spec.Monitoring = cr.Spec.Monitoring.ToCrunchy(ctx, postgresCluster)
 
 	postgresCluster.Spec.TLSOnly = cr.Spec.TLSOnly
 
@@ -381,6 +411,67 @@ func (cr *PerconaPGCluster) CompareVersion(ver string) int {
 	return cr.Version().Compare(gover.Must(gover.NewVersion(ver)))
 }
 
+type SwitchoverSpec struct {
+	// Identifies the request. A switchover is performed once per ID.
+	// +kubebuilder:validation:MinLength=1
+	ID string `json:"id"`
+
+	// Type of the request. "Switchover" changes the primary of a healthy
+	// cluster. "Failover" forces TargetInstance to become the primary and
+	// should be the last resort.
+	// +kubebuilder:validation:Enum={Switchover,Failover}
+	// +kubebuilder:default:=Switchover
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// The instance that should become the primary. When it's empty, Patroni
+	// chooses a healthy replica. It's required for a failover.
+	// +optional
+	TargetInstance string `json:"targetInstance,omitempty"`
+
+	// When the switchover should be performed. The default is immediately.
+	// +optional
+	ScheduledAt *metav1.Time `json:"scheduledAt,omitempty"`
+}
+
+type SwitchoverState string
+
+const (
+	SwitchoverStateScheduled SwitchoverState = "Scheduled"
+	SwitchoverStateRunning   SwitchoverState = "Running"
+	SwitchoverStateCompleted SwitchoverState = "Completed"
+	SwitchoverStateFailed    SwitchoverState = "Failed"
+)
+
+type SwitchoverStatus struct {
+	// The ID of the request.
+	ID string `json:"id"`
+
+	// +optional
+	State SwitchoverState `json:"state,omitempty"`
+
+	// When the operator observed the request.
+	// +optional
+	RequestedAt *metav1.Time `json:"requestedAt,omitempty"`
+
+	// When the request completed or failed.
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// The timeline of the cluster when the switchover started. It's used to
+	// detect switchovers that completed while the operator was interrupted.
+	// +optional
+	Timeline int64 `json:"timeline,omitempty"`
+
+	// The primary instance after the request completed.
+	// +optional
+	Primary string `json:"primary,omitempty"`
+
+	// Why the request failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
 type AppState string
 
 const (
@@ -414,12 +505,55 @@ type PostgresStatus struct {
 
 	// +optional
 	ImageID string `json:"imageID"`
+
+	// The instances currently used as synchronous standbys by Patroni.
+	// +optional
+	SynchronousStandbys []string `json:"synchronousStandbys,omitempty"`
+}
+
+// MaxFailoverHistory is the number of failovers kept in the status of a
+// PerconaPGCluster.
+const MaxFailoverHistory = 10
+
+type PatroniStatus struct {
+	// The instance that was the primary when the operator last checked.
+	// +optional
+	Primary string `json:"primary,omitempty"`
+
+	// Time of the last Patroni callback processed by the operator.
+	// +optional
+	LastCallbackTime *metav1.MicroTime `json:"lastCallbackTime,omitempty"`
+
+	// The most recent failovers and switchovers, oldest first.
+	// +optional
+	// +kubebuilder:validation:MaxItems=10
+	FailoverHistory []PatroniFailoverStatus `json:"failoverHistory,omitempty"`
+}
+
+type PatroniFailoverStatus struct {
+	// When the new primary was promoted.
+	Time metav1.Time `json:"time"`
+
+	// The instance that was the primary before, if known.
+	// +optional
+	OldPrimary string `json:"oldPrimary,omitempty"`
+
+	// The instance that was promoted.
+	NewPrimary string `json:"newPrimary"`
+
+	// The timeline of the new primary.
+	// +optional
+	Timeline int64 `json:"timeline,omitempty"`
 }
 
 type PGBouncerStatus struct {
 	Size int32 `json:"size"`
 
 	Ready int32 `json:"ready"`
+
+	// Names of the connection pools that are routed to replicas.
+	// +optional
+	ReadOnlyPools []string `json:"readOnlyPools,omitempty"`
 }
 
 type PerconaPGClusterStatus struct {
@@ -439,6 +573,23 @@ type PerconaPGClusterStatus struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	PatroniVersion string `json:"patroniVersion"`
 
+	// The last switchover requested in the spec.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Switchover *SwitchoverStatus `json:"switchover,omitempty"`
+
+	// Changes of the primary instance reported by the Patroni callbacks.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Patroni PatroniStatus `json:"patroni,omitempty"`
+
+	// Password rotation of the users that have a rotation policy.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	PasswordRotations []crunchyv1beta1.PostgresPasswordRotationStatus `json:"passwordRotations,omitempty"`
+
 	// +optional
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	Host string `json:"host"`
@@ -447,9 +598,101 @@ type PerconaPGClusterStatus struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	InstalledCustomExtensions []string `json:"installedCustomExtensions"`
 
+	// Versions of the custom extensions created in the databases from the spec.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	InstalledCustomExtensionVersions []InstalledExtensionStatus `json:"installedCustomExtensionVersions,omitempty"`
+
 	// +optional
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Result of the last evaluation of the backup retention policies.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	BackupRetention []PGBackRestRetentionStatus `json:"backupRetention,omitempty"`
+
+	// Versions applied from the version service by spec.upgradeOptions.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Versions *AppliedVersionsStatus `json:"versions,omitempty"`
+}
+
+type AppliedVersionsStatus struct {
+	// The PostgreSQL version.
+	// +optional
+	Postgres string `json:"postgres,omitempty"`
+
+	// The PgBouncer version.
+	// +optional
+	PGBouncer string `json:"pgbouncer,omitempty"`
+
+	// The pgBackRest version.
+	// +optional
+	PGBackRest string `json:"pgbackrest,omitempty"`
+
+	// The PMM client version. Empty when PMM is disabled.
+	// +optional
+	PMM string `json:"pmm,omitempty"`
+
+	// Time when the operator last applied versions from the version service.
+	// +optional
+	LastApplied *metav1.Time `json:"lastApplied,omitempty"`
+}
+
+// UpgradeApply is the version the operator updates a cluster to. Apart from
+// the values below, it may be a specific PostgreSQL version such as "17.5".
+type UpgradeApply string
+
+const (
+	UpgradeApplyDisabled    UpgradeApply = "disabled"
+	UpgradeApplyNever       UpgradeApply = "never"
+	UpgradeApplyRecommended UpgradeApply = "recommended"
+	UpgradeApplyLatest      UpgradeApply = "latest"
+)
+
+// Disabled returns true when the operator must not change the images.
+func (a UpgradeApply) Disabled() bool {
+	return a == "" || a == UpgradeApplyDisabled || a == UpgradeApplyNever
+}
+
+type UpgradeOptions struct {
+	// The URL of the version service. Defaults to https://check.percona.com.
+	// +optional
+	VersionServiceEndpoint string `json:"versionServiceEndpoint,omitempty"`
+
+	// The versions to update the images to: disabled, recommended, latest or
+	// a PostgreSQL version of the major version in spec.postgresVersion.
+	// +kubebuilder:default=disabled
+	// +optional
+	Apply UpgradeApply `json:"apply,omitempty"`
+
+	// The maintenance window, in Cron format, in which the operator checks
+	// the version service and updates the images.
+	// +kubebuilder:default="0 4 * * *"
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+}
+
+type PGBackRestRetentionStatus struct {
+	// The name of the pgBackRest repo the policy was evaluated for.
+	RepoName string `json:"repoName"`
+
+	// Time when the operator last expired backups in the repo.
+	// +optional
+	LastExpired *metav1.Time `json:"lastExpired,omitempty"`
+
+	// Labels of the backup sets expired during the last expiration.
+	// +optional
+	ExpiredBackups []string `json:"expiredBackups,omitempty"`
+
+	// Number of full backups kept in the repo after the last evaluation.
+	// +optional
+	RetainedFullBackups int32 `json:"retainedFullBackups,omitempty"`
+
+	// Error returned by the last evaluation, if any.
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 type Backups struct {
@@ -556,6 +799,46 @@ type PGBackRestArchive struct {
 	// Configuration for pgBackRest sidecar containers
 	// +optional
 	Containers *crunchyv1beta1.PGBackRestSidecars `json:"containers,omitempty"`
+
+	// Retention policies enforced by the operator. Backups that fall outside
+	// of the policy are expired with "pgbackrest expire".
+	// +listType=map
+	// +listMapKey=repoName
+	// +optional
+	Retention []PGBackRestRetention `json:"retention,omitempty"`
+}
+
+// RetentionFor returns the retention policy defined for the repo or nil.
+func (p PGBackRestArchive) RetentionFor(repoName string) *PGBackRestRetention {
+	for i := range p.Retention {
+		if p.Retention[i].RepoName == repoName {
+			return &p.Retention[i]
+		}
+	}
+	return nil
+}
+
+type PGBackRestRetention struct {
+	// The name of the pgBackRest repo the policy applies to.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=^repo[1-4]
+	RepoName string `json:"repoName"`
+
+	// Number of full backups to keep. Older full backups are expired together
+	// with the differential and incremental backups that depend on them.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Full *int32 `json:"full,omitempty"`
+
+	// Full backups that finished earlier than this duration ago are expired
+	// together with their dependent backups, e.g. "168h".
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+
+	// Keep at least the latest full backup and its dependent backups, even if
+	// it doesn't satisfy other rules of the policy. Defaults to true.
+	// +optional
+	KeepRestorableChain *bool `json:"keepRestorableChain,omitempty"`
 }
 
 type PMMQuerySource string
@@ -610,19 +893,160 @@ func (cr *PerconaPGCluster) PMMEnabled() bool {
 	return cr.Spec.PMM != nil && cr.Spec.PMM.Enabled
 }
 
+type MonitoringSpec struct {
+	// Defines a postgres_exporter sidecar for every PostgreSQL instance.
+	// +optional
+	Exporter *ExporterSpec `json:"exporter,omitempty"`
+}
+
+type ExporterSpec struct {
+	// +kubebuilder:validation:Required
+	Enabled bool `json:"enabled"`
+
+	// The image name to use for the postgres_exporter container. The image may
+	// also be set using the RELATED_IMAGE_PGEXPORTER environment variable.
+	// The container is started by a bash script, so the image must provide
+	// bash, e.g. crunchy-postgres-exporter. Images without a shell, such as
+	// the upstream prometheuscommunity/postgres-exporter, don't work.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Compute resources of the postgres_exporter container.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Projected volumes containing custom postgres_exporter queries.
+	// The queries replace the default pgMonitor queries unless
+	// appendCustomQueries is enabled.
+	// +optional
+	Configuration []corev1.VolumeProjection `json:"configuration,omitempty"`
+
+	// Append the custom queries from configuration to the default pgMonitor
+	// queries instead of replacing them.
+	// +optional
+	AppendCustomQueries bool `json:"appendCustomQueries,omitempty"`
+
+	// Projected secret containing custom TLS certificates to encrypt output
+	// from the exporter web server.
+	// +optional
+	CustomTLSSecret *corev1.SecretProjection `json:"customTLSSecret,omitempty"`
+
+	// Metadata of the Service exposing the exporter of all instances. Use its
+	// labels to select the Service from a ServiceMonitor.
+	// +optional
+	Service *crunchyv1beta1.Metadata `json:"service,omitempty"`
+}
+
+func (cr *PerconaPGCluster) ExporterEnabled() bool {
+	return cr.Spec.Monitoring != nil && cr.Spec.Monitoring.Exporter != nil && cr.Spec.Monitoring.Exporter.Enabled
+}
+
+func (m *MonitoringSpec) ToCrunchy(ctx context.Context, cluster *crunchyv1beta1.PostgresCluster) *crunchyv1beta1.MonitoringSpec {
+	if m == nil || m.Exporter == nil || !m.Exporter.Enabled {
+		return nil
+	}
+
+	configuration := m.Exporter.Configuration
+	// Crunchy appends the default queries only if the feature gate is enabled
+	// for the whole operator or there are no custom queries.
+	if m.Exporter.AppendCustomQueries && len(configuration) > 0 && !feature.Enabled(ctx, feature.AppendCustomQueries) {
+		configuration = append(slices.Clone(configuration), corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: naming.ExporterQueriesConfigMap(cluster).Name,
+				},
+			},
+		})
+	}
+
+	return &crunchyv1beta1.MonitoringSpec{
+		PGMonitor: &crunchyv1beta1.PGMonitorSpec{
+			Exporter: &crunchyv1beta1.ExporterSpec{
+				Configuration:   configuration,
+				CustomTLSSecret: m.Exporter.CustomTLSSecret,
+				Image:           m.Exporter.Image,
+				Resources:       m.Exporter.Resources,
+			},
+		},
+	}
+}
+
 type CustomExtensionSpec struct {
-	Name     string `json:"name,omitempty"`
-	Version  string `json:"version,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+
+	// The databases to create the extension in. The extension is updated to
+	// the version from the spec once the version is available on the primary.
+	// If empty, the extension is only installed on disk.
+	// +optional
+	Databases []string `json:"databases,omitempty"`
+
+	// The schema to create the extension in. It's created if it doesn't exist.
+	// Defaults to the schema from the extension control file or to the first
+	// schema in the search path.
+	// +optional
+	Schema string `json:"schema,omitempty"`
+
+	// The sha256 checksum of the extension archive, optionally prefixed with
+	// "sha256:". The extension isn't installed if the archive doesn't match it.
+	// +optional
 	Checksum string `json:"checksum,omitempty"`
 }
 
 type CustomExtensionsStorageSpec struct {
-	// +kubebuilder:validation:Enum={s3,gcs,azure}
-	Type     string                   `json:"type,omitempty"`
-	Bucket   string                   `json:"bucket,omitempty"`
-	Region   string                   `json:"region,omitempty"`
-	Endpoint string                   `json:"endpoint,omitempty"`
-	Secret   *corev1.SecretProjection `json:"secret,omitempty"`
+	// +kubebuilder:validation:Enum={s3,gcs,azure,filesystem,http}
+	Type   string                   `json:"type,omitempty"`
+	Bucket string                   `json:"bucket,omitempty"`
+	Region string                   `json:"region,omitempty"`
+	Secret *corev1.SecretProjection `json:"secret,omitempty"`
+
+	// The endpoint of the storage. It's ignored by the filesystem type, which
+	// always reads the archives from Volume.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The PersistentVolumeClaim with the extension archives. It is mounted
+	// read-only at /extensions-storage if the type is filesystem.
+	// +optional
+	Volume *corev1.PersistentVolumeClaimVolumeSource `json:"volume,omitempty"`
+}
+
+// Enabled returns true if custom extensions can be installed from the storage.
+// Object storages need credentials, while filesystem and HTTP storages may be
+// used without them.
+func (s *CustomExtensionsStorageSpec) Enabled() bool {
+	return s.Secret != nil || s.Type == "filesystem" || s.Type == "http"
+}
+
+// StorageVolume returns the read-only claim with the extension archives of a
+// filesystem storage or nil.
+func (s *CustomExtensionsStorageSpec) StorageVolume() *corev1.PersistentVolumeClaimVolumeSource {
+	if s.Type != "filesystem" || s.Volume == nil {
+		return nil
+	}
+
+	claim := *s.Volume
+	claim.ReadOnly = true
+
+	return &claim
+}
+
+type InstalledExtensionStatus struct {
+	Name     string `json:"name"`
+	Database string `json:"database"`
+
+	// The extension version installed in the database.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// The extension version from the spec the database was reconciled with.
+	// +optional
+	SpecVersion string `json:"specVersion,omitempty"`
+
+	// Whether the extension is waiting to be updated, e.g. until the new
+	// version is installed on the primary.
+	// +optional
+	Pending bool `json:"pending,omitempty"`
 }
 
 type BuiltInExtensionsSpec struct {
@@ -631,6 +1055,13 @@ type BuiltInExtensionsSpec struct {
 	PGAudit          *bool `json:"pg_audit,omitempty"`
 	PGVector         *bool `json:"pgvector,omitempty"`
 	PGRepack         *bool `json:"pg_repack,omitempty"`
+
+	// The databases and schemas to create the enabled built-in extensions
+	// in. An enabled extension without a target is created in every database.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Targets []crunchyv1beta1.ExtensionTarget `json:"targets,omitempty"`
 }
 
 type ExtensionsSpec struct {
@@ -639,6 +1070,12 @@ type ExtensionsSpec struct {
 	Storage         CustomExtensionsStorageSpec `json:"storage,omitempty"`
 	BuiltIn         BuiltInExtensionsSpec       `json:"builtin,omitempty"`
 	Custom          []CustomExtensionSpec       `json:"custom,omitempty"`
+
+	// The Secret key with a trusted PEM-encoded public key. If set, every
+	// custom extension archive must have a detached signature made with the
+	// private key stored next to it with the .sig suffix.
+	// +optional
+	SignatureKey *corev1.SecretKeySelector `json:"signatureKey,omitempty"`
 }
 
 type SecretsSpec struct {
@@ -795,6 +1232,10 @@ type PGInstanceSetSpec struct {
 	// InitContainer defines the init container for the instance container of a PostgreSQL pod.
 	// +optional
 	InitContainer *crunchyv1beta1.InitContainerSpec `json:"initContainer,omitempty"`
+
+	// Patroni settings of the instances of this set.
+	// +optional
+	Patroni *crunchyv1beta1.InstancePatroniSpec `json:"patroni,omitempty"`
 }
 
 func (p PGInstanceSetSpec) ToCrunchy() crunchyv1beta1.PostgresInstanceSetSpec {
@@ -816,7 +1257,9 @@ func (p PGInstanceSetSpec) ToCrunchy() crunchyv1beta1.PostgresInstanceSetSpec {
 		VolumeMounts:              p.VolumeMounts,
 		SecurityContext:           p.SecurityContext,
 		TablespaceVolumes:         p.TablespaceVolumes,
-		InitContainer:             p.InitContainer}
+		InitContainer:             p.InitContainer,
+		Patroni:                   p.Patroni,
+	}
 }
 
 type ServiceExpose struct {
@@ -1,9 +1,15 @@
 package v2
 
 import (
+	"context"
 	"testing"
 
 	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fulviodenza/percona-postgresql-operator/internal/feature"
+	crunchyv1beta1 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
 )
 
 func TestPerconaPGCluster_Default(t *testing.T) {
@@ -41,3 +47,87 @@ func TestPerconaPGCluster_BackupsEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestMonitoringSpec_ToCrunchy(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := &crunchyv1beta1.PostgresCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}}
+	customQueries := corev1.VolumeProjection{
+		ConfigMap: &corev1.ConfigMapProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "custom-queries"},
+		},
+	}
+	defaultQueries := corev1.VolumeProjection{
+		ConfigMap: &corev1.ConfigMapProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "cluster1-exporter-queries-config"},
+		},
+	}
+
+	tests := map[string]struct {
+		spec          *MonitoringSpec
+		gate          bool
+		expectedNil   bool
+		configuration []corev1.VolumeProjection
+	}{
+		"nil": {
+			expectedNil: true,
+		},
+		"disabled": {
+			spec:        &MonitoringSpec{Exporter: &ExporterSpec{Configuration: []corev1.VolumeProjection{customQueries}}},
+			expectedNil: true,
+		},
+		"default queries": {
+			spec: &MonitoringSpec{Exporter: &ExporterSpec{Enabled: true}},
+		},
+		"custom queries": {
+			spec:          &MonitoringSpec{Exporter: &ExporterSpec{Enabled: true, Configuration: []corev1.VolumeProjection{customQueries}}},
+			configuration: []corev1.VolumeProjection{customQueries},
+		},
+		"append custom queries": {
+			spec: &MonitoringSpec{Exporter: &ExporterSpec{
+				Enabled:             true,
+				AppendCustomQueries: true,
+				Configuration:       []corev1.VolumeProjection{customQueries},
+			}},
+			configuration: []corev1.VolumeProjection{customQueries, defaultQueries},
+		},
+		"append custom queries with feature gate": {
+			spec: &MonitoringSpec{Exporter: &ExporterSpec{
+				Enabled:             true,
+				AppendCustomQueries: true,
+				Configuration:       []corev1.VolumeProjection{customQueries},
+			}},
+			gate:          true,
+			configuration: []corev1.VolumeProjection{customQueries},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gate := feature.NewGate()
+			assert.NilError(t, gate.SetFromMap(map[string]bool{feature.AppendCustomQueries: tt.gate}))
+
+			monitoring := tt.spec.ToCrunchy(feature.NewContext(ctx, gate), cluster)
+			if tt.expectedNil {
+				assert.Assert(t, monitoring == nil)
+				return
+			}
+			assert.DeepEqual(t, monitoring.PGMonitor.Exporter.Configuration, tt.configuration)
+		})
+	}
+}
+
+func TestCustomExtensionsStorageSpec(t *testing.T) {
+	s3 := CustomExtensionsStorageSpec{Type: "s3"}
+	assert.Assert(t, !s3.Enabled())
+	assert.Assert(t, s3.StorageVolume() == nil)
+
+	fs := CustomExtensionsStorageSpec{
+		Type:   "filesystem",
+		Volume: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "pg-extensions"},
+	}
+	assert.Assert(t, fs.Enabled())
+	assert.DeepEqual(t, fs.StorageVolume(),
+		&corev1.PersistentVolumeClaimVolumeSource{ClaimName: "pg-extensions", ReadOnly: true})
+	assert.Assert(t, !fs.Volume.ReadOnly)
+}
@@ -1,7 +1,14 @@
 package v2
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	crunchyv1beta1 "github.com/fulviodenza/percona-postgresql-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
 )
 
 func init() {
@@ -52,6 +59,140 @@ type PerconaPGRestoreSpec struct {
 	// https://pgbackrest.org/command.html#command-restore
 	// +optional
 	Options []string `json:"options,omitempty"`
+
+	// The type of the point-in-time recovery target. The target is validated
+	// against the repo before the restore is started. It can't be combined
+	// with the --type, --target and --set options.
+	// +kubebuilder:validation:Enum={time,lsn,xid,immediate}
+	// +optional
+	Type PGRestoreTargetType `json:"type,omitempty"`
+
+	// The recovery target. Required unless the type is immediate. A time target
+	// uses the "2006-01-02 15:04:05-07" format, optionally with fractional seconds.
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// The name of the PerconaPGBackup to restore. Its backup set is used as
+	// the starting point of the recovery.
+	// +optional
+	BackupName string `json:"backupName,omitempty"`
+
+	// Restores the backup into a new cluster instead of restoring the cluster
+	// named in pgCluster in place.
+	// +optional
+	NewCluster *PGRestoreNewCluster `json:"newCluster,omitempty"`
+}
+
+type PGRestoreNewCluster struct {
+	// The name of the new PerconaPGCluster.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// The namespace of the new PerconaPGCluster. Defaults to the namespace of
+	// the restore. The operator must watch this namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Template for the spec of the new cluster. It is merged on top of the spec
+	// of the source cluster as a JSON merge patch. Backups of the new cluster
+	// are disabled unless the template configures them.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +optional
+	Template crunchyv1beta1.SchemalessObject `json:"template,omitempty"`
+}
+
+type PGRestoreTargetType string
+
+const (
+	PGRestoreTargetTypeTime      PGRestoreTargetType = "time"
+	PGRestoreTargetTypeLSN       PGRestoreTargetType = "lsn"
+	PGRestoreTargetTypeXID       PGRestoreTargetType = "xid"
+	PGRestoreTargetTypeImmediate PGRestoreTargetType = "immediate"
+)
+
+var targetTimeLayouts = []string{
+	"2006-01-02 15:04:05-07",
+	"2006-01-02 15:04:05-07:00",
+	time.RFC3339,
+}
+
+// ParseTargetTime parses a recovery target time in one of the formats that
+// pgBackRest accepts.
+func ParseTargetTime(target string) (time.Time, error) {
+	for _, layout := range targetTimeLayouts {
+		if t, err := time.Parse(layout, target); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("unsupported time format %q", target)
+}
+
+// ParseLSN parses a PostgreSQL log sequence number like "0/3000028".
+func ParseLSN(lsn string) (uint64, error) {
+	hi, lo, ok := strings.Cut(lsn, "/")
+	if !ok {
+		return 0, errors.Errorf("invalid LSN %q", lsn)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, errors.Errorf("invalid LSN %q", lsn)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, errors.Errorf("invalid LSN %q", lsn)
+	}
+	return h<<32 | l, nil
+}
+
+// ValidateTarget checks the recovery target fields of the restore without
+// looking at the repo.
+func (s PerconaPGRestoreSpec) ValidateTarget() error {
+	for _, opt := range s.Options {
+		name, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(opt), "--"), "=")
+		switch name {
+		case "type", "target", "set":
+			return errors.Errorf("option --%s can't be used together with the type, target and backupName fields", name)
+		}
+	}
+
+	if s.Type == "" {
+		if s.Target != "" {
+			return errors.Errorf("target %q requires a type", s.Target)
+		}
+		return nil
+	}
+
+	if s.Type == PGRestoreTargetTypeImmediate {
+		if s.Target != "" {
+			return errors.Errorf("target can't be set for type %s", s.Type)
+		}
+		return nil
+	}
+
+	if s.Target == "" {
+		return errors.Errorf("target is required for type %s", s.Type)
+	}
+
+	switch s.Type {
+	case PGRestoreTargetTypeTime:
+		if _, err := ParseTargetTime(s.Target); err != nil {
+			return errors.Errorf("invalid target: %s", err)
+		}
+	case PGRestoreTargetTypeLSN:
+		if _, err := ParseLSN(s.Target); err != nil {
+			return errors.Errorf("invalid target: %s", err)
+		}
+	case PGRestoreTargetTypeXID:
+		if xid, err := strconv.ParseUint(s.Target, 10, 32); err != nil || xid == 0 {
+			return errors.Errorf("invalid target: transaction ID %q is not a positive 32-bit integer", s.Target)
+		}
+	default:
+		return errors.Errorf("unknown target type %s", s.Type)
+	}
+
+	return nil
 }
 
 type PGRestoreState string
@@ -68,4 +209,5 @@ type PerconaPGRestoreStatus struct {
 	JobName     string         `json:"jobName,omitempty"`
 	State       PGRestoreState `json:"state,omitempty"`
 	CompletedAt *metav1.Time   `json:"completed,omitempty"`
+	Error       string         `json:"error,omitempty"`
 }
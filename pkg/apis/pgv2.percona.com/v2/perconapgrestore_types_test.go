@@ -0,0 +1,66 @@
+package v2
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPerconaPGRestoreSpec_ValidateTarget(t *testing.T) {
+	tests := map[string]struct {
+		spec        PerconaPGRestoreSpec
+		expectedErr string
+	}{
+		"time": {
+			spec: PerconaPGRestoreSpec{Type: PGRestoreTargetTypeTime, Target: "2024-06-01 10:00:00+03"},
+		},
+		"time with fractional seconds": {
+			spec: PerconaPGRestoreSpec{Type: PGRestoreTargetTypeTime, Target: "2024-06-01 10:00:00.123456+00:00"},
+		},
+		"invalid time": {
+			spec:        PerconaPGRestoreSpec{Type: PGRestoreTargetTypeTime, Target: "2024-06-01"},
+			expectedErr: `invalid target: unsupported time format "2024-06-01"`,
+		},
+		"lsn": {
+			spec: PerconaPGRestoreSpec{Type: PGRestoreTargetTypeLSN, Target: "0/3000028"},
+		},
+		"invalid lsn": {
+			spec:        PerconaPGRestoreSpec{Type: PGRestoreTargetTypeLSN, Target: "3000028"},
+			expectedErr: `invalid target: invalid LSN "3000028"`,
+		},
+		"invalid xid": {
+			spec:        PerconaPGRestoreSpec{Type: PGRestoreTargetTypeXID, Target: "-1"},
+			expectedErr: `invalid target: transaction ID "-1" is not a positive 32-bit integer`,
+		},
+		"immediate with target": {
+			spec:        PerconaPGRestoreSpec{Type: PGRestoreTargetTypeImmediate, Target: "0/3000028"},
+			expectedErr: "target can't be set for type immediate",
+		},
+		"missing target": {
+			spec:        PerconaPGRestoreSpec{Type: PGRestoreTargetTypeXID},
+			expectedErr: "target is required for type xid",
+		},
+		"target without type": {
+			spec:        PerconaPGRestoreSpec{Target: "0/3000028"},
+			expectedErr: `target "0/3000028" requires a type`,
+		},
+		"conflicting options": {
+			spec: PerconaPGRestoreSpec{
+				Type:    PGRestoreTargetTypeImmediate,
+				Options: []string{"--target-action=promote", "--set=20240601-000000F"},
+			},
+			expectedErr: "option --set can't be used together with the type, target and backupName fields",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tt.spec.ValidateTarget()
+			if tt.expectedErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Error(t, err, tt.expectedErr)
+		})
+	}
+}
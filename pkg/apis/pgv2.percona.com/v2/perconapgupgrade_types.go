@@ -107,10 +107,321 @@ type PerconaPGUpgradeSpec struct {
 	// The list of volume mounts to mount to upgrade pod.
 	// +optional
 	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// Maintenance to run on the primary once the upgraded cluster is ready.
+	// Each step is reported as a condition in the status.
+	// +optional
+	PostUpgrade *PostUpgradeSpec `json:"postUpgrade,omitempty"`
+
+	// Run the pre-flight checks of the upgrade without upgrading. The cluster
+	// is not paused and the report is published in the status.
+	// +optional
+	DryRun *DryRunSpec `json:"dryRun,omitempty"`
+
+	// Back up the cluster before it is paused for the upgrade.
+	// +optional
+	Backup *UpgradeBackupSpec `json:"backup,omitempty"`
+
+	// How the cluster is upgraded. "pgUpgrade" upgrades the cluster in place
+	// with pg_upgrade while it is paused. "logical" replicates the cluster to
+	// a new cluster at the target version and switches clients to it on
+	// request.
+	// +kubebuilder:validation:Enum={pgUpgrade,logical}
+	// +optional
+	Strategy UpgradeStrategy `json:"strategy,omitempty"`
+
+	// The settings of the "logical" strategy.
+	// +optional
+	Logical *LogicalUpgradeSpec `json:"logical,omitempty"`
+}
+
+type UpgradeStrategy string
+
+const (
+	UpgradeStrategyPGUpgrade UpgradeStrategy = "pgUpgrade"
+	UpgradeStrategyLogical   UpgradeStrategy = "logical"
+)
+
+type LogicalUpgradeSpec struct {
+	// The name of the new cluster. Defaults to the name of the cluster
+	// followed by the target version, e.g. "cluster1-pg17".
+	// +kubebuilder:validation:MaxLength=50
+	// +optional
+	TargetClusterName string `json:"targetClusterName,omitempty"`
+
+	// The databases to replicate. Defaults to every database that allows
+	// connections, except templates.
+	// +optional
+	Databases []string `json:"databases,omitempty"`
+
+	// Switch clients to the new cluster. Writes to the old cluster are
+	// blocked, and once the new cluster has caught up, the "<cluster>-primary"
+	// Service and PgBouncer of the old cluster connect to the new cluster.
+	// The old cluster is kept read-only. Clients that verify the hostname of
+	// the server certificate must use status.logical.targetHost instead.
+	// +optional
+	Cutover bool `json:"cutover,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule=`has(self.repoName) || has(self.volumeSnapshotClassName)`,message="repoName or volumeSnapshotClassName is required"
+// +kubebuilder:validation:XValidation:rule=`!has(self.rollback) || !self.rollback || has(self.repoName)`,message="rollback requires repoName"
+type UpgradeBackupSpec struct {
+	// The pgBackRest repository to take a full backup in.
+	// +kubebuilder:validation:Pattern=^repo[1-4]
+	// +optional
+	RepoName string `json:"repoName,omitempty"`
+
+	// Take VolumeSnapshots of the volumes of the primary with this
+	// VolumeSnapshotClass instead of a backup. It is used only when the
+	// VolumeSnapshots feature gate is enabled and rollback is disabled. The
+	// snapshots are kept for manual recovery; they are never restored by the
+	// operator.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+
+	// Roll back to the old version when the upgrade fails. The old data
+	// directory is recovered when possible; otherwise the full backup taken
+	// in repoName before the upgrade is restored while the cluster is still
+	// paused. The cluster is resumed with the version and images it had
+	// before once the rollback succeeds. Requires repoName.
+	// +optional
+	Rollback bool `json:"rollback,omitempty"`
+}
+
+type DryRunSpec struct {
+	// Run "pg_upgrade --check" in a Job against clones of the volumes of the
+	// primary. The storage class of the volumes must support CSI volume
+	// cloning; the check fails when a clone is not provisioned within 15
+	// minutes. Clusters with a separate WAL volume are not supported because
+	// their volumes cannot be cloned at the same point in time; the check is
+	// skipped with a warning. When disabled, only the checks that run against
+	// the online cluster are done.
+	// +optional
+	PGUpgradeCheck bool `json:"pgUpgradeCheck,omitempty"`
+}
+
+// PostUpgradeSpec defines the maintenance steps that run once the upgraded
+// cluster is ready. Analyze and UpdateExtensions run in Jobs that connect to
+// the primary with a temporary superuser. A failed Job is reported in the
+// condition of its step and stops the following steps; delete the Job to run
+// its step again.
+type PostUpgradeSpec struct {
+	// Collect optimizer statistics in all databases with
+	// "vacuumdb --analyze-in-stages". pg_upgrade does not transfer them
+	// to the new version.
+	// +optional
+	Analyze bool `json:"analyze,omitempty"`
+
+	// Update every installed extension in all databases to the default
+	// version of the new PostgreSQL installation.
+	// +optional
+	UpdateExtensions bool `json:"updateExtensions,omitempty"`
+
+	// Remove the data directory of the old PostgreSQL version from the
+	// primary. The old cluster cannot be started after this step.
+	// +optional
+	RemoveOldData bool `json:"removeOldData,omitempty"`
 }
 
 type PerconaPGUpgradeStatus struct {
 	crunchyv1beta1.PGUpgradeStatus `json:",inline"`
+
+	// The report of the pre-flight checks of a dry run.
+	// +optional
+	Preflight *PreflightReport `json:"preflight,omitempty"`
+
+	// The cluster before the upgrade and its backup.
+	// +optional
+	PreUpgrade *PreUpgradeStatus `json:"preUpgrade,omitempty"`
+
+	// The rollback of a failed upgrade.
+	// +optional
+	Rollback *UpgradeRollbackStatus `json:"rollback,omitempty"`
+
+	// The progress of the "logical" strategy.
+	// +optional
+	Logical *LogicalUpgradeStatus `json:"logical,omitempty"`
+}
+
+// +kubebuilder:validation:Enum={Provisioning,Replicating,CuttingOver,Switching,Completed}
+type LogicalUpgradePhase string
+
+const (
+	// LogicalUpgradeProvisioning creates the new cluster and copies the
+	// roles and schema of the old cluster to it.
+	LogicalUpgradeProvisioning LogicalUpgradePhase = "Provisioning"
+
+	// LogicalUpgradeReplicating replicates the changes of the old cluster to
+	// the new cluster until a cutover is requested.
+	LogicalUpgradeReplicating LogicalUpgradePhase = "Replicating"
+
+	// LogicalUpgradeCuttingOver blocks writes to the old cluster and waits
+	// for the new cluster to catch up.
+	LogicalUpgradeCuttingOver LogicalUpgradePhase = "CuttingOver"
+
+	// LogicalUpgradeSwitching removes the replication and switches clients
+	// to the new cluster. It is entered once the new cluster has caught up
+	// and is resumed from the start if it fails.
+	LogicalUpgradeSwitching LogicalUpgradePhase = "Switching"
+
+	// LogicalUpgradeCompleted means the replication is removed and the
+	// primary Service and PgBouncer, if any, of the old cluster connect to
+	// the new cluster.
+	LogicalUpgradeCompleted LogicalUpgradePhase = "Completed"
+)
+
+type LogicalUpgradeStatus struct {
+	// +optional
+	Phase LogicalUpgradePhase `json:"phase,omitempty"`
+
+	// +optional
+	TargetClusterName string `json:"targetClusterName,omitempty"`
+
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Databases []LogicalReplicationStatus `json:"databases,omitempty"`
+
+	// The primary host of the new cluster. The primary Service of the old
+	// cluster resolves to it after cutover.
+	// +optional
+	TargetHost string `json:"targetHost,omitempty"`
+
+	// The time the replication was removed and clients were switched to
+	// the new cluster.
+	// +optional
+	CutoverTime *metav1.Time `json:"cutoverTime,omitempty"`
+
+	// Whether PgBouncer of the old cluster was switched to the new cluster
+	// at cutover.
+	// +optional
+	PgBouncerSwitched bool `json:"pgBouncerSwitched,omitempty"`
+}
+
+type LogicalReplicationStatus struct {
+	// +required
+	Name string `json:"name"`
+
+	// Whether the initial copy of every table has finished.
+	// +optional
+	Synchronized bool `json:"synchronized,omitempty"`
+
+	// The amount of WAL in bytes of the old cluster that the new cluster
+	// has not confirmed yet.
+	// +optional
+	LagBytes *int64 `json:"lagBytes,omitempty"`
+}
+
+type PreUpgradeStatus struct {
+	PostgresVersion int    `json:"postgresVersion"`
+	Image           string `json:"image,omitempty"`
+	PGBouncerImage  string `json:"pgBouncerImage,omitempty"`
+	PGBackRestImage string `json:"pgBackRestImage,omitempty"`
+
+	// The PerconaPGBackup taken before the upgrade.
+	// +optional
+	BackupName string `json:"backupName,omitempty"`
+
+	// The VolumeSnapshots taken before the upgrade.
+	// +optional
+	VolumeSnapshots []string `json:"volumeSnapshots,omitempty"`
+}
+
+// +kubebuilder:validation:Enum={Running,Succeeded,Failed}
+type UpgradeRollbackState string
+
+const (
+	RollbackRunning   UpgradeRollbackState = "Running"
+	RollbackSucceeded UpgradeRollbackState = "Succeeded"
+	RollbackFailed    UpgradeRollbackState = "Failed"
+)
+
+// +kubebuilder:validation:Enum={DataDirectory,Restore}
+type UpgradeRollbackMethod string
+
+const (
+	// RollbackDataDirectory recovers the data directory of the old version
+	// that pg_upgrade left behind.
+	RollbackDataDirectory UpgradeRollbackMethod = "DataDirectory"
+
+	// RollbackRestore restores the backup taken before the upgrade.
+	RollbackRestore UpgradeRollbackMethod = "Restore"
+)
+
+type UpgradeRollbackStatus struct {
+	State UpgradeRollbackState `json:"state"`
+
+	// +optional
+	Method UpgradeRollbackMethod `json:"method,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:validation:Enum={Running,Passed,Failed}
+type PreflightState string
+
+const (
+	PreflightRunning PreflightState = "Running"
+	PreflightPassed  PreflightState = "Passed"
+	PreflightFailed  PreflightState = "Failed"
+)
+
+type PreflightReport struct {
+	// Passed when no check failed. Checks with warnings do not fail the
+	// dry run.
+	// +optional
+	State PreflightState `json:"state,omitempty"`
+
+	// The time the last check completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Checks []PreflightCheck `json:"checks,omitempty"`
+}
+
+// +kubebuilder:validation:Enum={Passed,Warning,Failed}
+type PreflightResult string
+
+const (
+	PreflightResultPassed  PreflightResult = "Passed"
+	PreflightResultWarning PreflightResult = "Warning"
+	PreflightResultFailed  PreflightResult = "Failed"
+)
+
+type PreflightCheck struct {
+	// +required
+	Name string `json:"name"`
+
+	// +required
+	Result PreflightResult `json:"result"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The objects that caused a warning or failure.
+	// +optional
+	Details []string `json:"details,omitempty"`
 }
 
 const AnnotationAllowUpgrade = "pgv2.percona.com/allow-upgrade"
+
+// Conditions of the post-upgrade steps in PerconaPGUpgradeStatus.
+const (
+	ConditionPostUpgradeAnalyze          = "PostUpgradeAnalyze"
+	ConditionPostUpgradeUpdateExtensions = "PostUpgradeUpdateExtensions"
+	ConditionPostUpgradeRemoveOldData    = "PostUpgradeRemoveOldData"
+)
+
+// ConditionPreUpgradeBackup is the condition of the backup taken before the
+// cluster is paused for the upgrade.
+const ConditionPreUpgradeBackup = "PreUpgradeBackup"
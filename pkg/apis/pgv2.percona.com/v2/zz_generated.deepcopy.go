@@ -16,6 +16,25 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppliedVersionsStatus) DeepCopyInto(out *AppliedVersionsStatus) {
+	*out = *in
+	if in.LastApplied != nil {
+		in, out := &in.LastApplied, &out.LastApplied
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppliedVersionsStatus.
+func (in *AppliedVersionsStatus) DeepCopy() *AppliedVersionsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AppliedVersionsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Backups) DeepCopyInto(out *Backups) {
 	*out = *in
@@ -70,6 +89,13 @@ func (in *BuiltInExtensionsSpec) DeepCopyInto(out *BuiltInExtensionsSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]v1beta1.ExtensionTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuiltInExtensionsSpec.
@@ -85,6 +111,11 @@ func (in *BuiltInExtensionsSpec) DeepCopy() *BuiltInExtensionsSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomExtensionSpec) DeepCopyInto(out *CustomExtensionSpec) {
 	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomExtensionSpec.
@@ -105,6 +136,11 @@ func (in *CustomExtensionsStorageSpec) DeepCopyInto(out *CustomExtensionsStorage
 		*out = new(v1.SecretProjection)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Volume != nil {
+		in, out := &in.Volume, &out.Volume
+		*out = new(v1.PersistentVolumeClaimVolumeSource)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomExtensionsStorageSpec.
@@ -117,6 +153,54 @@ func (in *CustomExtensionsStorageSpec) DeepCopy() *CustomExtensionsStorageSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunSpec) DeepCopyInto(out *DryRunSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunSpec.
+func (in *DryRunSpec) DeepCopy() *DryRunSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExporterSpec) DeepCopyInto(out *ExporterSpec) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Configuration != nil {
+		in, out := &in.Configuration, &out.Configuration
+		*out = make([]v1.VolumeProjection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CustomTLSSecret != nil {
+		in, out := &in.CustomTLSSecret, &out.CustomTLSSecret
+		*out = new(v1.SecretProjection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(v1beta1.Metadata)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExporterSpec.
+func (in *ExporterSpec) DeepCopy() *ExporterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExporterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtensionsSpec) DeepCopyInto(out *ExtensionsSpec) {
 	*out = *in
@@ -125,7 +209,14 @@ func (in *ExtensionsSpec) DeepCopyInto(out *ExtensionsSpec) {
 	if in.Custom != nil {
 		in, out := &in.Custom, &out.Custom
 		*out = make([]CustomExtensionSpec, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SignatureKey != nil {
+		in, out := &in.SignatureKey, &out.SignatureKey
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -139,6 +230,107 @@ func (in *ExtensionsSpec) DeepCopy() *ExtensionsSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstalledExtensionStatus) DeepCopyInto(out *InstalledExtensionStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstalledExtensionStatus.
+func (in *InstalledExtensionStatus) DeepCopy() *InstalledExtensionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InstalledExtensionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogicalReplicationStatus) DeepCopyInto(out *LogicalReplicationStatus) {
+	*out = *in
+	if in.LagBytes != nil {
+		in, out := &in.LagBytes, &out.LagBytes
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalReplicationStatus.
+func (in *LogicalReplicationStatus) DeepCopy() *LogicalReplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LogicalReplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogicalUpgradeSpec) DeepCopyInto(out *LogicalUpgradeSpec) {
+	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalUpgradeSpec.
+func (in *LogicalUpgradeSpec) DeepCopy() *LogicalUpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LogicalUpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogicalUpgradeStatus) DeepCopyInto(out *LogicalUpgradeStatus) {
+	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]LogicalReplicationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CutoverTime != nil {
+		in, out := &in.CutoverTime, &out.CutoverTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalUpgradeStatus.
+func (in *LogicalUpgradeStatus) DeepCopy() *LogicalUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LogicalUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+	*out = *in
+	if in.Exporter != nil {
+		in, out := &in.Exporter, &out.Exporter
+		*out = new(ExporterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
+func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PGBackRestArchive) DeepCopyInto(out *PGBackRestArchive) {
 	*out = *in
@@ -203,6 +395,13 @@ func (in *PGBackRestArchive) DeepCopyInto(out *PGBackRestArchive) {
 		*out = new(v1beta1.PGBackRestSidecars)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = make([]PGBackRestRetention, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGBackRestArchive.
@@ -215,6 +414,121 @@ func (in *PGBackRestArchive) DeepCopy() *PGBackRestArchive {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PGBackRestRetention) DeepCopyInto(out *PGBackRestRetention) {
+	*out = *in
+	if in.Full != nil {
+		in, out := &in.Full, &out.Full
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.KeepRestorableChain != nil {
+		in, out := &in.KeepRestorableChain, &out.KeepRestorableChain
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGBackRestRetention.
+func (in *PGBackRestRetention) DeepCopy() *PGBackRestRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(PGBackRestRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PGBackRestRetentionStatus) DeepCopyInto(out *PGBackRestRetentionStatus) {
+	*out = *in
+	if in.LastExpired != nil {
+		in, out := &in.LastExpired, &out.LastExpired
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiredBackups != nil {
+		in, out := &in.ExpiredBackups, &out.ExpiredBackups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGBackRestRetentionStatus.
+func (in *PGBackRestRetentionStatus) DeepCopy() *PGBackRestRetentionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PGBackRestRetentionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PGBackupVerification) DeepCopyInto(out *PGBackupVerification) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGBackupVerification.
+func (in *PGBackupVerification) DeepCopy() *PGBackupVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(PGBackupVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PGBackupVerificationStatus) DeepCopyInto(out *PGBackupVerificationStatus) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGBackupVerificationStatus.
+func (in *PGBackupVerificationStatus) DeepCopy() *PGBackupVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PGBackupVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PGBouncerSpec) DeepCopyInto(out *PGBouncerSpec) {
 	*out = *in
@@ -306,6 +620,11 @@ func (in *PGBouncerSpec) DeepCopy() *PGBouncerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PGBouncerStatus) DeepCopyInto(out *PGBouncerStatus) {
 	*out = *in
+	if in.ReadOnlyPools != nil {
+		in, out := &in.ReadOnlyPools, &out.ReadOnlyPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGBouncerStatus.
@@ -410,6 +729,11 @@ func (in *PGInstanceSetSpec) DeepCopyInto(out *PGInstanceSetSpec) {
 		*out = new(v1beta1.InitContainerSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Patroni != nil {
+		in, out := &in.Patroni, &out.Patroni
+		*out = new(v1beta1.InstancePatroniSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGInstanceSetSpec.
@@ -463,6 +787,22 @@ func (in *PGProxySpec) DeepCopy() *PGProxySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PGRestoreNewCluster) DeepCopyInto(out *PGRestoreNewCluster) {
+	*out = *in
+	out.Template = in.Template.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGRestoreNewCluster.
+func (in *PGRestoreNewCluster) DeepCopy() *PGRestoreNewCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(PGRestoreNewCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PITRestoreDateTime) DeepCopyInto(out *PITRestoreDateTime) {
 	*out = *in
@@ -491,25 +831,126 @@ func (in *PMMSpec) DeepCopyInto(out *PMMSpec) {
 		*out = new(v1.SecurityContext)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.RuntimeClassName != nil {
-		in, out := &in.RuntimeClassName, &out.RuntimeClassName
-		*out = new(string)
-		**out = **in
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PMMSpec.
+func (in *PMMSpec) DeepCopy() *PMMSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PMMSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PatroniFailoverStatus) DeepCopyInto(out *PatroniFailoverStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatroniFailoverStatus.
+func (in *PatroniFailoverStatus) DeepCopy() *PatroniFailoverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PatroniFailoverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PatroniStatus) DeepCopyInto(out *PatroniStatus) {
+	*out = *in
+	if in.LastCallbackTime != nil {
+		in, out := &in.LastCallbackTime, &out.LastCallbackTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FailoverHistory != nil {
+		in, out := &in.FailoverHistory, &out.FailoverHistory
+		*out = make([]PatroniFailoverStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatroniStatus.
+func (in *PatroniStatus) DeepCopy() *PatroniStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PatroniStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaPGBackup) DeepCopyInto(out *PerconaPGBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGBackup.
+func (in *PerconaPGBackup) DeepCopy() *PerconaPGBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaPGBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PerconaPGBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaPGBackupList) DeepCopyInto(out *PerconaPGBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PerconaPGBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PMMSpec.
-func (in *PMMSpec) DeepCopy() *PMMSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGBackupList.
+func (in *PerconaPGBackupList) DeepCopy() *PerconaPGBackupList {
 	if in == nil {
 		return nil
 	}
-	out := new(PMMSpec)
+	out := new(PerconaPGBackupList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PerconaPGBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PerconaPGBackup) DeepCopyInto(out *PerconaPGBackup) {
+func (in *PerconaPGBackupSchedule) DeepCopyInto(out *PerconaPGBackupSchedule) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -517,18 +958,18 @@ func (in *PerconaPGBackup) DeepCopyInto(out *PerconaPGBackup) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGBackup.
-func (in *PerconaPGBackup) DeepCopy() *PerconaPGBackup {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGBackupSchedule.
+func (in *PerconaPGBackupSchedule) DeepCopy() *PerconaPGBackupSchedule {
 	if in == nil {
 		return nil
 	}
-	out := new(PerconaPGBackup)
+	out := new(PerconaPGBackupSchedule)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PerconaPGBackup) DeepCopyObject() runtime.Object {
+func (in *PerconaPGBackupSchedule) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -536,37 +977,103 @@ func (in *PerconaPGBackup) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PerconaPGBackupList) DeepCopyInto(out *PerconaPGBackupList) {
+func (in *PerconaPGBackupScheduleList) DeepCopyInto(out *PerconaPGBackupScheduleList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]PerconaPGBackup, len(*in))
+		*out = make([]PerconaPGBackupSchedule, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGBackupList.
-func (in *PerconaPGBackupList) DeepCopy() *PerconaPGBackupList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGBackupScheduleList.
+func (in *PerconaPGBackupScheduleList) DeepCopy() *PerconaPGBackupScheduleList {
 	if in == nil {
 		return nil
 	}
-	out := new(PerconaPGBackupList)
+	out := new(PerconaPGBackupScheduleList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PerconaPGBackupList) DeepCopyObject() runtime.Object {
+func (in *PerconaPGBackupScheduleList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaPGBackupScheduleSpec) DeepCopyInto(out *PerconaPGBackupScheduleSpec) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SuccessfulBackupsHistoryLimit != nil {
+		in, out := &in.SuccessfulBackupsHistoryLimit, &out.SuccessfulBackupsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedBackupsHistoryLimit != nil {
+		in, out := &in.FailedBackupsHistoryLimit, &out.FailedBackupsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGBackupScheduleSpec.
+func (in *PerconaPGBackupScheduleSpec) DeepCopy() *PerconaPGBackupScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaPGBackupScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaPGBackupScheduleStatus) DeepCopyInto(out *PerconaPGBackupScheduleStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSkipTime != nil {
+		in, out := &in.LastSkipTime, &out.LastSkipTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGBackupScheduleStatus.
+func (in *PerconaPGBackupScheduleStatus) DeepCopy() *PerconaPGBackupScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaPGBackupScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PerconaPGBackupSpec) DeepCopyInto(out *PerconaPGBackupSpec) {
 	*out = *in
@@ -575,6 +1082,11 @@ func (in *PerconaPGBackupSpec) DeepCopyInto(out *PerconaPGBackupSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(PGBackupVerification)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGBackupSpec.
@@ -600,6 +1112,11 @@ func (in *PerconaPGBackupStatus) DeepCopyInto(out *PerconaPGBackupStatus) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.LatestRestorableTime.DeepCopyInto(&out.LatestRestorableTime)
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(PGBackupVerificationStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGBackupStatus.
@@ -720,6 +1237,11 @@ func (in *PerconaPGClusterSpec) DeepCopyInto(out *PerconaPGClusterSpec) {
 		*out = new(v1beta1.PatroniSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Switchover != nil {
+		in, out := &in.Switchover, &out.Switchover
+		*out = new(SwitchoverSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Users != nil {
 		in, out := &in.Users, &out.Users
 		*out = make([]v1beta1.PostgresUserSpec, len(*in))
@@ -765,12 +1287,22 @@ func (in *PerconaPGClusterSpec) DeepCopyInto(out *PerconaPGClusterSpec) {
 		*out = new(PMMSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(MonitoringSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Extensions.DeepCopyInto(&out.Extensions)
 	if in.AutoCreateUserSchema != nil {
 		in, out := &in.AutoCreateUserSchema, &out.AutoCreateUserSchema
 		*out = new(bool)
 		**out = **in
 	}
+	if in.UpgradeOptions != nil {
+		in, out := &in.UpgradeOptions, &out.UpgradeOptions
+		*out = new(UpgradeOptions)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGClusterSpec.
@@ -787,12 +1319,30 @@ func (in *PerconaPGClusterSpec) DeepCopy() *PerconaPGClusterSpec {
 func (in *PerconaPGClusterStatus) DeepCopyInto(out *PerconaPGClusterStatus) {
 	*out = *in
 	in.Postgres.DeepCopyInto(&out.Postgres)
-	out.PGBouncer = in.PGBouncer
+	in.PGBouncer.DeepCopyInto(&out.PGBouncer)
+	if in.Switchover != nil {
+		in, out := &in.Switchover, &out.Switchover
+		*out = new(SwitchoverStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Patroni.DeepCopyInto(&out.Patroni)
+	if in.PasswordRotations != nil {
+		in, out := &in.PasswordRotations, &out.PasswordRotations
+		*out = make([]v1beta1.PostgresPasswordRotationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.InstalledCustomExtensions != nil {
 		in, out := &in.InstalledCustomExtensions, &out.InstalledCustomExtensions
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.InstalledCustomExtensionVersions != nil {
+		in, out := &in.InstalledCustomExtensionVersions, &out.InstalledCustomExtensionVersions
+		*out = make([]InstalledExtensionStatus, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]metav1.Condition, len(*in))
@@ -800,6 +1350,18 @@ func (in *PerconaPGClusterStatus) DeepCopyInto(out *PerconaPGClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.BackupRetention != nil {
+		in, out := &in.BackupRetention, &out.BackupRetention
+		*out = make([]PGBackRestRetentionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = new(AppliedVersionsStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGClusterStatus.
@@ -879,6 +1441,11 @@ func (in *PerconaPGRestoreSpec) DeepCopyInto(out *PerconaPGRestoreSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NewCluster != nil {
+		in, out := &in.NewCluster, &out.NewCluster
+		*out = new(PGRestoreNewCluster)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGRestoreSpec.
@@ -1019,6 +1586,26 @@ func (in *PerconaPGUpgradeSpec) DeepCopyInto(out *PerconaPGUpgradeSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PostUpgrade != nil {
+		in, out := &in.PostUpgrade, &out.PostUpgrade
+		*out = new(PostUpgradeSpec)
+		**out = **in
+	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(DryRunSpec)
+		**out = **in
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(UpgradeBackupSpec)
+		**out = **in
+	}
+	if in.Logical != nil {
+		in, out := &in.Logical, &out.Logical
+		*out = new(LogicalUpgradeSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGUpgradeSpec.
@@ -1035,6 +1622,26 @@ func (in *PerconaPGUpgradeSpec) DeepCopy() *PerconaPGUpgradeSpec {
 func (in *PerconaPGUpgradeStatus) DeepCopyInto(out *PerconaPGUpgradeStatus) {
 	*out = *in
 	in.PGUpgradeStatus.DeepCopyInto(&out.PGUpgradeStatus)
+	if in.Preflight != nil {
+		in, out := &in.Preflight, &out.Preflight
+		*out = new(PreflightReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreUpgrade != nil {
+		in, out := &in.PreUpgrade, &out.PreUpgrade
+		*out = new(PreUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rollback != nil {
+		in, out := &in.Rollback, &out.Rollback
+		*out = new(UpgradeRollbackStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Logical != nil {
+		in, out := &in.Logical, &out.Logical
+		*out = new(LogicalUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaPGUpgradeStatus.
@@ -1047,6 +1654,21 @@ func (in *PerconaPGUpgradeStatus) DeepCopy() *PerconaPGUpgradeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostUpgradeSpec) DeepCopyInto(out *PostUpgradeSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostUpgradeSpec.
+func (in *PostUpgradeSpec) DeepCopy() *PostUpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostUpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgresInstanceSetStatus) DeepCopyInto(out *PostgresInstanceSetStatus) {
 	*out = *in
@@ -1070,6 +1692,11 @@ func (in *PostgresStatus) DeepCopyInto(out *PostgresStatus) {
 		*out = make([]PostgresInstanceSetStatus, len(*in))
 		copy(*out, *in)
 	}
+	if in.SynchronousStandbys != nil {
+		in, out := &in.SynchronousStandbys, &out.SynchronousStandbys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresStatus.
@@ -1082,6 +1709,72 @@ func (in *PostgresStatus) DeepCopy() *PostgresStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreUpgradeStatus) DeepCopyInto(out *PreUpgradeStatus) {
+	*out = *in
+	if in.VolumeSnapshots != nil {
+		in, out := &in.VolumeSnapshots, &out.VolumeSnapshots
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreUpgradeStatus.
+func (in *PreUpgradeStatus) DeepCopy() *PreUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightCheck) DeepCopyInto(out *PreflightCheck) {
+	*out = *in
+	if in.Details != nil {
+		in, out := &in.Details, &out.Details
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightCheck.
+func (in *PreflightCheck) DeepCopy() *PreflightCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightReport) DeepCopyInto(out *PreflightReport) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]PreflightCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightReport.
+func (in *PreflightReport) DeepCopy() *PreflightReport {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretsSpec) DeepCopyInto(out *SecretsSpec) {
 	*out = *in
@@ -1137,3 +1830,98 @@ func (in *ServiceExpose) DeepCopy() *ServiceExpose {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwitchoverSpec) DeepCopyInto(out *SwitchoverSpec) {
+	*out = *in
+	if in.ScheduledAt != nil {
+		in, out := &in.ScheduledAt, &out.ScheduledAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwitchoverSpec.
+func (in *SwitchoverSpec) DeepCopy() *SwitchoverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SwitchoverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwitchoverStatus) DeepCopyInto(out *SwitchoverStatus) {
+	*out = *in
+	if in.RequestedAt != nil {
+		in, out := &in.RequestedAt, &out.RequestedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwitchoverStatus.
+func (in *SwitchoverStatus) DeepCopy() *SwitchoverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SwitchoverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeBackupSpec) DeepCopyInto(out *UpgradeBackupSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeBackupSpec.
+func (in *UpgradeBackupSpec) DeepCopy() *UpgradeBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeOptions) DeepCopyInto(out *UpgradeOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeOptions.
+func (in *UpgradeOptions) DeepCopy() *UpgradeOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeRollbackStatus) DeepCopyInto(out *UpgradeRollbackStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeRollbackStatus.
+func (in *UpgradeRollbackStatus) DeepCopy() *UpgradeRollbackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeRollbackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
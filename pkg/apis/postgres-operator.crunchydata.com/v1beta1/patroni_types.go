@@ -46,12 +46,103 @@ type PatroniSpec struct {
 	// +optional
 	CreateReplicaMethods []CreateReplicaMethod `json:"createReplicaMethods,omitempty"`
 
+	// Synchronous replication settings. When set, these take precedence over
+	// the synchronous settings in dynamicConfiguration.
+	// More info: https://patroni.readthedocs.io/en/latest/replication_modes.html
+	// +optional
+	Synchronous *PatroniSynchronous `json:"synchronous,omitempty"`
+
 	// TODO(cbandy): Add UseConfigMaps bool, default false.
 	// TODO(cbandy): Allow other DCS: etcd, raft, etc?
 	// N.B. changing this will cause downtime.
 	// - https://patroni.readthedocs.io/en/latest/kubernetes.html
 }
 
+// +kubebuilder:validation:Enum={off,on,strict,quorum}
+type PatroniSynchronousMode string
+
+const (
+	PatroniSynchronousOff    PatroniSynchronousMode = "off"
+	PatroniSynchronousOn     PatroniSynchronousMode = "on"
+	PatroniSynchronousStrict PatroniSynchronousMode = "strict"
+	PatroniSynchronousQuorum PatroniSynchronousMode = "quorum"
+)
+
+type PatroniSynchronous struct {
+	// The synchronous replication mode. "on" makes Patroni pick synchronous
+	// standbys, but writes continue when none are available. "strict" blocks
+	// writes until a synchronous standby is available. "quorum" waits for any
+	// nodeCount standbys and requires Patroni 4.0 or later.
+	// +optional
+	// +kubebuilder:default=off
+	Mode PatroniSynchronousMode `json:"mode,omitempty"`
+
+	// The number of synchronous standbys.
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	NodeCount *int32 `json:"nodeCount,omitempty"`
+}
+
+// Enabled reports whether synchronous replication is requested.
+func (s *PatroniSynchronous) Enabled() bool {
+	return s != nil && s.Mode != "" && s.Mode != PatroniSynchronousOff
+}
+
+// GetNodeCount returns the number of synchronous standbys, defaulting to one.
+func (s *PatroniSynchronous) GetNodeCount() int32 {
+	if s == nil || s.NodeCount == nil {
+		return 1
+	}
+	return *s.NodeCount
+}
+
+// InstancePatroniSpec defines Patroni settings of the instances of a set.
+// The settings are rendered as Patroni tags of each instance.
+// More info: https://patroni.readthedocs.io/en/latest/yaml_configuration.html#tags
+type InstancePatroniSpec struct {
+	// Whether the instances of the set are never promoted to primary, neither
+	// by failover nor by switchover. Sets the Patroni "nofailover" tag.
+	// +optional
+	NoFailover bool `json:"noFailover,omitempty"`
+
+	// Whether the instances of the set are never chosen as synchronous
+	// standbys. Sets the Patroni "nosync" tag.
+	// +optional
+	NoSync bool `json:"noSync,omitempty"`
+
+	// Whether other instances may take their base backup from the instances of
+	// the set instead of the primary. Sets the Patroni "clonefrom" tag.
+	// +optional
+	CloneFrom bool `json:"cloneFrom,omitempty"`
+
+	// The name of another instance set the instances of the set replicate from
+	// instead of the primary, to build cascading replication. Each instance is
+	// assigned an available instance of that set and replicates from the
+	// primary while there is none. Sets the Patroni "replicatefrom" tag.
+	// +optional
+	ReplicateFrom string `json:"replicateFrom,omitempty"`
+}
+
+// Tags returns the Patroni tags of the instances. The "replicatefrom" tag names
+// a concrete instance and is set when the instance is reconciled.
+func (s *InstancePatroniSpec) Tags() map[string]any {
+	tags := map[string]any{}
+	if s == nil {
+		return tags
+	}
+	if s.NoFailover {
+		tags["nofailover"] = true
+	}
+	if s.NoSync {
+		tags["nosync"] = true
+	}
+	if s.CloneFrom {
+		tags["clonefrom"] = true
+	}
+	return tags
+}
+
 // +kubebuilder:validation:Enum={basebackup,pgbackrest}
 type CreateReplicaMethod string
 
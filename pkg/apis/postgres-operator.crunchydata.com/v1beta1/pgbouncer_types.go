@@ -5,6 +5,8 @@
 package v1beta1
 
 import (
+	"slices"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -42,6 +44,92 @@ type PGBouncerConfiguration struct {
 	// More info: https://www.pgbouncer.org/config.html#section-users
 	// +optional
 	Users map[string]string `json:"users,omitempty"`
+
+	// Rules of the PgBouncer HBA file, one per line. When specified, clients
+	// are authenticated according to these rules rather than "auth_type".
+	// Rules that let the metrics exporter connect over localhost are added
+	// before them when the exporter is enabled.
+	// Changing this value from or to empty causes PgBouncer to restart.
+	// More info: https://www.pgbouncer.org/config.html#hba-file-format
+	// +optional
+	HBA []string `json:"hba,omitempty"`
+
+	// Users that may connect to the "pgbouncer" admin console and run any
+	// command. Their passwords are stored in the "<cluster>-pgbouncer-users"
+	// Secret.
+	// More info: https://www.pgbouncer.org/config.html#admin_users
+	// +listType=set
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:items:MaxLength=63
+	// +optional
+	AdminUsers []string `json:"adminUsers,omitempty"`
+
+	// Users that may connect to the "pgbouncer" admin console and run
+	// read-only SHOW commands. Their passwords are stored in the
+	// "<cluster>-pgbouncer-users" Secret.
+	// More info: https://www.pgbouncer.org/config.html#stats_users
+	// +listType=set
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:items:MaxLength=63
+	// +optional
+	StatsUsers []string `json:"statsUsers,omitempty"`
+
+	// Connection pools that connect to replica PostgreSQL instances through
+	// the replica Service. They are added to the database definitions.
+	// +optional
+	ReadOnlyPools *PGBouncerReadOnlyPools `json:"readOnlyPools,omitempty"`
+}
+
+// PGBouncerReadOnlyPools defines connection pools that are routed to replicas.
+type PGBouncerReadOnlyPools struct {
+	// Databases to create a read-only pool for. Each pool is named after its
+	// database followed by the suffix.
+	// +listType=set
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Pattern=`^[a-zA-Z0-9_][-a-zA-Z0-9_.]*$`
+	// +kubebuilder:validation:items:MaxLength=63
+	Databases []string `json:"databases"`
+
+	// The suffix of the read-only pool names.
+	// +kubebuilder:default="_ro"
+	// +kubebuilder:validation:Pattern=`^[-a-zA-Z0-9_.]+$`
+	// +optional
+	Suffix string `json:"suffix,omitempty"`
+
+	// The maximum number of server connections of each read-only pool.
+	// Defaults to the "default_pool_size" setting.
+	// More info: https://www.pgbouncer.org/config.html#pool_size
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PoolSize *int32 `json:"poolSize,omitempty"`
+
+	// The maximum number of server connections to each database across all
+	// of its read-only pools. Defaults to the "max_db_connections" setting.
+	// More info: https://www.pgbouncer.org/config.html#max_db_connections
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxDBConnections *int32 `json:"maxDBConnections,omitempty"`
+}
+
+// PoolName returns the name of the read-only pool of database.
+func (p *PGBouncerReadOnlyPools) PoolName(database string) string {
+	suffix := p.Suffix
+	if suffix == "" {
+		suffix = "_ro"
+	}
+	return database + suffix
+}
+
+// ConsoleUsers returns the admin and stats users of the PgBouncer admin
+// console without duplicates.
+func (c PGBouncerConfiguration) ConsoleUsers() []string {
+	users := make([]string, 0, len(c.AdminUsers)+len(c.StatsUsers))
+	for _, user := range append(append([]string{}, c.AdminUsers...), c.StatsUsers...) {
+		if !slices.Contains(users, user) {
+			users = append(users, user)
+		}
+	}
+	return users
 }
 
 // PGBouncerPodSpec defines the desired state of a PgBouncer connection pooler.
@@ -146,6 +234,37 @@ type PGBouncerSidecars struct {
 	// Defines the configuration for the pgBouncer config sidecar container
 	// +optional
 	PGBouncerConfig *Sidecar `json:"pgbouncerConfig,omitempty"`
+
+	// Defines the configuration for the PgBouncer metrics exporter sidecar
+	// container. The exporter runs only when this is specified. Changing this
+	// value causes PgBouncer to restart.
+	// +optional
+	Exporter *PGBouncerExporter `json:"exporter,omitempty"`
+}
+
+// PGBouncerExporter defines a sidecar container that exposes metrics of the
+// PgBouncer pools, clients and servers to Prometheus.
+type PGBouncerExporter struct {
+	// Name of a container image that can run pgbouncer_exporter.
+	// More info: https://github.com/prometheus-community/pgbouncer_exporter
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Port on which the exporter serves metrics.
+	// +optional
+	// +kubebuilder:default=9127
+	// +kubebuilder:validation:Minimum=1024
+	Port *int32 `json:"port,omitempty"`
+
+	// Compute resources of the exporter container.
+	// More info: https://kubernetes.io/docs/concepts/configuration/manage-resources-containers
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Security settings of the exporter container. Defaults to the restricted
+	// settings of the other PgBouncer containers.
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
 }
 
 // Default returns the default port for PgBouncer (5432) if a port is not
@@ -173,4 +292,8 @@ type PGBouncerPodStatus struct {
 
 	// Total number of non-terminated pods.
 	Replicas int32 `json:"replicas,omitempty"`
+
+	// Names of the connection pools that are routed to replicas.
+	// +optional
+	ReadOnlyPools []string `json:"readOnlyPools,omitempty"`
 }
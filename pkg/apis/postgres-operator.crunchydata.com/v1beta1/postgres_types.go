@@ -4,6 +4,11 @@
 
 package v1beta1
 
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // PostgreSQL identifiers are limited in length but may contain any character.
 // More info: https://www.postgresql.org/docs/current/sql-syntax-lexical.html#SQL-SYNTAX-IDENTIFIERS
 //
@@ -19,6 +24,70 @@ type PostgresPasswordSpec struct {
 	// +kubebuilder:default=ASCII
 	// +kubebuilder:validation:Enum={ASCII,AlphaNumeric}
 	Type string `json:"type"`
+
+	// Policy to replace the password with a newly generated one periodically.
+	// Cannot be used together with an external source.
+	// +optional
+	Rotation *PostgresPasswordRotationSpec `json:"rotation,omitempty"`
+
+	// Read the password from an externally managed source rather than
+	// generating one. Only the SCRAM verifier of the password is stored in
+	// the user Secret; the password and the connection URIs are not. The
+	// user cannot log in to pgAdmin.
+	// +optional
+	Source *PostgresPasswordSource `json:"source,omitempty"`
+}
+
+// PostgresPasswordSource defines where an externally managed password is read
+// from. Exactly one of the fields must be set.
+// +kubebuilder:validation:XValidation:rule=`[has(self.secretKeyRef),has(self.file)].exists_one(x,x)`,message=`exactly one of "secretKeyRef" or "file" is required`
+type PostgresPasswordSource struct {
+	// A key of a Secret in the namespace of the cluster that holds the
+	// password, e.g. one synchronized by the External Secrets Operator.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+
+	// The path of a file that holds the password, relative to the
+	// subdirectory named after the namespace of the cluster in the directory
+	// in the PGO_PASSWORD_FILES_DIR environment variable of the operator,
+	// e.g. one rendered by Vault Agent or mounted by the Secrets Store CSI
+	// driver into the operator Pod. Paths outside of that subdirectory are
+	// rejected.
+	// +kubebuilder:validation:Pattern=`^[^/]`
+	// +optional
+	File string `json:"file,omitempty"`
+}
+
+// PostgresPasswordRotationSpec defines how often the password of a user is
+// rotated.
+type PostgresPasswordRotationSpec struct {
+	// How often to generate a new password, e.g. "720h" for every 30 days.
+	// The first rotation happens one interval after the policy is applied.
+	Interval metav1.Duration `json:"interval"`
+
+	// How long the previous credentials keep working after a rotation.
+	// PostgreSQL accepts only one password per role, so with a grace period
+	// the user takes turns logging in as itself and as the role
+	// "<name>_alt", which acts as the user. The "user" key of the Secret
+	// holds the current login role; the previous one is kept in the
+	// "previous-user", "previous-password", and "previous-verifier" keys
+	// until the grace period ends and it can no longer log in. The name of a
+	// user with a grace period can be at most 59 characters long.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// PostgresPasswordRotationStatus describes the password rotation of a user.
+type PostgresPasswordRotationStatus struct {
+	// The name of the PostgreSQL user.
+	Name string `json:"name"`
+
+	// When the current password was generated or the rotation policy was
+	// first applied.
+	LastRotationTime metav1.Time `json:"lastRotationTime"`
+
+	// When the password will be rotated next.
+	NextRotationTime metav1.Time `json:"nextRotationTime"`
 }
 
 // PostgresPasswordSpec types.
@@ -205,6 +205,52 @@ type ExtensionsSpec struct {
 	PGStatStatements bool `json:"pgStatStatements,omitempty"`
 	PGVector         bool `json:"pgvector,omitempty"`
 	PGRepack         bool `json:"pgRepack,omitempty"`
+
+	// The databases and schemas to create the enabled extensions in. An
+	// enabled extension without a target is created in every database.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Targets []ExtensionTarget `json:"targets,omitempty"`
+
+	// The PersistentVolumeClaim with custom extension archives. It is mounted
+	// read-only into PostgreSQL instance pods.
+	// +optional
+	StorageVolume *corev1.PersistentVolumeClaimVolumeSource `json:"storageVolume,omitempty"`
+
+	// The Secret key with the public key that verifies custom extension
+	// archives. It is mounted into PostgreSQL instance pods.
+	// +optional
+	SignatureKey *corev1.SecretKeySelector `json:"signatureKey,omitempty"`
+}
+
+// ExtensionTarget limits where an extension is created.
+type ExtensionTarget struct {
+	// The name of the extension.
+	// +kubebuilder:validation:Enum={pg_stat_monitor,pg_stat_statements,pgaudit,vector,pg_repack}
+	// +required
+	Name string `json:"name"`
+
+	// The databases to create the extension in.
+	// +kubebuilder:validation:MinItems=1
+	// +required
+	Databases []string `json:"databases"`
+
+	// The schema to create the extension in. It's created if it doesn't exist.
+	// Defaults to the schema from the extension control file or to the first
+	// schema in the search path.
+	// +optional
+	Schema string `json:"schema,omitempty"`
+}
+
+// Target returns the target of the extension with name, if any.
+func (s *ExtensionsSpec) Target(name string) *ExtensionTarget {
+	for i := range s.Targets {
+		if s.Targets[i].Name == name {
+			return &s.Targets[i]
+		}
+	}
+	return nil
 }
 
 // DataSource defines data sources for a new PostgresCluster.
@@ -415,6 +461,12 @@ type PostgresClusterStatus struct {
 	// Identifies the users that have been installed into PostgreSQL.
 	UsersRevision string `json:"usersRevision,omitempty"`
 
+	// Password rotation of the users that have a rotation policy.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	PasswordRotations []PostgresPasswordRotationStatus `json:"passwordRotations,omitempty"`
+
 	// Current state of PostgreSQL cluster monitoring tool configuration
 	// +optional
 	Monitoring MonitoringStatus `json:"monitoring,omitempty"`
@@ -440,12 +492,14 @@ type PostgresClusterStatus struct {
 
 // PostgresClusterStatus condition types.
 const (
+	ExternalPasswordsReady     = "ExternalPasswordsReady"
 	PersistentVolumeResizing   = "PersistentVolumeResizing"
 	PostgresClusterProgressing = "Progressing"
 	ProxyAvailable             = "ProxyAvailable"
 	Registered                 = "Registered"
 )
 
+// +kubebuilder:validation:XValidation:rule=`!has(self.patroni) || !has(self.patroni.replicateFrom) || !has(self.name) || self.patroni.replicateFrom != self.name`,message="an instance set cannot replicate from itself"
 type PostgresInstanceSetSpec struct {
 	// +optional
 	Metadata *Metadata `json:"metadata,omitempty"`
@@ -555,6 +609,10 @@ type PostgresInstanceSetSpec struct {
 	// InitContainer defines the init container for the instance container of a PostgreSQL pod.
 	// +optional
 	InitContainer *InitContainerSpec `json:"initContainer,omitempty"`
+
+	// Patroni settings of the instances of this set.
+	// +optional
+	Patroni *InstancePatroniSpec `json:"patroni,omitempty"`
 }
 
 // K8SPG-708